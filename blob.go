@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/opencontainers/go-digest"
+
 	"github.com/regclient/regclient/internal/pqueue"
 	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/scheme"
@@ -23,12 +26,32 @@ import (
 const blobCBFreq = time.Millisecond * 100
 
 type blobOpt struct {
+	cache    BlobCache
+	noMount  bool
 	callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
+	actionFn func(ImageCopyAction) // internal use, reports how BlobCopy handled the blob
+	dryRun   bool                  // internal use, only check if the blob exists on the target
+}
+
+// BlobCache stores blobs pulled by [RegClient.BlobCopy] so later copies can reuse them.
+// Implementations must be safe for concurrent use.
+type BlobCache interface {
+	// Get returns the content of a cached blob, or an error if the blob is not in the cache.
+	Get(ctx context.Context, d descriptor.Descriptor) (io.ReadCloser, error)
+	// Put reads the blob content from rdr and adds it to the cache.
+	Put(ctx context.Context, d descriptor.Descriptor, rdr io.Reader) error
 }
 
 // BlobOpts define options for the Image* commands.
 type BlobOpts func(*blobOpt)
 
+// BlobWithCache reads blobs from the cache when copying, and adds blobs pulled from the source to the cache.
+func BlobWithCache(cache BlobCache) BlobOpts {
+	return func(opts *blobOpt) {
+		opts.cache = cache
+	}
+}
+
 // BlobWithCallback provides progress data to a callback function.
 func BlobWithCallback(callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)) BlobOpts {
 	return func(opts *blobOpt) {
@@ -36,9 +59,17 @@ func BlobWithCallback(callback func(kind types.CallbackKind, instance string, st
 	}
 }
 
+// BlobWithoutMount disables cross repository blob mounts, always uploading the full blob content.
+// This is a workaround for registries that report a successful mount without the blob.
+func BlobWithoutMount() BlobOpts {
+	return func(opts *blobOpt) {
+		opts.noMount = true
+	}
+}
+
 // BlobCopy copies a blob between two locations.
 // If the blob already exists in the target, the copy is skipped.
-// A server side cross repository blob mount is attempted.
+// A server side cross repository blob mount is attempted unless [BlobWithoutMount] is set.
 func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor, opts ...BlobOpts) error {
 	if !refSrc.IsSetRepo() {
 		return fmt.Errorf("refSrc is not set: %s%.0w", refSrc.CommonName(), errs.ErrInvalidReference)
@@ -64,6 +95,9 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 		if opt.callback != nil {
 			opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, 0, d.Size)
 		}
+		if opt.actionFn != nil {
+			opt.actionFn(ImageCopySkipped)
+		}
 		rc.slog.Debug("Blob copy skipped, same repo",
 			slog.String("src", refSrc.Reference),
 			slog.String("tgt", refTgt.Reference),
@@ -75,12 +109,21 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 		if opt.callback != nil {
 			opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, 0, d.Size)
 		}
+		if opt.actionFn != nil {
+			opt.actionFn(ImageCopySkipped)
+		}
 		rc.slog.Debug("Blob copy skipped, already exists",
 			slog.String("src", refSrc.Reference),
 			slog.String("tgt", refTgt.Reference),
 			slog.String("digest", string(d.Digest)))
 		return nil
 	}
+	if opt.dryRun {
+		if opt.actionFn != nil {
+			opt.actionFn(ImageCopyPending)
+		}
+		return nil
+	}
 	// acquire throttle for both src and tgt to avoid deadlocks
 	tList := []*pqueue.Queue[reqmeta.Data]{}
 	schemeSrcAPI, err := rc.schemeGet(refSrc.Scheme)
@@ -108,13 +151,19 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 		ctx = ctxMulti
 	}
 
-	// try mounting blob from the source repo is the registry is the same
-	if ref.EqualRegistry(refSrc, refTgt) {
+	if opt.noMount {
+		ctx = scheme.WithBlobNoMount(ctx)
+	}
+	// try mounting blob from the source repo is the registry is the same, or linking files between OCI Layouts
+	if !opt.noMount && (ref.EqualRegistry(refSrc, refTgt) || (refSrc.Scheme == "ocidir" && refTgt.Scheme == "ocidir")) {
 		err := rc.BlobMount(ctx, refSrc, refTgt, d)
 		if err == nil {
 			if opt.callback != nil {
 				opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, 0, d.Size)
 			}
+			if opt.actionFn != nil {
+				opt.actionFn(ImageCopyMounted)
+			}
 			rc.slog.Debug("Blob copy performed server side with registry mount",
 				slog.String("src", refSrc.Reference),
 				slog.String("tgt", refTgt.Reference),
@@ -126,8 +175,19 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 			slog.String("tgt", refTgt.Reference),
 			slog.String("err", err.Error()))
 	}
-	// fast options failed, download layer from source and push to target
-	blobIO, err := rc.BlobGet(ctx, refSrc, d)
+	// fast options failed, download layer from source (or the cache) and push to target
+	var blobRdr io.ReadCloser
+	pushDesc := d
+	if opt.cache != nil {
+		blobRdr, err = rc.blobGetCache(ctx, refSrc, d, opt.cache)
+	} else {
+		var blobIO blob.Reader
+		blobIO, err = rc.BlobGet(ctx, refSrc, d)
+		if err == nil {
+			blobRdr = blobIO
+			pushDesc = blobIO.GetDescriptor()
+		}
+	}
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			rc.slog.Warn("Failed to retrieve blob",
@@ -148,22 +208,24 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 				opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackFinished, d.Size, d.Size)
 			}
 		}()
-		go func() {
-			for {
-				select {
-				case <-done:
-					return
-				case <-ticker.C:
-					offset, err := blobIO.Seek(0, io.SeekCurrent)
-					if err == nil && offset > 0 {
-						opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackActive, offset, d.Size)
+		if seeker, ok := blobRdr.(io.Seeker); ok {
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						offset, err := seeker.Seek(0, io.SeekCurrent)
+						if err == nil && offset > 0 {
+							opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackActive, offset, d.Size)
+						}
 					}
 				}
-			}
-		}()
+			}()
+		}
 	}
-	defer blobIO.Close()
-	if _, err := rc.BlobPut(ctx, refTgt, blobIO.GetDescriptor(), blobIO); err != nil {
+	defer blobRdr.Close()
+	if _, err := rc.BlobPut(ctx, refTgt, pushDesc, blobRdr); err != nil {
 		if !errors.Is(err, context.Canceled) {
 			rc.slog.Warn("Failed to push blob",
 				slog.String("src", refSrc.Reference),
@@ -172,9 +234,73 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 		}
 		return err
 	}
+	if opt.actionFn != nil {
+		opt.actionFn(ImageCopyCopied)
+	}
 	return nil
 }
 
+// blobCacheFill tracks the blobs being pulled to add to a cache.
+type blobCacheFill struct {
+	mu      sync.Mutex
+	pending map[digest.Digest]chan struct{}
+}
+
+// blobGetCache returns the blob from the cache, pulling from the source and adding it to the cache on a miss.
+// Concurrent misses for the same digest wait for the first pull to finish so the source is only read once.
+func (rc *RegClient) blobGetCache(ctx context.Context, r ref.Ref, d descriptor.Descriptor, cache BlobCache) (io.ReadCloser, error) {
+	for {
+		if rdr, err := cache.Get(ctx, d); err == nil {
+			rc.slog.Debug("Blob read from cache",
+				slog.String("src", r.Reference),
+				slog.String("digest", string(d.Digest)))
+			return rdr, nil
+		}
+		rc.cacheFill.mu.Lock()
+		waitCh, ok := rc.cacheFill.pending[d.Digest]
+		if !ok {
+			fillCh := make(chan struct{})
+			rc.cacheFill.pending[d.Digest] = fillCh
+			rc.cacheFill.mu.Unlock()
+			defer func() {
+				rc.cacheFill.mu.Lock()
+				delete(rc.cacheFill.pending, d.Digest)
+				rc.cacheFill.mu.Unlock()
+				close(fillCh)
+			}()
+			break
+		}
+		rc.cacheFill.mu.Unlock()
+		// after the other pull finishes, check the cache again and pull the blob here if it is still missing
+		select {
+		case <-waitCh:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	blobIO, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		return nil, err
+	}
+	err = cache.Put(ctx, d, blobIO)
+	_ = blobIO.Close()
+	if err == nil {
+		var rdr io.ReadCloser
+		rdr, err = cache.Get(ctx, d)
+		if err == nil {
+			return rdr, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	rc.slog.Warn("Failed to add blob to cache",
+		slog.String("src", r.Reference),
+		slog.String("digest", string(d.Digest)),
+		slog.String("err", err.Error()))
+	return rc.BlobGet(ctx, r, d)
+}
+
 // BlobDelete removes a blob from the registry.
 // This method should only be used to repair a damaged registry.
 // Typically a server side garbage collection should be used to purge unused blobs.
@@ -206,6 +332,25 @@ func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descri
 	return schemeAPI.BlobGet(ctx, r, d)
 }
 
+// BlobGetResume retrieves a blob starting at an offset, used to resume a partial download.
+// The descriptor must include the size and digest of the blob.
+// To verify the digest, pass the first offset bytes to [blob.BReader.HashPrefix] before reading.
+// [errs.ErrUnsupported] is returned when the scheme or registry cannot resume downloads.
+func (rc *RegClient) BlobGetResume(ctx context.Context, r ref.Ref, d descriptor.Descriptor, offset int64) (blob.Reader, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	sr, ok := schemeAPI.(scheme.BlobResumer)
+	if !ok {
+		return nil, fmt.Errorf("blob resume is not supported by scheme %s%.0w", r.Scheme, errs.ErrUnsupported)
+	}
+	return sr.BlobGetResume(ctx, r, d, offset)
+}
+
 // BlobGetOCIConfig retrieves an OCI config from a blob, automatically extracting the JSON.
 func (rc *RegClient) BlobGetOCIConfig(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.OCIConfig, error) {
 	if !r.IsSetRepo() {
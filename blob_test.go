@@ -283,6 +283,20 @@ func TestBlobGet(t *testing.T) {
 		}
 	})
 
+	t.Run("Resume Unsupported", func(t *testing.T) {
+		ref, err := ref.New("ocidir://" + t.TempDir() + "/repo")
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := rc.BlobGetResume(ctx, ref, descriptor.Descriptor{Digest: d1, Size: int64(len(blob1))}, 10)
+		if err == nil {
+			br.Close()
+			t.Fatalf("Unexpected success running BlobGetResume")
+		}
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("Error does not match \"ErrUnsupported\": %v", err)
+		}
+	})
 }
 
 func TestBlobPut(t *testing.T) {
@@ -98,6 +98,33 @@ defaults:
 			},
 			expErr: nil,
 		},
+		{
+			name: "ListFilter",
+			script: ConfigScript{
+				Name: "ListFilter",
+				Script: `
+				function check(list, expect)
+					if #list ~= #expect then
+						error("unexpected count: " .. #list .. ", expected " .. #expect)
+					end
+					for i, tag in ipairs(expect) do
+						if list[i] ~= tag then
+							error("unexpected tag: " .. tostring(list[i]) .. ", expected " .. tag)
+						end
+					end
+				end
+				check(tag.ls("registry.example.org/testrepo", {prefix = "b"}), {"b1", "b2", "b3"})
+				check(tag.ls("registry.example.org/testrepo", {regex = "^[av][12]$"}), {"a1", "a2", "v1", "v2"})
+				check(tag.ls("registry.example.org/testrepo", {semver = ">=2, <4"}), {"v2", "v3"})
+				check(tag.ls("registry.example.org/testrepo", {prefix = "v", limit = 2}), {"v1", "v2"})
+				list = tag.ls("registry.example.org/testrepo", {semver = "1", digest = true})
+				if #list ~= 1 or list[1].tag ~= "v1" or list[1].digest ~= "sha256:190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09" then
+					error "unexpected digest result"
+				end
+				`,
+			},
+			expErr: nil,
+		},
 		{
 			name: "GetConfig",
 			script: ConfigScript{
@@ -123,6 +150,32 @@ defaults:
 			},
 			exists: []string{"registry.example.org/testcopy:latest"},
 		},
+		{
+			name: "ReferrerList",
+			script: ConfigScript{
+				Name: "ReferrerList",
+				Script: `
+				subject = "registry.example.org/testrepo@sha256:ee378b79279b57eb5ac1f3b892c9ad2a9be9d9ccabe1a29a9cbaed8cad182358"
+				list = referrer.list(subject)
+				if #list ~= 1 or list[1].digest ~= "sha256:30bc58e881e9e21ce6b77b7b3f69dac5e9371c9ea5a445234c22234826563023" or list[1].artifactType ~= "application/example.arms" then
+					error "unexpected referrer list"
+				end
+				if #referrer.list(subject, {artifactType = "application/example.arms"}) ~= 1 then
+					error "artifactType filter did not match"
+				end
+				if #referrer.list(subject, {artifactType = "application/example.missing"}) ~= 0 then
+					error "artifactType filter did not exclude referrer"
+				end
+				if #referrer.list(subject, {annotations = {["org.example.missing"] = "value"}}) ~= 0 then
+					error "annotations filter did not exclude referrer"
+				end
+				for _, d in ipairs(list) do
+					image.copy(d.ref, "registry.example.org/testreferrer:arms")
+				end
+				`,
+			},
+			exists: []string{"registry.example.org/testreferrer:arms"},
+		},
 		{
 			name: "DeleteCopy",
 			script: ConfigScript{
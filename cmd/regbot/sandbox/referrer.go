@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"fmt"
+	"log/slog"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/regclient/regclient/cmd/regbot/internal/go2lua"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/descriptor"
+)
+
+func setupReferrer(s *Sandbox) {
+	s.setupMod(
+		luaReferrerName,
+		map[string]lua.LGFunction{
+			"list": s.referrerList,
+		},
+		map[string]map[string]lua.LGFunction{
+			"__index": {},
+		},
+	)
+}
+
+type referrerListOpts struct {
+	ArtifactType string            `json:"artifactType"`
+	Annotations  map[string]string `json:"annotations"`
+}
+
+func (s *Sandbox) referrerList(ls *lua.LState) int {
+	err := s.ctx.Err()
+	if err != nil {
+		ls.RaiseError("Context error: %v", err)
+	}
+	r := s.checkReference(ls, 1)
+	opts := referrerListOpts{}
+	optsArgs := []scheme.ReferrerOpts{}
+	if ls.GetTop() > 1 {
+		tab := ls.CheckTable(2)
+		err := go2lua.Import(ls, tab, &opts, nil)
+		if err != nil {
+			ls.ArgError(2, fmt.Sprintf("Failed to parse options: %v", err))
+		}
+		optsArgs = append(optsArgs, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{
+			ArtifactType: opts.ArtifactType,
+			Annotations:  opts.Annotations,
+		}))
+	}
+	s.log.Debug("Listing referrers",
+		slog.String("script", s.name),
+		slog.String("image", r.r.CommonName()),
+		slog.Any("opts", opts))
+	rl, err := s.rc.ReferrerList(s.ctx, r.r, optsArgs...)
+	if err != nil {
+		ls.RaiseError("Failed retrieving referrer list: %v", err)
+	}
+	lDescs := ls.NewTable()
+	for _, d := range rl.Descriptors {
+		lDesc := ls.NewTable()
+		lDesc.RawSetString("mediaType", lua.LString(d.MediaType))
+		lDesc.RawSetString("artifactType", lua.LString(d.ArtifactType))
+		lDesc.RawSetString("digest", lua.LString(d.Digest.String()))
+		lDesc.RawSetString("size", lua.LNumber(d.Size))
+		lAnnotations := ls.NewTable()
+		for k, v := range d.Annotations {
+			lAnnotations.RawSetString(k, lua.LString(v))
+		}
+		lDesc.RawSetString("annotations", lAnnotations)
+		// include a reference to the referrer for use with other functions
+		ud := ls.NewUserData()
+		ud.Value = &reference{r: rl.Subject.SetDigest(d.Digest.String())}
+		ls.SetMetatable(ud, ls.GetTypeMetatable(luaReferenceName))
+		lDesc.RawSetString("ref", ud)
+		lDescs.Append(lDesc)
+	}
+	ls.Push(lDescs)
+	return 1
+}
@@ -16,6 +16,7 @@ import (
 const (
 	luaRepoName        = "repo"
 	luaReferenceName   = "reference"
+	luaReferrerName    = "referrer"
 	luaTagName         = "tag"
 	luaManifestName    = "manifest"
 	luaImageName       = "image"
@@ -40,6 +41,7 @@ type LuaMod func(*Sandbox)
 var luaMods = []LuaMod{
 	setupRepo,
 	setupReference,
+	setupReferrer,
 	setupTag,
 	setupImage,
 	setupManifest,
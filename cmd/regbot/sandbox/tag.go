@@ -1,9 +1,17 @@
 package sandbox
 
 import (
+	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	lua "github.com/yuin/gopher-lua"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/cmd/regbot/internal/go2lua"
+	"github.com/regclient/regclient/internal/semver"
+	"github.com/regclient/regclient/scheme"
 )
 
 func setupTag(s *Sandbox) {
@@ -45,16 +53,52 @@ func (s *Sandbox) tagDelete(ls *lua.LState) int {
 	return 0
 }
 
+type tagLsOpts struct {
+	Prefix string `json:"prefix"`
+	Regex  string `json:"regex"`
+	Semver string `json:"semver"`
+	Limit  int    `json:"limit"`
+	Last   string `json:"last"`
+	Digest bool   `json:"digest"`
+}
+
 func (s *Sandbox) tagLs(ls *lua.LState) int {
 	err := s.ctx.Err()
 	if err != nil {
 		ls.RaiseError("Context error: %v", err)
 	}
 	r := s.checkReference(ls, 1)
+	opts := tagLsOpts{}
+	optsArgs := []scheme.TagOpts{}
+	var re *regexp.Regexp
+	var semverCons semver.Constraints
+	if ls.GetTop() > 1 {
+		tab := ls.CheckTable(2)
+		err := go2lua.Import(ls, tab, &opts, nil)
+		if err != nil {
+			ls.ArgError(2, fmt.Sprintf("Failed to parse options: %v", err))
+		}
+		if opts.Last != "" {
+			optsArgs = append(optsArgs, scheme.WithTagLast(opts.Last))
+		}
+		if opts.Regex != "" {
+			re, err = regexp.Compile(opts.Regex)
+			if err != nil {
+				ls.ArgError(2, fmt.Sprintf("Failed to parse regex: %v", err))
+			}
+		}
+		if opts.Semver != "" {
+			semverCons, err = semver.ParseConstraints(opts.Semver)
+			if err != nil {
+				ls.ArgError(2, fmt.Sprintf("Failed to parse semver: %v", err))
+			}
+		}
+	}
 	s.log.Debug("Listing tags",
 		slog.String("script", s.name),
-		slog.String("repo", r.r.CommonName()))
-	tl, err := s.rc.TagList(s.ctx, r.r)
+		slog.String("repo", r.r.CommonName()),
+		slog.Any("opts", opts))
+	tl, err := s.rc.TagList(s.ctx, r.r, optsArgs...)
 	if err != nil {
 		ls.RaiseError("Failed retrieving tag list: %v", err)
 	}
@@ -63,8 +107,38 @@ func (s *Sandbox) tagLs(ls *lua.LState) int {
 	if err != nil {
 		ls.RaiseError("Failed retrieving tag list: %v", err)
 	}
+	count := 0
 	for _, tag := range lTagsList {
-		lTags.Append(lua.LString(tag))
+		if opts.Limit > 0 && count >= opts.Limit {
+			break
+		}
+		if opts.Prefix != "" && !strings.HasPrefix(tag, opts.Prefix) {
+			continue
+		}
+		if re != nil && !re.MatchString(tag) {
+			continue
+		}
+		if semverCons != nil && !semverCons.MatchString(tag) {
+			continue
+		}
+		count++
+		if !opts.Digest {
+			lTags.Append(lua.LString(tag))
+			continue
+		}
+		err = s.ctx.Err()
+		if err != nil {
+			ls.RaiseError("Context error: %v", err)
+		}
+		rTag := r.r.SetTag(tag)
+		m, err := s.rc.ManifestHead(s.ctx, rTag, regclient.WithManifestRequireDigest())
+		if err != nil {
+			ls.RaiseError("Failed retrieving \"%s\" manifest: %v", rTag.CommonName(), err)
+		}
+		lTag := ls.NewTable()
+		lTag.RawSetString("tag", lua.LString(tag))
+		lTag.RawSetString("digest", lua.LString(m.GetDescriptor().Digest.String()))
+		lTags.Append(lTag)
 	}
 	ls.Push(lTags)
 	return 1
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	// crypto libraries included for go-digest
@@ -69,7 +70,7 @@ type artifactCmd struct {
 	byDigest         bool
 	digestTags       bool
 	externalRepo     string
-	filterAT         string
+	filterAT         []string
 	filterAnnot      []string
 	formatList       string
 	formatPut        string
@@ -77,9 +78,13 @@ type artifactCmd struct {
 	getConfig        bool
 	index            bool
 	latest           bool
+	noEmptyConfig    bool
 	outputDir        string
 	platform         string
 	refers           string
+	replaceAT        string
+	replaceDryRun    bool
+	replaceType      bool
 	sortAnnot        string
 	sortDesc         bool
 	stripDirs        bool
@@ -125,20 +130,29 @@ regctl artifact get registry.example.org/artifact:0.0.1 --config`,
 # list all referrers of the regsync package for the local platform
 regctl artifact list ghcr.io/regclient/regctl --platform local
 
+# list the SBOM and signature referrers to an image
+regctl artifact list registry.example.com/repo:v1 \
+  --filter-artifact-type application/spdx+json \
+  --filter-artifact-type application/vnd.dev.cosign.artifact.sig.v1+json
+
 # return the original referrers response
 regctl artifact list registry.example.com/repo:v1 --format body
 
 # pretty print the referrers response
 regctl artifact list registry.example.com/repo:v1 --format '{{jsonPretty .Manifest}}'`,
-		Args:      cobra.ExactArgs(1),
-		ValidArgs: []string{}, // do not auto complete repository/tag
-		RunE:      artifactOpts.runArtifactList,
+		Args:        cobra.ExactArgs(1),
+		ValidArgs:   []string{}, // do not auto complete repository/tag
+		RunE:        artifactOpts.runArtifactList,
+		Annotations: map[string]string{annotationOutput: "true"},
 	}
 	var artifactPutCmd = &cobra.Command{
 		Use:     "put <reference>",
 		Aliases: []string{"push"},
 		Short:   "upload artifacts",
-		Long:    `Upload artifacts to the registry.`,
+		Long: `Upload artifacts to the registry.
+When --replace-subject-referrers or --replace-type is used with a subject,
+other referrers to the subject with the same artifact type are deleted after the push.
+Adding --dry-run pushes the artifact but only shows the referrers that would be deleted.`,
 		Example: `
 # push a simple artifact by name
 regctl artifact put \
@@ -157,7 +171,19 @@ regctl artifact put \
 regctl artifact put \
   --artifact-type application/spdx+json \
   --subject registry.example.com/repo:v1 \
-  < spdx.json`,
+  < spdx.json
+
+# push a new SBOM and delete the previous SBOMs for the same image
+regctl artifact put \
+  --artifact-type application/spdx+json \
+  --subject registry.example.com/repo:v1 \
+  --replace-subject-referrers application/spdx+json \
+  < spdx.json
+
+# push an artifact without a config, using the non-portable artifact manifest
+regctl artifact put --no-empty-config \
+  --artifact-type application/example.test \
+  registry.example.com/repo:artifact <text.txt`,
 		Args:      cobra.RangeArgs(0, 1),
 		ValidArgs: []string{}, // do not auto complete repository/tag
 		RunE:      artifactOpts.runArtifactPut,
@@ -174,7 +200,10 @@ For a single image, it is better to run "regctl artifact list".`,
 regctl artifact tree ghcr.io/regclient/regsync:latest
 
 # include digest tags (used by sigstore)
-regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
+regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest
+
+# render the graph with Graphviz
+regctl artifact tree --format dot ghcr.io/regclient/regsync:latest | dot -Tsvg >regsync.svg`,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{}, // do not auto complete repository/tag
 		RunE:      artifactOpts.runArtifactTree,
@@ -183,7 +212,7 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 	artifactGetCmd.Flags().StringVar(&artifactOpts.subject, "subject", "", "Get a referrer to the subject reference")
 	artifactGetCmd.Flags().StringVar(&artifactOpts.externalRepo, "external", "", "Query referrers from a separate source")
 	artifactGetCmd.Flags().StringVarP(&artifactOpts.platform, "platform", "p", "", "Specify platform of a subject (e.g. linux/amd64 or local)")
-	artifactGetCmd.Flags().StringVar(&artifactOpts.filterAT, "filter-artifact-type", "", "Filter referrers by artifactType")
+	artifactGetCmd.Flags().StringSliceVar(&artifactOpts.filterAT, "filter-artifact-type", []string{}, "Filter referrers by artifactType, repeat to match any of several types")
 	artifactGetCmd.Flags().StringArrayVar(&artifactOpts.filterAnnot, "filter-annotation", []string{}, "Filter referrers by annotation (key=value)")
 	artifactGetCmd.Flags().BoolVar(&artifactOpts.getConfig, "config", false, "Show the config, overrides file options")
 	artifactGetCmd.Flags().StringVar(&artifactOpts.artifactConfig, "config-file", "", "Output config to a file")
@@ -193,7 +222,7 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 		return artifactFileKnownTypes, cobra.ShellCompDirectiveNoFileComp
 	})
 	artifactGetCmd.Flags().BoolVar(&artifactOpts.latest, "latest", false, "Get the most recent referrer using the OCI created annotation")
-	artifactGetCmd.Flags().StringVarP(&artifactOpts.outputDir, "output", "o", "", "Output directory for multiple artifacts")
+	artifactGetCmd.Flags().StringVarP(&artifactOpts.outputDir, "output", "o", "", "Output directory for multiple artifacts, replacing the global --output flag for this command")
 	artifactGetCmd.Flags().BoolVar(&artifactOpts.stripDirs, "strip-dirs", false, "Strip directories from filenames in output dir")
 	artifactGetCmd.Flags().StringVar(&artifactOpts.refers, "refers", "", "Deprecated: Get a referrer to the reference")
 	_ = artifactGetCmd.Flags().MarkHidden("refers")
@@ -202,7 +231,7 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 
 	artifactListCmd.Flags().BoolVar(&artifactOpts.digestTags, "digest-tags", false, "Include digest tags")
 	artifactListCmd.Flags().StringVar(&artifactOpts.externalRepo, "external", "", "Query referrers from a separate source")
-	artifactListCmd.Flags().StringVar(&artifactOpts.filterAT, "filter-artifact-type", "", "Filter descriptors by artifactType")
+	artifactListCmd.Flags().StringSliceVar(&artifactOpts.filterAT, "filter-artifact-type", []string{}, "Filter descriptors by artifactType, repeat to match any of several types")
 	artifactListCmd.Flags().StringArrayVar(&artifactOpts.filterAnnot, "filter-annotation", []string{}, "Filter descriptors by annotation (key=value)")
 	artifactListCmd.Flags().StringVar(&artifactOpts.formatList, "format", "{{printPretty .}}", "Format output with go template syntax")
 	artifactListCmd.Flags().BoolVar(&artifactOpts.latest, "latest", false, "Sort using the OCI created annotation")
@@ -233,17 +262,24 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 	artifactPutCmd.Flags().BoolVar(&artifactOpts.byDigest, "by-digest", false, "Push manifest by digest instead of tag")
 	artifactPutCmd.Flags().StringVar(&artifactOpts.formatPut, "format", "", "Format output with go template syntax")
 	artifactPutCmd.Flags().BoolVar(&artifactOpts.index, "index", false, "Create/append artifact to an index")
+	artifactPutCmd.Flags().BoolVar(&artifactOpts.noEmptyConfig, "no-empty-config", false, "Omit the config by pushing an OCI artifact manifest, not supported by all registries")
 	artifactPutCmd.Flags().StringVar(&artifactOpts.subject, "subject", "", "Set the subject to a reference (used for referrer queries)")
 	artifactPutCmd.Flags().BoolVar(&artifactOpts.stripDirs, "strip-dirs", false, "Strip directories from filenames in file-title")
 	artifactPutCmd.Flags().StringVarP(&artifactOpts.platform, "platform", "p", "", "Specify platform of a subject (e.g. linux/amd64 or local)")
+	artifactPutCmd.Flags().BoolVar(&artifactOpts.replaceType, "replace-type", false, "Delete other referrers to the subject with the same artifact type")
+	artifactPutCmd.Flags().StringVar(&artifactOpts.replaceAT, "replace-subject-referrers", "", "Delete other referrers to the subject with this artifact type, which must match the pushed artifact")
+	_ = artifactPutCmd.RegisterFlagCompletionFunc("replace-subject-referrers", completeArgNone)
+	artifactPutCmd.Flags().BoolVar(&artifactOpts.replaceDryRun, "dry-run", false, "Show referrers that would be replaced without deleting them")
+	artifactPutCmd.Flags().BoolVar(&artifactOpts.replaceDryRun, "replace-dry-run", false, "Show referrers that --replace-type would delete without deleting them")
+	_ = artifactPutCmd.Flags().MarkHidden("replace-dry-run")
 	artifactPutCmd.Flags().StringVar(&artifactOpts.refers, "refers", "", "EXPERIMENTAL: Set a referrer to the reference")
 	_ = artifactPutCmd.Flags().MarkHidden("refers")
 
 	artifactTreeCmd.Flags().BoolVar(&artifactOpts.digestTags, "digest-tags", false, "Include digest tags")
 	artifactTreeCmd.Flags().StringVar(&artifactOpts.externalRepo, "external", "", "Query referrers from a separate source")
-	artifactTreeCmd.Flags().StringVar(&artifactOpts.filterAT, "filter-artifact-type", "", "Filter descriptors by artifactType")
+	artifactTreeCmd.Flags().StringSliceVar(&artifactOpts.filterAT, "filter-artifact-type", []string{}, "Filter descriptors by artifactType, repeat to match any of several types")
 	artifactTreeCmd.Flags().StringArrayVar(&artifactOpts.filterAnnot, "filter-annotation", []string{}, "Filter descriptors by annotation (key=value)")
-	artifactTreeCmd.Flags().StringVar(&artifactOpts.formatTree, "format", "{{printPretty .}}", "Format output with go template syntax")
+	artifactTreeCmd.Flags().StringVar(&artifactOpts.formatTree, "format", "{{printPretty .}}", "Format output with go template syntax, or \"dot\" for a Graphviz graph")
 
 	artifactTopCmd.AddCommand(artifactGetCmd)
 	artifactTopCmd.AddCommand(artifactListCmd)
@@ -286,7 +322,7 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 
 	r := ref.Ref{}
 	matchOpts := descriptor.MatchOpt{
-		ArtifactType:   artifactOpts.filterAT,
+		ArtifactTypes:  artifactOpts.filterAT,
 		SortAnnotation: artifactOpts.sortAnnot,
 		SortDesc:       artifactOpts.sortDesc,
 	}
@@ -319,6 +355,7 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 		if err != nil {
 			return err
 		}
+		artifactOpts.platform = artifactOpts.rootOpts.platformDefault(rSubject, artifactOpts.platform)
 		referrerMatchOpts := matchOpts
 		referrerMatchOpts.Platform = nil
 		referrerOpts := []scheme.ReferrerOpts{
@@ -568,6 +605,7 @@ func (artifactOpts *artifactCmd) runArtifactList(cmd *cobra.Command, args []stri
 	if err != nil {
 		return err
 	}
+	artifactOpts.platform = artifactOpts.rootOpts.platformDefault(rSubject, artifactOpts.platform)
 	if artifactOpts.latest && artifactOpts.sortAnnot != "" {
 		return fmt.Errorf("--latest cannot be used with --sort-annotation")
 	}
@@ -580,7 +618,7 @@ func (artifactOpts *artifactCmd) runArtifactList(cmd *cobra.Command, args []stri
 	defer rc.Close(ctx, rSubject)
 
 	matchOpts := descriptor.MatchOpt{
-		ArtifactType:   artifactOpts.filterAT,
+		ArtifactTypes:  artifactOpts.filterAT,
 		SortAnnotation: artifactOpts.sortAnnot,
 		SortDesc:       artifactOpts.sortDesc,
 	}
@@ -661,7 +699,7 @@ func (artifactOpts *artifactCmd) runArtifactList(cmd *cobra.Command, args []stri
 	case "rawHeaders", "raw-headers", "headers":
 		artifactOpts.formatList = "{{ range $key,$vals := .Manifest.RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatList, rl)
+	return artifactOpts.rootOpts.resultOutput(cmd, "", artifactOpts.formatList, rl)
 }
 
 func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []string) error {
@@ -670,9 +708,19 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	var r, rArt, rSubject ref.Ref
 	var err error
 
+	if artifactOpts.noEmptyConfig {
+		if flagChanged(cmd, "media-type") && artifactOpts.artifactMT != mediatype.OCI1Artifact {
+			return fmt.Errorf("--no-empty-config is not supported with media-type %s, the config is required%.0w", artifactOpts.artifactMT, errs.ErrUnsupportedMediaType)
+		}
+		artifactOpts.artifactMT = mediatype.OCI1Artifact
+	}
 	switch artifactOpts.artifactMT {
 	case mediatype.OCI1Artifact:
-		artifactOpts.rootOpts.log.Warn("changing media-type is experimental and non-portable")
+		if artifactOpts.noEmptyConfig {
+			artifactOpts.rootOpts.log.Warn("artifacts without a config use the artifact manifest media-type, which is non-portable and rejected by many registries")
+		} else {
+			artifactOpts.rootOpts.log.Warn("changing media-type is experimental and non-portable")
+		}
 		hasConfig = false
 	case "", mediatype.OCI1Manifest:
 		hasConfig = true
@@ -727,6 +775,16 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	if !rArt.IsSet() && !rSubject.IsSet() {
 		return fmt.Errorf("either a reference or subject must be provided")
 	}
+	replacing := artifactOpts.replaceType || artifactOpts.replaceAT != ""
+	if replacing && !rSubject.IsSet() {
+		return fmt.Errorf("replacing referrers requires a subject%.0w", errs.ErrUnsupported)
+	}
+	if artifactOpts.replaceDryRun && !replacing {
+		return fmt.Errorf("--dry-run requires --replace-subject-referrers or --replace-type%.0w", errs.ErrUnsupported)
+	}
+	if artifactOpts.replaceAT != "" && !mediatype.Valid(artifactOpts.replaceAT) {
+		return fmt.Errorf("invalid media type: %s%.0w", artifactOpts.replaceAT, errs.ErrUnsupportedMediaType)
+	}
 
 	// validate/set artifactType and config.mediaType
 	if artifactOpts.artifactConfigMT != "" && !mediatype.Valid(artifactOpts.artifactConfigMT) {
@@ -794,10 +852,11 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	var subjectDesc *descriptor.Descriptor
 	if rSubject.IsSet() {
 		mOpts := []regclient.ManifestOpts{regclient.WithManifestRequireDigest()}
-		if artifactOpts.platform != "" {
-			p, err := platform.Parse(artifactOpts.platform)
+		// the default platform only selects the subject, it is not added to an index descriptor
+		if subjectPlat := artifactOpts.rootOpts.platformDefault(rSubject, artifactOpts.platform); subjectPlat != "" {
+			p, err := platform.Parse(subjectPlat)
 			if err != nil {
-				return fmt.Errorf("failed to parse platform %s: %w", artifactOpts.platform, err)
+				return fmt.Errorf("failed to parse platform %s: %w", subjectPlat, err)
 			}
 			mOpts = append(mOpts, regclient.WithManifestPlatform(p))
 		}
@@ -853,7 +912,7 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 					return err
 				}
 				if fi.IsDir() {
-					tf, err := os.CreateTemp("", "regctl-artifact-*.tgz")
+					tf, err := os.CreateTemp(artifactOpts.rootOpts.tmpDir, "regctl-artifact-*.tgz")
 					if err != nil {
 						return err
 					}
@@ -968,6 +1027,17 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	if err != nil {
 		return err
 	}
+	replaceAT := ""
+	if replacing {
+		replaceAT = artifactTypeOf(mm)
+		if replaceAT == "" {
+			return fmt.Errorf("replacing referrers requires an artifact type or config media type%.0w", errs.ErrUnsupported)
+		}
+		// only referrers of the same type as the pushed artifact are replaced
+		if artifactOpts.replaceAT != "" && artifactOpts.replaceAT != replaceAT {
+			return fmt.Errorf("--replace-subject-referrers %s does not match the artifact type %s%.0w", artifactOpts.replaceAT, replaceAT, errs.ErrUnsupported)
+		}
+	}
 
 	if artifactOpts.byDigest || artifactOpts.index || rArt.IsZero() {
 		r.Tag = ""
@@ -1035,6 +1105,14 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 		}
 	}
 
+	// delete older referrers with the same artifact type
+	if replacing {
+		err = artifactOpts.replaceReferrers(ctx, rc, r, rSubject, subjectDesc, mm.GetDescriptor(), replaceAT)
+		if err != nil {
+			return err
+		}
+	}
+
 	result := struct {
 		Manifest manifest.Manifest
 	}{
@@ -1046,6 +1124,60 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatPut, result)
 }
 
+// replaceReferrers deletes referrers of the subject with the artifact type of a newly pushed artifact.
+func (artifactOpts *artifactCmd) replaceReferrers(ctx context.Context, rc *regclient.RegClient, r, rSubject ref.Ref, subjectDesc *descriptor.Descriptor, newDesc descriptor.Descriptor, artifactType string) error {
+	if artifactType == "" {
+		return fmt.Errorf("replacing referrers requires an artifact type%.0w", errs.ErrUnsupported)
+	}
+	rSubject = rSubject.SetDigest(subjectDesc.Digest.String())
+	rOpts := []scheme.ReferrerOpts{
+		scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactType: artifactType}),
+	}
+	if !ref.EqualRepository(r, rSubject) {
+		rOpts = append(rOpts, scheme.WithReferrerSource(r))
+	}
+	rl, err := rc.ReferrerList(ctx, rSubject, rOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers to replace: %w", err)
+	}
+	for _, d := range rl.Descriptors {
+		if d.Digest == newDesc.Digest {
+			continue
+		}
+		rDel := r.SetDigest(d.Digest.String())
+		if artifactOpts.replaceDryRun {
+			artifactOpts.rootOpts.log.Info("Referrer would be replaced",
+				slog.String("ref", rDel.CommonName()),
+				slog.String("artifactType", d.ArtifactType))
+			continue
+		}
+		artifactOpts.rootOpts.log.Info("Deleting replaced referrer",
+			slog.String("ref", rDel.CommonName()),
+			slog.String("artifactType", d.ArtifactType))
+		err = rc.ManifestDelete(ctx, rDel, regclient.WithManifestCheckReferrers())
+		if err != nil {
+			return fmt.Errorf("failed to delete referrer %s: %w", rDel.CommonName(), err)
+		}
+	}
+	return nil
+}
+
+// artifactTypeOf returns the artifact type of a manifest, falling back to the config media type like the referrers API.
+func artifactTypeOf(m manifest.Manifest) string {
+	switch orig := m.GetOrig().(type) {
+	case v1.Manifest:
+		if orig.ArtifactType != "" {
+			return orig.ArtifactType
+		}
+		return orig.Config.MediaType
+	case v1.ArtifactManifest:
+		return orig.ArtifactType
+	case v1.Index:
+		return orig.ArtifactType
+	}
+	return ""
+}
+
 func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
@@ -1063,8 +1195,8 @@ func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []stri
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
 	}
 	referrerOpts := []scheme.ReferrerOpts{}
-	if artifactOpts.filterAT != "" {
-		referrerOpts = append(referrerOpts, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactType: artifactOpts.filterAT}))
+	if len(artifactOpts.filterAT) > 0 {
+		referrerOpts = append(referrerOpts, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactTypes: artifactOpts.filterAT}))
 	}
 	if artifactOpts.filterAnnot != nil {
 		af := map[string]string{}
@@ -1100,7 +1232,13 @@ func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []stri
 	seen := []string{}
 	tr, err := artifactOpts.treeAddResult(ctx, rc, r, seen, referrerOpts, tags)
 	var twErr error
-	if tr != nil {
+	if tr != nil && artifactOpts.formatTree == "dot" {
+		var out []byte
+		out, twErr = tr.MarshalDot()
+		if twErr == nil {
+			_, twErr = cmd.OutOrStdout().Write(out)
+		}
+	} else if tr != nil {
 		twErr = template.Writer(cmd.OutOrStdout(), artifactOpts.formatTree, tr)
 	}
 	if err != nil {
@@ -1237,7 +1375,41 @@ func (tr *treeResult) MarshalPretty() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return []byte(fmt.Sprintf("Ref: %s\nDigest: %s", tr.Ref.CommonName(), mp)), nil
+	return []byte(fmt.Sprintf("Ref: %s\nDigest: %s", tr.Ref.ShortName(), mp)), nil
+}
+
+// MarshalDot outputs the tree as a Graphviz DOT graph.
+// Child manifests are connected with solid edges and referrers with dashed edges, both pointing from the parent.
+func (tr *treeResult) MarshalDot() ([]byte, error) {
+	result := bytes.NewBufferString("digraph {\n  node [shape=box];\n")
+	tr.marshalDot(result, map[string]bool{})
+	result.WriteString("}\n")
+	return result.Bytes(), nil
+}
+
+func (tr *treeResult) marshalDot(result *bytes.Buffer, nodes map[string]bool) {
+	dig := tr.Manifest.GetDescriptor().Digest.String()
+	if !nodes[dig] {
+		nodes[dig] = true
+		label := dig
+		if tr.Platform != nil {
+			label += "\n" + tr.Platform.String()
+		}
+		if tr.ArtifactType != "" {
+			label += "\n" + tr.ArtifactType
+		} else if strings.HasPrefix(tr.Ref.Tag, "sha256-") {
+			label += "\n" + tr.Ref.Tag
+		}
+		fmt.Fprintf(result, "  %s [label=%s];\n", strconv.Quote(dig), strconv.Quote(label))
+	}
+	for _, trChild := range tr.Child {
+		fmt.Fprintf(result, "  %s -> %s [label=\"child\"];\n", strconv.Quote(dig), strconv.Quote(trChild.Manifest.GetDescriptor().Digest.String()))
+		trChild.marshalDot(result, nodes)
+	}
+	for _, trReferrer := range tr.Referrer {
+		fmt.Fprintf(result, "  %s -> %s [label=\"referrer\", style=dashed];\n", strconv.Quote(dig), strconv.Quote(trReferrer.Manifest.GetDescriptor().Digest.String()))
+		trReferrer.marshalDot(result, nodes)
+	}
 }
 
 func (tr *treeResult) marshalPretty(indent string) ([]byte, error) {
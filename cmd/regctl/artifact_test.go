@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/mediatype"
 )
 
 func TestArtifactGet(t *testing.T) {
@@ -134,6 +136,16 @@ func TestArtifactList(t *testing.T) {
 			args:      []string{"artifact", "list", "ocidir://../../testdata/testrepo:v2", "--filter-artifact-type", "application/example.sbom", "--format", "{{ ( index .Descriptors 0 ).ArtifactType }}"},
 			expectOut: "application/example.sbom",
 		},
+		{
+			name:      "Filter Multiple Types",
+			args:      []string{"artifact", "list", "ocidir://../../testdata/testrepo:v2", "--filter-artifact-type", "application/example.sbom", "--filter-artifact-type", "application/example.signature", "--format", "{{ len .Descriptors }}"},
+			expectOut: "2",
+		},
+		{
+			name:      "Filter Comma Separated Types",
+			args:      []string{"artifact", "list", "ocidir://../../testdata/testrepo:v2", "--filter-artifact-type", "application/example.sbom,application/example.missing", "--format", "{{ ( index .Descriptors 0 ).ArtifactType }} {{ len .Descriptors }}"},
+			expectOut: "application/example.sbom 1",
+		},
 		{
 			name:        "External referrers",
 			args:        []string{"artifact", "list", "ocidir://../../testdata/testrepo:v2", "--external", "ocidir://../../testdata/external"},
@@ -268,6 +280,25 @@ func TestArtifactPut(t *testing.T) {
 			args: []string{"artifact", "put", "--artifact-type", "application/vnd.example", "--annotation", "test=b", "--platform", "linux/arm64", "--index", "ocidir://" + testDir + ":index"},
 			in:   testData,
 		},
+		{
+			name:        "Put no empty config",
+			args:        []string{"artifact", "put", "--no-empty-config", "--artifact-type", "application/vnd.example", "ocidir://" + testDir + ":no-config"},
+			in:          testData,
+			expectOut:   "non-portable",
+			outContains: true,
+		},
+		{
+			name:      "Put no empty config with config file",
+			args:      []string{"artifact", "put", "--no-empty-config", "--config-type", "application/vnd.example", "--config-file", testConfName, "ocidir://" + testDir + ":err"},
+			in:        testData,
+			expectErr: errs.ErrUnsupportedMediaType,
+		},
+		{
+			name:      "Put no empty config with image manifest",
+			args:      []string{"artifact", "put", "--no-empty-config", "--media-type", mediatype.OCI1Manifest, "ocidir://" + testDir + ":err"},
+			in:        testData,
+			expectErr: errs.ErrUnsupportedMediaType,
+		},
 		{
 			name:      "Invalid-artifact-media-type",
 			args:      []string{"artifact", "put", "--artifact-type", "application/vnd.example;version=1.0", "ocidir://" + testDir + ":err"},
@@ -310,6 +341,152 @@ func TestArtifactPut(t *testing.T) {
 			}
 		})
 	}
+	t.Run("Get no empty config", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "get", "--format", "body", "ocidir://"+testDir+":no-config")
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		m := map[string]any{}
+		err = json.Unmarshal([]byte(out), &m)
+		if err != nil {
+			t.Fatalf("failed to parse manifest: %v", err)
+		}
+		if _, ok := m["config"]; ok {
+			t.Errorf("manifest contains a config: %s", out)
+		}
+		if m["mediaType"] != mediatype.OCI1Artifact {
+			t.Errorf("unexpected media type: %v", m["mediaType"])
+		}
+		out, err = cobraTest(t, nil, "artifact", "get", "ocidir://"+testDir+":no-config")
+		if err != nil {
+			t.Fatalf("failed to get artifact: %v", err)
+		}
+		if out != string(testData) {
+			t.Errorf("unexpected artifact content, expected %s, received %s", testData, out)
+		}
+	})
+}
+
+func TestArtifactPutReplace(t *testing.T) {
+	testDir := t.TempDir()
+	subject := "ocidir://" + testDir + ":subject"
+	sbomAT := "application/example.sbom"
+	_, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("subject")}, "artifact", "put", "--artifact-type", "application/example.subject", subject)
+	if err != nil {
+		t.Fatalf("failed to push subject: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("sig")}, "artifact", "put", "--artifact-type", "application/example.sig", "--subject", subject)
+	if err != nil {
+		t.Fatalf("failed to push signature: %v", err)
+	}
+	sbomDigests := []string{}
+	for i, args := range [][]string{
+		{"artifact", "put", "--artifact-type", sbomAT, "--subject", subject, "--by-digest"},
+		{"artifact", "put", "--artifact-type", sbomAT, "--subject", subject, "--by-digest", "--replace-type"},
+		{"artifact", "put", "--artifact-type", sbomAT, "--subject", subject, "--by-digest", "--replace-type", "--replace-dry-run"},
+	} {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString(fmt.Sprintf("sbom %d", i))}, args...)
+		if err != nil {
+			t.Fatalf("failed to push sbom %d: %v", i, err)
+		}
+		sbomDigests = append(sbomDigests, strings.TrimSpace(out))
+	}
+	out, err := cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", sbomAT, "--format", "{{range .Descriptors}}{{println .Digest}}{{end}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	found := strings.Fields(out)
+	if len(found) != 2 {
+		t.Fatalf("unexpected referrers, expected the second and third sbom, received %v", found)
+	}
+	for _, dig := range found {
+		if dig != sbomDigests[1] && dig != sbomDigests[2] {
+			t.Errorf("unexpected referrer found: %s, expected %v", dig, sbomDigests[1:])
+		}
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", "application/example.sig", "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "1" {
+		t.Errorf("referrer with a different artifact type was modified, found %s", out)
+	}
+	// without an artifact type, the config media type selects the referrers to replace
+	configMT := "application/example.config"
+	configDigests := []string{}
+	for i := 0; i < 2; i++ {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString(fmt.Sprintf("config %d", i))}, "artifact", "put", "--config-type", configMT, "--subject", subject, "--by-digest", "--replace-type")
+		if err != nil {
+			t.Fatalf("failed to push artifact with a config type %d: %v", i, err)
+		}
+		configDigests = append(configDigests, strings.TrimSpace(out))
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", configMT, "--format", "{{range .Descriptors}}{{println .Digest}}{{end}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != configDigests[1] {
+		t.Errorf("unexpected referrers with the config type, expected %s, received %s", configDigests[1], out)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", "application/example.sig", "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "1" {
+		t.Errorf("referrer with a different artifact type was deleted by a config type replace, found %s", out)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", sbomAT, "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "2" {
+		t.Errorf("referrers with a different artifact type were deleted by a config type replace, found %s", out)
+	}
+	// replace by an explicit artifact type, only the latest sbom remains
+	subject2 := "ocidir://" + testDir + ":subject2"
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("subject2")}, "artifact", "put", "--artifact-type", "application/example.subject", subject2)
+	if err != nil {
+		t.Fatalf("failed to push subject: %v", err)
+	}
+	latest := ""
+	for i := 0; i < 2; i++ {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString(fmt.Sprintf("sbom v%d", i))}, "artifact", "put", "--artifact-type", sbomAT, "--subject", subject2, "--by-digest", "--replace-subject-referrers", sbomAT)
+		if err != nil {
+			t.Fatalf("failed to push sbom v%d: %v", i, err)
+		}
+		latest = strings.TrimSpace(out)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject2, "--filter-artifact-type", sbomAT, "--format", "{{range .Descriptors}}{{println .Digest}}{{end}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != latest {
+		t.Errorf("unexpected referrers after replace, expected %s, received %s", latest, out)
+	}
+	// dry run leaves the previous sbom
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("sbom dry run")}, "artifact", "put", "--artifact-type", sbomAT, "--subject", subject2, "--by-digest", "--replace-subject-referrers", sbomAT, "--dry-run")
+	if err != nil {
+		t.Fatalf("failed to push sbom with dry run: %v", err)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", subject2, "--filter-artifact-type", sbomAT, "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "2" {
+		t.Errorf("dry run deleted referrers, found %s", out)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("mismatch")}, "artifact", "put", "--artifact-type", sbomAT, "--subject", subject2, "--replace-subject-referrers", "application/example.sig")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("replace with a different artifact type did not fail, received %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("dry run")}, "artifact", "put", "--artifact-type", sbomAT, "--subject", subject2, "--dry-run")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("dry run without replace did not fail, received %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("no subject")}, "artifact", "put", "--artifact-type", sbomAT, "--replace-type", "ocidir://"+testDir+":no-subject")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("replace without a subject did not fail, received %v", err)
+	}
 }
 
 func TestArtifactTree(t *testing.T) {
@@ -374,6 +551,16 @@ func TestArtifactTree(t *testing.T) {
 			args:        []string{"artifact", "tree", "ocidir://../../testdata/testrepo:v2", "--external", "ocidir://../../testdata/external"},
 			expectOut:   "Referrers",
 			outContains: true,
+		},
+		{
+			name: "Format dot",
+			args: []string{"artifact", "tree", "ocidir://../../testdata/testrepo@sha256:ee378b79279b57eb5ac1f3b892c9ad2a9be9d9ccabe1a29a9cbaed8cad182358", "--format", "dot"},
+			expectOut: `digraph {
+  node [shape=box];
+  "sha256:ee378b79279b57eb5ac1f3b892c9ad2a9be9d9ccabe1a29a9cbaed8cad182358" [label="sha256:ee378b79279b57eb5ac1f3b892c9ad2a9be9d9ccabe1a29a9cbaed8cad182358"];
+  "sha256:ee378b79279b57eb5ac1f3b892c9ad2a9be9d9ccabe1a29a9cbaed8cad182358" -> "sha256:30bc58e881e9e21ce6b77b7b3f69dac5e9371c9ea5a445234c22234826563023" [label="referrer", style=dashed];
+  "sha256:30bc58e881e9e21ce6b77b7b3f69dac5e9371c9ea5a445234c22234826563023" [label="sha256:30bc58e881e9e21ce6b77b7b3f69dac5e9371c9ea5a445234c22234826563023\napplication/example.arms"];
+}`,
 		}}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
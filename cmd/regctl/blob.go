@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"log/slog"
 	"math"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -20,17 +21,22 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient/internal/diff"
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/warning"
 )
 
 type blobCmd struct {
 	rootOpts       *rootCmd
+	decompress     bool
 	diffCtx        int
 	diffFullCtx    bool
 	diffIgnoreTime bool
+	formatCopy     string
 	formatGet      string
 	formatFile     string
 	formatHead     string
@@ -97,12 +103,20 @@ regctl blob diff-layer \
 		Short:   "download a blob/layer",
 		Long: `Download a blob from the registry. The output is the blob itself which may
 be a compressed tar file, a json config, or any other blob supported by the
-registry. The blob or layer digest can be found in the image manifest.`,
+registry. The blob or layer digest can be found in the image manifest.
+With --decompress, a gzip, zstd, bzip2, or xz compressed blob is decompressed.
+The compressed blob is downloaded to a temporary file to verify the digest
+before any uncompressed content is output.`,
 		Example: `
 # inspect the layer contents of a busybox image
 regctl blob get busybox \
   sha256:a58ecd4f0c864650a4286c3c2d49c7219a3f2fc8d7a0bf478aa9834acfe14ae7 \
-  | tar -tvzf -`,
+  | tar -tvzf -
+
+# output the uncompressed tar of a layer
+regctl blob get --decompress busybox \
+  sha256:a58ecd4f0c864650a4286c3c2d49c7219a3f2fc8d7a0bf478aa9834acfe14ae7 \
+  | tar -tvf -`,
 		Args:      cobra.ExactArgs(2),
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobGet,
@@ -125,11 +139,18 @@ regctl blob get-file alpine \
 		Use:     "head <repository> <digest>",
 		Aliases: []string{"digest"},
 		Short:   "http head request for a blob",
-		Long:    `Shows the headers for a blob head request.`,
+		Long: `Shows the headers for a blob head request.
+The format template has access to the .Digest, .Size, .MediaType, and .Headers
+of the response, without downloading the blob.`,
 		Example: `
 # verify the existence of a blob
 regctl blob head alpine \
-  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c`,
+  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c
+
+# show the size of a blob
+regctl blob head alpine \
+  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c \
+  --format '{{.Size}}'`,
 		Args:      cobra.ExactArgs(2),
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobHead,
@@ -151,18 +172,27 @@ regctl blob put registry.example.org/repo <layer.tgz`,
 		Use:     "copy <src_image_ref> <dst_image_ref> <digest>",
 		Aliases: []string{"cp"},
 		Short:   "copy blob",
-		Long: `Copy a blob between repositories. This works in the same registry only. It
-attempts to mount the layers between repositories. And within the same repository
-it only sends the manifest with the new tag.`,
+		Long: `Copy a blob between repositories. When both repositories are on the same
+registry, a cross repository mount is attempted first. Otherwise the blob is
+pulled from the source and pushed to the destination. The digest of the copied
+blob is verified on the destination.`,
 		Example: `
 # copy a blob
 regctl blob copy alpine registry.example.org/library/alpine \
-  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c`,
+  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c
+
+# copy a blob and output the size
+regctl blob copy alpine registry.example.org/library/alpine \
+  sha256:9123ac7c32f74759e6283f04dbf571f18246abe5bb2c779efcb32cd50f3ff13c \
+  --format '{{ .Size }}'`,
 		Args:      cobra.ExactArgs(3),
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobCopy,
 	}
 
+	blobCopyCmd.Flags().StringVarP(&blobOpts.formatCopy, "format", "", "", "Format output of the copied descriptor with go template syntax")
+	_ = blobCopyCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	blobDiffConfigCmd.Flags().IntVarP(&blobOpts.diffCtx, "context", "", 3, "Lines of context")
 	blobDiffConfigCmd.Flags().BoolVarP(&blobOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
 
@@ -170,6 +200,7 @@ regctl blob copy alpine registry.example.org/library/alpine \
 	blobDiffLayerCmd.Flags().BoolVarP(&blobOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
 	blobDiffLayerCmd.Flags().BoolVarP(&blobOpts.diffIgnoreTime, "ignore-timestamp", "", false, "Ignore timestamps on files")
 
+	blobGetCmd.Flags().BoolVarP(&blobOpts.decompress, "decompress", "", false, "Decompress the blob after verifying the digest")
 	blobGetCmd.Flags().StringVarP(&blobOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	blobGetCmd.Flags().StringVarP(&blobOpts.mt, "media-type", "", "", "Set the requested mediaType (deprecated)")
 	_ = blobGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
@@ -378,6 +409,9 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if blobOpts.decompress && flagChanged(cmd, "format") {
+		return fmt.Errorf("--decompress cannot be used with --format%.0w", errs.ErrUnsupported)
+	}
 	rc := blobOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 	if blobOpts.mt != "" {
@@ -393,6 +427,9 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if blobOpts.decompress {
+		return blobDecompress(cmd.OutOrStdout(), blob, blobOpts.rootOpts.tmpDir)
+	}
 
 	switch blobOpts.formatGet {
 	case "raw":
@@ -410,6 +447,36 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 	return template.Writer(cmd.OutOrStdout(), blobOpts.formatGet, blob)
 }
 
+// blobDecompress verifies the blob with a temporary file in tmpDir before outputting the decompressed content.
+func blobDecompress(w io.Writer, rdr io.ReadCloser, tmpDir string) error {
+	defer rdr.Close()
+	fh, err := os.CreateTemp(tmpDir, "regctl-blob-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fh.Close()
+		_ = os.Remove(fh.Name())
+	}()
+	_, err = io.Copy(fh, rdr)
+	if err != nil {
+		return fmt.Errorf("failed to download blob: %w", err)
+	}
+	_, err = fh.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	dr, err := archive.Decompress(fh)
+	if err != nil {
+		return fmt.Errorf("failed to decompress blob: %w", err)
+	}
+	if drc, ok := dr.(io.Closer); ok {
+		defer drc.Close()
+	}
+	_, err = io.Copy(w, dr)
+	return err
+}
+
 func (blobOpts *blobCmd) runBlobGetFile(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
@@ -491,7 +558,7 @@ func (blobOpts *blobCmd) runBlobHead(cmd *cobra.Command, args []string) error {
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository),
 		slog.String("digest", args[1]))
-	blob, err := rc.BlobHead(ctx, r, descriptor.Descriptor{Digest: d})
+	b, err := rc.BlobHead(ctx, r, descriptor.Descriptor{Digest: d})
 	if err != nil {
 		return err
 	}
@@ -500,8 +567,26 @@ func (blobOpts *blobCmd) runBlobHead(cmd *cobra.Command, args []string) error {
 	case "", "rawHeaders", "raw-headers", "headers":
 		blobOpts.formatHead = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
+	bDesc := b.GetDescriptor()
+	result := blobHeadResult{
+		BReader:   b,
+		Digest:    bDesc.Digest,
+		Size:      bDesc.Size,
+		MediaType: bDesc.MediaType,
+		Headers:   b.RawHeaders(),
+	}
+
+	return template.Writer(cmd.OutOrStdout(), blobOpts.formatHead, result)
+}
 
-	return template.Writer(cmd.OutOrStdout(), blobOpts.formatHead, blob)
+// blobHeadResult is the output of the blob head command.
+// The blob is embedded to support templates using the blob methods, e.g. {{.RawHeaders}}.
+type blobHeadResult struct {
+	*blob.BReader `json:"-"`
+	Digest        digest.Digest `json:"digest"`
+	Size          int64         `json:"size"`
+	MediaType     string        `json:"mediaType,omitempty"`
+	Headers       http.Header   `json:"headers,omitempty"`
 }
 
 func (blobOpts *blobCmd) runBlobPut(cmd *cobra.Command, args []string) error {
@@ -553,6 +638,7 @@ func (blobOpts *blobCmd) runBlobCopy(cmd *cobra.Command, args []string) error {
 	}
 	rc := blobOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, rSrc)
+	defer rc.Close(ctx, rTgt)
 
 	blobOpts.rootOpts.log.Debug("Blob copy",
 		slog.String("source", rSrc.CommonName()),
@@ -562,7 +648,26 @@ func (blobOpts *blobCmd) runBlobCopy(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	// verify the blob on the target
+	bh, err := rc.BlobHead(ctx, rTgt, descriptor.Descriptor{Digest: d})
+	if err != nil {
+		return err
+	}
+	desc := bh.GetDescriptor()
+	_ = bh.Close()
+	// the descriptor digest is the requested digest, compare against the digest returned by the registry
+	if h := bh.RawHeaders(); h != nil && h.Get("Docker-Content-Digest") != "" {
+		if hd, err := digest.Parse(h.Get("Docker-Content-Digest")); err != nil || hd != d {
+			return fmt.Errorf("blob digest mismatch on %s, expected %s, received %s%.0w", rTgt.CommonName(), d.String(), h.Get("Docker-Content-Digest"), errs.ErrDigestMismatch)
+		}
+	}
+	if desc.Digest == "" {
+		desc.Digest = d
+	}
+	if blobOpts.formatCopy == "" {
+		return nil
+	}
+	return template.Writer(cmd.OutOrStdout(), blobOpts.formatCopy, desc)
 }
 
 func (blobOpts *blobCmd) blobReportLayer(tr *tar.Reader) ([]string, error) {
@@ -2,7 +2,19 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/errs"
 )
 
 func TestBlob(t *testing.T) {
@@ -51,6 +63,57 @@ func TestBlob(t *testing.T) {
 		}
 	})
 
+	t.Run("Get decompress", func(t *testing.T) {
+		d, err := digest.Parse(digBaseA)
+		if err != nil {
+			t.Fatalf("failed to parse digest: %v", err)
+		}
+		fh, err := os.Open("../../testdata/testrepo/blobs/sha256/" + d.Encoded())
+		if err != nil {
+			t.Fatalf("failed to open layer: %v", err)
+		}
+		defer fh.Close()
+		gr, err := gzip.NewReader(fh)
+		if err != nil {
+			t.Fatalf("failed to decompress layer: %v", err)
+		}
+		expect, err := io.ReadAll(gr)
+		if err != nil {
+			t.Fatalf("failed to read layer: %v", err)
+		}
+		out, err := cobraTest(t, nil, "blob", "get", "--decompress", repo, digBaseA)
+		if err != nil {
+			t.Fatalf("failed to blob get: %v", err)
+		}
+		if out != strings.TrimSpace(string(expect)) {
+			t.Errorf("decompressed output mismatch, expected %d bytes, received %d", len(expect), len(out))
+		}
+		// the config is not compressed and output unchanged
+		out, err = cobraTest(t, nil, "blob", "get", "--decompress", repo, digConf1)
+		if err != nil {
+			t.Fatalf("failed to blob get: %v", err)
+		}
+		if !strings.HasPrefix(out, "{") {
+			t.Errorf("unexpected config output: %s", out)
+		}
+		// the temporary file is created in tmp-dir
+		out, err = cobraTest(t, nil, "--tmp-dir", t.TempDir(), "blob", "get", "--decompress", repo, digBaseA)
+		if err != nil {
+			t.Fatalf("failed to blob get with tmp-dir: %v", err)
+		}
+		if out != strings.TrimSpace(string(expect)) {
+			t.Errorf("decompressed output mismatch with tmp-dir, expected %d bytes, received %d", len(expect), len(out))
+		}
+		_, err = cobraTest(t, nil, "--tmp-dir", t.TempDir()+"/missing", "blob", "get", "--decompress", repo, digBaseA)
+		if err == nil {
+			t.Errorf("blob get succeeded with a missing tmp-dir")
+		}
+		_, err = cobraTest(t, nil, "blob", "get", "--decompress", "--format", "{{printPretty .}}", repo, digBaseA)
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("unexpected error with format, expected %v, received %v", errs.ErrUnsupported, err)
+		}
+	})
+
 	t.Run("Put and Delete", func(t *testing.T) {
 		dir := t.TempDir()
 		bufStr := "hello world"
@@ -97,6 +160,14 @@ func TestBlob(t *testing.T) {
 		if out == "" {
 			t.Errorf("no blob output received")
 		}
+		// copy again with the descriptor output
+		out, err = cobraTest(t, nil, "blob", "copy", "--format", "{{ .Digest }}", repo, "ocidir://"+dir, digBaseA)
+		if err != nil {
+			t.Fatalf("failed to blob copy: %v", err)
+		}
+		if out != digBaseA {
+			t.Errorf("unexpected output, expected %s, received %s", digBaseA, out)
+		}
 	})
 
 	t.Run("Diff", func(t *testing.T) {
@@ -119,3 +190,116 @@ func TestBlob(t *testing.T) {
 	})
 
 }
+
+func TestBlobCopyVerify(t *testing.T) {
+	blobDigest := digest.FromString("mocked blob")
+	wrongDigest := digest.FromString("wrong blob")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/good/blobs/"+blobDigest.String():
+			w.Header().Set("Content-Length", "11")
+			w.Header().Set("Docker-Content-Digest", blobDigest.String())
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/bad/blobs/"+blobDigest.String():
+			w.Header().Set("Content-Length", "11")
+			w.Header().Set("Docker-Content-Digest", wrongDigest.String())
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	hostFlag := "reg=" + tsHost + ",tls=disabled"
+	t.Run("matching digest", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "--host", hostFlag, "blob", "copy", tsHost+"/src", tsHost+"/good", blobDigest.String(), "--format", "{{.Digest}}")
+		if err != nil {
+			t.Fatalf("failed to copy blob: %v", err)
+		}
+		if out != blobDigest.String() {
+			t.Errorf("unexpected output, expected %s, received %s", blobDigest.String(), out)
+		}
+	})
+	t.Run("mismatched digest", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "--host", hostFlag, "blob", "copy", tsHost+"/src", tsHost+"/bad", blobDigest.String())
+		if !errors.Is(err, errs.ErrDigestMismatch) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrDigestMismatch, err)
+		}
+	})
+}
+
+func TestBlobHeadFormat(t *testing.T) {
+	blobDigest := digest.FromString("mocked blob")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/testrepo/blobs/"+blobDigest.String():
+			w.Header().Set("Content-Type", "application/vnd.oci.image.layer.v1.tar+gzip")
+			w.Header().Set("Content-Length", "123456")
+			w.Header().Set("Docker-Content-Digest", blobDigest.String())
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	hostFlag := "reg=" + tsHost + ",tls=disabled"
+	tt := []struct {
+		name      string
+		format    string
+		expectOut string
+	}{
+		{
+			name:      "size and media type",
+			format:    "{{.Size}} {{.MediaType}}",
+			expectOut: "123456 application/vnd.oci.image.layer.v1.tar+gzip",
+		},
+		{
+			name:      "digest",
+			format:    "{{.Digest}}",
+			expectOut: blobDigest.String(),
+		},
+		{
+			name:      "header",
+			format:    `{{.Headers.Get "Content-Length"}}`,
+			expectOut: "123456",
+		},
+		{
+			name:      "descriptor",
+			format:    "{{.GetDescriptor.Size}}",
+			expectOut: "123456",
+		},
+		{
+			name:      "raw headers",
+			format:    "",
+			expectOut: "Content-Length: 123456",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			args := []string{"--host", hostFlag, "blob", "head", tsHost + "/testrepo", blobDigest.String()}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
+			out, err := cobraTest(t, nil, args...)
+			if err != nil {
+				t.Fatalf("failed to run blob head: %v", err)
+			}
+			if tc.format == "" {
+				if !strings.Contains(out, tc.expectOut) {
+					t.Errorf("missing %s in output: %s", tc.expectOut, out)
+				}
+			} else if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
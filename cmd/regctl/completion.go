@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
 	"github.com/regclient/regclient/types/ref"
 )
 
+// completeTimeout limits the time spent querying a registry for completion suggestions.
+const completeTimeout = time.Second * 5
+
 func NewCompletionCmd(rootOpts *rootCmd) *cobra.Command {
 	var completionTopCmd = &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
@@ -105,6 +110,50 @@ func completeArgPlatform(cmd *cobra.Command, args []string, toComplete string) (
 	}, cobra.ShellCompDirectiveNoFileComp
 }
 
+// completeArgPlatformRef suggests the platforms found in the index of the ref in the first arg.
+// The static platform list is returned when the ref is missing, unreachable, or not an index.
+func (rootOpts *rootCmd) completeArgPlatformRef(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeArgPlatform(cmd, args, toComplete)
+	}
+	r, err := ref.New(args[0])
+	if err != nil {
+		return completeArgPlatform(cmd, args, toComplete)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completeTimeout)
+	defer cancel()
+	rc := rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil || !m.IsList() {
+		return completeArgPlatform(cmd, args, toComplete)
+	}
+	pl, err := manifest.GetPlatformList(m)
+	if err != nil {
+		return completeArgPlatform(cmd, args, toComplete)
+	}
+	result := []string{}
+	seen := map[string]bool{}
+	for _, p := range pl {
+		if p == nil || p.OS == "" || p.OS == "unknown" {
+			continue
+		}
+		pStr := p.String()
+		if p.OSVersion != "" {
+			pStr = pStr + ",osver=" + p.OSVersion
+		}
+		if seen[pStr] || !strings.HasPrefix(pStr, toComplete) {
+			continue
+		}
+		seen[pStr] = true
+		result = append(result, pStr)
+	}
+	if len(result) == 0 {
+		return completeArgPlatform(cmd, args, toComplete)
+	}
+	return result, cobra.ShellCompDirectiveNoFileComp
+}
+
 func completeArgMediaTypeManifest(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return []string{
 		mediatype.Docker2Manifest,
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompletePlatform(t *testing.T) {
+	tt := []struct {
+		name         string
+		args         []string
+		expectIn     []string
+		expectNotIn  []string
+		expectStatic bool
+	}{
+		{
+			name:        "index platforms",
+			args:        []string{"__complete", "manifest", "get", "ocidir://../../testdata/testrepo:v3", "--platform", ""},
+			expectIn:    []string{"linux/amd64", "linux/arm64", "linux/arm/v7"},
+			expectNotIn: []string{"linux/s390x", "unknown"},
+		},
+		{
+			name:        "index platforms prefix",
+			args:        []string{"__complete", "image", "digest", "ocidir://../../testdata/testrepo:v3", "--platform", "linux/arm"},
+			expectIn:    []string{"linux/arm64", "linux/arm/v7"},
+			expectNotIn: []string{"linux/amd64"},
+		},
+		{
+			name:         "no ref",
+			args:         []string{"__complete", "manifest", "get", "--platform", ""},
+			expectStatic: true,
+		},
+		{
+			name:         "missing ref",
+			args:         []string{"__complete", "manifest", "get", "ocidir://../../testdata/testrepo:missing", "--platform", ""},
+			expectStatic: true,
+		},
+		{
+			name:         "image manifest",
+			args:         []string{"__complete", "manifest", "get", "ocidir://../../testdata/testrepo:a1", "--platform", ""},
+			expectStatic: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if err != nil {
+				t.Fatalf("failed to complete: %v", err)
+			}
+			lines := strings.Split(out, "\n")
+			if tc.expectStatic {
+				tc.expectIn = []string{"linux/s390x", "linux/ppc64le"}
+			}
+			for _, exp := range tc.expectIn {
+				if !sliceHas(lines, exp) {
+					t.Errorf("missing %s in completion: %v", exp, lines)
+				}
+			}
+			for _, exp := range tc.expectNotIn {
+				if sliceHas(lines, exp) {
+					t.Errorf("unexpected %s in completion: %v", exp, lines)
+				}
+			}
+		})
+	}
+}
+
+func sliceHas(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -8,13 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/opencontainers/go-digest"
@@ -22,6 +27,9 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/ascii"
+	"github.com/regclient/regclient/internal/blobcache"
+	"github.com/regclient/regclient/internal/reghttp"
+	"github.com/regclient/regclient/internal/splitfile"
 	"github.com/regclient/regclient/internal/strparse"
 	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/mod"
@@ -42,32 +50,66 @@ import (
 
 type imageCmd struct {
 	rootOpts        *rootCmd
+	afterCopy       string
+	afterCopyWarn   bool
+	allowSingle     bool
 	annotations     []string
 	byDigest        bool
 	checkBaseRef    string
 	checkBaseDigest string
 	checkSkipConfig bool
+	concurrency     int
 	create          string
 	created         string
+	diffBase        string
 	digestTags      bool
+	dryRun          bool
 	exportCompress  bool
 	exportRef       string
+	exportSplit     string
 	fastCheck       bool
+	followIndex     bool
 	forceRecursive  bool
 	format          string
 	formatCreate    string
 	formatFile      string
+	importAll       bool
 	importName      string
 	includeExternal bool
 	labels          []string
+	layerAddMT      string
+	layerAdds       []imageLayerAdd // layer-add entries without a media type, replaced when layer-add-media-type is set
+	layerCacheDir   string
+	layerCacheMax   int64
+	listPlatforms   bool
+	logSkipped      bool
 	mediaType       string
+	modAnnotations  map[string]bool // annotations set by flag take precedence over the annotation file
 	modOpts         []mod.Opts
+	noMount         bool
+	noTrunc         bool
 	platform        string
+	platformFilter  []string
 	platforms       []string
+	preserveTags    bool
+	rateLimitFail   int
+	rateLimitWarn   int
+	rebase          bool // set when a rebase flag is used
 	referrers       bool
 	referrerSrc     string
 	referrerTgt     string
 	replace         bool
+	resumeRateLimit bool
+	resumeWait      time.Duration
+	srcManifestFile string
+	storeDigestTags string
+}
+
+type imageLayerAdd struct {
+	index     int
+	rdr       io.Reader
+	platforms []platform.Platform
+	createdBy string
 }
 
 var imageKnownTypes = []string{
@@ -106,13 +148,14 @@ regctl image check-base ghcr.io/regclient/regctl:alpine -v info`,
 		RunE:              imageOpts.runImageCheckBase,
 	}
 	var imageCopyCmd = &cobra.Command{
-		Use:     "copy <src_image_ref> <dst_image_ref>",
+		Use:     "copy <src_image_ref> <dst_image_ref> [<dst_image_ref>...]",
 		Aliases: []string{"cp"},
 		Short:   "copy or retag image",
 		Long: `Copy or retag an image. This works between registries and only pulls layers
 that do not exist at the target. In the same registry it attempts to mount
 the layers between repositories. And within the same repository it only
-sends the manifest with the new tag.`,
+sends the manifest with the new tag. When multiple destinations are provided,
+each layer is only pulled from the source once.`,
 		Example: `
 # copy an image
 regctl image copy \
@@ -122,13 +165,64 @@ regctl image copy \
 regctl image copy --digest-tags \
   ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
 
+# copy an image, converting signature digest tags to referrers
+regctl image copy --store-digest-tags-as referrers \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
 # copy only the local platform image
 regctl image copy --platform local \
   ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
 
+# copy an index with only the amd64 and arm64 platforms
+regctl image copy --platform-filter linux/amd64,linux/arm64 \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
+# sign the image after it is copied
+regctl image copy --after-copy 'cosign sign {{.CommonName}}' \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
+# wait for rate limits to reset instead of failing the copy
+regctl image copy --resume-on-ratelimit \
+  docker.io/library/alpine:latest registry.example.org/library/alpine:latest
+
 # retag an image
 regctl image copy registry.example.org/repo:v1.2.3 registry.example.org/repo:v1
 
+# reuse pulled layers when copying to multiple registries
+regctl image copy --layer-cache-dir ~/.cache/regctl-layers \
+  ghcr.io/regclient/regctl:edge registry1.example.org/regclient/regctl:edge
+regctl image copy --layer-cache-dir ~/.cache/regctl-layers \
+  ghcr.io/regclient/regctl:edge registry2.example.org/regclient/regctl:edge
+
+# list the manifests and blobs missing on the target without copying them
+regctl image copy --dry-run --referrers \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
+# copy an image using a previously fetched manifest instead of getting it from the source
+regctl image copy --source-manifest-file manifest.json \
+  ghcr.io/regclient/regctl@sha256:... registry.example.org/regclient/regctl:edge
+
+# copy an image to multiple registries, pulling each layer once
+regctl image copy ghcr.io/regclient/regctl:edge \
+  registry1.example.org/regclient/regctl:edge \
+  registry2.example.org/regclient/regctl:edge
+
+# limit the copy to 2 concurrent blob transfers
+regctl image copy --concurrency 2 \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
+# upload every blob for a registry that reports mounts without copying the blob
+regctl image copy --no-cross-repo-mount \
+  registry.example.org/repo1:v1 registry.example.org/repo2:v1
+
+# log each blob that was skipped, e.g. to verify blobs are mounted
+regctl image copy -v info --log-skipped \
+  registry.example.org/repo1:v1 registry.example.org/repo2:v1
+
+# promote a release, including every other tag in the source repo for the same digest
+regctl image copy --preserve-repo-tags \
+  registry.example.org/staging/app:v1.2.3 registry.example.org/prod/app:v1.2.3
+
 # copy an image to an OCI Layout including referrers
 regctl image copy --referrers \
   ghcr.io/regclient/regctl:edge ocidir://regctl:edge
@@ -136,9 +230,10 @@ regctl image copy --referrers \
 # copy a windows image, including foreign layers
 regctl image copy --platform windows/amd64,osver=10.0.17763.4974 --include-external \
   golang:latest registry.example.org/library/golang:windows`,
-		Args:              cobra.ExactArgs(2),
+		Args:              cobra.MinimumNArgs(2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageCopy,
+		Annotations:       map[string]string{annotationOutput: "true"},
 	}
 	var imageCreateCmd = &cobra.Command{
 		Use:     "create <image_ref>",
@@ -188,10 +283,15 @@ regctl image digest ghcr.io/regclient/regctl`,
 		Short: "export image",
 		Long: `Exports an image into a tar file that can be later loaded into a docker
 engine with "docker load". The tar file is output to stdout by default.
-Compression is typically not useful since layers are already compressed.`,
+Compression is typically not useful since layers are already compressed.
+With "--split", the output is written to numbered parts of the filename that
+can be concatenated back into the tar file, or imported with "regctl image import".`,
 		Example: `
 # export an image
-regctl image export registry.example.org/repo:v1 >image-v1.tar`,
+regctl image export registry.example.org/repo:v1 >image-v1.tar
+
+# export an image into 2GB parts, image-v1.tar.000, image-v1.tar.001, ...
+regctl image export --split 2GB registry.example.org/repo:v1 image-v1.tar`,
 		Args:              cobra.RangeArgs(1, 2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageExport,
@@ -200,24 +300,56 @@ regctl image export registry.example.org/repo:v1 >image-v1.tar`,
 		Use:     "get-file <image_ref> <filename> [out-file]",
 		Aliases: []string{"cat"},
 		Short:   "get a file from an image",
-		Long:    `Go through each of the image layers searching for the requested file.`,
+		Long: `Go through each of the image layers searching for the requested file.
+For an index, the local platform is used unless "--platform" is set.
+With "--follow-index", the only platform in the index is used.`,
 		Example: `
 # get the alpine-release file from the latest alpine image
-regctl image get-file --platform local alpine /etc/alpine-release`,
+regctl image get-file --platform local alpine /etc/alpine-release
+
+# get a file from an index with a single platform
+regctl image get-file --follow-index registry.example.org/app:arm64 /etc/os-release`,
 		Args:              cobra.RangeArgs(2, 3),
 		ValidArgsFunction: completeArgList([]completeFunc{rootOpts.completeArgTag, completeArgNone, completeArgNone}),
 		RunE:              imageOpts.runImageGetFile,
 	}
+	var imageHistoryCmd = &cobra.Command{
+		Use:   "history <image_ref>",
+		Short: "show the history of an image",
+		Long: `Show the history entries from the image config, similar to "docker history".
+Each history entry that created a layer is shown with the size of that layer.
+For an index, the local platform is used unless "--platform" is set.`,
+		Example: `
+# show the history of the alpine image
+regctl image history alpine
+
+# show the full commands for a specific platform
+regctl image history --platform linux/arm64 --no-trunc alpine
+
+# output the history as json
+regctl image history --format '{{jsonPretty .}}' alpine`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageHistory,
+	}
 	var imageImportCmd = &cobra.Command{
-		Use:   "import <image_ref> <filename>",
+		Use:   "import <image_ref> <filename> [filename...]",
 		Short: "import image",
 		Long: `Imports an image from a tar file. This must be either a docker formatted tar
 from "docker save" or an OCI Layout compatible tar. The output from
-"regctl image export" can be used. Stdin is not permitted for the tar file.`,
+"regctl image export" can be used. Stdin is not permitted for the tar file.
+Multiple filenames are concatenated in order. When the filename does not exist,
+the parts created by "regctl image export --split" are used.`,
 		Example: `
 # import an image saved from docker
-regctl image import registry.example.org/repo:v1 image-v1.tar`,
-		Args:              cobra.ExactArgs(2),
+regctl image import registry.example.org/repo:v1 image-v1.tar
+
+# import an image from the parts image-v1.tar.000, image-v1.tar.001, ...
+regctl image import registry.example.org/repo:v1 image-v1.tar
+
+# import every tagged image from an OCI Layout tar into a repository
+regctl image import --all registry.example.org/repo images.tar`,
+		Args:              cobra.MinimumNArgs(2),
 		ValidArgsFunction: completeArgList([]completeFunc{rootOpts.completeArgTag, completeArgDefault}),
 		RunE:              imageOpts.runImageImport,
 	}
@@ -226,13 +358,25 @@ regctl image import registry.example.org/repo:v1 image-v1.tar`,
 		Aliases: []string{"config"},
 		Short:   "inspect image",
 		Long: `Shows the config json for an image and is equivalent to pulling the image
-in docker, and inspecting it, but without pulling any of the image layers.`,
+in docker, and inspecting it, but without pulling any of the image layers.
+For an index, the local platform is used unless "--platform" is set.
+With "--follow-index", the only platform in the index is used, failing when
+the index contains multiple platforms.
+With "--diff-base", the config fields and layers added relative to a base image
+are shown instead.`,
 		Example: `
 # return the image config for the nginx image
-regctl image inspect --platform local nginx`,
+regctl image inspect --platform local nginx
+
+# return the image config from an index with a single platform
+regctl image inspect --follow-index registry.example.org/app:arm64
+
+# show the changes an image makes on top of its base image
+regctl image inspect --diff-base alpine:3 registry.example.org/app:latest`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageInspect,
+		Annotations:       map[string]string{annotationOutput: "true"},
 	}
 	var imageManifestCmd = &cobra.Command{
 		Use:   "manifest <image_ref>",
@@ -244,6 +388,7 @@ regctl image manifest golang`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestGet,
+		Annotations:       map[string]string{annotationOutput: "true"},
 	}
 	var imageModCmd = &cobra.Command{
 		Use:   "mod <image_ref>",
@@ -270,26 +415,61 @@ regctl image mod alpine:3.5 --to-oci --create registry.example.org/alpine:3.5
 regctl image mod registry.example.org/repo:v1 --create v1-extended \
   --layer-add "tar=file.tar,platform=linux/amd64"
 
+# append an uncompressed layer to all platforms from a tar or tgz file
+regctl image mod registry.example.org/repo:v1 --create v1-extended \
+  --layer-add file.tgz --layer-add-media-type application/vnd.oci.image.layer.v1.tar
+
 # append a layer to all platforms using the contents of a directory
 regctl image mod registry.example.org/repo:v1 --create v1-extended \
   --layer-add "dir=path/to/directory"
 
+# add an environment variable and remove another from the linux/amd64 image
+regctl image mod registry.example.org/repo:v1 --create v1-patched \
+  --env-add FOO=bar --env-rm "[linux/amd64]OLD_VAR"
+
+# remove buildkit attestations from an index
+regctl image mod registry.example.org/repo:v1 --replace --attestations-rm
+
+# remove duplicate consecutive layers from an image
+regctl image mod registry.example.org/repo:v1 --replace --dedup-layers
+
 # set the timestamp on the config and layers, ignoring the alpine base image layers
 regctl image mod registry.example.org/repo:v1 --create v1-time \
   --time "set=2021-02-03T04:05:06Z,base-ref=alpine:3"
 
 # set the entrypoint to be bash and unset the default command
 regctl image mod registry.example.org/repo:v1 --create v1-bash \
-  --config-entrypoint '["bash"]' --config-cmd ""
+  --entrypoint '["bash"]' --cmd ""
+
+# set the entrypoint only on the linux/arm64 image
+regctl image mod registry.example.org/repo:v1 --create v1-arm \
+  --entrypoint '[linux/arm64]["/app/server-arm64"]'
+
+# record the base image annotations so the image can be rebased later with --rebase
+regctl image mod registry.example.org/repo:v1 --replace \
+  --base registry.example.org/base:1.2
+
+# set annotations from a json file, overriding the version from the file
+regctl image mod registry.example.org/repo:v1 --create v1-annotated \
+  --annotation-file annotations.json --annotation org.opencontainers.image.version=1.2.3
 
 # delete an environment variable from only the linux/arm64 image
 regctl image mod registry.example.org/repo:v1 --create v1-env \
   --env "[linux/arm64]LD_PRELOAD="
 
+# convert an artifact to the OCI 1.1 image manifest form,
+# converting back to the removed OCI artifact manifest is not supported
+regctl image mod registry.example.org/repo:sbom --create sbom-normalized \
+  --normalize-artifact
+
 # Rebase an older regctl image, copying to the local registry.
 # This uses annotations that were included in the original image build.
 regctl image mod registry.example.org/regctl:v0.5.1-alpine \
-  --rebase --create v0.5.1-alpine-rebase`,
+  --rebase --create v0.5.1-alpine-rebase
+
+# Show the base layers that a rebase would swap without pushing the image.
+regctl image mod registry.example.org/regctl:v0.5.1-alpine \
+  --rebase --dry-run`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageMod,
@@ -300,35 +480,58 @@ regctl image mod registry.example.org/regctl:v0.5.1-alpine \
 		Short:   "show the current rate limit",
 		Long: `Shows the rate limit using an http head request against the image manifest.
 If Set is false, the Remain value was not provided.
-The other values may be 0 if not provided by the registry.`,
+The other values may be 0 if not provided by the registry.
+With --warn-below, a warning is logged when the remaining pulls are below the value.
+With --fail-below, the command exits with an error when the remaining pulls are below the value.`,
 		Example: `
 # return the current rate limit for pulling the alpine image
 regctl image ratelimit alpine
 
 # return the number of pulls remaining
-regctl image ratelimit alpine --format '{{.Remain}}'`,
+regctl image ratelimit alpine --format '{{.Remain}}'
+
+# fail when fewer than 10 pulls remain
+regctl image ratelimit alpine --fail-below 10`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageRateLimit,
 	}
 
 	imageOpts.modOpts = []mod.Opts{}
+	imageOpts.modAnnotations = map[string]bool{}
 
 	imageCheckBaseCmd.Flags().StringVar(&imageOpts.checkBaseRef, "base", "", "Base image reference (including tag)")
 	imageCheckBaseCmd.Flags().StringVar(&imageOpts.checkBaseDigest, "digest", "", "Base image digest (checks if digest matches base)")
 	imageCheckBaseCmd.Flags().BoolVar(&imageOpts.checkSkipConfig, "no-config", false, "Skip check of config history")
 	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 
+	imageCopyCmd.Flags().StringVar(&imageOpts.afterCopy, "after-copy", "", "Command to run after a successful copy, formatted with go template syntax using the target ref")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.afterCopyWarn, "after-copy-warn", false, "Warn instead of failing when the after-copy command fails")
+	imageCopyCmd.Flags().IntVar(&imageOpts.concurrency, "concurrency", 0, "Limit the number of concurrent blob copies, also limited by the registry req-concurrent setting (0 for no additional limit)")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.dryRun, "dry-run", false, "Output the manifests and blobs missing on the target without copying anything")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.fastCheck, "fast", false, "Fast check, skip referrers and digest tag checks when image exists, overrides force-recursive")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.forceRecursive, "force-recursive", false, "Force recursive copy of image, repairs missing nested blobs and manifests")
 	imageCopyCmd.Flags().StringVar(&imageOpts.format, "format", "", "Format output with go template syntax")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.includeExternal, "include-external", false, "Include external layers")
+	imageCopyCmd.Flags().StringVar(&imageOpts.layerCacheDir, "layer-cache-dir", "", "Directory to cache pulled layers for reuse by later copies")
+	imageCopyCmd.Flags().Int64Var(&imageOpts.layerCacheMax, "layer-cache-max", 0, "Max size of the layer cache in bytes, least recently used layers are removed (0 for unlimited)")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.logSkipped, "log-skipped", false, "Log each manifest and blob that was skipped because it exists or was mounted, at the info level")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.noMount, "no-cross-repo-mount", false, "Disable cross repository blob mounts and always upload blobs, for registries with broken mount support")
 	imageCopyCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	imageCopyCmd.Flags().StringSliceVar(&imageOpts.platformFilter, "platform-filter", []string{}, "Copy only the listed platforms from an index, rebuilding the target index (e.g. linux/amd64,linux/arm64)")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.preserveTags, "preserve-repo-tags", false, "Also push every other tag in the source repository that points to the copied digest")
+	imageCopyCmd.Flags().StringVar(&imageOpts.srcManifestFile, "source-manifest-file", "", "File with the source manifest, skipping the request to get the manifest from the source")
 	imageCopyCmd.Flags().StringArrayVar(&imageOpts.platforms, "platforms", []string{}, "Copy only specific platforms, registry validation must be disabled")
 	// platforms should be treated as experimental since it will break many registries
 	_ = imageCopyCmd.Flags().MarkHidden("platforms")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.digestTags, "digest-tags", false, "Include digest tags (\"sha256-<digest>.*\") when copying manifests")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.referrers, "referrers", false, "Include referrers")
+	imageCopyCmd.Flags().StringVar(&imageOpts.storeDigestTags, "store-digest-tags-as", "", "Copy digest tags to the target as \"referrers\" of the image instead of tags, implies --digest-tags")
+	_ = imageCopyCmd.RegisterFlagCompletionFunc("store-digest-tags-as", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"referrers"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	imageCopyCmd.Flags().BoolVar(&imageOpts.resumeRateLimit, "resume-on-ratelimit", false, "Wait for the rate limit to reset and resume the copy instead of failing")
+	imageCopyCmd.Flags().DurationVar(&imageOpts.resumeWait, "resume-wait", time.Minute*15, "Time to wait before resuming when the registry does not send a Retry-After header")
 	imageCopyCmd.Flags().StringVar(&imageOpts.referrerSrc, "referrers-src", "", "External source for referrers")
 	imageCopyCmd.Flags().StringVar(&imageOpts.referrerTgt, "referrers-tgt", "", "External target for referrers")
 
@@ -348,33 +551,49 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 	imageDigestCmd.Flags().BoolVar(&manifestOpts.list, "list", true, "Do not resolve platform from manifest list (enabled by default)")
 	imageDigestCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local, requires a get request)")
 	imageDigestCmd.Flags().BoolVar(&manifestOpts.requireList, "require-list", false, "Fail if manifest list is not received")
-	_ = imageDigestCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	_ = imageDigestCmd.RegisterFlagCompletionFunc("platform", rootOpts.completeArgPlatformRef)
 	_ = imageDigestCmd.Flags().MarkHidden("list")
 
+	imageGetFileCmd.Flags().BoolVar(&imageOpts.followIndex, "follow-index", false, "Use the only platform in an index when a platform is not specified")
 	imageGetFileCmd.Flags().StringVar(&imageOpts.formatFile, "format", "", "Format output with go template syntax")
 	imageGetFileCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 
 	imageExportCmd.Flags().BoolVar(&imageOpts.exportCompress, "compress", false, "Compress output with gzip")
 	imageExportCmd.Flags().StringVar(&imageOpts.exportRef, "name", "", "Name of image to embed for docker load")
 	imageExportCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	imageExportCmd.Flags().StringVar(&imageOpts.exportSplit, "split", "", "Split the output into numbered parts of this size (e.g. 2GB), requires a filename")
+
+	imageHistoryCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
+	imageHistoryCmd.Flags().BoolVar(&imageOpts.noTrunc, "no-trunc", false, "Do not truncate the created by command")
+	imageHistoryCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = imageHistoryCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageHistoryCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
+	imageImportCmd.Flags().BoolVar(&imageOpts.importAll, "all", false, "Import every tagged image from the index.json of an OCI Layout tar")
 	imageImportCmd.Flags().StringVar(&imageOpts.importName, "name", "", "Name of image or tag to import when multiple images are packaged in the tar")
 
+	imageInspectCmd.Flags().BoolVar(&imageOpts.allowSingle, "allow-single", false, "Output the platform of a single manifest with --platforms instead of failing")
+	imageInspectCmd.Flags().StringVar(&imageOpts.diffBase, "diff-base", "", "Compare the image config to a base image")
+	imageInspectCmd.Flags().BoolVar(&imageOpts.followIndex, "follow-index", false, "Use the only platform in an index when a platform is not specified")
+	imageInspectCmd.Flags().BoolVar(&imageOpts.listPlatforms, "platforms", false, "List the platforms and digests of an index without pulling any config")
 	imageInspectCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	imageInspectCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
-	_ = imageInspectCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	_ = imageInspectCmd.RegisterFlagCompletionFunc("diff-base", completeArgNone)
+	_ = imageInspectCmd.RegisterFlagCompletionFunc("platform", rootOpts.completeArgPlatformRef)
 	_ = imageInspectCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
 	imageManifestCmd.Flags().BoolVar(&manifestOpts.list, "list", true, "Output manifest list if available (enabled by default)")
 	imageManifestCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	imageManifestCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Fail if manifest list is not received")
 	imageManifestCmd.Flags().StringVar(&manifestOpts.formatGet, "format", "{{printPretty .}}", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
-	_ = imageManifestCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	_ = imageManifestCmd.RegisterFlagCompletionFunc("platform", rootOpts.completeArgPlatformRef)
 	_ = imageManifestCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	_ = imageManifestCmd.Flags().MarkHidden("list")
 
 	imageModCmd.Flags().StringVar(&imageOpts.create, "create", "", "Create image or tag")
+	imageModCmd.Flags().BoolVar(&imageOpts.dryRun, "dry-run", false, "Output the base layers a rebase would swap without pushing the image")
 	imageModCmd.Flags().BoolVar(&imageOpts.replace, "replace", false, "Replace tag (ignored when \"create\" is used)")
+	imageModCmd.Flags().StringVar(&imageOpts.layerAddMT, "layer-add-media-type", "", "Media type for layer-add entries that do not specify a mediaType")
 	// most image mod flags are order dependent, so they are added using VarP/VarPF to append to modOpts
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "stringArray",
@@ -387,9 +606,27 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			} else {
 				return fmt.Errorf("invalid annotation")
 			}
+			imageOpts.modAnnotations[strings.TrimSpace(vs[0])] = true
 			return nil
 		},
 	}, "annotation", `set an annotation (name=value, omit value to delete, prefix with platform list [p1,p2] or [*] for all images)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			names, values, err := modAnnotationFileRead(val)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				// skip annotations set by flag
+				if imageOpts.modAnnotations[name] {
+					continue
+				}
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithAnnotation(name, values[name]))
+			}
+			return nil
+		},
+	}, "annotation-file", `set annotations from a file, either a json object or name=value lines (empty value to delete, --annotation flags take precedence)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "stringArray",
 		f: func(val string) error {
@@ -423,6 +660,17 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "annotation-base", `set base image annotations (image/name:tag,sha256:digest)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			r, err := ref.New(val)
+			if err != nil {
+				return fmt.Errorf("invalid image reference: %w", err)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithBaseImage(r, ""))
+			return nil
+		},
+	}, "base", `set base image annotations from a reference, resolving the digest when not provided (image/name:tag)`)
 	flagAnnotationPromote := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -437,6 +685,20 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "annotation-promote", "", `promote common annotations from child images to index`)
 	flagAnnotationPromote.NoOptDefVal = "true"
+	flagAttestationsRm := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithIndexAttestationsRm())
+			}
+			return nil
+		},
+	}, "attestations-rm", "", `remove buildkit attestation entries from an index`)
+	flagAttestationsRm.NoOptDefVal = "true"
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -465,34 +727,42 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "buildarg-rm-regex", `delete a build arg with a regex value`)
+	modCmdFlag := func(val string) error {
+		platforms, vSlice, err := imageModParseExec(val)
+		if err != nil {
+			return err
+		}
+		imageOpts.modOpts = append(imageOpts.modOpts,
+			mod.WithConfigCmd(vSlice, platforms...),
+		)
+		return nil
+	}
+	modEntrypointFlag := func(val string) error {
+		platforms, vSlice, err := imageModParseExec(val)
+		if err != nil {
+			return err
+		}
+		imageOpts.modOpts = append(imageOpts.modOpts,
+			mod.WithConfigEntrypoint(vSlice, platforms...),
+		)
+		return nil
+	}
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
-		f: func(val string) error {
-			vSlice := []string{}
-			err := json.Unmarshal([]byte(val), &vSlice)
-			if err != nil && val != "" {
-				vSlice = []string{"/bin/sh", "-c", val}
-			}
-			imageOpts.modOpts = append(imageOpts.modOpts,
-				mod.WithConfigCmd(vSlice),
-			)
-			return nil
-		},
+		f: modCmdFlag,
+	}, "cmd", `set command in the config (json array or string, empty string to delete, optional [platform,...] prefix)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: modCmdFlag,
 	}, "config-cmd", `set command in the config (json array or string, empty string to delete)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
-		f: func(val string) error {
-			vSlice := []string{}
-			err := json.Unmarshal([]byte(val), &vSlice)
-			if err != nil && val != "" {
-				vSlice = []string{"/bin/sh", "-c", val}
-			}
-			imageOpts.modOpts = append(imageOpts.modOpts,
-				mod.WithConfigEntrypoint(vSlice),
-			)
-			return nil
-		},
+		f: modEntrypointFlag,
 	}, "config-entrypoint", `set entrypoint in the config (json array or string, empty string to delete)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: modEntrypointFlag,
+	}, "entrypoint", `set entrypoint in the config (json array or string, empty string to delete, optional [platform,...] prefix)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -574,6 +844,24 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "env", `set an environment variable (name=value, omit value to delete, prefix with platform list [p1,p2] for subset of images)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			vs := strings.SplitN(val, "=", 2)
+			if len(vs) != 2 {
+				return fmt.Errorf("env must be in the format name=value: %s", val)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithEnvAdd(vs[0], vs[1]))
+			return nil
+		},
+	}, "env-add", `add or replace an environment variable (name=value, prefix with platform list [p1,p2] for subset of images)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithEnvRm(val))
+			return nil
+		},
+	}, "env-rm", `delete an environment variable (name, prefix with platform list [p1,p2] for subset of images)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "stringArray",
 		f: func(val string) error {
@@ -675,12 +963,16 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
-			kvSplit, err := strparse.SplitCSKV(val)
-			if err != nil {
-				return fmt.Errorf("failed to parse layer-add options %s", val)
+			kvSplit := map[string]string{"tar": val}
+			if strings.Contains(val, "=") {
+				var err error
+				kvSplit, err = strparse.SplitCSKV(val)
+				if err != nil {
+					return fmt.Errorf("failed to parse layer-add options %s", val)
+				}
 			}
 			var rdr io.Reader
-			var mt string
+			var mt, createdBy string
 			var platforms []platform.Platform
 			if filename, ok := kvSplit["tar"]; ok {
 				//#nosec G304 command is run by a user accessing their own files
@@ -689,6 +981,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 					return fmt.Errorf("failed to open tar file %s: %v", filename, err)
 				}
 				rdr = fh
+				createdBy = "regclient layer add " + filepath.Base(filename)
 				cobra.OnFinalize(func() {
 					_ = fh.Close()
 				})
@@ -706,6 +999,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 					_ = pw.Close()
 				}()
 				rdr = pr
+				createdBy = "regclient layer add " + filepath.Base(dir) + "/"
 				cobra.OnFinalize(func() {
 					_ = pr.Close()
 				})
@@ -723,24 +1017,52 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 				}
 				platforms = append(platforms, p)
 			}
+			if mt == "" {
+				imageOpts.layerAdds = append(imageOpts.layerAdds, imageLayerAdd{
+					index:     len(imageOpts.modOpts),
+					rdr:       rdr,
+					platforms: platforms,
+					createdBy: createdBy,
+				})
+			}
 			imageOpts.modOpts = append(imageOpts.modOpts,
-				mod.WithLayerAddTar(rdr, mt, platforms))
+				mod.WithLayerAddTarCreatedBy(rdr, mt, platforms, createdBy))
 			return nil
 		},
-	}, "layer-add", `add a new layer (tar=file,dir=directory,platform=val)`)
+	}, "layer-add", `add a new layer from a tar file, which may be compressed (file or tar=file,dir=directory,mediaType=type,platform=val)`)
+	modLayerCompressFlag := func(val string) error {
+		var algo archive.CompressType
+		err := algo.UnmarshalText([]byte(val))
+		if err != nil {
+			return fmt.Errorf("unknown layer compression %s", val)
+		}
+		imageOpts.modOpts = append(imageOpts.modOpts,
+			mod.WithLayerCompression(algo))
+		return nil
+	}
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
+		f: modLayerCompressFlag,
+	}, "layer-compress", `change layer compression (gzip, none, zstd)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: modLayerCompressFlag,
+	}, "layer-compression", `change layer compression (gzip, none, zstd)`)
+	_ = imageModCmd.Flags().MarkHidden("layer-compression") // alias of layer-compress
+	flagDedupLayers := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
 		f: func(val string) error {
-			var algo archive.CompressType
-			err := algo.UnmarshalText([]byte(val))
+			b, err := strconv.ParseBool(val)
 			if err != nil {
-				return fmt.Errorf("unknown layer compression %s", val)
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerDedup())
 			}
-			imageOpts.modOpts = append(imageOpts.modOpts,
-				mod.WithLayerCompression(algo))
 			return nil
 		},
-	}, "layer-compress", `change layer compression (gzip, none, zstd)`)
+	}, "dedup-layers", "", `remove a layer that is identical to the previous layer`)
+	flagDedupLayers.NoOptDefVal = "true"
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -807,6 +1129,23 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "layer-time-max", `max timestamp for a layer`)
 	_ = imageModCmd.Flags().MarkHidden("layer-time-max") // TODO: deprecate in favor of layer-time
+	flagNormalizeArtifact := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			if val == "artifact" || val == "artifact-manifest" {
+				return fmt.Errorf("converting to the OCI artifact manifest is not supported, it was removed from the OCI 1.1 release%.0w", errs.ErrUnsupported)
+			}
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithManifestNormalizeArtifact())
+			}
+			return nil
+		},
+	}, "normalize-artifact", "", `convert artifacts to an OCI image manifest with artifactType and an empty config (one way only)`)
+	flagNormalizeArtifact.NoOptDefVal = "true"
 	flagRebase := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -819,6 +1158,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			}
 			// pull the manifest, get the base image annotations
 			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithRebase())
+			imageOpts.rebase = true
 			return nil
 		},
 	}, "rebase", "", `rebase an image using OCI annotations`)
@@ -840,6 +1180,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 				return fmt.Errorf("failed parsing new base image ref: %w", err)
 			}
 			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithRebaseRefs(rOld, rNew))
+			imageOpts.rebase = true
 			return nil
 		},
 	}, "rebase-ref", `rebase an image with base references (base:old,base:new)`)
@@ -955,8 +1296,12 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "volume-rm", `delete a volume definition`)
 
+	imageRateLimitCmd.Flags().IntVar(&imageOpts.rateLimitFail, "fail-below", 0, "Exit with an error when the remaining pulls are below this value")
+	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("fail-below", completeArgNone)
 	imageRateLimitCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
 	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageRateLimitCmd.Flags().IntVar(&imageOpts.rateLimitWarn, "warn-below", 0, "Log a warning when the remaining pulls are below this value")
+	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("warn-below", completeArgNone)
 
 	imageTopCmd.AddCommand(imageCheckBaseCmd)
 	imageTopCmd.AddCommand(imageCopyCmd)
@@ -965,6 +1310,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 	imageTopCmd.AddCommand(imageDigestCmd)
 	imageTopCmd.AddCommand(imageExportCmd)
 	imageTopCmd.AddCommand(imageGetFileCmd)
+	imageTopCmd.AddCommand(imageHistoryCmd)
 	imageTopCmd.AddCommand(imageImportCmd)
 	imageTopCmd.AddCommand(imageInspectCmd)
 	imageTopCmd.AddCommand(imageManifestCmd)
@@ -1015,12 +1361,59 @@ func imageParseOptTime(s string) (mod.OptTime, map[string]string, error) {
 	return ot, otherFields, nil
 }
 
+// imageModParseExec parses an entrypoint or cmd value with an optional platform prefix.
+// The value may be a json array for the exec form, a string to run with a shell, or empty to delete the setting.
+func imageModParseExec(val string) ([]platform.Platform, []string, error) {
+	platforms := []platform.Platform{}
+	vSlice := []string{}
+	// a leading list of platforms followed by a json array or nothing is the platform list, e.g. [linux/amd64]["bash"]
+	if strings.HasPrefix(val, "[") && json.Unmarshal([]byte(val), &vSlice) != nil {
+		if end := strings.Index(val, "]"); end > 0 {
+			plats, ok := imageModParsePlatforms(val[1:end])
+			rest := strings.TrimSpace(val[end+1:])
+			if ok && (rest == "" || json.Unmarshal([]byte(rest), &vSlice) == nil) {
+				platforms = plats
+				val = rest
+			}
+		}
+	}
+	if val == "" {
+		return platforms, nil, nil
+	}
+	err := json.Unmarshal([]byte(val), &vSlice)
+	if err != nil {
+		vSlice = []string{"/bin/sh", "-c", val}
+	}
+	return platforms, vSlice, nil
+}
+
+// imageModParsePlatforms parses a comma separated list of platforms, returning false if any entry is not a platform.
+func imageModParsePlatforms(val string) ([]platform.Platform, bool) {
+	platforms := []platform.Platform{}
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" {
+			continue
+		}
+		if entry == "" {
+			return nil, false
+		}
+		p, err := platform.Parse(entry)
+		if err != nil {
+			return nil, false
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, true
+}
+
 func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
+	imageOpts.platform = imageOpts.rootOpts.platformDefault(r, imageOpts.platform)
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
@@ -1058,16 +1451,39 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	rTgt, err := ref.New(args[1])
-	if err != nil {
-		return err
+	if len(imageOpts.platformFilter) > 0 && imageOpts.platform != "" {
+		return fmt.Errorf("--platform cannot be combined with --platform-filter%.0w", errs.ErrUnsupported)
+	}
+	if imageOpts.srcManifestFile != "" && imageOpts.platform != "" {
+		return fmt.Errorf("--platform cannot be combined with --source-manifest-file%.0w", errs.ErrUnsupported)
+	}
+	if len(imageOpts.platforms) == 0 && len(imageOpts.platformFilter) == 0 && imageOpts.srcManifestFile == "" {
+		imageOpts.platform = imageOpts.rootOpts.platformDefault(rSrc, imageOpts.platform)
+	}
+	rTgts := []ref.Ref{}
+	for _, arg := range args[1:] {
+		rTgt, err := ref.New(arg)
+		if err != nil {
+			return err
+		}
+		rTgts = append(rTgts, rTgt)
 	}
 	if (imageOpts.referrerSrc != "" || imageOpts.referrerTgt != "") && !imageOpts.referrers {
 		return fmt.Errorf("referrers must be enabled to specify an external referrers source or target%.0w", errs.ErrUnsupported)
 	}
+	if imageOpts.storeDigestTags != "" && imageOpts.storeDigestTags != "referrers" {
+		return fmt.Errorf("unsupported value for --store-digest-tags-as: %s%.0w", imageOpts.storeDigestTags, errs.ErrUnsupported)
+	}
+	if imageOpts.dryRun && (imageOpts.afterCopy != "" || imageOpts.preserveTags) {
+		return fmt.Errorf("--dry-run cannot be combined with --after-copy or --preserve-repo-tags%.0w", errs.ErrUnsupported)
+	}
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, rSrc)
-	defer rc.Close(ctx, rTgt)
+	for _, rTgt := range rTgts {
+		defer rc.Close(ctx, rTgt)
+	}
+	// repo tags are matched against the source before a platform is selected
+	rSrcTags := rSrc
 	if imageOpts.platform != "" {
 		p, err := platform.Parse(imageOpts.platform)
 		if err != nil {
@@ -1081,10 +1497,13 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	}
 	imageOpts.rootOpts.log.Debug("Image copy",
 		slog.String("source", rSrc.CommonName()),
-		slog.String("target", rTgt.CommonName()),
+		slog.String("target", strings.Join(args[1:], ", ")),
 		slog.Bool("recursive", imageOpts.forceRecursive),
 		slog.Bool("digest-tags", imageOpts.digestTags))
 	opts := []regclient.ImageOpts{}
+	if imageOpts.concurrency > 0 {
+		opts = append(opts, regclient.ImageWithConcurrency(imageOpts.concurrency))
+	}
 	if imageOpts.fastCheck {
 		opts = append(opts, regclient.ImageWithFastCheck())
 	}
@@ -1094,7 +1513,9 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if imageOpts.includeExternal {
 		opts = append(opts, regclient.ImageWithIncludeExternal())
 	}
-	if imageOpts.digestTags {
+	if imageOpts.storeDigestTags == "referrers" {
+		opts = append(opts, regclient.ImageWithDigestTagsAsReferrers())
+	} else if imageOpts.digestTags {
 		opts = append(opts, regclient.ImageWithDigestTags())
 	}
 	if imageOpts.referrers {
@@ -1117,8 +1538,48 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if len(imageOpts.platforms) > 0 {
 		opts = append(opts, regclient.ImageWithPlatforms(imageOpts.platforms))
 	}
+	if len(imageOpts.platformFilter) > 0 {
+		pl := []platform.Platform{}
+		for _, pStr := range imageOpts.platformFilter {
+			p, err := platform.Parse(pStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse platform %s: %w", pStr, err)
+			}
+			pl = append(pl, p)
+		}
+		opts = append(opts, regclient.ImageWithPlatformFilter(pl))
+	}
+	if imageOpts.noMount {
+		opts = append(opts, regclient.ImageWithoutMount())
+	}
+	if imageOpts.srcManifestFile != "" {
+		raw, err := os.ReadFile(imageOpts.srcManifestFile)
+		if err != nil {
+			return fmt.Errorf("failed to read source manifest: %w", err)
+		}
+		// the digest of the source ref is verified when it is set
+		m, err := manifest.New(
+			manifest.WithRef(rSrc),
+			manifest.WithRaw(raw),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to parse source manifest %s: %w", imageOpts.srcManifestFile, err)
+		}
+		opts = append(opts, regclient.ImageWithSourceManifest(m))
+	}
+	if imageOpts.layerCacheDir != "" {
+		cache, err := blobcache.New(imageOpts.layerCacheDir, imageOpts.layerCacheMax)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, regclient.ImageWithBlobCache(cache))
+	}
+	if imageOpts.dryRun {
+		return imageOpts.copyDryRun(cmd, rc, rSrc, rTgts, opts)
+	}
 	// check for a tty and attach progress reporter
 	done := make(chan bool)
+	var callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
 	var progress *imageProgress
 	if !flagChanged(cmd, "verbosity") && ascii.IsWriterTerminal(cmd.ErrOrStderr()) {
 		progress = &imageProgress{
@@ -1140,20 +1601,301 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 				}
 			}
 		}()
-		opts = append(opts, regclient.ImageWithCallback(progress.callback))
+		callback = progress.callback
+	}
+	if imageOpts.logSkipped {
+		callback = imageOpts.logSkippedCallback(callback)
+	}
+	if callback != nil {
+		opts = append(opts, regclient.ImageWithCallback(callback))
+	}
+	copyFn := func() error {
+		if len(rTgts) == 1 {
+			return rc.ImageCopy(ctx, rSrc, rTgts[0], opts...)
+		}
+		return rc.ImageCopyMulti(ctx, rSrc, rTgts, opts...)
+	}
+	err = copyFn()
+	for err != nil && imageOpts.resumeRateLimit && errors.Is(err, errs.ErrHTTPRateLimit) {
+		wait := imageOpts.resumeWait
+		var raErr *reghttp.RetryAfterError
+		if errors.As(err, &raErr) {
+			wait = raErr.Delay
+		}
+		imageOpts.rootOpts.log.Warn("Rate limit reached, waiting to resume copy",
+			slog.String("source", rSrc.CommonName()),
+			slog.String("target", strings.Join(args[1:], ", ")),
+			slog.String("wait", wait.String()),
+			slog.String("err", err.Error()))
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(wait):
+			err = copyFn()
+		}
 	}
-	err = rc.ImageCopy(ctx, rSrc, rTgt, opts...)
 	if progress != nil {
 		close(done)
 		progress.display(true)
 	}
-	if err != nil {
+	if err != nil && len(rTgts) == 1 {
 		return err
 	}
+	// with multiple targets, finish the targets that succeeded before returning any error
+	copyErr := err
+	failed := map[string]bool{}
+	if joinErr, ok := copyErr.(interface{ Unwrap() []error }); ok {
+		for _, e := range joinErr.Unwrap() {
+			var tgtErr *regclient.ImageCopyTargetError
+			if errors.As(e, &tgtErr) {
+				failed[tgtErr.Target.CommonName()] = true
+			}
+		}
+	}
 	if !flagChanged(cmd, "format") {
 		imageOpts.format = "{{ .CommonName }}\n"
 	}
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rTgt)
+	structured := imageOpts.rootOpts.outputStructured()
+	result := imageCopyOutput{Source: rSrc.CommonName(), Targets: []string{}}
+	for _, rTgt := range rTgts {
+		if failed[rTgt.CommonName()] {
+			continue
+		}
+		if imageOpts.preserveTags {
+			err = imageOpts.copyRepoTags(ctx, rc, rSrcTags, rTgt)
+			if err != nil {
+				return err
+			}
+		}
+		if imageOpts.afterCopy != "" {
+			err = imageOpts.runAfterCopy(cmd, rc, rSrc, rTgt)
+			if err != nil {
+				if !imageOpts.afterCopyWarn {
+					return err
+				}
+				imageOpts.rootOpts.log.Warn("After copy command failed",
+					slog.String("target", rTgt.CommonName()),
+					slog.String("err", err.Error()))
+			}
+		}
+		if structured {
+			result.Targets = append(result.Targets, rTgt.CommonName())
+			continue
+		}
+		err = template.Writer(cmd.OutOrStdout(), imageOpts.format, rTgt)
+		if err != nil {
+			return err
+		}
+	}
+	if structured {
+		err = imageOpts.rootOpts.resultOutput(cmd, "", "", result)
+		if err != nil {
+			return err
+		}
+	}
+	return copyErr
+}
+
+// imageCopyOutput is the result of an image copy with --output json or yaml.
+type imageCopyOutput struct {
+	Source  string   `json:"source"`
+	Targets []string `json:"targets"`
+}
+
+type imageCopyDryRunList []imageCopyDryRun
+
+type imageCopyDryRun struct {
+	Source    string                  `json:"source"`
+	Target    string                  `json:"target"`
+	Manifests []descriptor.Descriptor `json:"manifests"`
+	Blobs     []descriptor.Descriptor `json:"blobs"`
+	Size      int64                   `json:"size"`
+}
+
+// MarshalPretty is used for printPretty template formatting.
+func (dl imageCopyDryRunList) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for i, d := range dl {
+		if i > 0 {
+			fmt.Fprintf(buf, "\n")
+		}
+		fmt.Fprintf(buf, "Target: %s\n", d.Target)
+		tw := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(tw, "KIND\tDIGEST\tSIZE\n")
+		for _, m := range d.Manifests {
+			fmt.Fprintf(tw, "manifest\t%s\t%s\n", m.Digest.String(), units.HumanSize(float64(m.Size)))
+		}
+		for _, b := range d.Blobs {
+			fmt.Fprintf(tw, "blob\t%s\t%s\n", b.Digest.String(), units.HumanSize(float64(b.Size)))
+		}
+		err := tw.Flush()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(buf, "Total: %d manifests, %d blobs, %s\n", len(d.Manifests), len(d.Blobs), units.HumanSize(float64(d.Size)))
+	}
+	return buf.Bytes(), nil
+}
+
+// copyDryRun outputs the content that an image copy would push to each target.
+func (imageOpts *imageCmd) copyDryRun(cmd *cobra.Command, rc *regclient.RegClient, rSrc ref.Ref, rTgts []ref.Ref, opts []regclient.ImageOpts) error {
+	ctx := cmd.Context()
+	opts = append(opts, regclient.ImageWithDryRun())
+	dl := imageCopyDryRunList{}
+	for _, rTgt := range rTgts {
+		result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", rTgt.CommonName(), err)
+		}
+		d := imageCopyDryRun{
+			Source:    rSrc.CommonName(),
+			Target:    rTgt.CommonName(),
+			Manifests: []descriptor.Descriptor{},
+			Blobs:     []descriptor.Descriptor{},
+		}
+		for _, e := range result.Manifests {
+			if e.Action == regclient.ImageCopyPending {
+				d.Manifests = append(d.Manifests, e.Descriptor)
+				d.Size += e.Descriptor.Size
+			}
+		}
+		for _, e := range result.Blobs {
+			if e.Action == regclient.ImageCopyPending {
+				d.Blobs = append(d.Blobs, e.Descriptor)
+				d.Size += e.Descriptor.Size
+			}
+		}
+		dl = append(dl, d)
+	}
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{printPretty .}}"
+	}
+	return imageOpts.rootOpts.resultOutput(cmd, "", imageOpts.format, dl)
+}
+
+// copyRepoTags pushes the copied manifest to the target for each source tag pointing to the same digest.
+// The source tags are compared to the digest of rSrc, which is the source before any platform was selected.
+// The manifest is read back from the target, so a platform or filtered index that was copied is pushed for each tag.
+func (imageOpts *imageCmd) copyRepoTags(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref) error {
+	mSrc, err := rc.ManifestHead(ctx, rSrc, regclient.WithManifestRequireDigest())
+	if err != nil {
+		return fmt.Errorf("failed to get source digest: %w", err)
+	}
+	dig := mSrc.GetDescriptor().Digest
+	m, err := rc.ManifestGet(ctx, rTgt)
+	if err != nil {
+		return fmt.Errorf("failed to get copied manifest: %w", err)
+	}
+	tl, err := rc.TagList(ctx, rSrc)
+	if err != nil {
+		return fmt.Errorf("failed to list source tags: %w", err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return fmt.Errorf("failed to list source tags: %w", err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errFirst error
+	sem := make(chan struct{}, tagConcurrent)
+	for _, t := range tags {
+		if t == rTgt.Tag {
+			continue
+		}
+		t := t
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := func() error {
+				mh, err := rc.ManifestHead(ctx, rSrc.SetTag(t), regclient.WithManifestRequireDigest())
+				if err != nil {
+					return fmt.Errorf("failed to check source tag %s: %w", t, err)
+				}
+				if mh.GetDescriptor().Digest != dig {
+					return nil
+				}
+				rTag := rTgt.SetTag(t)
+				imageOpts.rootOpts.log.Info("Copy tag",
+					slog.String("target", rTag.CommonName()),
+					slog.String("digest", m.GetDescriptor().Digest.String()))
+				return rc.ManifestPut(ctx, rTag, m)
+			}()
+			if err != nil {
+				mu.Lock()
+				if errFirst == nil {
+					errFirst = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errFirst
+}
+
+// imageCopyHook is the template data for the after-copy command.
+type imageCopyHook struct {
+	ref.Ref         // target of the copy, including the resolved digest
+	Source  ref.Ref // source of the copy
+}
+
+// afterCopyExec runs the after-copy command in a shell, this is replaced in tests.
+var afterCopyExec = func(ctx context.Context, command string, out io.Writer) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	c.Stdout = out
+	c.Stderr = out
+	return c.Run()
+}
+
+func (imageOpts *imageCmd) runAfterCopy(cmd *cobra.Command, rc *regclient.RegClient, rSrc, rTgt ref.Ref) error {
+	ctx := cmd.Context()
+	mh, err := rc.ManifestHead(ctx, rTgt, regclient.WithManifestRequireDigest())
+	if err != nil {
+		return fmt.Errorf("failed to resolve digest of %s: %w", rTgt.CommonName(), err)
+	}
+	data := imageCopyHook{
+		Ref:    rTgt,
+		Source: rSrc,
+	}
+	data.Ref.Digest = mh.GetDescriptor().Digest.String()
+	buf := &bytes.Buffer{}
+	err = template.Writer(buf, imageOpts.afterCopy, data)
+	if err != nil {
+		return fmt.Errorf("failed to format after-copy command: %w", err)
+	}
+	command := buf.String()
+	imageOpts.rootOpts.log.Info("Running after copy command",
+		slog.String("target", data.CommonName()),
+		slog.String("command", command))
+	err = afterCopyExec(ctx, command, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("after-copy command failed: %s: %w", command, err)
+	}
+	return nil
+}
+
+// logSkippedCallback logs each skipped manifest and blob before calling the next callback.
+func (imageOpts *imageCmd) logSkippedCallback(next func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)) func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+	return func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+		if state == types.CallbackSkipped {
+			imageOpts.rootOpts.log.Info("Copy skipped",
+				slog.String("kind", kind.String()),
+				slog.String("digest", instance),
+				slog.Int64("size", total))
+		}
+		if next != nil {
+			next(kind, instance, state, cur, total)
+		}
+	}
 }
 
 type imageProgress struct {
@@ -1432,12 +2174,31 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 	if err != nil {
 		return err
 	}
+	imageOpts.platform = imageOpts.rootOpts.platformDefault(r, imageOpts.platform)
 	var w io.Writer
-	if len(args) == 2 {
-		w, err = os.Create(args[1])
+	closeFn := func() error { return nil }
+	if imageOpts.exportSplit != "" {
+		if len(args) != 2 {
+			return fmt.Errorf("a filename is required to split the export")
+		}
+		size, err := units.FromHumanSize(imageOpts.exportSplit)
+		if err != nil {
+			return fmt.Errorf("failed to parse split size: %w%.0w", err, errs.ErrParsingFailed)
+		}
+		sw, err := splitfile.NewWriter(args[1], size)
 		if err != nil {
 			return err
 		}
+		defer sw.Close()
+		w = sw
+		closeFn = sw.Close
+	} else if len(args) == 2 {
+		fh, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		w = fh
 	} else {
 		w = cmd.OutOrStdout()
 	}
@@ -1467,7 +2228,12 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 	}
 	imageOpts.rootOpts.log.Debug("Image export",
 		slog.String("ref", r.CommonName()))
-	return rc.ImageExport(ctx, r, w, opts...)
+	err = rc.ImageExport(ctx, r, w, opts...)
+	if err != nil {
+		return err
+	}
+	// the final part is only complete after it is closed
+	return closeFn()
 }
 
 func (imageOpts *imageCmd) runImageGetFile(cmd *cobra.Command, args []string) error {
@@ -1480,6 +2246,7 @@ func (imageOpts *imageCmd) runImageGetFile(cmd *cobra.Command, args []string) er
 	if err != nil {
 		return err
 	}
+	imageOpts.platform = imageOpts.rootOpts.platformDefault(r, imageOpts.platform)
 	filename := args[1]
 	filename = strings.TrimPrefix(filename, "/")
 	rc := imageOpts.rootOpts.newRegClient()
@@ -1489,6 +2256,12 @@ func (imageOpts *imageCmd) runImageGetFile(cmd *cobra.Command, args []string) er
 		slog.String("ref", r.CommonName()),
 		slog.String("filename", filename))
 
+	if imageOpts.platform == "" && imageOpts.followIndex {
+		imageOpts.platform, err = imageFollowIndex(ctx, rc, r)
+		if err != nil {
+			return err
+		}
+	}
 	if imageOpts.platform == "" {
 		imageOpts.platform = "local"
 	}
@@ -1576,10 +2349,31 @@ func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) err
 		return err
 	}
 	opts := []regclient.ImageOpts{}
+	if imageOpts.importAll && imageOpts.importName != "" {
+		return fmt.Errorf("--all cannot be combined with --name%.0w", errs.ErrUnsupported)
+	}
 	if imageOpts.importName != "" {
 		opts = append(opts, regclient.ImageWithImportName(imageOpts.importName))
 	}
-	rs, err := os.Open(args[1])
+	importCount := 0
+	if imageOpts.importAll {
+		opts = append(opts, regclient.ImageWithImportAll(func(rTag ref.Ref) {
+			importCount++
+			imageOpts.rootOpts.log.Info("Imported tag",
+				slog.String("ref", rTag.CommonName()))
+		}))
+	}
+	files := args[1:]
+	if len(files) == 1 {
+		if _, err := os.Stat(files[0]); errors.Is(err, fs.ErrNotExist) {
+			// import the parts from an export with --split
+			parts, errParts := splitfile.Parts(files[0])
+			if errParts == nil {
+				files = parts
+			}
+		}
+	}
+	rs, err := splitfile.Open(files...)
 	if err != nil {
 		return err
 	}
@@ -1588,9 +2382,131 @@ func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) err
 	defer rc.Close(ctx, r)
 	imageOpts.rootOpts.log.Debug("Image import",
 		slog.String("ref", r.CommonName()),
-		slog.String("file", args[1]))
+		slog.Any("files", files))
 
-	return rc.ImageImport(ctx, r, rs, opts...)
+	err = rc.ImageImport(ctx, r, rs, opts...)
+	if err != nil {
+		return err
+	}
+	if imageOpts.importAll {
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d tags\n", importCount)
+	}
+	return nil
+}
+
+// imageHistoryCreatedByLen is the length of the created by command before it is truncated.
+const imageHistoryCreatedByLen = 45
+
+type imageHistoryList struct {
+	History []imageHistoryEntry `json:"history"`
+	noTrunc bool
+}
+
+type imageHistoryEntry struct {
+	Created    *time.Time    `json:"created,omitempty"`
+	CreatedBy  string        `json:"created_by,omitempty"`
+	Comment    string        `json:"comment,omitempty"`
+	EmptyLayer bool          `json:"empty_layer,omitempty"`
+	Size       int64         `json:"size"`
+	Digest     digest.Digest `json:"digest,omitempty"`
+}
+
+// MarshalPretty is used for printPretty template formatting.
+func (hl imageHistoryList) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "CREATED\tCREATED BY\tSIZE\tCOMMENT\n")
+	for _, h := range hl.History {
+		created := "-"
+		if h.Created != nil {
+			created = h.Created.UTC().Format(time.RFC3339)
+		}
+		createdBy := strings.ReplaceAll(h.CreatedBy, "\t", " ")
+		if !hl.noTrunc {
+			createdBy = strings.ReplaceAll(createdBy, "\n", " ")
+			if r := []rune(createdBy); len(r) > imageHistoryCreatedByLen {
+				createdBy = string(r[:imageHistoryCreatedByLen-3]) + "..."
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", created, createdBy, units.HumanSize(float64(h.Size)), h.Comment)
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+func (imageOpts *imageCmd) runImageHistory(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	imageOpts.platform = imageOpts.rootOpts.platformDefault(r, imageOpts.platform)
+	if imageOpts.platform == "" {
+		imageOpts.platform = "local"
+	}
+	p, err := platform.Parse(imageOpts.platform)
+	if err != nil {
+		return fmt.Errorf("failed to parse platform %s: %w", imageOpts.platform, err)
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	imageOpts.rootOpts.log.Debug("Image history",
+		slog.String("ref", r.CommonName()),
+		slog.String("platform", imageOpts.platform))
+
+	m, err := rc.ManifestGet(ctx, r, regclient.WithManifestPlatform(p))
+	if err != nil {
+		return err
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return fmt.Errorf("reference is not a known image media type%.0w", errs.ErrUnsupportedMediaType)
+	}
+	confDesc, err := mi.GetConfig()
+	if err != nil {
+		return err
+	}
+	if confDesc.MediaType != mediatype.OCI1ImageConfig && confDesc.MediaType != mediatype.Docker2ImageConfig {
+		return fmt.Errorf("artifacts are not supported with \"regctl image history\", config media type %s%.0w", confDesc.MediaType, errs.ErrUnsupportedMediaType)
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return err
+	}
+	blobConfig, err := rc.BlobGetOCIConfig(ctx, r, confDesc)
+	if err != nil {
+		return err
+	}
+	conf := blobConfig.GetConfig()
+	hl := imageHistoryList{
+		History: make([]imageHistoryEntry, len(conf.History)),
+		noTrunc: imageOpts.noTrunc,
+	}
+	// each history entry that is not empty corresponds to the next layer
+	li := 0
+	for i, h := range conf.History {
+		hl.History[i] = imageHistoryEntry{
+			Created:    h.Created,
+			CreatedBy:  h.CreatedBy,
+			Comment:    h.Comment,
+			EmptyLayer: h.EmptyLayer,
+		}
+		if h.EmptyLayer {
+			continue
+		}
+		if li < len(layers) {
+			hl.History[i].Size = layers[li].Size
+			hl.History[i].Digest = layers[li].Digest
+		}
+		li++
+	}
+	if li != len(layers) {
+		imageOpts.rootOpts.log.Warn("History does not match the layers",
+			slog.Int("history", li),
+			slog.Int("layers", len(layers)))
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, hl)
 }
 
 func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) error {
@@ -1599,8 +2515,21 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 	if err != nil {
 		return err
 	}
+	if imageOpts.listPlatforms {
+		if imageOpts.platform != "" || imageOpts.diffBase != "" {
+			return fmt.Errorf("--platforms cannot be combined with --platform or --diff-base%.0w", errs.ErrUnsupported)
+		}
+		return imageOpts.inspectPlatforms(cmd, r)
+	}
+	imageOpts.platform = imageOpts.rootOpts.platformDefault(r, imageOpts.platform)
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
+	if imageOpts.platform == "" && imageOpts.followIndex {
+		imageOpts.platform, err = imageFollowIndex(ctx, rc, r)
+		if err != nil {
+			return err
+		}
+	}
 
 	imageOpts.rootOpts.log.Debug("Image inspect",
 		slog.String("host", r.Registry),
@@ -1619,6 +2548,9 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 		}
 		return err
 	}
+	if imageOpts.diffBase != "" {
+		return imageOpts.inspectDiffBase(cmd, rc, r, blobConfig)
+	}
 	result := struct {
 		*blob.BOCIConfig
 		v1.Image
@@ -1626,6 +2558,10 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 		BOCIConfig: blobConfig,
 		Image:      blobConfig.GetConfig(),
 	}
+	if imageOpts.rootOpts.outputStructured() {
+		// the embedded config would otherwise replace the output with the raw config
+		return imageOpts.rootOpts.resultOutput(cmd, "", "", result.Image)
+	}
 	switch imageOpts.format {
 	case "raw":
 		imageOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -1637,6 +2573,228 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 	return template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
 }
 
+type imagePlatformList []imagePlatformEntry
+
+type imagePlatformEntry struct {
+	Platform platform.Platform `json:"platform"`
+	Digest   digest.Digest     `json:"digest"`
+}
+
+// MarshalPretty is used for printPretty template formatting.
+func (pl imagePlatformList) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "PLATFORM\tOS VERSION\tDIGEST\n")
+	for _, e := range pl {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Platform.String(), e.Platform.OSVersion, e.Digest.String())
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+// String returns the platform and digest of the entry.
+func (e imagePlatformEntry) String() string {
+	p := e.Platform.String()
+	if e.Platform.OSVersion != "" {
+		p += ",osver=" + e.Platform.OSVersion
+	}
+	return p + " " + e.Digest.String()
+}
+
+// inspectPlatforms outputs the platforms of an index without pulling the config of each image.
+func (imageOpts *imageCmd) inspectPlatforms(cmd *cobra.Command, r ref.Ref) error {
+	ctx := cmd.Context()
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return err
+	}
+	pl := imagePlatformList{}
+	if m.IsList() {
+		dl, err := manifest.GetPlatformDescriptors(m)
+		if err != nil {
+			return err
+		}
+		for _, d := range dl {
+			pl = append(pl, imagePlatformEntry{Platform: *d.Platform, Digest: d.Digest})
+		}
+	} else if imageOpts.allowSingle {
+		// a single manifest only has a platform in the config
+		p, err := indexGetPlatform(ctx, rc, r, m)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return fmt.Errorf("platform not found in %s%.0w", r.CommonName(), errs.ErrNotFound)
+		}
+		pl = append(pl, imagePlatformEntry{Platform: *p, Digest: m.GetDescriptor().Digest})
+	} else {
+		return fmt.Errorf("%s is not an index, use --allow-single to output the platform of a single manifest%.0w", r.CommonName(), errs.ErrUnsupportedMediaType)
+	}
+	return imageOpts.rootOpts.resultOutput(cmd, "", imageOpts.format, pl)
+}
+
+// imageFollowIndex returns the platform of the only image in an index, ignoring entries without a platform like attestations.
+// An empty string is returned when the ref is not an index.
+func imageFollowIndex(ctx context.Context, rc *regclient.RegClient, r ref.Ref) (string, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !m.IsList() || !ok {
+		return "", nil
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return "", err
+	}
+	platforms := []string{}
+	for _, d := range dl {
+		if d.Platform == nil || d.Platform.OS == "unknown" {
+			continue
+		}
+		p := d.Platform.String()
+		found := false
+		for _, cur := range platforms {
+			if cur == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			platforms = append(platforms, p)
+		}
+	}
+	switch len(platforms) {
+	case 0:
+		return "", fmt.Errorf("no platforms found in index %s%.0w", r.CommonName(), errs.ErrNotFound)
+	case 1:
+		return platforms[0], nil
+	default:
+		return "", fmt.Errorf("index %s contains multiple platforms, select one with --platform: %s", r.CommonName(), strings.Join(platforms, ", "))
+	}
+}
+
+type imageDiffBase struct {
+	Base         string             `json:"base"`
+	BaseLayers   int                `json:"baseLayers"`
+	ImageLayers  int                `json:"imageLayers"`
+	Env          imageDiffBaseMap   `json:"env"`
+	Labels       imageDiffBaseMap   `json:"labels"`
+	ExposedPorts imageDiffBaseMap   `json:"exposedPorts"`
+	Entrypoint   *imageDiffBaseList `json:"entrypoint,omitempty"`
+	Cmd          *imageDiffBaseList `json:"cmd,omitempty"`
+}
+
+type imageDiffBaseMap struct {
+	Added   map[string]string `json:"added,omitempty"`
+	Changed map[string]string `json:"changed,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+}
+
+type imageDiffBaseList struct {
+	Base  []string `json:"base"`
+	Image []string `json:"image"`
+}
+
+// inspectDiffBase reports the config changes and layers added on top of a base image.
+func (imageOpts *imageCmd) inspectDiffBase(cmd *cobra.Command, rc *regclient.RegClient, r ref.Ref, blobConfig *blob.BOCIConfig) error {
+	ctx := cmd.Context()
+	baseR, err := ref.New(imageOpts.diffBase)
+	if err != nil {
+		return err
+	}
+	defer rc.Close(ctx, baseR)
+	plat := imageOpts.platform
+	if plat == "" {
+		plat = "local"
+	}
+	// verify the base layers are the first layers in the image
+	err = rc.ImageCheckBase(ctx, r,
+		regclient.ImageWithCheckBaseRef(baseR.CommonName()),
+		regclient.ImageWithCheckSkipConfig(),
+		regclient.ImageWithPlatform(plat))
+	if err != nil {
+		return fmt.Errorf("image %s is not based on %s: %w", r.CommonName(), baseR.CommonName(), err)
+	}
+	baseConfig, err := rc.ImageConfig(ctx, baseR, regclient.ImageWithPlatform(plat))
+	if err != nil {
+		return err
+	}
+	img := blobConfig.GetConfig()
+	baseImg := baseConfig.GetConfig()
+	result := imageDiffBase{
+		Base:         baseR.CommonName(),
+		BaseLayers:   len(baseImg.RootFS.DiffIDs),
+		ImageLayers:  len(img.RootFS.DiffIDs) - len(baseImg.RootFS.DiffIDs),
+		Env:          imageDiffBaseMapCmp(imageEnvMap(baseImg.Config.Env), imageEnvMap(img.Config.Env)),
+		Labels:       imageDiffBaseMapCmp(baseImg.Config.Labels, img.Config.Labels),
+		ExposedPorts: imageDiffBaseMapCmp(imagePortMap(baseImg.Config.ExposedPorts), imagePortMap(img.Config.ExposedPorts)),
+		Entrypoint:   imageDiffBaseListCmp(baseImg.Config.Entrypoint, img.Config.Entrypoint),
+		Cmd:          imageDiffBaseListCmp(baseImg.Config.Cmd, img.Config.Cmd),
+	}
+	return imageOpts.rootOpts.resultOutput(cmd, "", imageOpts.format, result)
+}
+
+func imageDiffBaseMapCmp(base, image map[string]string) imageDiffBaseMap {
+	result := imageDiffBaseMap{}
+	for k, v := range image {
+		if baseV, ok := base[k]; !ok {
+			if result.Added == nil {
+				result.Added = map[string]string{}
+			}
+			result.Added[k] = v
+		} else if baseV != v {
+			if result.Changed == nil {
+				result.Changed = map[string]string{}
+			}
+			result.Changed[k] = v
+		}
+	}
+	for k := range base {
+		if _, ok := image[k]; !ok {
+			result.Removed = append(result.Removed, k)
+		}
+	}
+	sort.Strings(result.Removed)
+	return result
+}
+
+func imageDiffBaseListCmp(base, image []string) *imageDiffBaseList {
+	if len(base) == len(image) {
+		same := true
+		for i := range base {
+			if base[i] != image[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return nil
+		}
+	}
+	return &imageDiffBaseList{Base: base, Image: image}
+}
+
+func imageEnvMap(env []string) map[string]string {
+	result := map[string]string{}
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		result[k] = v
+	}
+	return result
+}
+
+func imagePortMap(ports map[string]struct{}) map[string]string {
+	result := map[string]string{}
+	for p := range ports {
+		result[p] = ""
+	}
+	return result
+}
+
 func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	rSrc, err := ref.New(args[0])
@@ -1659,7 +2817,21 @@ func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error
 		rTgt = rSrc
 		rTgt.Tag = ""
 	}
+	if imageOpts.layerAddMT != "" {
+		for _, la := range imageOpts.layerAdds {
+			imageOpts.modOpts[la.index] = mod.WithLayerAddTarCreatedBy(la.rdr, imageOpts.layerAddMT, la.platforms, la.createdBy)
+		}
+	}
 	imageOpts.modOpts = append(imageOpts.modOpts, mod.WithRefTgt(rTgt))
+	if imageOpts.rootOpts.tmpDir != "" {
+		imageOpts.modOpts = append(imageOpts.modOpts, mod.WithTempDir(imageOpts.rootOpts.tmpDir))
+	}
+	if imageOpts.dryRun {
+		if !imageOpts.rebase {
+			return fmt.Errorf("--dry-run requires --rebase or --rebase-ref%.0w", errs.ErrUnsupported)
+		}
+		return imageOpts.modRebaseDryRun(cmd, rSrc)
+	}
 	rc := imageOpts.rootOpts.newRegClient()
 
 	imageOpts.rootOpts.log.Debug("Modifying image",
@@ -1678,6 +2850,41 @@ func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error
 	return nil
 }
 
+// modRebaseDryRun outputs the base layers swapped in each image by a rebase without pushing any content.
+func (imageOpts *imageCmd) modRebaseDryRun(cmd *cobra.Command, rSrc ref.Ref) error {
+	ctx := cmd.Context()
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, rSrc)
+	changes := []mod.RebaseChange{}
+	opts := append(imageOpts.modOpts, mod.WithDryRun(), mod.WithRebaseReport(func(c mod.RebaseChange) {
+		changes = append(changes, c)
+	}))
+	_, err := mod.Apply(ctx, rc, rSrc, opts...)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No base layers to swap in %s\n", rSrc.ShortName())
+		return nil
+	}
+	for _, c := range changes {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s -> %s\n", c.Platform.String(), c.BaseOld.ShortName(), c.BaseNew.ShortName())
+		// layers shared by both bases are unchanged, the remaining layers are swapped
+		same := 0
+		for same < len(c.LayersOld) && same < len(c.LayersNew) && c.LayersOld[same].Digest == c.LayersNew[same].Digest {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", c.LayersOld[same].Digest.String())
+			same++
+		}
+		for _, l := range c.LayersOld[same:] {
+			fmt.Fprintf(cmd.OutOrStdout(), "- %s\n", l.Digest.String())
+		}
+		for _, l := range c.LayersNew[same:] {
+			fmt.Fprintf(cmd.OutOrStdout(), "+ %s\n", l.Digest.String())
+		}
+	}
+	return nil
+}
+
 func (imageOpts *imageCmd) runImageRateLimit(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
@@ -1697,7 +2904,67 @@ func (imageOpts *imageCmd) runImageRateLimit(cmd *cobra.Command, args []string)
 		return err
 	}
 
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, manifest.GetRateLimit(m))
+	rl := manifest.GetRateLimit(m)
+	err = template.Writer(cmd.OutOrStdout(), imageOpts.format, rl)
+	if err != nil {
+		return err
+	}
+	// thresholds only apply when the registry reports the remaining pulls
+	if !rl.Set {
+		return nil
+	}
+	if rl.Remain < imageOpts.rateLimitFail {
+		return fmt.Errorf("rate limit remaining %d is below %d%.0w", rl.Remain, imageOpts.rateLimitFail, errs.ErrHTTPRateLimit)
+	}
+	if rl.Remain < imageOpts.rateLimitWarn {
+		imageOpts.rootOpts.log.Warn("Rate limit below threshold",
+			slog.String("ref", r.CommonName()),
+			slog.Int("remain", rl.Remain),
+			slog.Int("limit", rl.Limit),
+			slog.Int("threshold", imageOpts.rateLimitWarn))
+	}
+	return nil
+}
+
+// modAnnotationFileRead parses a file of annotations to set, returning the names in the order to apply them.
+// The file may contain a json object, or lines of name=value, ignoring empty lines and comments.
+func modAnnotationFileRead(filename string) ([]string, map[string]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read annotation file: %w", err)
+	}
+	values := map[string]string{}
+	names := []string{}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		err = json.Unmarshal(b, &values)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse annotation file %s: %w", filename, err)
+		}
+		for name := range values {
+			if strings.TrimSpace(name) == "" {
+				return nil, nil, fmt.Errorf("invalid empty annotation name in file %s", filename)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, values, nil
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, nil, fmt.Errorf("invalid annotation in file %s: %s", filename, line)
+		}
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+	return names, values, nil
 }
 
 type modFlagFunc struct {
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/olareg/olareg"
 	oConfig "github.com/olareg/olareg/config"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient/types/errs"
 )
@@ -62,11 +71,41 @@ func TestImageCopy(t *testing.T) {
 			args:      []string{"image", "copy", "--platform", "linux/amd64", tsHost + "/testrepo:v3", tsHost + "/newrepo:v3"},
 			expectOut: tsHost + "/newrepo:v3",
 		},
+		{
+			name:      "ocidir-to-reg-platform-filter",
+			args:      []string{"image", "copy", "--platform-filter", "linux/amd64,linux/arm64", "ocidir://../../testdata/testrepo:v1", tsHost + "/newrepo:filter"},
+			expectOut: tsHost + "/newrepo:filter",
+		},
+		{
+			name:      "platform-filter-with-platform",
+			args:      []string{"image", "copy", "--platform-filter", "linux/amd64", "--platform", "linux/amd64", srcRef, tsHost + "/newrepo:filter"},
+			expectErr: errs.ErrUnsupported,
+		},
 		{
 			name:      "ocidir-to-reg-external-referrers",
 			args:      []string{"image", "copy", srcRef, tsHost + "/newrepo:v4", "--referrers", "--referrers-src", "ocidir://../../testdata/external", "--referrers-tgt", tsHost + "/external"},
 			expectOut: tsHost + "/newrepo:v4",
 		},
+		{
+			name:      "ocidir-to-reg-digest-tags-as-referrers",
+			args:      []string{"image", "copy", "--store-digest-tags-as", "referrers", "ocidir://../../testdata/testrepo:v1", tsHost + "/newrepo:v5"},
+			expectOut: tsHost + "/newrepo:v5",
+		},
+		{
+			name:      "store-digest-tags-as-invalid",
+			args:      []string{"image", "copy", "--store-digest-tags-as", "tags", srcRef, tsHost + "/newrepo:v5"},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "ocidir-to-multiple",
+			args:      []string{"image", "copy", srcRef, tsHost + "/multi1:v2", "ocidir://" + tempDir + "multi2:v2"},
+			expectOut: tsHost + "/multi1:v2\nocidir://" + tempDir + "multi2:v2",
+		},
+		{
+			name:      "ocidir-to-multiple-invalid",
+			args:      []string{"image", "copy", srcRef, tsHost + "/multi1:v2", tsHost + "/Invalid:v2"},
+			expectErr: errs.ErrInvalidReference,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -89,6 +128,522 @@ func TestImageCopy(t *testing.T) {
 	}
 }
 
+func TestImageCopyResumeRateLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// rate limit enough manifest requests to exceed the retry limit of the first copy
+	var mu sync.Mutex
+	limited := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if strings.HasPrefix(r.URL.Path, "/v2/testrepo/manifests/") && limited < 6 {
+			limited++
+			mu.Unlock()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		mu.Unlock()
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	tgtRef := "ocidir://" + tempDir + "/testrepo:v1"
+	out, err := cobraTest(t, nil, "image", "copy", "--resume-on-ratelimit", tsHost+"/testrepo:v1", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy with rate limit: %v", err)
+	}
+	if !strings.HasSuffix(out, tgtRef) {
+		t.Errorf("unexpected output, expected %s, received %s", tgtRef, out)
+	}
+	if limited < 6 {
+		t.Errorf("rate limit was not triggered, limited requests: %d", limited)
+	}
+}
+
+func TestImageCopySourceManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// track the manifest requests sent to the source
+	var mu sync.Mutex
+	reqs := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/testrepo/manifests/") {
+			mu.Lock()
+			reqs[r.URL.Path]++
+			mu.Unlock()
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	srcDir := "ocidir://../../testdata/testrepo"
+	dig, err := cobraTest(t, nil, "image", "digest", srcDir+":v1")
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	raw, err := cobraTest(t, nil, "manifest", "get", "--format", "raw-body", srcDir+":v1")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	mFile := filepath.Join(tempDir, "manifest.json")
+	err = os.WriteFile(mFile, []byte(raw), 0600)
+	if err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	tt := []struct {
+		name      string
+		src       string
+		expectErr error
+	}{
+		{
+			name: "by digest",
+			src:  tsHost + "/testrepo@" + dig,
+		},
+		{
+			name: "by tag",
+			src:  tsHost + "/testrepo:v1",
+		},
+		{
+			name:      "digest mismatch",
+			src:       tsHost + "/testrepo@sha256:" + strings.Repeat("0", 64),
+			expectErr: errs.ErrDigestMismatch,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			mu.Lock()
+			reqs = map[string]int{}
+			mu.Unlock()
+			tgtRef := "ocidir://" + t.TempDir() + "/testrepo:v1"
+			_, err := cobraTest(t, nil, "image", "copy", "--source-manifest-file", mFile, tc.src, tgtRef)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to copy: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if reqs["/v2/testrepo/manifests/"+dig] > 0 || reqs["/v2/testrepo/manifests/v1"] > 0 {
+				t.Errorf("source manifest was requested: %v", reqs)
+			}
+			if len(reqs) == 0 {
+				t.Errorf("child manifests were not requested from the source")
+			}
+			tgtDig, err := cobraTest(t, nil, "image", "digest", tgtRef)
+			if err != nil {
+				t.Fatalf("failed to get target digest: %v", err)
+			}
+			if tgtDig != dig {
+				t.Errorf("unexpected target digest, expected %s, received %s", dig, tgtDig)
+			}
+		})
+	}
+}
+
+func TestImageCopyLayerCache(t *testing.T) {
+	tempDir := t.TempDir()
+	cacheDir := filepath.Join(tempDir, "cache")
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// count the blobs pulled from the source
+	var mu sync.Mutex
+	blobGets := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/testrepo/blobs/") {
+			mu.Lock()
+			blobGets++
+			mu.Unlock()
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--layer-cache-dir", cacheDir, tsHost+"/testrepo:v1", "ocidir://"+tempDir+"/tgt1:v1")
+	if err != nil {
+		t.Fatalf("failed to copy to first target: %v", err)
+	}
+	mu.Lock()
+	firstGets := blobGets
+	mu.Unlock()
+	if firstGets == 0 {
+		t.Fatalf("no blobs pulled from the source")
+	}
+	cached, err := filepath.Glob(filepath.Join(cacheDir, "sha256", "*"))
+	if err != nil || len(cached) == 0 {
+		t.Errorf("cache directory is empty: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--layer-cache-dir", cacheDir, tsHost+"/testrepo:v1", "ocidir://"+tempDir+"/tgt2:v1")
+	if err != nil {
+		t.Fatalf("failed to copy to second target: %v", err)
+	}
+	mu.Lock()
+	secondGets := blobGets - firstGets
+	mu.Unlock()
+	if secondGets != 0 {
+		t.Errorf("second copy pulled %d blobs from the source, expected all blobs from the cache", secondGets)
+	}
+	blobs1, _ := filepath.Glob(filepath.Join(tempDir, "tgt1", "blobs", "sha256", "*"))
+	blobs2, _ := filepath.Glob(filepath.Join(tempDir, "tgt2", "blobs", "sha256", "*"))
+	if len(blobs1) == 0 || len(blobs1) != len(blobs2) {
+		t.Errorf("unexpected blobs in targets, first %d, second %d", len(blobs1), len(blobs2))
+	}
+}
+
+func TestImageCopyNoMount(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// count the mount requests and blob uploads to each repository
+	var mu sync.Mutex
+	mounts := map[string]int{}
+	uploads := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo, _, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/blobs/uploads/")
+		if ok {
+			mu.Lock()
+			if r.Method == http.MethodPost && r.URL.Query().Has("mount") {
+				mounts[repo]++
+			} else if r.Method == http.MethodPut || r.Method == http.MethodPatch {
+				uploads[repo]++
+			}
+			mu.Unlock()
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	srcDig, err := cobraTest(t, nil, "image", "digest", tsHost+"/testrepo:b1")
+	if err != nil {
+		t.Fatalf("failed to get source digest: %v", err)
+	}
+
+	_, err = cobraTest(t, nil, "image", "copy", tsHost+"/testrepo:b1", tsHost+"/mount:b1")
+	if err != nil {
+		t.Fatalf("failed to copy with mount: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--no-cross-repo-mount", tsHost+"/testrepo:b1", tsHost+"/nomount:b1")
+	if err != nil {
+		t.Fatalf("failed to copy without mount: %v", err)
+	}
+	mu.Lock()
+	mountCount, noMountCount, noMountUploads := mounts["mount"], mounts["nomount"], uploads["nomount"]
+	mu.Unlock()
+	if mountCount == 0 {
+		t.Errorf("copy did not attempt a blob mount")
+	}
+	if noMountCount != 0 {
+		t.Errorf("copy with --no-cross-repo-mount sent %d mount requests", noMountCount)
+	}
+	if noMountUploads == 0 {
+		t.Errorf("copy with --no-cross-repo-mount did not upload blobs")
+	}
+	tgtDig, err := cobraTest(t, nil, "image", "digest", tsHost+"/nomount:b1")
+	if err != nil {
+		t.Fatalf("failed to get target digest: %v", err)
+	}
+	if srcDig != tgtDig {
+		t.Errorf("digest mismatch, expected %s, received %s", srcDig, tgtDig)
+	}
+}
+
+func TestImageCopyPreserveRepoTags(t *testing.T) {
+	tempDir := t.TempDir()
+	srcRepo := "ocidir://" + tempDir + "/src"
+	tgtRepo := "ocidir://" + tempDir + "/tgt"
+	for _, args := range [][]string{
+		{"image", "copy", "ocidir://../../testdata/testrepo:v1", srcRepo + ":v1.2.3"},
+		{"image", "copy", srcRepo + ":v1.2.3", srcRepo + ":v1.2"},
+		{"image", "copy", srcRepo + ":v1.2.3", srcRepo + ":v1"},
+		{"image", "copy", "ocidir://../../testdata/testrepo:v2", srcRepo + ":v2"},
+	} {
+		_, err := cobraTest(t, nil, args...)
+		if err != nil {
+			t.Fatalf("failed to setup source with %v: %v", args, err)
+		}
+	}
+	_, err := cobraTest(t, nil, "image", "copy", "--preserve-repo-tags", srcRepo+":v1.2.3", tgtRepo+":v1.2.3")
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	out, err := cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "v1\nv1.2\nv1.2.3" {
+		t.Errorf("unexpected tags, expected v1, v1.2, and v1.2.3, received %s", out)
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", srcRepo+":v1.2.3")
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	for _, tag := range []string{"v1", "v1.2"} {
+		out, err := cobraTest(t, nil, "image", "digest", tgtRepo+":"+tag)
+		if err != nil {
+			t.Fatalf("failed to get digest of %s: %v", tag, err)
+		}
+		if out != dig {
+			t.Errorf("unexpected digest for %s, expected %s, received %s", tag, dig, out)
+		}
+	}
+	// the tags point to the filtered or platform manifest that was copied
+	for _, tc := range []struct {
+		name string
+		args []string
+	}{
+		{name: "platform-filter", args: []string{"--platform-filter", "linux/amd64"}},
+		{name: "platform", args: []string{"--platform", "linux/amd64"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tgtRepo := "ocidir://" + tempDir + "/" + tc.name
+			args := append([]string{"image", "copy", "--preserve-repo-tags"}, tc.args...)
+			_, err := cobraTest(t, nil, append(args, srcRepo+":v1.2.3", tgtRepo+":v1.2.3")...)
+			if err != nil {
+				t.Fatalf("failed to copy: %v", err)
+			}
+			out, err := cobraTest(t, nil, "tag", "ls", tgtRepo)
+			if err != nil {
+				t.Fatalf("failed to list tags: %v", err)
+			}
+			if out != "v1\nv1.2\nv1.2.3" {
+				t.Errorf("unexpected tags, expected v1, v1.2, and v1.2.3, received %s", out)
+			}
+			tgtDig, err := cobraTest(t, nil, "image", "digest", tgtRepo+":v1.2.3")
+			if err != nil {
+				t.Fatalf("failed to get digest: %v", err)
+			}
+			if tgtDig == dig {
+				t.Errorf("target digest was not changed by %v", tc.args)
+			}
+			for _, tag := range []string{"v1", "v1.2"} {
+				out, err := cobraTest(t, nil, "image", "digest", tgtRepo+":"+tag)
+				if err != nil {
+					t.Fatalf("failed to get digest of %s: %v", tag, err)
+				}
+				if out != tgtDig {
+					t.Errorf("unexpected digest for %s, expected %s, received %s", tag, tgtDig, out)
+				}
+			}
+		})
+	}
+}
+
+func TestImageCopyLogSkipped(t *testing.T) {
+	tempDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	repo1 := "ocidir://" + tempDir + "/repo1:v1"
+	repo2 := "ocidir://" + tempDir + "/repo2:v1"
+	digLayer, err := cobraTest(t, nil, "manifest", "get", srcRef, "--platform", "linux/amd64", "--format", "{{(index .Layers 0).Digest}}")
+	if err != nil {
+		t.Fatalf("failed to get layer digest: %v", err)
+	}
+	out, err := cobraTest(t, nil, "image", "copy", "-v", "info", srcRef, repo1)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if strings.Contains(out, "Copy skipped") {
+		t.Errorf("skipped blobs logged without the flag: %s", out)
+	}
+	// blobs are linked between OCI Layouts, reporting them as skipped
+	out, err = cobraTest(t, nil, "image", "copy", "-v", "info", "--log-skipped", repo1, repo2)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if !strings.Contains(out, "Copy skipped") || !strings.Contains(out, "kind=blob digest="+digLayer) {
+		t.Errorf("skipped layer %s not logged: %s", digLayer, out)
+	}
+}
+
+func TestImageCopyAfterCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	tgtRef := "ocidir://" + tempDir + "/testrepo:v1"
+	dig, err := cobraTest(t, nil, "image", "digest", srcRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	var commands []string
+	errHook := errors.New("hook failed")
+	origExec := afterCopyExec
+	t.Cleanup(func() { afterCopyExec = origExec })
+	afterCopyExec = func(ctx context.Context, command string, out io.Writer) error {
+		commands = append(commands, command)
+		if strings.HasPrefix(command, "fail") {
+			return errHook
+		}
+		return nil
+	}
+	tt := []struct {
+		name          string
+		args          []string
+		expectCommand string
+		expectErr     error
+	}{
+		{
+			name:          "templated command",
+			args:          []string{"image", "copy", "--after-copy", "sign {{.CommonName}} {{.Digest}}", srcRef, tgtRef},
+			expectCommand: "sign " + tgtRef + "@" + dig + " " + dig,
+		},
+		{
+			name:          "source ref",
+			args:          []string{"image", "copy", "--after-copy", "echo {{.Source.CommonName}}", srcRef, tgtRef},
+			expectCommand: "echo " + srcRef,
+		},
+		{
+			name:          "failing command",
+			args:          []string{"image", "copy", "--after-copy", "fail {{.Digest}}", srcRef, tgtRef},
+			expectCommand: "fail " + dig,
+			expectErr:     errHook,
+		},
+		{
+			name:          "failing command with warn",
+			args:          []string{"image", "copy", "--after-copy", "fail {{.Digest}}", "--after-copy-warn", srcRef, tgtRef},
+			expectCommand: "fail " + dig,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commands = []string{}
+			_, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+			} else if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if len(commands) != 1 || commands[0] != tc.expectCommand {
+				t.Errorf("unexpected command, expected %s, received %v", tc.expectCommand, commands)
+			}
+		})
+	}
+}
+
+func TestImageCopyDryRun(t *testing.T) {
+	tempDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRef := "ocidir://" + tempDir + "/testrepo:v2"
+	tt := []struct {
+		name        string
+		args        []string
+		expectTotal string
+		expectErr   error
+	}{
+		{
+			name:        "index",
+			args:        []string{"image", "copy", "--dry-run", srcRef, tgtRef},
+			expectTotal: "Total: 4 manifests, 6 blobs,",
+		},
+		{
+			name:        "referrers",
+			args:        []string{"image", "copy", "--dry-run", "--referrers", srcRef, tgtRef},
+			expectTotal: "Total: 9 manifests, 12 blobs,",
+		},
+		{
+			name:        "platform",
+			args:        []string{"image", "copy", "--dry-run", "--platform", "linux/amd64", srcRef, tgtRef},
+			expectTotal: "Total: 1 manifests, 4 blobs,",
+		},
+		{
+			name:      "after-copy",
+			args:      []string{"image", "copy", "--dry-run", "--after-copy", "echo", srcRef, tgtRef},
+			expectErr: errs.ErrUnsupported,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tc.expectTotal) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectTotal, out)
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, "testrepo")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("target created by a dry run: %v", err)
+			}
+		})
+	}
+	// nothing is missing after the copy
+	_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	out, err := cobraTest(t, nil, "image", "copy", "--dry-run", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run dry run: %v", err)
+	}
+	if !strings.Contains(out, "Total: 0 manifests, 0 blobs,") {
+		t.Errorf("unexpected output after copy: %s", out)
+	}
+}
+
 func TestImageCreate(t *testing.T) {
 	tmpDir := t.TempDir()
 	imageRef := fmt.Sprintf("ocidir://%s/repo:scratch", tmpDir)
@@ -124,6 +679,22 @@ func TestImageExportImport(t *testing.T) {
 	if out != "" {
 		t.Errorf("unexpected output: %v", out)
 	}
+	importRefAll := fmt.Sprintf("ocidir://%s/repo-all", tmpDir)
+	out, err = cobraTest(t, nil, "image", "import", "--all", importRefAll, exportFile)
+	if err != nil {
+		t.Fatalf("failed to run image import --all: %v", err)
+	}
+	if out != "Imported 1 tags" {
+		t.Errorf("unexpected output: %v", out)
+	}
+	_, err = cobraTest(t, nil, "image", "digest", importRefAll+":v2")
+	if err != nil {
+		t.Errorf("failed to get digest of imported tag: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "import", "--all", "--name", "v2", importRefAll, exportFile)
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error combining --all and --name: %v", err)
+	}
 
 	out, err = cobraTest(t, nil, "image", "export", "--name", exportName, "--platform", "linux/amd64", srcRef, exportFile)
 	if err != nil {
@@ -132,10 +703,151 @@ func TestImageExportImport(t *testing.T) {
 	if out != "" {
 		t.Errorf("unexpected output: %v", out)
 	}
+
+	// split the export and verify the concatenated parts match the full export
+	fullBytes, err := os.ReadFile(exportFile)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	splitFile := tmpDir + "/split.tar"
+	out, err = cobraTest(t, nil, "image", "export", "--name", exportName, "--platform", "linux/amd64", "--split", "4KiB", srcRef, splitFile)
+	if err != nil {
+		t.Fatalf("failed to run image export: %v", err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output: %v", out)
+	}
+	parts, err := filepath.Glob(splitFile + ".*")
+	if err != nil {
+		t.Fatalf("failed to list parts: %v", err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("export was not split, parts: %v", parts)
+	}
+	splitBytes := []byte{}
+	for _, part := range parts {
+		b, err := os.ReadFile(part)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", part, err)
+		}
+		if len(b) > 4096 {
+			t.Errorf("part %s exceeds split size: %d", part, len(b))
+		}
+		splitBytes = append(splitBytes, b...)
+	}
+	if !bytes.Equal(fullBytes, splitBytes) {
+		t.Errorf("concatenated parts do not match the export, full size %d, split size %d", len(fullBytes), len(splitBytes))
+	}
+	importRefB := fmt.Sprintf("ocidir://%s/repo:split", tmpDir)
+	out, err = cobraTest(t, nil, "image", "import", importRefB, splitFile)
+	if err != nil {
+		t.Fatalf("failed to import split parts: %v", err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output: %v", out)
+	}
+	importRefC := fmt.Sprintf("ocidir://%s/repo:parts", tmpDir)
+	_, err = cobraTest(t, nil, append([]string{"image", "import", importRefC}, parts...)...)
+	if err != nil {
+		t.Fatalf("failed to import list of parts: %v", err)
+	}
+	digB, err := cobraTest(t, nil, "image", "digest", importRefB)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	digC, err := cobraTest(t, nil, "image", "digest", importRefC)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	if digB != digC {
+		t.Errorf("digest mismatch between imports, %s and %s", digB, digC)
+	}
+	// a second export with fewer parts removes the stale parts from the first
+	_, err = cobraTest(t, nil, "image", "export", "--name", exportName, "--platform", "linux/amd64", "--split", "1MiB", srcRef, splitFile)
+	if err != nil {
+		t.Fatalf("failed to export with a larger split: %v", err)
+	}
+	partsLarge, err := filepath.Glob(splitFile + ".*")
+	if err != nil || len(partsLarge) >= len(parts) {
+		t.Fatalf("stale parts remain after the second export: %v, %v", partsLarge, err)
+	}
+	importRefD := fmt.Sprintf("ocidir://%s/repo:resplit", tmpDir)
+	_, err = cobraTest(t, nil, "image", "import", importRefD, splitFile)
+	if err != nil {
+		t.Fatalf("failed to import the second split export: %v", err)
+	}
+}
+
+func TestImageHistory(t *testing.T) {
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	layerOut, err := cobraTest(t, nil, "image", "manifest", "--platform", "linux/amd64", "--format", "{{range .Layers}}{{println .Size}}{{end}}", srcRef)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	layerSizes := strings.Split(layerOut, "\n")
+	if len(layerSizes) < 2 {
+		t.Fatalf("expected a multi-layer image, received layer sizes: %v", layerSizes)
+	}
+	t.Run("sizes", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "image", "history", "--platform", "linux/amd64", "--format", "{{range .History}}{{if not .EmptyLayer}}{{println .Size}}{{end}}{{end}}", srcRef)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		historySizes := strings.Split(out, "\n")
+		if strings.Join(historySizes, ",") != strings.Join(layerSizes, ",") {
+			t.Errorf("history sizes do not match layers, expected %v, received %v", layerSizes, historySizes)
+		}
+	})
+	t.Run("table", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "image", "history", "--platform", "linux/amd64", srcRef)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		rows := strings.Split(out, "\n")
+		if len(rows) != 9 || !strings.HasPrefix(rows[0], "CREATED") {
+			t.Fatalf("unexpected rows: %s", out)
+		}
+		if !strings.Contains(rows[1], "COPY base-a.txt /base.txt") || !strings.Contains(rows[1], "106.000B") {
+			t.Errorf("unexpected first row: %s", rows[1])
+		}
+		if !strings.Contains(rows[2], "LABEL base=a") || !strings.Contains(rows[2], "0.000B") {
+			t.Errorf("unexpected empty layer row: %s", rows[2])
+		}
+	})
+	t.Run("truncate", func(t *testing.T) {
+		createdBy := "RUN " + strings.Repeat("x", imageHistoryCreatedByLen)
+		hl := imageHistoryList{History: []imageHistoryEntry{{CreatedBy: createdBy, Size: 1000}}}
+		out, err := hl.MarshalPretty()
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		if strings.Contains(string(out), createdBy) || !strings.Contains(string(out), "...") {
+			t.Errorf("created by was not truncated: %s", out)
+		}
+		hl.noTrunc = true
+		out, err = hl.MarshalPretty()
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		if !strings.Contains(string(out), createdBy) {
+			t.Errorf("created by was truncated: %s", out)
+		}
+	})
+	t.Run("artifact", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "image", "history", "ocidir://../../testdata/testrepo:a1")
+		if !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrUnsupportedMediaType, err)
+		}
+	})
 }
 
 func TestImageInspect(t *testing.T) {
 	srcRef := "ocidir://../../testdata/testrepo:v3"
+	singleRef := "ocidir://" + t.TempDir() + "/repo:single"
+	_, err := cobraTest(t, nil, "index", "create", singleRef, "--ref", srcRef, "--platform", "linux/arm64")
+	if err != nil {
+		t.Fatalf("failed to create single platform index: %v", err)
+	}
 	tt := []struct {
 		name        string
 		cmd         []string
@@ -179,6 +891,75 @@ func TestImageInspect(t *testing.T) {
 			expectOut:   "linux",
 			outContains: false,
 		},
+		{
+			name:      "diff base layers",
+			cmd:       []string{"image", "inspect", srcRef, "--platform", "linux/amd64", "--diff-base", "ocidir://../../testdata/testrepo:b1", "--format", `{{ .BaseLayers }} {{ .ImageLayers }}`},
+			expectOut: "1 4",
+		},
+		{
+			name:      "diff base labels",
+			cmd:       []string{"image", "inspect", srcRef, "--platform", "linux/amd64", "--diff-base", "ocidir://../../testdata/testrepo:b1", "--format", `{{ index .Labels.Added "version" }} {{ .Labels.Removed }}`},
+			expectOut: "3 [base]",
+		},
+		{
+			name:      "diff base mismatch",
+			cmd:       []string{"image", "inspect", srcRef, "--platform", "linux/amd64", "--diff-base", "ocidir://../../testdata/testrepo:b3"},
+			expectErr: errs.ErrMismatch,
+		},
+		{
+			name:      "single platform",
+			cmd:       []string{"image", "inspect", singleRef, "--platform", "linux/arm64", "--format", `{{ .GetConfig.Architecture }}`},
+			expectOut: "arm64",
+		},
+		{
+			name:      "follow index",
+			cmd:       []string{"image", "inspect", singleRef, "--follow-index", "--format", `{{ .GetConfig.Architecture }}`},
+			expectOut: "arm64",
+		},
+		{
+			name:      "follow index with platform",
+			cmd:       []string{"image", "inspect", srcRef, "--follow-index", "--platform", "linux/amd64", "--format", `{{ .GetConfig.Architecture }}`},
+			expectOut: "amd64",
+		},
+		{
+			name:      "follow index multiple platforms",
+			cmd:       []string{"image", "inspect", srcRef, "--follow-index"},
+			expectErr: fmt.Errorf("index %s contains multiple platforms, select one with --platform: linux/amd64, linux/arm64, linux/arm/v7, linux/arm/v6", srcRef),
+		},
+		{
+			name:      "follow index get-file",
+			cmd:       []string{"image", "get-file", singleRef, "/layer1", "--follow-index"},
+			expectOut: "1",
+		},
+		{
+			name:        "platforms",
+			cmd:         []string{"image", "inspect", srcRef, "--platforms"},
+			expectOut:   "PLATFORM",
+			outContains: true,
+		},
+		{
+			name: "platforms format",
+			cmd:  []string{"image", "inspect", srcRef, "--platforms", "--format", `{{range .}}{{println .}}{{end}}`},
+			expectOut: "linux/amd64 sha256:f8c9d547514d66b562f791c361e4e9795340a7626aff22980138718689ef2a44\n" +
+				"linux/arm64 sha256:e2a061deaaf445494e98f544b7dc3717288733d6bf918d888d50aec982a587ab\n" +
+				"linux/arm/v7 sha256:f4682754068e9235e63d24d8e5a2b9faca41bbfff1e74b131293b9d86cb0bc2b\n" +
+				"linux/arm/v6 sha256:8fb6a85012f44e45a0555da6449e1444bdfe9b6589c3090ffccbdbcdcf979011",
+		},
+		{
+			name:      "platforms single manifest",
+			cmd:       []string{"image", "inspect", "ocidir://../../testdata/testrepo@sha256:f8c9d547514d66b562f791c361e4e9795340a7626aff22980138718689ef2a44", "--platforms"},
+			expectErr: errs.ErrUnsupportedMediaType,
+		},
+		{
+			name:      "platforms allow single",
+			cmd:       []string{"image", "inspect", "ocidir://../../testdata/testrepo@sha256:f8c9d547514d66b562f791c361e4e9795340a7626aff22980138718689ef2a44", "--platforms", "--allow-single", "--format", `{{range .}}{{println .}}{{end}}`},
+			expectOut: "linux/amd64 sha256:f8c9d547514d66b562f791c361e4e9795340a7626aff22980138718689ef2a44",
+		},
+		{
+			name:      "platforms with platform",
+			cmd:       []string{"image", "inspect", srcRef, "--platforms", "--platform", "linux/amd64"},
+			expectErr: errs.ErrUnsupported,
+		},
 		{
 			name:      "invalid ref",
 			cmd:       []string{"image", "inspect", "invalid://ref*format"},
@@ -211,11 +992,142 @@ func TestImageInspect(t *testing.T) {
 	}
 }
 
+func TestImageModAnnotationFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v3"
+	modRef := fmt.Sprintf("ocidir://%s/repo:mod", tmpDir)
+	jsonFile := filepath.Join(tmpDir, "annotations.json")
+	err := os.WriteFile(jsonFile, []byte(`{"com.example.a":"1","com.example.b":"2","org.opencontainers.image.version":"file"}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write annotation file: %v", err)
+	}
+	lineFile := filepath.Join(tmpDir, "annotations.txt")
+	err = os.WriteFile(lineFile, []byte("# delete a and set c\ncom.example.a=\n\ncom.example.c=3\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write annotation file: %v", err)
+	}
+	format := `{{ range $k, $v := .GetAnnotations }}{{ printf "%s=%s\n" $k $v }}{{ end }}`
+
+	// the flag is applied before the file to verify flags take precedence
+	_, err = cobraTest(t, nil, "image", "mod", srcRef, "--create", modRef,
+		"--annotation", "org.opencontainers.image.version=flag", "--annotation-file", jsonFile)
+	if err != nil {
+		t.Fatalf("failed to mod image with json file: %v", err)
+	}
+	out, err := cobraTest(t, nil, "manifest", "get", modRef, "--format", format)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	for _, expect := range []string{"com.example.a=1", "com.example.b=2", "org.opencontainers.image.version=flag"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("annotation %s missing, received: %s", expect, out)
+		}
+	}
+
+	_, err = cobraTest(t, nil, "image", "mod", modRef, "--replace", "--annotation-file", lineFile)
+	if err != nil {
+		t.Fatalf("failed to mod image with line file: %v", err)
+	}
+	out, err = cobraTest(t, nil, "manifest", "get", modRef, "--format", format)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	if strings.Contains(out, "com.example.a=") {
+		t.Errorf("annotation com.example.a was not deleted: %s", out)
+	}
+	for _, expect := range []string{"com.example.b=2", "com.example.c=3"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("annotation %s missing, received: %s", expect, out)
+		}
+	}
+
+	_, err = cobraTest(t, nil, "image", "mod", modRef, "--replace", "--annotation-file", filepath.Join(tmpDir, "missing.json"))
+	if err == nil {
+		t.Errorf("missing annotation file did not fail")
+	}
+}
+
+func TestImageModLayerCompression(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v3"
+	modRef := fmt.Sprintf("ocidir://%s/repo:zstd", tmpDir)
+	layerFormat := `{{ range .GetLayers }}{{ printf "%s %s\n" .MediaType .Digest }}{{ end }}`
+	diffFormat := `{{ json .RootFS.DiffIDs }}`
+	_, err := cobraTest(t, nil, "image", "mod", srcRef, "--create", modRef, "--layer-compression", "zstd")
+	if err != nil {
+		t.Fatalf("failed to mod image: %v", err)
+	}
+	for _, p := range []string{"linux/amd64", "linux/arm64"} {
+		srcLayers, err := cobraTest(t, nil, "manifest", "get", srcRef, "--platform", p, "--format", layerFormat)
+		if err != nil {
+			t.Fatalf("failed to get source manifest for %s: %v", p, err)
+		}
+		modLayers, err := cobraTest(t, nil, "manifest", "get", modRef, "--platform", p, "--format", layerFormat)
+		if err != nil {
+			t.Fatalf("failed to get modified manifest for %s: %v", p, err)
+		}
+		srcLines, modLines := strings.Split(srcLayers, "\n"), strings.Split(modLayers, "\n")
+		if len(srcLines) != len(modLines) {
+			t.Fatalf("layer count changed for %s, expected %d, received %d", p, len(srcLines), len(modLines))
+		}
+		for i := range modLines {
+			mt, dig, _ := strings.Cut(modLines[i], " ")
+			if mt != "application/vnd.oci.image.layer.v1.tar+zstd" {
+				t.Errorf("unexpected media type for %s layer %d: %s", p, i, mt)
+			}
+			_, srcDig, _ := strings.Cut(srcLines[i], " ")
+			if dig == srcDig {
+				t.Errorf("digest did not change for %s layer %d: %s", p, i, dig)
+			}
+			d, err := digest.Parse(dig)
+			if err != nil {
+				t.Fatalf("failed to parse digest %s: %v", dig, err)
+			}
+			blob, err := os.ReadFile(filepath.Join(tmpDir, "repo", "blobs", d.Algorithm().String(), d.Encoded()))
+			if err != nil {
+				t.Fatalf("failed to read blob %s: %v", dig, err)
+			}
+			if d != d.Algorithm().FromBytes(blob) {
+				t.Errorf("blob content does not match the digest %s", dig)
+			}
+			if !bytes.HasPrefix(blob, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+				t.Errorf("blob %s is not zstd compressed", dig)
+			}
+		}
+		// the uncompressed content, and the diff ids, are unchanged
+		srcDiff, err := cobraTest(t, nil, "image", "config", srcRef, "--platform", p, "--format", diffFormat)
+		if err != nil {
+			t.Fatalf("failed to get source config for %s: %v", p, err)
+		}
+		modDiff, err := cobraTest(t, nil, "image", "config", modRef, "--platform", p, "--format", diffFormat)
+		if err != nil {
+			t.Fatalf("failed to get modified config for %s: %v", p, err)
+		}
+		if srcDiff != modDiff {
+			t.Errorf("diff ids changed for %s, expected %s, received %s", p, srcDiff, modDiff)
+		}
+	}
+}
+
 func TestImageMod(t *testing.T) {
 	tmpDir := t.TempDir()
 	srcRef := "ocidir://../../testdata/testrepo:v3"
 	baseRef := "ocidir://../../testdata/testrepo:b1"
 	modRef := fmt.Sprintf("ocidir://%s/repo:mod", tmpDir)
+	dryRunRef := fmt.Sprintf("ocidir://%s/dryrun:mod", tmpDir)
+	tarBytes, err := os.ReadFile("../../testdata/layer.tar")
+	if err != nil {
+		t.Fatalf("failed to read layer: %v", err)
+	}
+	tgzBuf := &bytes.Buffer{}
+	gzW := gzip.NewWriter(tgzBuf)
+	_, _ = gzW.Write(tarBytes)
+	_ = gzW.Close()
+	tgzFile := filepath.Join(tmpDir, "layer.tgz")
+	err = os.WriteFile(tgzFile, tgzBuf.Bytes(), 0600)
+	if err != nil {
+		t.Fatalf("failed to write layer: %v", err)
+	}
 	tt := []struct {
 		name        string
 		cmd         []string
@@ -233,16 +1145,112 @@ func TestImageMod(t *testing.T) {
 			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-add", "dir=../../cmd"},
 			expectOut: modRef,
 		},
+		{
+			name:      "layer-add-file-media-type",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-add", tgzFile, "--layer-add-media-type", "application/vnd.oci.image.layer.v1.tar"},
+			expectOut: modRef,
+		},
+		{
+			name:      "layer-add-file-media-type-check",
+			cmd:       []string{"manifest", "get", modRef, "--platform", "linux/amd64", "--format", "{{ (index .Layers 5).MediaType }} {{ (index .Layers 5).Digest }}"},
+			expectOut: "application/vnd.oci.image.layer.v1.tar " + digest.FromBytes(tarBytes).String(),
+		},
+		{
+			name:        "layer-add-file-history",
+			cmd:         []string{"image", "config", modRef, "--platform", "linux/amd64", "--format", "{{ range .History }}{{ println .CreatedBy }}{{ end }}"},
+			outContains: true,
+			expectOut:   "regclient layer add layer.tgz",
+		},
+		{
+			name:      "layer-add-dir-history",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-add", "dir=../../cmd"},
+			expectOut: modRef,
+		},
+		{
+			name:        "layer-add-dir-history-check",
+			cmd:         []string{"image", "config", modRef, "--platform", "linux/amd64", "--format", "{{ range .History }}{{ println .CreatedBy }}{{ end }}"},
+			outContains: true,
+			expectOut:   "regclient layer add cmd/",
+		},
 		{
 			name:      "layer-add-both",
 			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-add", "tar=../../testdata/layer.tar,dir=../../cmd,platform=linux/amd64"},
 			expectErr: fmt.Errorf(`invalid argument "tar=../../testdata/layer.tar,dir=../../cmd,platform=linux/amd64" for "--layer-add" flag: cannot use dir and tar options together in layer-add`),
 		},
+		{
+			name:      "env-add-rm",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--env-add", "FOO=bar", "--env-rm", "[linux/amd64]PATH", "--env-rm", "MISSING"},
+			expectOut: modRef,
+		},
+		{
+			name:      "env-add-invalid",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--env-add", "FOO"},
+			expectErr: fmt.Errorf(`invalid argument "FOO" for "--env-add" flag: env must be in the format name=value: FOO`),
+		},
+		{
+			name:      "attestations-rm",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--attestations-rm"},
+			expectOut: modRef,
+		},
+		{
+			name:      "layer-compression",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-compression", "zstd"},
+			expectOut: modRef,
+		},
+		{
+			name:      "layer-compression-invalid",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-compression", "lz4"},
+			expectErr: fmt.Errorf(`invalid argument "lz4" for "--layer-compression" flag: unknown layer compression lz4`),
+		},
+		{
+			name:      "normalize-artifact",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--normalize-artifact"},
+			expectOut: modRef,
+		},
+		{
+			name:      "normalize-artifact-reverse",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--normalize-artifact=artifact"},
+			expectErr: fmt.Errorf(`invalid argument "artifact" for "--normalize-artifact" flag: converting to the OCI artifact manifest is not supported, it was removed from the OCI 1.1 release`),
+		},
+		{
+			name:      "entrypoint-cmd",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--entrypoint", `["/app"]`, "--cmd", ""},
+			expectOut: modRef,
+		},
+		{
+			name:      "entrypoint-platform",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--entrypoint", `[linux/amd64]["/app"]`, "--cmd", "[linux/amd64]serve -v"},
+			expectOut: modRef,
+		},
+		{
+			name:      "entrypoint-platform-invalid-shell",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--entrypoint", `[linux/amd 64]["/app"]`},
+			expectOut: modRef,
+		},
 		{
 			name:      "timestamps",
 			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--time", "set=2000-01-01T00:00:00Z,base-ref=" + baseRef},
 			expectOut: modRef,
 		},
+		{
+			name: "rebase-dry-run",
+			cmd: []string{"image", "mod", "ocidir://../../testdata/testrepo:v2", "--create", dryRunRef, "--dry-run",
+				"--rebase-ref", baseRef + ",ocidir://../../testdata/testrepo:b3"},
+			expectOut: "linux/amd64: ocidir://../../testdata/testrepo@sha256:2f295c8e37f19a4fb8d49a3f2130863ec40288cb010f721742748e3dde5b1819 -> ocidir://../../testdata/testrepo@sha256:5db14b573e806eb81f78502636f5fc2b6648dd0b64d90231fbcf70e14f52bd64\n" +
+				"- sha256:ac4ae1712ec852391e6aae58abf8ff4665df9ae87c71d1e81aa421508a7b831d\n" +
+				"+ sha256:95768439f03e261c83969a2c1ab7d4eba0af517ed0666aa203d4c7bff5405f29",
+			outContains: true,
+		},
+		{
+			name:      "rebase-dry-run-not-pushed",
+			cmd:       []string{"manifest", "head", dryRunRef},
+			expectErr: fs.ErrNotExist,
+		},
+		{
+			name:      "dry-run-without-rebase",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--dry-run", "--env-add", "FOO=bar"},
+			expectErr: errs.ErrUnsupported,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -265,3 +1273,162 @@ func TestImageMod(t *testing.T) {
 		})
 	}
 }
+
+func TestImageModParseExec(t *testing.T) {
+	tt := []struct {
+		name        string
+		val         string
+		expectPlats []string
+		expectExec  []string
+	}{
+		{
+			name:       "empty",
+			val:        "",
+			expectExec: nil,
+		},
+		{
+			name:       "json",
+			val:        `["/app", "-v"]`,
+			expectExec: []string{"/app", "-v"},
+		},
+		{
+			name:       "empty json",
+			val:        `[]`,
+			expectExec: nil,
+		},
+		{
+			name:       "shell",
+			val:        "/app -v",
+			expectExec: []string{"/bin/sh", "-c", "/app -v"},
+		},
+		{
+			name:        "platform json",
+			val:         `[linux/amd64,linux/arm64]["/app"]`,
+			expectPlats: []string{"linux/amd64", "linux/arm64"},
+			expectExec:  []string{"/app"},
+		},
+		{
+			name:        "platform empty",
+			val:         `[linux/arm64]`,
+			expectPlats: []string{"linux/arm64"},
+			expectExec:  nil,
+		},
+		{
+			name:       "platform wildcard",
+			val:        `[*]["/app", "-v"]`,
+			expectExec: []string{"/app", "-v"},
+		},
+		{
+			name:       "shell test",
+			val:        `[ -f /x ] && app`,
+			expectExec: []string{"/bin/sh", "-c", "[ -f /x ] && app"},
+		},
+		{
+			name:       "shell after platform",
+			val:        `[linux/amd64] && app`,
+			expectExec: []string{"/bin/sh", "-c", "[linux/amd64] && app"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plats, exec, err := imageModParseExec(tc.val)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if len(plats) != len(tc.expectPlats) {
+				t.Fatalf("unexpected platforms, expected %v, received %v", tc.expectPlats, plats)
+			}
+			for i := range plats {
+				if plats[i].String() != tc.expectPlats[i] {
+					t.Errorf("unexpected platform %d, expected %s, received %s", i, tc.expectPlats[i], plats[i].String())
+				}
+			}
+			if len(exec) != len(tc.expectExec) {
+				t.Fatalf("unexpected exec, expected %v, received %v", tc.expectExec, exec)
+			}
+			for i := range exec {
+				if exec[i] != tc.expectExec[i] {
+					t.Errorf("unexpected exec %d, expected %s, received %s", i, tc.expectExec[i], exec[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImageRateLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/testrepo/manifests/") {
+			w.Header().Set("RateLimit-Limit", "100;w=21600")
+			w.Header().Set("RateLimit-Remaining", "20;w=21600")
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	tt := []struct {
+		name       string
+		args       []string
+		expectErr  error
+		expectOut  string
+		expectWarn bool
+	}{
+		{
+			name:      "format",
+			args:      []string{"image", "ratelimit", "--format", "{{.Limit}} {{.Remain}} {{.Set}}", tsHost + "/testrepo:v1"},
+			expectOut: "100 20 true",
+		},
+		{
+			name:      "above thresholds",
+			args:      []string{"image", "ratelimit", "--format", "{{.Remain}}", "--warn-below", "20", "--fail-below", "10", tsHost + "/testrepo:v1"},
+			expectOut: "20",
+		},
+		{
+			name:       "below warn",
+			args:       []string{"image", "ratelimit", "--format", "{{.Remain}}", "--warn-below", "50", "--fail-below", "10", tsHost + "/testrepo:v1"},
+			expectOut:  "20",
+			expectWarn: true,
+		},
+		{
+			name:      "below fail",
+			args:      []string{"image", "ratelimit", "--format", "{{.Remain}}", "--warn-below", "50", "--fail-below", "21", tsHost + "/testrepo:v1"},
+			expectErr: errs.ErrHTTPRateLimit,
+			expectOut: "20",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+			} else if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(out, tc.expectOut) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+			if strings.Contains(out, "Rate limit below threshold") != tc.expectWarn {
+				t.Errorf("unexpected warning, expected %t, received %s", tc.expectWarn, out)
+			}
+		})
+	}
+}
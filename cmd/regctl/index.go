@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/docker/schema2"
@@ -32,7 +33,7 @@ type indexCmd struct {
 	artifactType    string
 	byDigest        bool
 	descAnnotations []string
-	descPlatform    string
+	descPlatforms   []string
 	digests         []string
 	format          string
 	incDigestTags   bool
@@ -108,8 +109,22 @@ regctl index delete registry.example.org/repo:v1 \
 		RunE:      indexOpts.runIndexDelete,
 	}
 
-	indexAddCmd.Flags().StringArrayVar(&indexOpts.descAnnotations, "desc-annotation", []string{}, "Annotation to add to descriptors of new entries")
-	indexAddCmd.Flags().StringVar(&indexOpts.descPlatform, "desc-platform", "", "Platform to set in descriptors of new entries")
+	var indexGCCmd = &cobra.Command{
+		Use:   "gc <ocidir_ref>",
+		Short: "garbage collect an OCI Layout",
+		Long: `Delete every blob in an OCI Layout that is not reachable from the index.
+Manifests, configs, layers, subjects, and referrers stored with a fallback tag are preserved.
+The number of blobs and bytes reclaimed are output.`,
+		Example: `
+# remove unreferenced blobs after overwriting tags
+regctl index gc ocidir://path/to/layout`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{}, // do not auto complete digests
+		RunE:      indexOpts.runIndexGC,
+	}
+
+	indexAddCmd.Flags().StringArrayVar(&indexOpts.descAnnotations, "desc-annotation", []string{}, "Annotation to add to descriptors of new entries (key=value), or to a single entry (digest=key=value)")
+	indexAddCmd.Flags().StringArrayVar(&indexOpts.descPlatforms, "desc-platform", []string{}, "Platform to set in descriptors of new entries, or for a single entry (digest=platform)")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to add")
 	indexAddCmd.Flags().BoolVar(&indexOpts.incDigestTags, "digest-tags", false, "Include digest tags")
 	indexAddCmd.Flags().BoolVar(&indexOpts.incReferrers, "referrers", false, "Include referrers")
@@ -119,8 +134,8 @@ regctl index delete registry.example.org/repo:v1 \
 	indexCreateCmd.Flags().StringArrayVar(&indexOpts.annotations, "annotation", []string{}, "Annotation to set on manifest")
 	indexCreateCmd.Flags().StringVar(&indexOpts.artifactType, "artifact-type", "", "Include an artifactType value")
 	indexCreateCmd.Flags().BoolVar(&indexOpts.byDigest, "by-digest", false, "Push manifest by digest instead of tag")
-	indexCreateCmd.Flags().StringArrayVar(&indexOpts.descAnnotations, "desc-annotation", []string{}, "Annotation to add to descriptors of new entries")
-	indexCreateCmd.Flags().StringVar(&indexOpts.descPlatform, "desc-platform", "", "Platform to set in descriptors of new entries")
+	indexCreateCmd.Flags().StringArrayVar(&indexOpts.descAnnotations, "desc-annotation", []string{}, "Annotation to add to descriptors of new entries (key=value), or to a single entry (digest=key=value)")
+	indexCreateCmd.Flags().StringArrayVar(&indexOpts.descPlatforms, "desc-platform", []string{}, "Platform to set in descriptors of new entries, or for a single entry (digest=platform)")
 	indexCreateCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to include in new index")
 	indexCreateCmd.Flags().StringVar(&indexOpts.format, "format", "", "Format output with go template syntax")
 	indexCreateCmd.Flags().BoolVar(&indexOpts.incDigestTags, "digest-tags", false, "Include digest tags")
@@ -136,9 +151,13 @@ regctl index delete registry.example.org/repo:v1 \
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to delete")
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platform to delete")
 
+	indexGCCmd.Flags().StringVar(&indexOpts.format, "format", "", "Format output with go template syntax")
+	_ = indexGCCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	indexTopCmd.AddCommand(indexAddCmd)
 	indexTopCmd.AddCommand(indexCreateCmd)
 	indexTopCmd.AddCommand(indexDeleteCmd)
+	indexTopCmd.AddCommand(indexGCCmd)
 	return indexTopCmd
 }
 
@@ -408,6 +427,27 @@ func (indexOpts *indexCmd) runIndexDelete(cmd *cobra.Command, args []string) err
 	return template.Writer(cmd.OutOrStdout(), indexOpts.format, result)
 }
 
+func (indexOpts *indexCmd) runIndexGC(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if r.Scheme != "ocidir" {
+		return fmt.Errorf("garbage collection is only supported for an OCI Layout, received %s%.0w", r.CommonName(), errs.ErrUnsupported)
+	}
+	rc := indexOpts.rootOpts.newRegClient()
+	result, err := rc.GC(ctx, r)
+	if err != nil {
+		return err
+	}
+	if indexOpts.format != "" {
+		return template.Writer(cmd.OutOrStdout(), indexOpts.format, result)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %d blobs, reclaimed %s\n", result.Blobs, units.HumanSize(float64(result.Bytes)))
+	return nil
+}
+
 func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient.RegClient, r ref.Ref) ([]descriptor.Descriptor, error) {
 	imgCopyOpts := []regclient.ImageOpts{
 		regclient.ImageWithChild(),
@@ -419,13 +459,43 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 		imgCopyOpts = append(imgCopyOpts, regclient.ImageWithReferrers())
 	}
 
+	// parse the descriptor changes before anything is copied
 	descAnnotations := map[string]string{}
+	digAnnotations := map[digest.Digest]map[string]string{}
 	for _, a := range indexOpts.descAnnotations {
+		dig, a, err := indexSplitDigest(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid desc-annotation %s: %w", a, err)
+		}
+		annotations := descAnnotations
+		if dig != "" {
+			if digAnnotations[dig] == nil {
+				digAnnotations[dig] = map[string]string{}
+			}
+			annotations = digAnnotations[dig]
+		}
 		aSplit := strings.SplitN(a, "=", 2)
 		if len(aSplit) == 1 {
-			descAnnotations[aSplit[0]] = ""
+			annotations[aSplit[0]] = ""
+		} else {
+			annotations[aSplit[0]] = aSplit[1]
+		}
+	}
+	var descPlatform *platform.Platform
+	digPlatforms := map[digest.Digest]platform.Platform{}
+	for _, pStr := range indexOpts.descPlatforms {
+		dig, pStr, err := indexSplitDigest(pStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid desc-platform %s: %w", pStr, err)
+		}
+		p, err := platform.Parse(pStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse desc-platform %s: %w", pStr, err)
+		}
+		if dig != "" {
+			digPlatforms[dig] = p
 		} else {
-			descAnnotations[aSplit[0]] = aSplit[1]
+			descPlatform = &p
 		}
 	}
 	platforms := []platform.Platform{}
@@ -437,10 +507,15 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 		platforms = append(platforms, p)
 	}
 
-	// copy each ref by digest to the destination repository
+	// resolve each ref to the digests copied to the destination repository
 	if indexOpts.digests == nil {
 		indexOpts.digests = []string{}
 	}
+	type indexCopy struct {
+		src ref.Ref
+		dig digest.Digest
+	}
+	copies := []indexCopy{}
 	for _, rStr := range indexOpts.refs {
 		srcRef, err := ref.New(rStr)
 		if err != nil {
@@ -452,13 +527,7 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 		}
 		if !mCopy.IsList() || len(platforms) == 0 {
 			// single manifest
-			desc := mCopy.GetDescriptor()
-			tgtRef := r.SetDigest(desc.Digest.String())
-			err = rc.ImageCopy(ctx, srcRef, tgtRef, imgCopyOpts...)
-			if err != nil {
-				return nil, err
-			}
-			indexOpts.digests = append(indexOpts.digests, desc.Digest.String())
+			copies = append(copies, indexCopy{src: srcRef, dig: mCopy.GetDescriptor().Digest})
 		} else {
 			// platform specific descriptors are being extracted from a manifest list
 			mCopy, err = rc.ManifestGet(ctx, srcRef)
@@ -475,17 +544,36 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 			}
 			for _, d := range dl {
 				if d.Platform != nil && indexPlatformInList(*d.Platform, platforms) {
-					dRef := srcRef.SetDigest(d.Digest.String())
-					tgtRef := r.SetDigest(d.Digest.String())
-					err = rc.ImageCopy(ctx, dRef, tgtRef, imgCopyOpts...)
-					if err != nil {
-						return nil, err
-					}
-					indexOpts.digests = append(indexOpts.digests, d.Digest.String())
+					copies = append(copies, indexCopy{src: srcRef.SetDigest(d.Digest.String()), dig: d.Digest})
 				}
 			}
 		}
 	}
+	digests := []digest.Digest{}
+	for _, dig := range indexOpts.digests {
+		digests = append(digests, digest.Digest(dig))
+	}
+	for _, c := range copies {
+		digests = append(digests, c.dig)
+	}
+	// every digest specific change must match a new entry
+	for dig := range digAnnotations {
+		if !indexDigestListHas(digests, dig) {
+			return nil, fmt.Errorf("desc-annotation digest %s is not a new entry in the index%.0w", dig.String(), errs.ErrNotFound)
+		}
+	}
+	for dig := range digPlatforms {
+		if !indexDigestListHas(digests, dig) {
+			return nil, fmt.Errorf("desc-platform digest %s is not a new entry in the index%.0w", dig.String(), errs.ErrNotFound)
+		}
+	}
+	for _, c := range copies {
+		err := rc.ImageCopy(ctx, c.src, r.SetDigest(c.dig.String()), imgCopyOpts...)
+		if err != nil {
+			return nil, err
+		}
+		indexOpts.digests = append(indexOpts.digests, c.dig.String())
+	}
 
 	// parse each digest, pull manifest, get config, append to list of descriptors
 	descList := []descriptor.Descriptor{}
@@ -496,16 +584,15 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 			return nil, err
 		}
 		desc := mDig.GetDescriptor()
-		plat := &platform.Platform{}
-		if indexOpts.descPlatform != "" {
-			*plat, err = platform.Parse(indexOpts.descPlatform)
-		} else {
-			plat, err = indexGetPlatform(ctx, rc, rDig, mDig)
-		}
-		if err == nil {
+		if p, ok := digPlatforms[desc.Digest]; ok {
+			desc.Platform = &p
+		} else if descPlatform != nil {
+			p := *descPlatform
+			desc.Platform = &p
+		} else if plat, err := indexGetPlatform(ctx, rc, rDig, mDig); err == nil {
 			desc.Platform = plat
 		}
-		if len(descAnnotations) > 0 {
+		if len(descAnnotations) > 0 || len(digAnnotations[desc.Digest]) > 0 {
 			desc.Annotations = map[string]string{}
 		} else {
 			desc.Annotations = nil
@@ -513,11 +600,41 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 		for k, v := range descAnnotations {
 			desc.Annotations[k] = v
 		}
+		for k, v := range digAnnotations[desc.Digest] {
+			desc.Annotations[k] = v
+		}
 		descList = append(descList, desc)
 	}
 	return descList, nil
 }
 
+// indexSplitDigest separates a "digest=value" prefix from a flag value.
+// An empty digest is returned when the value does not begin with a known digest algorithm.
+func indexSplitDigest(s string) (digest.Digest, string, error) {
+	algo, _, ok := strings.Cut(s, ":")
+	if !ok || !digest.Algorithm(algo).Available() {
+		return "", s, nil
+	}
+	digStr, val, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", s, fmt.Errorf("missing value after digest %s%.0w", digStr, errs.ErrParsingFailed)
+	}
+	dig, err := digest.Parse(digStr)
+	if err != nil {
+		return "", s, fmt.Errorf("failed to parse digest %s: %w%.0w", digStr, err, errs.ErrParsingFailed)
+	}
+	return dig, val, nil
+}
+
+func indexDigestListHas(dl []digest.Digest, dig digest.Digest) bool {
+	for _, d := range dl {
+		if d == dig {
+			return true
+		}
+	}
+	return false
+}
+
 func indexGetPlatform(ctx context.Context, rc *regclient.RegClient, r ref.Ref, m manifest.Manifest) (*platform.Platform, error) {
 	if mi, ok := m.(manifest.Imager); ok {
 		if !m.IsSet() {
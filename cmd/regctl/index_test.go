@@ -1,8 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/errs"
 )
 
 func TestIndex(t *testing.T) {
@@ -75,3 +83,106 @@ func TestIndex(t *testing.T) {
 		t.Errorf("manifest artifact type, expected %s, received %s", testArtifactType, out)
 	}
 }
+
+func TestIndexDesc(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	amdDig, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", srcRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/amd64: %v", err)
+	}
+	armDig, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/arm64", srcRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/arm64: %v", err)
+	}
+
+	t.Run("per digest", func(t *testing.T) {
+		tgtRef := fmt.Sprintf("ocidir://%s/desc:latest", tmpDir)
+		_, err := cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--platform", "linux/arm64",
+			"--desc-annotation", "all=true",
+			"--desc-annotation", amdDig+"=org.example.variant=amd",
+			"--desc-platform", armDig+"=linux/riscv64",
+			tgtRef)
+		if err != nil {
+			t.Fatalf("failed to run index create: %v", err)
+		}
+		out, err := cobraTest(t, nil, "manifest", "get", tgtRef, "--format", `{{range .Manifests}}{{.Digest}} {{.Platform}} {{index .Annotations "all"}} {{index .Annotations "org.example.variant"}}{{"\n"}}{{end}}`)
+		if err != nil {
+			t.Fatalf("failed to get index: %v", err)
+		}
+		expect := amdDig + " linux/amd64 true amd\n" + armDig + " linux/riscv64 true"
+		if out != expect {
+			t.Errorf("unexpected descriptors, expected:\n%s\nreceived:\n%s", expect, out)
+		}
+	})
+	t.Run("unknown digest", func(t *testing.T) {
+		tgtRef := fmt.Sprintf("ocidir://%s/unknown:latest", tmpDir)
+		unknown := digest.FromString("unknown").String()
+		_, err := cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--desc-annotation", unknown+"=a=b", tgtRef)
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrNotFound, err)
+		}
+		_, err = cobraTest(t, nil, "manifest", "head", tgtRef)
+		if err == nil {
+			t.Errorf("index pushed with an unknown digest")
+		}
+		// the digest is validated before any image is copied
+		if _, err := os.Stat(filepath.Join(tmpDir, "unknown")); err == nil {
+			t.Errorf("image copied before the unknown digest was detected")
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		tgtRef := fmt.Sprintf("ocidir://%s/invalid:latest", tmpDir)
+		_, err := cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--desc-annotation", "sha256:1234=a=b", tgtRef)
+		if !errors.Is(err, errs.ErrParsingFailed) {
+			t.Errorf("invalid digest did not fail: %v", err)
+		}
+		_, err = cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--desc-platform", amdDig+"=linux/", tgtRef)
+		if err == nil {
+			t.Errorf("invalid platform did not fail")
+		}
+		_, err = cobraTest(t, nil, "tag", "ls", fmt.Sprintf("ocidir://%s/invalid", tmpDir))
+		if err == nil {
+			t.Errorf("content copied after a validation failure")
+		}
+	})
+}
+
+func TestIndexGC(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:v1", tmpDir)
+	_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	// add an unreferenced blob directly, a blob put would be removed when the ref is closed
+	orphan := []byte("orphan")
+	dig := digest.FromBytes(orphan)
+	err = os.WriteFile(filepath.Join(tmpDir, "repo", "blobs", dig.Algorithm().String(), dig.Encoded()), orphan, 0600)
+	if err != nil {
+		t.Fatalf("failed to write blob: %v", err)
+	}
+	out, err := cobraTest(t, nil, "index", "gc", "--format", "{{.Blobs}} {{.Bytes}}", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run index gc: %v", err)
+	}
+	if out != "1 6" {
+		t.Errorf("unexpected output, expected 1 6, received %s", out)
+	}
+	out, err = cobraTest(t, nil, "index", "gc", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run index gc: %v", err)
+	}
+	if !strings.HasPrefix(out, "Removed 0 blobs") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "image", "inspect", "--platform", "linux/amd64", tgtRef)
+	if err != nil {
+		t.Errorf("image missing after gc: %v", err)
+	}
+	_, err = cobraTest(t, nil, "index", "gc", "registry.example.org/repo:v1")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error for a registry, expected %v, received %v", errs.ErrUnsupported, err)
+	}
+}
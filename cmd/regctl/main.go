@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -25,12 +26,16 @@ func main() {
 	}()
 	godbg.SignalTrace()
 
-	if err := rootTopCmd.ExecuteContext(ctx); err != nil {
+	err := rootTopCmd.ExecuteContext(ctx)
+	rootOpts.close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		// provide tips for common error messages
 		switch {
 		case strings.Contains(err.Error(), "http: server gave HTTP response to HTTPS client"):
 			fmt.Fprintf(os.Stderr, "Try updating your registry with \"regctl registry set --tls disabled <registry>\"\n")
+		case errors.Is(err, context.DeadlineExceeded) && rootOpts.timeout > 0:
+			fmt.Fprintf(os.Stderr, "Command exceeded the timeout of %s\n", rootOpts.timeout.String())
 		}
 		os.Exit(1)
 	}
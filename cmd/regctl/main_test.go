@@ -15,7 +15,8 @@ func cobraTest(t *testing.T, opts *cobraTestOpts, args ...string) (string, error
 	t.Helper()
 
 	buf := new(bytes.Buffer)
-	rootTopCmd, _ := NewRootCmd()
+	rootTopCmd, rootOpts := NewRootCmd()
+	defer rootOpts.close()
 	if opts != nil && opts.stdin != nil {
 		rootTopCmd.SetIn(opts.stdin)
 	}
@@ -1,19 +1,31 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/diff"
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/docker/schema1"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/warning"
@@ -23,13 +35,22 @@ type manifestCmd struct {
 	rootOpts      *rootCmd
 	byDigest      bool
 	contentType   string
+	convert       string
+	deleteRefs    bool
+	diffConfig    bool
 	diffCtx       int
+	dryRun        bool
 	diffFullCtx   bool
+	diffRecurse   bool
 	forceTagDeref bool
+	formatDiff    string
 	formatGet     string
 	formatHead    string
 	formatPut     string
+	ifNoneMatch   string
+	keepTags      bool
 	list          bool
+	listTags      bool
 	platform      string
 	referrers     bool
 	requireDigest bool
@@ -61,8 +82,23 @@ regctl manifest delete registry.example.org/repo@sha256:fab3c890d0480549d05d2ff3
 # delete the digest referenced by a tag (this is unsafe)
 regctl manifest delete registry.example.org/repo:v1.2.3 --force-tag-dereference
 
-# delete the digest and all manifests with a subject referencing the digest
+# show the tags that will be removed with the manifest
+regctl manifest delete --list-tags \
+  registry.example.org/repo@sha256:fab3c890d0480549d05d2ff3d746f42e360b7f0e3fe64bdf39fc572eab94911b
+
+# delete only the v1.2.3 tag, leaving the manifest for any other tags
+regctl manifest delete --keep-tags registry.example.org/repo:v1.2.3
+
+# delete the digest and update the referrers of its subject
 regctl manifest delete --referrers \
+  registry.example.org/repo@sha256:fab3c890d0480549d05d2ff3d746f42e360b7f0e3fe64bdf39fc572eab94911b
+
+# show the manifests that would be deleted, including any referrers
+regctl manifest delete --dry-run --delete-referrers \
+  registry.example.org/repo@sha256:fab3c890d0480549d05d2ff3d746f42e360b7f0e3fe64bdf39fc572eab94911b
+
+# delete the digest and all manifests with a subject referencing the digest
+regctl manifest delete --delete-referrers \
   registry.example.org/repo@sha256:fab3c890d0480549d05d2ff3d746f42e360b7f0e3fe64bdf39fc572eab94911b`,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{}, // do not auto complete digests
@@ -72,7 +108,16 @@ regctl manifest delete --referrers \
 	var manifestDiffCmd = &cobra.Command{
 		Use:   "diff <image_ref> <image_ref>",
 		Short: "compare manifests",
-		Long:  `Show the differences between two image manifests`,
+		Long: `Show the differences between two image manifests.
+The output includes a unified diff of the manifests and a summary of the changed media type,
+added, removed, and changed layers or platforms, and changed annotations.
+Use --config to include a diff of the image configs,
+and --recurse to compare each changed platform of an index.
+Comparing the configs of an index requires --recurse.
+Index entries are matched by the full platform, including the OS version and features,
+and attestations are matched by the digest of the image they reference.
+The --format flag outputs the Added, Removed, Changed, and Annotations lists,
+and Platforms containing the comparison of each changed platform with --recurse.`,
 		Example: `
 # compare the scratch and alpine images
 regctl manifest diff \
@@ -82,7 +127,20 @@ regctl manifest diff \
 # compare two digests and show the full context
 regctl manifest diff --context-full \
   ghcr.io/regclient/regctl@sha256:9b7057d06ce061cefc7a0b7cb28cad626164e6629a1a4f09cee4b4d400c9aef0 \
-  ghcr.io/regclient/regctl@sha256:4d113b278bd425d094848ba5d7b4d6baca13a2a9d20d265b32bc12020d501002`,
+  ghcr.io/regclient/regctl@sha256:4d113b278bd425d094848ba5d7b4d6baca13a2a9d20d265b32bc12020d501002
+
+# compare the linux/arm64 images
+regctl manifest diff --platform linux/arm64 \
+  ghcr.io/regclient/regctl:v0.5.0 ghcr.io/regclient/regctl:v0.6.0
+
+# compare the configs of each changed platform in an index
+regctl manifest diff --recurse --config \
+  ghcr.io/regclient/regctl:v0.5.0 ghcr.io/regclient/regctl:v0.6.0
+
+# output the digests of added layers or platforms
+regctl manifest diff \
+  --format '{{ range .Added }}{{ println .Digest }}{{ end }}' \
+  ghcr.io/regclient/regctl:v0.5.0 ghcr.io/regclient/regctl:v0.6.0`,
 		Args:              cobra.ExactArgs(2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestDiff,
@@ -101,10 +159,14 @@ regctl manifest get alpine
 regctl manifest get alpine --format raw-body --platform local
 
 # retrieve the manifest for a specific windows version
-regctl manifest get golang --platform windows/amd64,osver=10.0.17763.4974`,
+regctl manifest get golang --platform windows/amd64,osver=10.0.17763.4974
+
+# show a docker schema1 manifest converted to an OCI manifest
+regctl manifest get registry.example.org/legacy:v1 --convert oci`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestGet,
+		Annotations:       map[string]string{annotationOutput: "true"},
 	}
 
 	var manifestHeadCmd = &cobra.Command{
@@ -120,7 +182,10 @@ regctl manifest head alpine
 regctl manifest head alpine --platform linux/arm64
 
 # show all headers for the request
-regctl manifest head alpine --format raw-headers`,
+regctl manifest head alpine --format raw-headers
+
+# check if a tag has changed from a previous digest
+regctl manifest head alpine --if-none-match sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestHead,
@@ -141,30 +206,43 @@ regctl manifest put \
 		RunE:              manifestOpts.runManifestPut,
 	}
 
+	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.deleteRefs, "delete-referrers", "", false, "Delete the referrers to the manifest, recursively, before deleting the manifest")
+	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.dryRun, "dry-run", "", false, "Output the manifests that would be deleted without deleting them")
 	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.forceTagDeref, "force-tag-dereference", "", false, "Dereference the a tag to a digest, this is unsafe")
+	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.keepTags, "keep-tags", "", false, "Delete only the tag when a tag is provided, and fail if a digest being deleted is referenced by any tags, outputting those tags")
+	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.listTags, "list-tags", "", false, "Output the tags that point to the digest before deleting it")
 	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.referrers, "referrers", "", false, "Check for referrers, recommended when deleting artifacts")
 
+	manifestDiffCmd.Flags().BoolVarP(&manifestOpts.diffConfig, "config", "", false, "Include a diff of the image config")
 	manifestDiffCmd.Flags().IntVarP(&manifestOpts.diffCtx, "context", "", 3, "Lines of context")
 	manifestDiffCmd.Flags().BoolVarP(&manifestOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
+	manifestDiffCmd.Flags().StringVarP(&manifestOpts.formatDiff, "format", "", "", "Format output with go template syntax")
+	manifestDiffCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	manifestDiffCmd.Flags().BoolVarP(&manifestOpts.diffRecurse, "recurse", "", false, "Compare the manifests of each changed platform in an index")
+	_ = manifestDiffCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = manifestDiffCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
 	manifestHeadCmd.Flags().StringVarP(&manifestOpts.formatHead, "format", "", "", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
+	manifestHeadCmd.Flags().StringVarP(&manifestOpts.ifNoneMatch, "if-none-match", "", "", "Output \"unchanged\" if the manifest matches the digest")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Do not resolve platform from manifest list (enabled by default)")
 	manifestHeadCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local, requires a get request)")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireDigest, "require-digest", "", false, "Fallback to get request if digest is not received")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Fail if manifest list is not received")
-	_ = manifestHeadCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	_ = manifestHeadCmd.RegisterFlagCompletionFunc("if-none-match", completeArgNone)
+	_ = manifestHeadCmd.RegisterFlagCompletionFunc("platform", rootOpts.completeArgPlatformRef)
 	_ = manifestHeadCmd.Flags().MarkHidden("list")
 
 	manifestGetCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Deprecated: Output manifest list if available")
 	manifestGetCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	manifestGetCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Deprecated: Fail if manifest list is not received")
+	manifestGetCmd.Flags().StringVarP(&manifestOpts.convert, "convert", "", "", "Convert a docker schema1 manifest for output (schema2 or oci), the result is not pushed")
 	manifestGetCmd.Flags().StringVarP(&manifestOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
-	_ = manifestGetCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	_ = manifestGetCmd.RegisterFlagCompletionFunc("platform", rootOpts.completeArgPlatformRef)
 	_ = manifestGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	_ = manifestGetCmd.Flags().MarkHidden("list")
 
 	manifestPutCmd.Flags().BoolVarP(&manifestOpts.byDigest, "by-digest", "", false, "Push manifest by digest instead of tag")
-	manifestPutCmd.Flags().StringVarP(&manifestOpts.contentType, "content-type", "t", "", "Specify content-type (e.g. application/vnd.docker.distribution.manifest.v2+json)")
+	manifestPutCmd.Flags().StringVarP(&manifestOpts.contentType, "content-type", "t", "", "Content-Type header to send, also used to parse a manifest without a mediaType field (e.g. application/vnd.docker.distribution.manifest.v2+json)")
 	_ = manifestPutCmd.RegisterFlagCompletionFunc("content-type", completeArgMediaTypeManifest)
 	manifestPutCmd.Flags().StringVarP(&manifestOpts.formatPut, "format", "", "", "Format output with go template syntax")
 
@@ -189,6 +267,21 @@ func (manifestOpts *manifestCmd) runManifestDelete(cmd *cobra.Command, args []st
 	rc := manifestOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
+	if manifestOpts.keepTags && r.Tag != "" {
+		// remove the single tag rather than the manifest and every tag pointing to it
+		rTag := r.SetTag(r.Tag)
+		if manifestOpts.listTags {
+			fmt.Fprintln(cmd.OutOrStdout(), r.Tag)
+		}
+		if manifestOpts.dryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), rTag.CommonName())
+			return nil
+		}
+		manifestOpts.rootOpts.log.Info("Deleting tag, leaving the manifest and other tags",
+			slog.String("tag", r.Tag))
+		return rc.TagDelete(ctx, rTag)
+	}
+
 	if r.Digest == "" && manifestOpts.forceTagDeref {
 		m, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
 		if err != nil {
@@ -200,6 +293,83 @@ func (manifestOpts *manifestCmd) runManifestDelete(cmd *cobra.Command, args []st
 			slog.String("digest", r.Digest))
 	}
 
+	if (manifestOpts.keepTags || manifestOpts.listTags || manifestOpts.dryRun) && r.Digest != "" {
+		tags, err := manifestDeleteTags(ctx, rc, r)
+		if err != nil {
+			return err
+		}
+		// with --keep-tags, the tags blocking the delete are always output
+		if manifestOpts.listTags || (manifestOpts.keepTags && len(tags) > 0) {
+			for _, t := range tags {
+				fmt.Fprintln(cmd.OutOrStdout(), t)
+			}
+		}
+		if len(tags) > 0 {
+			if manifestOpts.keepTags && manifestOpts.dryRun {
+				manifestOpts.rootOpts.log.Warn("Manifest is referenced by tags and would not be deleted with --keep-tags",
+					slog.String("digest", r.Digest),
+					slog.Any("tags", tags))
+				return nil
+			}
+			if manifestOpts.keepTags {
+				return fmt.Errorf("manifest %s is referenced by tags %s, delete the tags first or remove --keep-tags", r.Digest, strings.Join(tags, ", "))
+			}
+			manifestOpts.rootOpts.log.Warn("Tags will be removed with the manifest",
+				slog.String("digest", r.Digest),
+				slog.Any("tags", tags))
+		}
+	}
+
+	// discover referrers before the subject is deleted
+	referrers := []ref.Ref{}
+	if r.Digest != "" {
+		referrers, err = manifestDeleteReferrers(ctx, rc, r, map[string]bool{})
+		if err != nil {
+			if manifestOpts.deleteRefs || manifestOpts.dryRun {
+				return err
+			}
+			manifestOpts.rootOpts.log.Debug("Failed to list referrers",
+				slog.String("digest", r.Digest),
+				slog.String("err", err.Error()))
+		} else if len(referrers) > 0 && !manifestOpts.deleteRefs {
+			manifestOpts.rootOpts.log.Warn("Referrers will be orphaned by deleting the manifest, see --delete-referrers",
+				slog.String("digest", r.Digest),
+				slog.Int("count", len(referrers)))
+		}
+	}
+	if manifestOpts.dryRun {
+		if r.Digest != "" {
+			digestTags, err := manifestDeleteDigestTags(ctx, rc, r)
+			if err != nil {
+				return err
+			}
+			if len(digestTags) > 0 {
+				manifestOpts.rootOpts.log.Warn("Digest tags reference the manifest",
+					slog.String("digest", r.Digest),
+					slog.Any("tags", digestTags))
+			}
+		}
+		if manifestOpts.deleteRefs {
+			for _, rr := range referrers {
+				fmt.Fprintln(cmd.OutOrStdout(), rr.CommonName())
+			}
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), r.CommonName())
+		return nil
+	}
+
+	if manifestOpts.deleteRefs {
+		for _, rr := range referrers {
+			manifestOpts.rootOpts.log.Info("Deleting referrer",
+				slog.String("subject", r.Digest),
+				slog.String("referrer", rr.Digest))
+			err = rc.ManifestDelete(ctx, rr, regclient.WithManifestCheckReferrers())
+			if err != nil {
+				return fmt.Errorf("failed to delete referrer %s: %w", rr.CommonName(), err)
+			}
+		}
+	}
+
 	manifestOpts.rootOpts.log.Debug("Manifest delete",
 		slog.String("host", r.Registry),
 		slog.String("repo", r.Repository),
@@ -216,6 +386,72 @@ func (manifestOpts *manifestCmd) runManifestDelete(cmd *cobra.Command, args []st
 	return nil
 }
 
+// manifestDeleteReferrers returns the referrers to a digest, recursively, with the deepest referrers first.
+func manifestDeleteReferrers(ctx context.Context, rc *regclient.RegClient, r ref.Ref, seen map[string]bool) ([]ref.Ref, error) {
+	rl, err := rc.ReferrerList(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers for %s: %w", r.CommonName(), err)
+	}
+	referrers := []ref.Ref{}
+	for _, d := range rl.Descriptors {
+		if seen[d.Digest.String()] {
+			continue
+		}
+		seen[d.Digest.String()] = true
+		rr := r.SetDigest(d.Digest.String())
+		children, err := manifestDeleteReferrers(ctx, rc, rr, seen)
+		if err != nil {
+			return nil, err
+		}
+		referrers = append(referrers, children...)
+		referrers = append(referrers, rr)
+	}
+	return referrers, nil
+}
+
+// manifestDeleteDigestTags returns tags named after the digest, e.g. the referrers fallback tag and signature tags.
+func manifestDeleteDigestTags(ctx context.Context, rc *regclient.RegClient, r ref.Ref) ([]string, error) {
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tagList, err := tl.GetTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	prefix := strings.Replace(r.Digest, ":", "-", 1)
+	tags := []string{}
+	for _, t := range tagList {
+		if t == prefix || strings.HasPrefix(t, prefix+".") {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// manifestDeleteTags returns the tags in the repository that point to the digest of the reference.
+func manifestDeleteTags(ctx context.Context, rc *regclient.RegClient, r ref.Ref) ([]string, error) {
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tagList, err := tl.GetTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags := []string{}
+	for _, t := range tagList {
+		mh, err := rc.ManifestHead(ctx, r.SetTag(t), regclient.WithManifestRequireDigest())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check tag %s: %w", t, err)
+		}
+		if mh.GetDescriptor().Digest.String() == r.Digest {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
 func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []string) error {
 	diffOpts := []diff.Opt{}
 	if manifestOpts.diffCtx > 0 {
@@ -244,30 +480,328 @@ func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []stri
 		slog.String("ref1", r1.CommonName()),
 		slog.String("ref2", r2.CommonName()))
 
-	m1, err := rc.ManifestGet(ctx, r1)
+	mOpts := []regclient.ManifestOpts{}
+	if manifestOpts.platform != "" {
+		p, err := platform.Parse(manifestOpts.platform)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform %s: %w", manifestOpts.platform, err)
+		}
+		mOpts = append(mOpts, regclient.WithManifestPlatform(p))
+	}
+	m1, err := rc.ManifestGet(ctx, r1, mOpts...)
 	if err != nil {
 		return err
 	}
-	m2, err := rc.ManifestGet(ctx, r2)
+	m2, err := rc.ManifestGet(ctx, r2, mOpts...)
 	if err != nil {
 		return err
 	}
 
-	m1Json, err := json.MarshalIndent(m1, "", "  ")
+	if manifestOpts.diffConfig && !manifestOpts.diffRecurse && (m1.IsList() || m2.IsList()) {
+		return fmt.Errorf("--config cannot be used with an index without --recurse or --platform%.0w", errs.ErrUnsupported)
+	}
+
+	result, err := manifestOpts.manifestDiff(ctx, rc, r1, r2, m1, m2, diffOpts)
 	if err != nil {
 		return err
 	}
-	m2Json, err := json.MarshalIndent(m2, "", "  ")
+
+	if manifestOpts.formatDiff != "" {
+		return template.Writer(cmd.OutOrStdout(), manifestOpts.formatDiff, result)
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(result.Diff, "\n"))
 	if err != nil {
 		return err
 	}
+	if len(result.ConfigDiff) > 0 {
+		_, err = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", strings.Join(result.ConfigDiff, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	summary := result.summary()
+	if len(summary) > 0 {
+		_, err = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", strings.Join(summary, "\n"))
+	}
+	return err
+}
+
+// manifestDiff compares two manifests, including the config and changed platforms when requested.
+func (manifestOpts *manifestCmd) manifestDiff(ctx context.Context, rc *regclient.RegClient, r1, r2 ref.Ref, m1, m2 manifest.Manifest, diffOpts []diff.Opt) (manifestDiffResult, error) {
+	m1Json, err := json.MarshalIndent(m1, "", "  ")
+	if err != nil {
+		return manifestDiffResult{}, err
+	}
+	m2Json, err := json.MarshalIndent(m2, "", "  ")
+	if err != nil {
+		return manifestDiffResult{}, err
+	}
+	result, err := manifestDiffCompare(m1, m2)
+	if err != nil {
+		return result, err
+	}
+	result.Diff = diff.Diff(strings.Split(string(m1Json), "\n"), strings.Split(string(m2Json), "\n"), diffOpts...)
+	if mt1, mt2 := m1.GetDescriptor().MediaType, m2.GetDescriptor().MediaType; mt1 != mt2 {
+		result.MediaType = []string{mt1, mt2}
+	}
+	mi1, ok1 := m1.(manifest.Imager)
+	mi2, ok2 := m2.(manifest.Imager)
+	if manifestOpts.diffConfig && ok1 && ok2 {
+		c1Json, err := manifestDiffConfig(ctx, rc, r1, mi1)
+		if err != nil {
+			return result, err
+		}
+		c2Json, err := manifestDiffConfig(ctx, rc, r2, mi2)
+		if err != nil {
+			return result, err
+		}
+		if !bytes.Equal(c1Json, c2Json) {
+			result.ConfigDiff = diff.Diff(strings.Split(string(c1Json), "\n"), strings.Split(string(c2Json), "\n"), diffOpts...)
+		}
+	}
+	if manifestOpts.diffRecurse && m1.IsList() && m2.IsList() {
+		for i, d := range result.Changed {
+			if d.Platform == nil {
+				continue
+			}
+			rp1 := r1.SetDigest(result.changedFrom[i].Digest.String())
+			rp2 := r2.SetDigest(d.Digest.String())
+			mp1, err := rc.ManifestGet(ctx, rp1, regclient.WithManifestDesc(result.changedFrom[i]))
+			if err != nil {
+				return result, err
+			}
+			mp2, err := rc.ManifestGet(ctx, rp2, regclient.WithManifestDesc(d))
+			if err != nil {
+				return result, err
+			}
+			pResult, err := manifestOpts.manifestDiff(ctx, rc, rp1, rp2, mp1, mp2, diffOpts)
+			if err != nil {
+				return result, err
+			}
+			result.Platforms = append(result.Platforms, manifestDiffPlatform{Platform: d.Platform.String(), manifestDiffResult: pResult})
+		}
+	}
+	return result, nil
+}
 
-	mDiff := diff.Diff(strings.Split(string(m1Json), "\n"), strings.Split(string(m2Json), "\n"), diffOpts...)
+// manifestDiffConfig returns the formatted config of an image.
+func manifestDiffConfig(ctx context.Context, rc *regclient.RegClient, r ref.Ref, mi manifest.Imager) ([]byte, error) {
+	cd, err := mi.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, r, cd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config %s: %w", cd.Digest.String(), err)
+	}
+	return json.MarshalIndent(conf.GetConfig(), "", "  ")
+}
 
-	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(mDiff, "\n"))
-	return err
-	// TODO: support templating
-	// return template.Writer(cmd.OutOrStdout(), manifestOpts.format, mDiff)
+// manifestDiffResult is the output of the manifest diff command.
+type manifestDiffResult struct {
+	Diff        []string                 // unified diff of the manifest json
+	ConfigDiff  []string                 // unified diff of the image config json, with --config
+	MediaType   []string                 // media type of the first and second manifest when they differ
+	Added       []descriptor.Descriptor  // layers or platforms only found in the second manifest
+	Removed     []descriptor.Descriptor  // layers or platforms only found in the first manifest
+	Changed     []descriptor.Descriptor  // config or platforms with a different digest, from the second manifest
+	Annotations []manifestDiffAnnotation // annotations that were added, removed, or changed
+	Platforms   []manifestDiffPlatform   // comparison of each changed platform in an index, with --recurse
+	changedFrom []descriptor.Descriptor
+}
+
+type manifestDiffPlatform struct {
+	Platform string
+	manifestDiffResult
+}
+
+type manifestDiffAnnotation struct {
+	Key string
+	Old string // empty when the annotation was added
+	New string // empty when the annotation was removed
+}
+
+// manifestDiffCompare compares the descriptors and annotations of two manifests.
+// Image layers are matched by digest, and index entries are matched by platform.
+func manifestDiffCompare(m1, m2 manifest.Manifest) (manifestDiffResult, error) {
+	result := manifestDiffResult{
+		Added:       []descriptor.Descriptor{},
+		Removed:     []descriptor.Descriptor{},
+		Changed:     []descriptor.Descriptor{},
+		Annotations: []manifestDiffAnnotation{},
+	}
+	mi1, ok1 := m1.(manifest.Imager)
+	mi2, ok2 := m2.(manifest.Imager)
+	if ok1 && ok2 {
+		c1, err := mi1.GetConfig()
+		if err != nil {
+			return result, err
+		}
+		c2, err := mi2.GetConfig()
+		if err != nil {
+			return result, err
+		}
+		if c1.Digest != c2.Digest {
+			result.Changed = append(result.Changed, c2)
+			result.changedFrom = append(result.changedFrom, c1)
+		}
+		l1, err := mi1.GetLayers()
+		if err != nil {
+			return result, err
+		}
+		l2, err := mi2.GetLayers()
+		if err != nil {
+			return result, err
+		}
+		result.Added = manifestDiffMissing(l2, l1, manifestDiffDigestKey)
+		result.Removed = manifestDiffMissing(l1, l2, manifestDiffDigestKey)
+	}
+	ml1, ok1 := m1.(manifest.Indexer)
+	ml2, ok2 := m2.(manifest.Indexer)
+	if ok1 && ok2 {
+		d1, err := ml1.GetManifestList()
+		if err != nil {
+			return result, err
+		}
+		d2, err := ml2.GetManifestList()
+		if err != nil {
+			return result, err
+		}
+		result.Added = manifestDiffMissing(d2, d1, manifestDiffPlatformKey)
+		result.Removed = manifestDiffMissing(d1, d2, manifestDiffPlatformKey)
+		for _, dNew := range d2 {
+			for _, dOld := range d1 {
+				if manifestDiffPlatformKey(dNew) == manifestDiffPlatformKey(dOld) && dNew.Digest != dOld.Digest {
+					result.Changed = append(result.Changed, dNew)
+					result.changedFrom = append(result.changedFrom, dOld)
+					break
+				}
+			}
+		}
+	}
+	a1 := map[string]string{}
+	a2 := map[string]string{}
+	if ma, ok := m1.(manifest.Annotator); ok {
+		a, err := ma.GetAnnotations()
+		if err == nil && a != nil {
+			a1 = a
+		}
+	}
+	if ma, ok := m2.(manifest.Annotator); ok {
+		a, err := ma.GetAnnotations()
+		if err == nil && a != nil {
+			a2 = a
+		}
+	}
+	keys := []string{}
+	for k := range a1 {
+		keys = append(keys, k)
+	}
+	for k := range a2 {
+		if _, ok := a1[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if a1[k] != a2[k] {
+			result.Annotations = append(result.Annotations, manifestDiffAnnotation{Key: k, Old: a1[k], New: a2[k]})
+		}
+	}
+	return result, nil
+}
+
+// manifestDiffMissing returns the entries in list a that are not found in list b.
+// Repeated entries are matched individually.
+func manifestDiffMissing(a, b []descriptor.Descriptor, key func(descriptor.Descriptor) string) []descriptor.Descriptor {
+	counts := map[string]int{}
+	for _, d := range b {
+		counts[key(d)]++
+	}
+	missing := []descriptor.Descriptor{}
+	for _, d := range a {
+		k := key(d)
+		if counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		missing = append(missing, d)
+	}
+	return missing
+}
+
+func manifestDiffDigestKey(d descriptor.Descriptor) string {
+	return d.Digest.String()
+}
+
+// dockerReferenceDigest is the annotation on docker attestations with the digest of the referenced image.
+const dockerReferenceDigest = "vnd.docker.reference.digest"
+
+// manifestDiffPlatformKey matches index entries by the full platform, falling back to the digest for entries without a platform.
+// Attestations are matched by the digest of the image they reference.
+func manifestDiffPlatformKey(d descriptor.Descriptor) string {
+	if ref, ok := d.Annotations[dockerReferenceDigest]; ok && ref != "" {
+		return "attestation:" + ref
+	}
+	if d.Platform == nil {
+		return d.Digest.String()
+	}
+	key := d.Platform.String()
+	if d.Platform.OSVersion != "" {
+		key += ",osver=" + d.Platform.OSVersion
+	}
+	if len(d.Platform.OSFeatures) > 0 {
+		key += ",osfeatures=" + strings.Join(d.Platform.OSFeatures, ",")
+	}
+	if len(d.Platform.Features) > 0 {
+		key += ",features=" + strings.Join(d.Platform.Features, ",")
+	}
+	return key
+}
+
+// summary returns a line for each change, used for the default output.
+func (result manifestDiffResult) summary() []string {
+	lines := []string{}
+	if len(result.MediaType) == 2 {
+		lines = append(lines, fmt.Sprintf("Changed media type: %s -> %s", result.MediaType[0], result.MediaType[1]))
+	}
+	for i, d := range result.Changed {
+		name := "config"
+		if d.Platform != nil {
+			name = "platform " + d.Platform.String()
+		}
+		lines = append(lines, fmt.Sprintf("Changed %s: %s -> %s", name, result.changedFrom[i].Digest.String(), d.Digest.String()))
+	}
+	for _, d := range result.Removed {
+		lines = append(lines, "Removed "+manifestDiffName(d))
+	}
+	for _, d := range result.Added {
+		lines = append(lines, "Added "+manifestDiffName(d))
+	}
+	for _, a := range result.Annotations {
+		lines = append(lines, fmt.Sprintf("Changed annotation %s: %q -> %q", a.Key, a.Old, a.New))
+	}
+	for _, p := range result.Platforms {
+		lines = append(lines, fmt.Sprintf("Platform %s:", p.Platform))
+		for _, line := range p.ConfigDiff {
+			lines = append(lines, "  "+line)
+		}
+		for _, line := range p.summary() {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines
+}
+
+func manifestDiffName(d descriptor.Descriptor) string {
+	if d.Platform != nil {
+		return fmt.Sprintf("platform %s: %s", d.Platform.String(), d.Digest.String())
+	}
+	switch mediatype.Base(d.MediaType) {
+	case mediatype.OCI1Manifest, mediatype.OCI1ManifestList, mediatype.Docker2Manifest, mediatype.Docker2ManifestList:
+		return fmt.Sprintf("manifest: %s", d.Digest.String())
+	}
+	return fmt.Sprintf("layer: %s", d.Digest.String())
 }
 
 func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []string) error {
@@ -283,6 +817,9 @@ func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []stri
 	if err != nil {
 		return err
 	}
+	if !manifestOpts.requireList {
+		manifestOpts.platform = manifestOpts.rootOpts.platformDefault(r, manifestOpts.platform)
+	}
 	rc := manifestOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
@@ -302,9 +839,15 @@ func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []stri
 		}
 		mOpts = append(mOpts, regclient.WithManifestPlatform(p))
 	}
+	if manifestOpts.ifNoneMatch != "" {
+		mOpts = append(mOpts, regclient.WithManifestIfNoneMatch(manifestOpts.ifNoneMatch))
+	}
 
 	m, err := rc.ManifestHead(ctx, r, mOpts...)
 	if err != nil {
+		if manifestOpts.ifNoneMatch != "" && errors.Is(err, errs.ErrNotModified) {
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), "unchanged")
+		}
 		return err
 	}
 
@@ -325,11 +868,17 @@ func (manifestOpts *manifestCmd) runManifestGet(cmd *cobra.Command, args []strin
 	if manifestOpts.platform != "" && manifestOpts.requireList {
 		return fmt.Errorf("cannot request a platform and require-list simultaneously")
 	}
+	if manifestOpts.convert != "" && manifestOpts.convert != "schema2" && manifestOpts.convert != "oci" {
+		return fmt.Errorf("unsupported convert type %s, expected schema2 or oci%.0w", manifestOpts.convert, errs.ErrUnsupported)
+	}
 
 	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
+	if !manifestOpts.requireList {
+		manifestOpts.platform = manifestOpts.rootOpts.platformDefault(r, manifestOpts.platform)
+	}
 	rc := manifestOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
@@ -351,6 +900,14 @@ func (manifestOpts *manifestCmd) runManifestGet(cmd *cobra.Command, args []strin
 	if err != nil {
 		return err
 	}
+	if manifestOpts.convert != "" {
+		m, _, err = manifestConvertSchema1(m, manifestOpts.convert, func(d digest.Digest) (int64, digest.Digest, error) {
+			return manifestLayerInfo(ctx, rc, r, d)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	switch manifestOpts.formatGet {
 	case "raw":
@@ -360,7 +917,142 @@ func (manifestOpts *manifestCmd) runManifestGet(cmd *cobra.Command, args []strin
 	case "rawHeaders", "raw-headers", "headers":
 		manifestOpts.formatGet = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), manifestOpts.formatGet, m)
+	return manifestOpts.rootOpts.resultOutput(cmd, "", manifestOpts.formatGet, m)
+}
+
+// schema1EmptyLayer is the gzip compressed empty tar commonly used by schema1 manifests.
+var schema1EmptyLayer = descriptor.Descriptor{
+	Digest: digest.Digest("sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"),
+	Size:   32,
+}
+
+// schema1EmptyDiffID is the digest of the uncompressed empty tar.
+var schema1EmptyDiffID = digest.Digest("sha256:5f70bf18a086007016e948b04aed3b82103a36bea41755b6cddfaf10ace3c6ef")
+
+// schema1History contains the fields from the v1Compatibility history used in the conversion.
+type schema1History struct {
+	Created         *time.Time `json:"created,omitempty"`
+	Author          string     `json:"author,omitempty"`
+	Comment         string     `json:"comment,omitempty"`
+	ThrowAway       bool       `json:"throwaway,omitempty"`
+	ContainerConfig struct {
+		Cmd []string `json:"Cmd,omitempty"`
+	} `json:"container_config,omitempty"`
+}
+
+// manifestConvertSchema1 reconstructs a schema2 or oci manifest and config from a docker schema1 manifest.
+// The layerInfo func returns the compressed size and uncompressed digest for each layer.
+func manifestConvertSchema1(m manifest.Manifest, convert string, layerInfo func(digest.Digest) (int64, digest.Digest, error)) (manifest.Manifest, v1.Image, error) {
+	var sm schema1.Manifest
+	switch orig := m.GetOrig().(type) {
+	case schema1.Manifest:
+		sm = orig
+	case schema1.SignedManifest:
+		sm = orig.Manifest
+	default:
+		return nil, v1.Image{}, fmt.Errorf("convert requires a docker schema1 manifest, received %s%.0w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+	}
+	if len(sm.History) == 0 || len(sm.History) != len(sm.FSLayers) {
+		return nil, v1.Image{}, fmt.Errorf("schema1 manifest has %d history entries and %d layers%.0w", len(sm.History), len(sm.FSLayers), errs.ErrParsingFailed)
+	}
+	// the first history entry contains the image config
+	conf := v1.Image{}
+	if err := json.Unmarshal([]byte(sm.History[0].V1Compatibility), &conf); err != nil {
+		return nil, v1.Image{}, fmt.Errorf("failed to parse schema1 config: %w", err)
+	}
+	if conf.Architecture == "" {
+		conf.Architecture = sm.Architecture
+	}
+	conf.RootFS = v1.RootFS{Type: "layers", DiffIDs: []digest.Digest{}}
+	conf.History = []v1.History{}
+	layerMT := mediatype.OCI1LayerGzip
+	if convert == "schema2" {
+		layerMT = mediatype.Docker2LayerGzip
+	}
+	layers := []descriptor.Descriptor{}
+	// schema1 lists the history and layers from newest to oldest
+	for i := len(sm.History) - 1; i >= 0; i-- {
+		h := schema1History{}
+		if err := json.Unmarshal([]byte(sm.History[i].V1Compatibility), &h); err != nil {
+			return nil, v1.Image{}, fmt.Errorf("failed to parse schema1 history %d: %w", i, err)
+		}
+		conf.History = append(conf.History, v1.History{
+			Created:    h.Created,
+			CreatedBy:  strings.Join(h.ContainerConfig.Cmd, " "),
+			Author:     h.Author,
+			Comment:    h.Comment,
+			EmptyLayer: h.ThrowAway,
+		})
+		if h.ThrowAway {
+			continue
+		}
+		size, diffID, err := layerInfo(sm.FSLayers[i].BlobSum)
+		if err != nil {
+			return nil, v1.Image{}, fmt.Errorf("failed to get layer %s: %w", sm.FSLayers[i].BlobSum.String(), err)
+		}
+		layers = append(layers, descriptor.Descriptor{
+			MediaType: layerMT,
+			Digest:    sm.FSLayers[i].BlobSum,
+			Size:      size,
+		})
+		conf.RootFS.DiffIDs = append(conf.RootFS.DiffIDs, diffID)
+	}
+	confJSON, err := json.Marshal(conf)
+	if err != nil {
+		return nil, v1.Image{}, err
+	}
+	confDesc := descriptor.Descriptor{
+		MediaType: mediatype.OCI1ImageConfig,
+		Digest:    digest.Canonical.FromBytes(confJSON),
+		Size:      int64(len(confJSON)),
+	}
+	var orig interface{}
+	if convert == "schema2" {
+		confDesc.MediaType = mediatype.Docker2ImageConfig
+		orig = schema2.Manifest{
+			Versioned: schema2.ManifestSchemaVersion,
+			Config:    confDesc,
+			Layers:    layers,
+		}
+	} else {
+		orig = v1.Manifest{
+			Versioned: v1.ManifestSchemaVersion,
+			MediaType: mediatype.OCI1Manifest,
+			Config:    confDesc,
+			Layers:    layers,
+		}
+	}
+	mc, err := manifest.New(manifest.WithOrig(orig))
+	if err != nil {
+		return nil, v1.Image{}, err
+	}
+	return mc, conf, nil
+}
+
+// manifestLayerInfo pulls a layer to get the compressed size and uncompressed digest.
+func manifestLayerInfo(ctx context.Context, rc *regclient.RegClient, r ref.Ref, d digest.Digest) (int64, digest.Digest, error) {
+	if d == schema1EmptyLayer.Digest {
+		return schema1EmptyLayer.Size, schema1EmptyDiffID, nil
+	}
+	br, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d})
+	if err != nil {
+		return 0, "", err
+	}
+	defer br.Close()
+	dr, err := archive.Decompress(br)
+	if err != nil {
+		return 0, "", err
+	}
+	diffID, err := digest.Canonical.FromReader(dr)
+	if err != nil {
+		return 0, "", err
+	}
+	// drain any trailing data to finish the digest verification of the blob
+	_, err = io.Copy(io.Discard, br)
+	if err != nil {
+		return 0, "", err
+	}
+	return br.GetDescriptor().Size, diffID, nil
 }
 
 func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []string) error {
@@ -376,25 +1068,33 @@ func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []strin
 	if err != nil {
 		return err
 	}
-	opts := []manifest.Opts{
+	rcM, err := manifest.New(
 		manifest.WithRef(r),
 		manifest.WithRaw(raw),
+	)
+	if err != nil && manifestOpts.contentType != "" {
+		// a body without a media type field is parsed using the content-type
+		rcM, err = manifest.New(
+			manifest.WithRef(r),
+			manifest.WithRaw(raw),
+			manifest.WithDesc(descriptor.Descriptor{
+				MediaType: manifestOpts.contentType,
+			}),
+		)
 	}
-	if manifestOpts.contentType != "" {
-		opts = append(opts, manifest.WithDesc(descriptor.Descriptor{
-			MediaType: manifestOpts.contentType,
-		}))
-	}
-	rcM, err := manifest.New(opts...)
 	if err != nil {
 		return err
 	}
+	putOpts := []regclient.ManifestOpts{}
+	if manifestOpts.contentType != "" {
+		putOpts = append(putOpts, regclient.WithManifestContentType(manifestOpts.contentType))
+	}
 	if manifestOpts.byDigest {
 		r.Tag = ""
 		r.Digest = rcM.GetDescriptor().Digest.String()
 	}
 
-	err = rc.ManifestPut(ctx, r, rcM)
+	err = rc.ManifestPut(ctx, r, rcM, putOpts...)
 	if err != nil {
 		return err
 	}
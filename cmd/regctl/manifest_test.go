@@ -1,12 +1,26 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
 )
 
 func TestManifestHead(t *testing.T) {
@@ -71,3 +85,608 @@ func TestManifestHead(t *testing.T) {
 	}
 
 }
+
+func TestManifestHeadIfNoneMatch(t *testing.T) {
+	tempDir := t.TempDir()
+	digOld := digest.FromString("old manifest")
+	digNew := digest.FromString("new manifest")
+	// serve a manifest that only matches the new digest
+	var notModified atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/testrepo/manifests/latest" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"`+digNew.String()+`"` {
+			notModified.Add(1)
+			w.Header().Set("ETag", `"`+digNew.String()+`"`)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", mediatype.OCI1Manifest)
+		w.Header().Set("Content-Length", "1234")
+		w.Header().Set("Docker-Content-Digest", digNew.String())
+		w.Header().Set("ETag", `"`+digNew.String()+`"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(ts.Close)
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	ocidirDig, err := cobraTest(t, nil, "manifest", "head", "ocidir://../../testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+
+	tt := []struct {
+		name      string
+		args      []string
+		expectOut string
+	}{
+		{
+			name:      "Registry unchanged",
+			args:      []string{"manifest", "head", tsHost + "/testrepo:latest", "--if-none-match", digNew.String()},
+			expectOut: "unchanged",
+		},
+		{
+			name:      "Registry changed",
+			args:      []string{"manifest", "head", tsHost + "/testrepo:latest", "--if-none-match", digOld.String()},
+			expectOut: digNew.String(),
+		},
+		{
+			name:      "OCIDir unchanged",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--if-none-match", ocidirDig},
+			expectOut: "unchanged",
+		},
+		{
+			name:      "OCIDir changed",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--if-none-match", digOld.String()},
+			expectOut: ocidirDig,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+	if notModified.Load() == 0 {
+		t.Errorf("conditional request was not sent to the registry")
+	}
+}
+
+func TestManifestPutContentType(t *testing.T) {
+	tempDir := t.TempDir()
+	// record the content-type of each manifest put
+	var received atomic.Value
+	received.Store("")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, "/v2/testrepo/manifests/") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		received.Store(r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(ts.Close)
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	body := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`
+
+	tt := []struct {
+		name       string
+		args       []string
+		stdin      string
+		expectType string
+		expectErr  bool
+	}{
+		{
+			name:       "default",
+			args:       []string{"manifest", "put", tsHost + "/testrepo:default"},
+			stdin:      body,
+			expectType: mediatype.OCI1Manifest,
+		},
+		{
+			name:       "override",
+			args:       []string{"manifest", "put", "--content-type", mediatype.Docker2Manifest, tsHost + "/testrepo:override"},
+			stdin:      body,
+			expectType: mediatype.Docker2Manifest,
+		},
+		{
+			name:      "invalid body",
+			args:      []string{"manifest", "put", "--content-type", mediatype.Docker2Manifest, tsHost + "/testrepo:invalid"},
+			stdin:     `{"schemaVersion":2,"layers":"invalid"}`,
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			received.Store("")
+			_, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(tc.stdin)}, tc.args...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("command did not fail")
+				}
+				if received.Load().(string) != "" {
+					t.Errorf("manifest was pushed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if ct := received.Load().(string); ct != tc.expectType {
+				t.Errorf("unexpected content-type, expected %s, received %s", tc.expectType, ct)
+			}
+		})
+	}
+}
+
+func TestManifestGetConvert(t *testing.T) {
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr error
+	}{
+		{
+			name:      "Invalid convert type",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--convert", "schema3"},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "Not schema1",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--convert", "oci"},
+			expectErr: errs.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr == nil && err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if tc.expectErr != nil && !errors.Is(err, tc.expectErr) {
+				t.Fatalf("unexpected error, received %v, expected %v", err, tc.expectErr)
+			}
+		})
+	}
+
+	m, err := manifest.New(manifest.WithRaw(rawDockerSchema1Signed), manifest.WithDesc(descriptor.Descriptor{MediaType: mediatype.Docker1ManifestSigned}))
+	if err != nil {
+		t.Fatalf("failed to parse schema1 manifest: %v", err)
+	}
+	layerDig := digest.Digest("sha256:069873d23334d65630bbe5e303ced0c68181b694c7f5506b54bf5d8115b5af20")
+	layerDiffID := digest.Canonical.FromString("layer")
+	layerInfo := func(d digest.Digest) (int64, digest.Digest, error) {
+		switch d {
+		case schema1EmptyLayer.Digest:
+			return schema1EmptyLayer.Size, schema1EmptyDiffID, nil
+		case layerDig:
+			return 76534288, layerDiffID, nil
+		}
+		return 0, "", errs.ErrNotFound
+	}
+	for _, convert := range []string{"schema2", "oci"} {
+		t.Run(convert, func(t *testing.T) {
+			mc, conf, err := manifestConvertSchema1(m, convert, layerInfo)
+			if err != nil {
+				t.Fatalf("failed to convert: %v", err)
+			}
+			expectMT, expectConfMT, expectLayerMT := mediatype.OCI1Manifest, mediatype.OCI1ImageConfig, mediatype.OCI1LayerGzip
+			if convert == "schema2" {
+				expectMT, expectConfMT, expectLayerMT = mediatype.Docker2Manifest, mediatype.Docker2ImageConfig, mediatype.Docker2LayerGzip
+			}
+			if mc.GetDescriptor().MediaType != expectMT {
+				t.Errorf("unexpected media type, expected %s, received %s", expectMT, mc.GetDescriptor().MediaType)
+			}
+			mi, ok := mc.(manifest.Imager)
+			if !ok {
+				t.Fatalf("converted manifest is not an image")
+			}
+			confDesc, err := mi.GetConfig()
+			if err != nil {
+				t.Fatalf("failed to get config: %v", err)
+			}
+			confJSON, err := json.Marshal(conf)
+			if err != nil {
+				t.Fatalf("failed to marshal config: %v", err)
+			}
+			if confDesc.MediaType != expectConfMT || confDesc.Digest != digest.Canonical.FromBytes(confJSON) || confDesc.Size != int64(len(confJSON)) {
+				t.Errorf("unexpected config descriptor: %v", confDesc)
+			}
+			layers, err := mi.GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			if len(layers) != 2 || layers[0].Digest != layerDig || layers[0].Size != 76534288 || layers[1].Digest != schema1EmptyLayer.Digest {
+				t.Fatalf("unexpected layers: %v", layers)
+			}
+			for _, l := range layers {
+				if l.MediaType != expectLayerMT {
+					t.Errorf("unexpected layer media type: %s", l.MediaType)
+				}
+			}
+			// verify values reconstructed from the v1Compatibility history
+			if conf.Architecture != "amd64" || conf.OS != "linux" {
+				t.Errorf("unexpected platform: %s", conf.Platform.String())
+			}
+			if conf.Created == nil || !conf.Created.Equal(time.Date(2016, 2, 16, 21, 25, 24, 35599122, time.UTC)) {
+				t.Errorf("unexpected created time: %v", conf.Created)
+			}
+			if len(conf.Config.Cmd) != 1 || conf.Config.Cmd[0] != "/bin/bash" {
+				t.Errorf("unexpected cmd: %v", conf.Config.Cmd)
+			}
+			if conf.RootFS.Type != "layers" || len(conf.RootFS.DiffIDs) != 2 || conf.RootFS.DiffIDs[0] != layerDiffID || conf.RootFS.DiffIDs[1] != schema1EmptyDiffID {
+				t.Errorf("unexpected rootfs: %v", conf.RootFS)
+			}
+			if len(conf.History) != 2 ||
+				conf.History[0].CreatedBy != "/bin/sh -c #(nop) ADD file:09d717d62608e18d79af6b6cd5aae36f675bd5c4f34452ab1693b56bfbfe2520 in /" ||
+				conf.History[1].CreatedBy != `/bin/sh -c #(nop) CMD ["/bin/bash"]` {
+				t.Errorf("unexpected history: %v", conf.History)
+			}
+		})
+	}
+}
+
+// signed schemas are white space sensitive, contents here must be indented with 3 spaces, no tabs
+var rawDockerSchema1Signed = []byte(`
+{
+   "schemaVersion": 1,
+   "name": "library/debian",
+   "tag": "6",
+   "architecture": "amd64",
+   "fsLayers": [
+      {
+         "blobSum": "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+      },
+      {
+         "blobSum": "sha256:069873d23334d65630bbe5e303ced0c68181b694c7f5506b54bf5d8115b5af20"
+      }
+   ],
+   "history": [
+      {
+         "v1Compatibility": "{\"id\":\"ff11dd0897b8ded12196819a787b5bd6d5bf886d9a7836c21b070efb5d9e77e4\",\"parent\":\"4e507d091336a8ec91e1b0fd0e33f11625d8bf3494765d3dbec37ec17387cbf5\",\"created\":\"2016-02-16T21:25:24.035599122Z\",\"container\":\"0fd99658f7a77c1170f8ff325c14437eaced7bab6b3152264cb1946d8d018e2e\",\"container_config\":{\"Hostname\":\"71f62d8ce24c\",\"Domainname\":\"\",\"User\":\"\",\"AttachStdin\":false,\"AttachStdout\":false,\"AttachStderr\":false,\"Tty\":false,\"OpenStdin\":false,\"StdinOnce\":false,\"Env\":null,\"Cmd\":[\"/bin/sh\",\"-c\",\"#(nop) CMD [\\\"/bin/bash\\\"]\"],\"Image\":\"4e507d091336a8ec91e1b0fd0e33f11625d8bf3494765d3dbec37ec17387cbf5\",\"Volumes\":null,\"WorkingDir\":\"\",\"Entrypoint\":null,\"OnBuild\":null,\"Labels\":{}},\"docker_version\":\"1.9.1\",\"config\":{\"Hostname\":\"71f62d8ce24c\",\"Domainname\":\"\",\"User\":\"\",\"AttachStdin\":false,\"AttachStdout\":false,\"AttachStderr\":false,\"Tty\":false,\"OpenStdin\":false,\"StdinOnce\":false,\"Env\":null,\"Cmd\":[\"/bin/bash\"],\"Image\":\"4e507d091336a8ec91e1b0fd0e33f11625d8bf3494765d3dbec37ec17387cbf5\",\"Volumes\":null,\"WorkingDir\":\"\",\"Entrypoint\":null,\"OnBuild\":null,\"Labels\":{}},\"architecture\":\"amd64\",\"os\":\"linux\"}"
+      },
+      {
+         "v1Compatibility": "{\"id\":\"4e507d091336a8ec91e1b0fd0e33f11625d8bf3494765d3dbec37ec17387cbf5\",\"created\":\"2016-02-16T21:25:21.747984969Z\",\"container\":\"71f62d8ce24cd81b2835a2a4457e9e745f775a225cb2e75a5e76fc8b5f44874c\",\"container_config\":{\"Hostname\":\"71f62d8ce24c\",\"Domainname\":\"\",\"User\":\"\",\"AttachStdin\":false,\"AttachStdout\":false,\"AttachStderr\":false,\"Tty\":false,\"OpenStdin\":false,\"StdinOnce\":false,\"Env\":null,\"Cmd\":[\"/bin/sh\",\"-c\",\"#(nop) ADD file:09d717d62608e18d79af6b6cd5aae36f675bd5c4f34452ab1693b56bfbfe2520 in /\"],\"Image\":\"\",\"Volumes\":null,\"WorkingDir\":\"\",\"Entrypoint\":null,\"OnBuild\":null,\"Labels\":null},\"docker_version\":\"1.9.1\",\"config\":{\"Hostname\":\"71f62d8ce24c\",\"Domainname\":\"\",\"User\":\"\",\"AttachStdin\":false,\"AttachStdout\":false,\"AttachStderr\":false,\"Tty\":false,\"OpenStdin\":false,\"StdinOnce\":false,\"Env\":null,\"Cmd\":null,\"Image\":\"\",\"Volumes\":null,\"WorkingDir\":\"\",\"Entrypoint\":null,\"OnBuild\":null,\"Labels\":null},\"architecture\":\"amd64\",\"os\":\"linux\",\"Size\":76534288}"
+      }
+   ],
+   "signatures": [
+      {
+         "header": {
+            "jwk": {
+               "crv": "P-256",
+               "kid": "FD6K:7VOX:ZVOM:34T7:2ZT5:753N:ZM4C:RJIF:WPOO:NPC2:7VPJ:3TVM",
+               "kty": "EC",
+               "x": "kHg6ZEbadXH4gC5ggkduHEAeJP40vdudo7tekiigA00",
+               "y": "K5r269kJQV1ERenXMuEQbY7_hrbxy1JnTnSOBR0bvTg"
+            },
+            "alg": "ES256"
+         },
+         "signature": "mtuG3ORjrX8o7lqyx78tX_JIX-JuiBAWX2sEvf60t4zXzLB61gNecwasp56Mn3LT7fxmJzC3-IcHW-UryDm6uw",
+         "protected": "eyJmb3JtYXRMZW5ndGgiOjI3NDYsImZvcm1hdFRhaWwiOiJDbjAiLCJ0aW1lIjoiMjAyMS0xMi0xM1QxMzo0OTozNFoifQ"
+      }
+   ]
+} 
+`)
+
+func TestManifestDeleteTags(t *testing.T) {
+	repo := "ocidir://" + t.TempDir() + "/repo"
+	for _, args := range [][]string{
+		{"image", "create", "--created", "2020-01-01T00:00:00Z", repo + ":a"},
+		{"image", "copy", repo + ":a", repo + ":b"},
+		{"image", "create", "--created", "2021-01-01T00:00:00Z", repo + ":c"},
+	} {
+		_, err := cobraTest(t, nil, args...)
+		if err != nil {
+			t.Fatalf("failed to setup repo with %v: %v", args, err)
+		}
+	}
+	dig, err := cobraTest(t, nil, "manifest", "head", repo+":a")
+	if err != nil {
+		t.Fatalf("failed to head: %v", err)
+	}
+
+	t.Run("keep-tags digest", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "manifest", "delete", "--keep-tags", repo+"@"+dig)
+		if err == nil || !strings.Contains(err.Error(), "a, b") {
+			t.Errorf("delete did not fail with the tags listed: %v", err)
+		}
+		_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+dig)
+		if err != nil {
+			t.Errorf("manifest was deleted: %v", err)
+		}
+	})
+	t.Run("keep-tags digest output", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "delete", "--keep-tags", repo+"@"+dig)
+		if err == nil {
+			t.Errorf("delete did not fail")
+		}
+		if out != "a\nb" {
+			t.Errorf("unexpected output, expected a and b, received %s", out)
+		}
+	})
+	t.Run("keep-tags digest dry-run", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "delete", "--keep-tags", "--dry-run", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("dry-run failed: %v", err)
+		}
+		if !strings.HasPrefix(out, "a\nb") || strings.Contains(out, repo+"@"+dig) {
+			t.Errorf("unexpected output, expected the tags a and b without the manifest, received %s", out)
+		}
+		_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+dig)
+		if err != nil {
+			t.Errorf("manifest was deleted: %v", err)
+		}
+	})
+	t.Run("keep-tags tag dry-run", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "delete", "--keep-tags", "--dry-run", repo+":a")
+		if err != nil {
+			t.Fatalf("failed to run dry-run: %v", err)
+		}
+		if out != repo+":a" {
+			t.Errorf("unexpected output, expected %s, received %s", repo+":a", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "a\nb\nc" {
+			t.Errorf("dry-run modified the tags, expected a, b, and c, received %s", out)
+		}
+	})
+	t.Run("keep-tags tag", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "manifest", "delete", "--keep-tags", repo+":a")
+		if err != nil {
+			t.Fatalf("failed to delete tag: %v", err)
+		}
+		out, err := cobraTest(t, nil, "tag", "ls", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b\nc" {
+			t.Errorf("unexpected tags, expected b and c, received %s", out)
+		}
+	})
+	t.Run("list-tags", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "delete", "--list-tags", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+		if !strings.HasPrefix(out, "b") {
+			t.Errorf("affected tags not listed, received %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "c" {
+			t.Errorf("unexpected tags, expected c, received %s", out)
+		}
+	})
+}
+
+func TestManifestDeleteReferrers(t *testing.T) {
+	repo := "ocidir://" + t.TempDir() + "/repo"
+	setup := func(t *testing.T) (string, string, string) {
+		t.Helper()
+		_, err := cobraTest(t, nil, "image", "create", "--created", "2020-01-01T00:00:00Z", repo+":a")
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+		dig, err := cobraTest(t, nil, "manifest", "head", repo+":a")
+		if err != nil {
+			t.Fatalf("failed to head: %v", err)
+		}
+		_, err = cobraTest(t, nil, "artifact", "put", "--artifact-type", "application/example.sbom", "--subject", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("failed to put sbom: %v", err)
+		}
+		sbomDig, err := cobraTest(t, nil, "artifact", "list", repo+"@"+dig, "--format", "{{range .Descriptors}}{{println .Digest}}{{end}}")
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		_, err = cobraTest(t, nil, "artifact", "put", "--artifact-type", "application/example.sig", "--subject", repo+"@"+sbomDig)
+		if err != nil {
+			t.Fatalf("failed to put signature: %v", err)
+		}
+		sigDig, err := cobraTest(t, nil, "artifact", "list", repo+"@"+sbomDig, "--format", "{{range .Descriptors}}{{println .Digest}}{{end}}")
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		return dig, sbomDig, sigDig
+	}
+
+	t.Run("dry-run", func(t *testing.T) {
+		dig, sbomDig, sigDig := setup(t)
+		out, err := cobraTest(t, nil, "manifest", "delete", "--dry-run", "--delete-referrers", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("failed to run dry-run: %v", err)
+		}
+		expect := strings.Join([]string{repo + "@" + sigDig, repo + "@" + sbomDig, repo + "@" + dig}, "\n")
+		if !strings.HasSuffix(out, expect) {
+			t.Errorf("unexpected output, expected %s, received %s", expect, out)
+		}
+		for _, d := range []string{dig, sbomDig, sigDig} {
+			_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+d)
+			if err != nil {
+				t.Errorf("manifest %s was deleted: %v", d, err)
+			}
+		}
+	})
+	t.Run("without cascade", func(t *testing.T) {
+		dig, sbomDig, sigDig := setup(t)
+		out, err := cobraTest(t, nil, "manifest", "delete", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+		if !strings.Contains(out, "Referrers will be orphaned") {
+			t.Errorf("orphaned referrers warning missing: %s", out)
+		}
+		_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+dig)
+		if err == nil {
+			t.Errorf("manifest was not deleted")
+		}
+		for _, d := range []string{sbomDig, sigDig} {
+			_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+d)
+			if err != nil {
+				t.Errorf("referrer %s was deleted: %v", d, err)
+			}
+		}
+	})
+	t.Run("with cascade", func(t *testing.T) {
+		dig, sbomDig, sigDig := setup(t)
+		_, err := cobraTest(t, nil, "manifest", "delete", "--delete-referrers", repo+"@"+dig)
+		if err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+		for _, d := range []string{dig, sbomDig, sigDig} {
+			_, err = cobraTest(t, nil, "manifest", "head", repo+"@"+d)
+			if err == nil {
+				t.Errorf("manifest %s was not deleted", d)
+			}
+		}
+	})
+}
+
+func TestManifestDiff(t *testing.T) {
+	testrepo := "ocidir://../../testdata/testrepo"
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains []string
+	}{
+		{
+			name:      "same",
+			args:      []string{"manifest", "diff", "--format", "{{ len .Added }} {{ len .Removed }} {{ len .Changed }} {{ len .Annotations }}", testrepo + ":v1", testrepo + ":v1"},
+			expectOut: "0 0 0 0",
+		},
+		{
+			name: "index",
+			args: []string{"manifest", "diff", testrepo + ":v1", testrepo + ":v3"},
+			outContains: []string{
+				"Changed platform linux/amd64: sha256:1effc9d48232693f4584ceb9c5e8d84ddeb5924ea4aff341aa8204510422f668 -> sha256:f8c9d547514d66b562f791c361e4e9795340a7626aff22980138718689ef2a44",
+				"Added platform linux/arm/v7: sha256:f4682754068e9235e63d24d8e5a2b9faca41bbfff1e74b131293b9d86cb0bc2b",
+				`Changed annotation org.example.version: "v1" -> "v3"`,
+			},
+		},
+		{
+			name:      "index format",
+			args:      []string{"manifest", "diff", "--format", "{{ range .Added }}{{ println .Platform }}{{ end }}", testrepo + ":v1", testrepo + ":v3"},
+			expectOut: "linux/arm/v7\nlinux/arm/v6",
+		},
+		{
+			name: "platform",
+			args: []string{"manifest", "diff", "--platform", "linux/amd64", testrepo + ":v1", testrepo + ":v3"},
+			outContains: []string{
+				"Changed config: sha256:03d7b3c657a4af5b4ff7967bf843d04a93008f28d658a7df3f679b2c7e519639 -> sha256:2097cbe98aab004aa60148c1b49515a86cd1ff514310dcf8654313259aad0b12",
+				"Added layer: sha256:01399f08c7986d71d9b739a0899cb5b76eb2aa711d07dfe66b8f143b8a34b2f3",
+			},
+		},
+		{
+			name:      "platform format",
+			args:      []string{"manifest", "diff", "--platform", "linux/amd64", "--format", "{{ len .Added }} {{ len .Removed }} {{ range .Changed }}{{ .MediaType }}{{ end }}", testrepo + ":v1", testrepo + ":v3"},
+			expectOut: "3 0 " + mediatype.OCI1ImageConfig,
+		},
+		{
+			name: "platform config",
+			args: []string{"manifest", "diff", "--platform", "linux/amd64", "--config", testrepo + ":v1", testrepo + ":v3"},
+			outContains: []string{
+				`+       "created_by": "LABEL version=3",`,
+				"Added layer: sha256:01399f08c7986d71d9b739a0899cb5b76eb2aa711d07dfe66b8f143b8a34b2f3",
+			},
+		},
+		{
+			name:        "media type",
+			args:        []string{"manifest", "diff", testrepo + ":v1", testrepo + "@sha256:1effc9d48232693f4584ceb9c5e8d84ddeb5924ea4aff341aa8204510422f668"},
+			outContains: []string{"Changed media type: " + mediatype.OCI1ManifestList + " -> " + mediatype.OCI1Manifest},
+		},
+		{
+			name:      "recurse format",
+			args:      []string{"manifest", "diff", "--recurse", "--format", "{{ range .Platforms }}{{ .Platform }} {{ len .Added }} {{ len .Changed }}{{ println }}{{ end }}", testrepo + ":v1", testrepo + ":v3"},
+			expectOut: "linux/amd64 3 1\nlinux/arm64 3 1",
+		},
+		{
+			name:      "index config",
+			args:      []string{"manifest", "diff", "--config", testrepo + ":v1", testrepo + ":v3"},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "missing",
+			args:      []string{"manifest", "diff", testrepo + ":v1", testrepo + ":missing"},
+			expectErr: errs.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if tc.expectOut != "" && out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+			for _, s := range tc.outContains {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %s, received %s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestDiffPlatformKey(t *testing.T) {
+	t.Parallel()
+	winA := descriptor.Descriptor{MediaType: mediatype.OCI1Manifest, Digest: digest.FromString("win a"), Platform: &platform.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.1"}}
+	winB := descriptor.Descriptor{MediaType: mediatype.OCI1Manifest, Digest: digest.FromString("win b"), Platform: &platform.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.1"}}
+	winB2 := winB
+	winB2.Digest = digest.FromString("win b2")
+	attA := descriptor.Descriptor{MediaType: mediatype.OCI1Manifest, Digest: digest.FromString("att a"), Platform: &platform.Platform{OS: "unknown", Architecture: "unknown"}, Annotations: map[string]string{dockerReferenceDigest: winA.Digest.String()}}
+	attB := descriptor.Descriptor{MediaType: mediatype.OCI1Manifest, Digest: digest.FromString("att b"), Platform: &platform.Platform{OS: "unknown", Architecture: "unknown"}, Annotations: map[string]string{dockerReferenceDigest: winB.Digest.String()}}
+	attB2 := descriptor.Descriptor{MediaType: mediatype.OCI1Manifest, Digest: digest.FromString("att b2"), Platform: &platform.Platform{OS: "unknown", Architecture: "unknown"}, Annotations: map[string]string{dockerReferenceDigest: winB2.Digest.String()}}
+	m1, err := manifest.New(manifest.WithOrig(v1.Index{Versioned: v1.IndexSchemaVersion, MediaType: mediatype.OCI1ManifestList, Manifests: []descriptor.Descriptor{winA, winB, attA, attB}}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	m2, err := manifest.New(manifest.WithOrig(v1.Index{Versioned: v1.IndexSchemaVersion, MediaType: mediatype.OCI1ManifestList, Manifests: []descriptor.Descriptor{winA, winB2, attA, attB2}}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	result, err := manifestDiffCompare(m1, m2)
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	if len(result.Changed) != 1 || result.Changed[0].Digest != winB2.Digest {
+		t.Errorf("unexpected changed entries: %v", result.Changed)
+	}
+	if len(result.Added) != 1 || result.Added[0].Digest != attB2.Digest {
+		t.Errorf("unexpected added entries: %v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Digest != attB.Digest {
+		t.Errorf("unexpected removed entries: %v", result.Removed)
+	}
+}
@@ -32,6 +32,9 @@ This command is EXPERIMENTAL and could be removed in the future.`,
 		Example: `
 # extract the registry (docker.io)
 regctl ref nginx --format '{{ .Registry }}'
+
+# output the name as shown by the docker CLI (nginx:latest)
+regctl ref docker.io/library/nginx --format '{{ .ShortName }}'
 `,
 		Args: cobra.ExactArgs(1),
 		RunE: refOpts.runRef,
@@ -37,6 +37,11 @@ func TestRef(t *testing.T) {
 			cmd:       []string{"ref", "ghcr.io/regclient/regctl:v0.3", "--format", `{{.Digest}}`},
 			expectOut: "",
 		},
+		{
+			name:      "get short name",
+			cmd:       []string{"ref", "docker.io/library/nginx:1.25", "--format", `{{.ShortName}}`},
+			expectOut: "nginx:1.25",
+		},
 		{
 			name:      "get ocidir path",
 			cmd:       []string{"ref", "ocidir://regclient/regctl:v0.3", "--format", `{{.Path}}`},
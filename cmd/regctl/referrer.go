@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+type referrerCmd struct {
+	rootOpts *rootCmd
+	dryRun   bool
+}
+
+func NewReferrerCmd(rootOpts *rootCmd) *cobra.Command {
+	referrerOpts := referrerCmd{
+		rootOpts: rootOpts,
+	}
+	var referrerTopCmd = &cobra.Command{
+		Use:   "referrer <cmd>",
+		Short: "manage referrers",
+	}
+	var referrerPruneCmd = &cobra.Command{
+		Use:   "prune <image_ref>",
+		Short: "remove missing manifests from the referrers fallback tag",
+		Long: `Remove missing manifests from the referrers fallback tag of an image.
+Registries without the referrers API track referrers in an index pushed to a
+"sha256-<hex>" tag. When a referrer is deleted without updating that index, the
+entry remains and is still listed. This checks each manifest in the index and
+pushes the index without the entries that no longer exist.
+Each removed referrer is output.`,
+		Example: `
+# remove deleted signatures and attestations from the referrers of an image
+regctl referrer prune registry.example.org/repo:v1
+
+# show the referrers that would be removed
+regctl referrer prune --dry-run registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              referrerOpts.runReferrerPrune,
+	}
+
+	referrerPruneCmd.Flags().BoolVar(&referrerOpts.dryRun, "dry-run", false, "Output the missing referrers without updating the fallback tag")
+
+	referrerTopCmd.AddCommand(referrerPruneCmd)
+	return referrerTopCmd
+}
+
+func (referrerOpts *referrerCmd) runReferrerPrune(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rc := referrerOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	if r.Digest == "" {
+		mh, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+		if err != nil {
+			return fmt.Errorf("failed to get manifest digest: %w", err)
+		}
+		r = r.SetDigest(mh.GetDescriptor().Digest.String())
+	}
+	rTag, err := referrer.FallbackTag(r)
+	if err != nil {
+		return fmt.Errorf("failed to compute fallback tag: %w", err)
+	}
+	m, err := rc.ManifestGet(ctx, rTag)
+	if errors.Is(err, errs.ErrNotFound) {
+		referrerOpts.rootOpts.log.Info("Referrers fallback tag not found",
+			slog.String("tag", rTag.CommonName()))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get referrers fallback tag: %w", err)
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok {
+		return fmt.Errorf("referrers fallback tag is not an index: %s%.0w", rTag.CommonName(), errs.ErrUnsupportedMediaType)
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return err
+	}
+	keep := []descriptor.Descriptor{}
+	pruned := 0
+	for _, d := range dl {
+		rReferrer := r.SetDigest(d.Digest.String())
+		_, err := rc.ManifestHead(ctx, rReferrer, regclient.WithManifestRequireDigest())
+		if errors.Is(err, errs.ErrNotFound) {
+			pruned++
+			fmt.Fprintln(cmd.OutOrStdout(), rReferrer.CommonName())
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to check referrer %s: %w", rReferrer.CommonName(), err)
+		}
+		keep = append(keep, d)
+	}
+	referrerOpts.rootOpts.log.Debug("Referrers fallback tag checked",
+		slog.String("tag", rTag.CommonName()),
+		slog.Int("entries", len(dl)),
+		slog.Int("missing", pruned))
+	if pruned == 0 || referrerOpts.dryRun {
+		return nil
+	}
+	err = mi.SetManifestList(keep)
+	if err != nil {
+		return err
+	}
+	err = rc.ManifestPut(ctx, rTag, m)
+	if err != nil {
+		return fmt.Errorf("failed to push referrers fallback tag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReferrerPrune(t *testing.T) {
+	repo := "ocidir://" + t.TempDir() + "/repo"
+	subject := repo + ":v1"
+	_, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("subject")}, "artifact", "put", "--artifact-type", "application/example.subject", subject)
+	if err != nil {
+		t.Fatalf("failed to put subject: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("sig")}, "artifact", "put", "--artifact-type", "application/example.sig", "--subject", subject)
+	if err != nil {
+		t.Fatalf("failed to put signature: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("sbom")}, "artifact", "put", "--artifact-type", "application/example.sbom", "--subject", subject)
+	if err != nil {
+		t.Fatalf("failed to put sbom: %v", err)
+	}
+	digSig, err := cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", "application/example.sig", "--format", "{{range .Descriptors}}{{.Digest}}{{end}}")
+	if err != nil || digSig == "" {
+		t.Fatalf("failed to list signature: %s, %v", digSig, err)
+	}
+	digSBOM, err := cobraTest(t, nil, "artifact", "list", subject, "--filter-artifact-type", "application/example.sbom", "--format", "{{range .Descriptors}}{{.Digest}}{{end}}")
+	if err != nil || digSBOM == "" {
+		t.Fatalf("failed to list sbom: %s, %v", digSBOM, err)
+	}
+	// removing the blob leaves the entry in the fallback tag
+	_, err = cobraTest(t, nil, "blob", "rm", repo, digSig)
+	if err != nil {
+		t.Fatalf("failed to delete signature: %v", err)
+	}
+	listFmt := "{{range .Descriptors}}{{println .Digest}}{{end}}"
+
+	t.Run("dry run", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "referrer", "prune", "--dry-run", subject)
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		if out != repo+"@"+digSig {
+			t.Errorf("unexpected output, expected %s, received %s", repo+"@"+digSig, out)
+		}
+		out, err = cobraTest(t, nil, "artifact", "list", subject, "--format", listFmt)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if !strings.Contains(out, digSig) {
+			t.Errorf("dry run removed the missing referrer: %s", out)
+		}
+	})
+	t.Run("prune", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "referrer", "prune", subject)
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		if out != repo+"@"+digSig {
+			t.Errorf("unexpected output, expected %s, received %s", repo+"@"+digSig, out)
+		}
+		out, err = cobraTest(t, nil, "artifact", "list", subject, "--format", listFmt)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if out != digSBOM {
+			t.Errorf("unexpected referrers, expected %s, received %s", digSBOM, out)
+		}
+		out, err = cobraTest(t, nil, "referrer", "prune", subject)
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		if out != "" {
+			t.Errorf("second prune removed referrers: %s", out)
+		}
+	})
+}
@@ -2,20 +2,26 @@ package main
 
 import (
 	"bufio"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/timejson"
 	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -24,19 +30,28 @@ type registryCmd struct {
 	formatConf           string
 	user, pass           string // login opts
 	passStdin            bool
+	token                string
 	credHelper           string
 	hostname, pathPrefix string
 	cacert, tls          string // set opts
+	anonymous            bool
+	defaultPlatform      string
 	clientCert           string
 	clientKey            string
 	mirrors              []string
 	priority             uint
 	repoAuth             bool
+	resolve              bool
 	blobChunk, blobMax   int64
 	reqPerSec            float64
 	reqConcurrent        int64
+	reqTimeout           time.Duration
+	retryBackoff         float64
+	retryJitter          float64
 	skipCheck            bool
 	apiOpts              []string
+	headers              []string
+	pinCert              []string
 	scheme               string   // TODO: remove
 	dns                  []string // TODO: remove
 }
@@ -65,7 +80,11 @@ regctl registry config registry.example.org
 regctl registry config docker.io
 
 # show the username used to login to docker hub
-regctl registry config docker.io --format '{{.User}}'`,
+regctl registry config docker.io --format '{{.User}}'
+
+# show the mirrors and registry in the order requests are attempted
+regctl registry config --resolve docker.io/library/alpine:latest \
+  --format '{{range .}}{{println .Name}}{{end}}'`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryConfig,
@@ -74,7 +93,10 @@ regctl registry config docker.io --format '{{.User}}'`,
 		Use:   "login <registry>",
 		Short: "login to a registry",
 		Long: `Provide login credentials for a registry. This may not be necessary if you
-have already logged in with docker.`,
+have already logged in with docker. The credentials are verified with the
+registry before they are saved, unless "--skip-check" is used. When the registry
+is configured with a credential helper, the credentials are stored with the
+helper instead of the regctl config.`,
 		Example: `
 # login to Docker Hub
 regctl registry login
@@ -83,7 +105,10 @@ regctl registry login
 regctl registry login registry.example.org
 
 # login to GHCR with a provided password
-echo "${token}" | regctl registry login ghcr.io -u "${username}" --pass-stdin`,
+echo "${token}" | regctl registry login ghcr.io -u "${username}" --pass-stdin
+
+# login with an identity token, exchanged for an access token with an OAuth2 refresh_token grant
+regctl registry login registry.example.org --identity-token "${token}"`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryLogin,
@@ -116,39 +141,72 @@ regctl registry set registry.example.org --cacert "$(cat reg-ca.crt)"
 # specify a local mirror for Docker Hub
 regctl registry set docker.io --mirror hub-mirror.example.org
 
+# attempt a mirror before other mirrors, lower priorities are attempted first
+regctl registry set hub-mirror.example.org --priority 1
+
 # specify the requests per sec throttle
-regctl registry set quay.io --req-per-sec 10`,
+regctl registry set quay.io --req-per-sec 10
+
+# use linux/arm64 when a platform is not specified
+# (image, manifest get/head, and artifact get/list/put commands, --require-list returns the index)
+regctl registry set registry.example.org --default-platform linux/arm64
+
+# ignore credentials and only use anonymous access to Docker Hub
+regctl registry set docker.io --anonymous
+
+# add a header to every request sent to the registry
+regctl registry set registry.example.org --header X-Tenant=acme
+
+# require the registry certificate to match a sha256 fingerprint
+regctl registry set registry.example.org \
+  --pin-cert "$(openssl x509 -in reg.crt -outform der | sha256sum | cut -f1 -d' ')"`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistrySet,
 	}
 
 	registryConfigCmd.Flags().StringVar(&registryOpts.formatConf, "format", "{{jsonPretty .}}", "Format output with go template syntax")
+	registryConfigCmd.Flags().BoolVar(&registryOpts.resolve, "resolve", false, "Output the mirrors and registry for a registry or image reference in the order they are attempted")
 
 	registryLoginCmd.Flags().StringVarP(&registryOpts.user, "user", "u", "", "Username")
 	registryLoginCmd.Flags().StringVarP(&registryOpts.pass, "pass", "p", "", "Password")
 	registryLoginCmd.Flags().BoolVar(&registryOpts.passStdin, "pass-stdin", false, "Read password from stdin")
+	registryLoginCmd.Flags().BoolVar(&registryOpts.passStdin, "password-stdin", false, "Read password from stdin")
+	registryLoginCmd.Flags().BoolVar(&registryOpts.repoAuth, "repo-auth", false, "Separate auth requests per repository instead of per registry")
 	registryLoginCmd.Flags().BoolVar(&registryOpts.skipCheck, "skip-check", false, "Skip checking connectivity to the registry")
+	registryLoginCmd.Flags().StringVar(&registryOpts.token, "identity-token", "", "Identity token, used as an OAuth2 refresh token")
+	registryLoginCmd.Flags().StringVar(&registryOpts.token, "token", "", "Identity token")
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("user", completeArgNone)
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("pass", completeArgNone)
+	_ = registryLoginCmd.RegisterFlagCompletionFunc("identity-token", completeArgNone)
+	_ = registryLoginCmd.RegisterFlagCompletionFunc("token", completeArgNone)
+	_ = registryLoginCmd.Flags().MarkHidden("password-stdin")
 
 	registrySetCmd.Flags().StringVar(&registryOpts.credHelper, "cred-helper", "", "Credential helper (full binary name, including docker-credential- prefix)")
 	registrySetCmd.Flags().StringVar(&registryOpts.cacert, "cacert", "", "CA Certificate (not a filename, use \"$(cat ca.pem)\" to use a file)")
 	registrySetCmd.Flags().StringVar(&registryOpts.clientCert, "client-cert", "", "Client certificate for mTLS (not a filename, use \"$(cat client.pem)\" to use a file)")
 	registrySetCmd.Flags().StringVar(&registryOpts.clientKey, "client-key", "", "Client key for mTLS (not a filename, use \"$(cat client.key)\" to use a file)")
 	registrySetCmd.Flags().StringVar(&registryOpts.tls, "tls", "", "TLS (enabled, insecure, disabled)")
+	registrySetCmd.Flags().BoolVar(&registryOpts.anonymous, "anonymous", false, "Ignore any credentials and only use anonymous access")
+	registrySetCmd.Flags().StringVar(&registryOpts.defaultPlatform, "default-platform", "", "Platform to use when a command does not specify one (e.g. linux/arm64)")
 	registrySetCmd.Flags().StringVar(&registryOpts.hostname, "hostname", "", "Hostname or ip with port")
 	registrySetCmd.Flags().StringVar(&registryOpts.pathPrefix, "path-prefix", "", "Prefix to all repositories")
 	registrySetCmd.Flags().StringArrayVar(&registryOpts.mirrors, "mirror", nil, "List of mirrors (registry names)")
-	registrySetCmd.Flags().UintVar(&registryOpts.priority, "priority", 0, "Priority (for sorting mirrors)")
+	registrySetCmd.Flags().UintVar(&registryOpts.priority, "priority", 0, "Priority for sorting mirrors, lower values are attempted first")
 	registrySetCmd.Flags().BoolVar(&registryOpts.repoAuth, "repo-auth", false, "Separate auth requests per repository instead of per registry")
 	registrySetCmd.Flags().Int64Var(&registryOpts.blobChunk, "blob-chunk", 0, "Blob chunk size")
 	registrySetCmd.Flags().Int64Var(&registryOpts.blobMax, "blob-max", 0, "Blob size before switching to chunked push, -1 to disable")
 	registrySetCmd.Flags().Float64Var(&registryOpts.reqPerSec, "req-per-sec", 0, "Requests per second")
 	registrySetCmd.Flags().Int64Var(&registryOpts.reqConcurrent, "req-concurrent", 0, "Concurrent requests")
+	registrySetCmd.Flags().DurationVar(&registryOpts.reqTimeout, "req-timeout", 0, "Time limit for each request attempt including the response body, 0 for no limit")
+	registrySetCmd.Flags().Float64Var(&registryOpts.retryBackoff, "retry-backoff", 0, "Multiplier of the delay for each retry")
+	registrySetCmd.Flags().Float64Var(&registryOpts.retryJitter, "retry-jitter", 0, "Fraction of the retry delay that is randomized, 0 to 1")
 	registrySetCmd.Flags().BoolVar(&registryOpts.skipCheck, "skip-check", false, "Skip checking connectivity to the registry")
 	registrySetCmd.Flags().StringArrayVar(&registryOpts.apiOpts, "api-opts", nil, "List of options (key=value))")
+	registrySetCmd.Flags().StringArrayVar(&registryOpts.headers, "header", nil, "Header to add to requests (name=value), an empty value removes the header")
+	registrySetCmd.Flags().StringArrayVar(&registryOpts.pinCert, "pin-cert", nil, "SHA256 fingerprint of a certificate that must be presented by the registry, an empty value removes all pins")
 	_ = registrySetCmd.RegisterFlagCompletionFunc("cacert", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("pin-cert", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("tls", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
 			"enabled",
@@ -156,6 +214,7 @@ regctl registry set quay.io --req-per-sec 10`,
 			"disabled",
 		}, cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("default-platform", completeArgPlatform)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("hostname", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("path-prefix", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("mirror", completeArgNone)
@@ -201,6 +260,12 @@ func (registryOpts *registryCmd) runRegistryConfig(cmd *cobra.Command, args []st
 		c.Hosts[i].Token = ""
 		c.Hosts[i].ClientKey = ""
 	}
+	if registryOpts.resolve {
+		if len(args) < 1 {
+			return fmt.Errorf("a registry or image reference is required to resolve%.0w", errs.ErrMissingName)
+		}
+		return template.Writer(cmd.OutOrStdout(), registryOpts.formatConf, registryResolve(c, args[0]))
+	}
 	if len(args) > 0 {
 		h, ok := c.Hosts[args[0]]
 		if !ok {
@@ -214,6 +279,38 @@ func (registryOpts *registryCmd) runRegistryConfig(cmd *cobra.Command, args []st
 	}
 }
 
+// registryResolve returns the hosts for a registry or image reference in the order requests are attempted.
+func registryResolve(c *Config, arg string) []*config.Host {
+	name := arg
+	if _, ok := c.Hosts[arg]; !ok && strings.Contains(arg, "/") {
+		if r, err := ref.New(arg); err == nil {
+			name = r.Registry
+		}
+	}
+	getHost := func(name string) *config.Host {
+		if h, ok := c.Hosts[name]; ok {
+			return h
+		}
+		return config.HostNewDefName(c.HostDefault, name)
+	}
+	upstream := getHost(name)
+	hosts := []*config.Host{}
+	seen := map[string]bool{upstream.Name: true}
+	for _, m := range upstream.Mirrors {
+		h := getHost(m)
+		if seen[h.Name] {
+			continue
+		}
+		seen[h.Name] = true
+		hosts = append(hosts, h)
+	}
+	hosts = append(hosts, upstream)
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return config.HostLess(hosts[i], hosts[j], upstream.Name)
+	})
+	return hosts
+}
+
 func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	// disable signal handler to allow ctrl-c to be used on prompts (context cancel on a blocking reader is difficult)
@@ -231,10 +328,70 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 	} else {
 		c.Hosts[h.Name] = h
 	}
+	if flagChanged(cmd, "repo-auth") {
+		h.RepoAuth = registryOpts.repoAuth
+	}
+	cred, err := registryOpts.loginCred(cmd, h)
+	if err != nil {
+		return err
+	}
+	// if username is <token> then process password as an identity token
+	if cred.User == "<token>" {
+		cred = config.Cred{Token: cred.Password}
+	}
+	if !registryOpts.skipCheck {
+		// verify the credentials before they are saved
+		r, err := ref.NewHost(args[0])
+		if err != nil {
+			return err
+		}
+		hVerify := *h
+		hVerify.CredHelper = ""
+		hVerify.User, hVerify.Pass, hVerify.Token = cred.User, cred.Password, cred.Token
+		cVerify := *c
+		cVerify.Hosts = map[string]*config.Host{}
+		for name, curH := range c.Hosts {
+			cVerify.Hosts[name] = curH
+		}
+		cVerify.Hosts[h.Name] = &hVerify
+		rc := registryOpts.rootOpts.newRegClientConf(&cVerify)
+		_, err = rc.Ping(ctx, r)
+		if err != nil {
+			registryOpts.rootOpts.log.Warn("Failed to ping registry, credentials were not stored")
+			return err
+		}
+	}
+	if h.CredHelper != "" {
+		err = h.CredStore(cred)
+		if err != nil {
+			return err
+		}
+	} else {
+		h.User, h.Pass, h.Token = cred.User, cred.Password, cred.Token
+	}
+	err = c.ConfigSave()
+	if err != nil {
+		return err
+	}
+	registryOpts.rootOpts.log.Info("Credentials set",
+		slog.String("registry", args[0]))
+	return nil
+}
+
+// loginCred returns the credential from the flags, stdin, or prompting the user.
+func (registryOpts *registryCmd) loginCred(cmd *cobra.Command, h *config.Host) (config.Cred, error) {
+	if flagChanged(cmd, "identity-token") || flagChanged(cmd, "token") {
+		if registryOpts.token == "" {
+			registryOpts.rootOpts.log.Error("Token is required")
+			return config.Cred{}, ErrMissingInput
+		}
+		return config.Cred{Token: registryOpts.token}, nil
+	}
+	cred := config.Cred{}
 	if flagChanged(cmd, "user") {
-		h.User = registryOpts.user
+		cred.User = registryOpts.user
 	} else if registryOpts.passStdin {
-		return fmt.Errorf("user must be provided to read password from stdin")
+		return cred, fmt.Errorf("user must be provided to read password from stdin")
 	} else {
 		// prompt for username
 		reader := bufio.NewReader(cmd.InOrStdin())
@@ -246,27 +403,29 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 		user, _ := reader.ReadString('\n')
 		user = strings.TrimSpace(user)
 		if user != "" {
-			h.User = user
-		} else if h.User == "" {
+			cred.User = user
+		} else if h.User != "" {
+			cred.User = h.User
+		} else {
 			registryOpts.rootOpts.log.Error("Username is required")
 
-			return ErrMissingInput
+			return cred, ErrMissingInput
 		}
 	}
 	if flagChanged(cmd, "pass") {
-		h.Pass = registryOpts.pass
+		cred.Password = registryOpts.pass
 	} else if registryOpts.passStdin {
 		pass, err := io.ReadAll(cmd.InOrStdin())
 		if err != nil {
-			return fmt.Errorf("failed to read password from stdin: %w", err)
+			return cred, fmt.Errorf("failed to read password from stdin: %w", err)
 		}
 		passwd := strings.TrimRight(string(pass), "\n")
 		if passwd != "" {
-			h.Pass = passwd
+			cred.Password = passwd
 		} else {
 			registryOpts.rootOpts.log.Error("Password is required")
 
-			return ErrMissingInput
+			return cred, ErrMissingInput
 		}
 	} else {
 		// prompt for a password
@@ -274,51 +433,24 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 		if ifd, ok := cmd.InOrStdin().(interface{ Fd() uintptr }); ok {
 			fd = int(ifd.Fd())
 		} else {
-			return fmt.Errorf("file descriptor needed to prompt for password (resolve by using \"-p\" flag)")
+			return cred, fmt.Errorf("file descriptor needed to prompt for password (resolve by using \"-p\" flag)")
 		}
 		fmt.Fprint(cmd.OutOrStdout(), "Enter Password: ")
 		pass, err := term.ReadPassword(fd)
 		if err != nil {
-			return fmt.Errorf("unable to read from tty (resolve by using \"-p\" flag, or winpty on Windows): %w", err)
+			return cred, fmt.Errorf("unable to read from tty (resolve by using \"-p\" flag, or winpty on Windows): %w", err)
 		}
 		passwd := strings.TrimRight(string(pass), "\n")
 		fmt.Fprint(cmd.OutOrStdout(), "\n")
 		if passwd != "" {
-			h.Pass = passwd
+			cred.Password = passwd
 		} else {
 			registryOpts.rootOpts.log.Error("Password is required")
 
-			return ErrMissingInput
-		}
-	}
-	// if username is <token> then process password as an identity token
-	if h.User == "<token>" {
-		h.Token = h.Pass
-		h.User = ""
-		h.Pass = ""
-	} else {
-		h.Token = ""
-	}
-	err = c.ConfigSave()
-	if err != nil {
-		return err
-	}
-	if !registryOpts.skipCheck {
-		r, err := ref.NewHost(args[0])
-		if err != nil {
-			return err
-		}
-		rc := registryOpts.rootOpts.newRegClient()
-		_, err = rc.Ping(ctx, r)
-		if err != nil {
-			registryOpts.rootOpts.log.Warn("Failed to ping registry, credentials were still stored")
-
-			return err
+			return cred, ErrMissingInput
 		}
 	}
-	registryOpts.rootOpts.log.Info("Credentials set",
-		slog.String("registry", args[0]))
-	return nil
+	return cred, nil
 }
 
 func (registryOpts *registryCmd) runRegistryLogout(cmd *cobra.Command, args []string) error {
@@ -393,6 +525,14 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "client-key") {
 		h.ClientKey = registryOpts.clientKey
 	}
+	if flagChanged(cmd, "default-platform") {
+		if registryOpts.defaultPlatform != "" {
+			if _, err := platform.Parse(registryOpts.defaultPlatform); err != nil {
+				return fmt.Errorf("failed to parse platform %s: %w", registryOpts.defaultPlatform, err)
+			}
+		}
+		h.DefaultPlatform = registryOpts.defaultPlatform
+	}
 	if flagChanged(cmd, "hostname") {
 		h.Hostname = registryOpts.hostname
 	}
@@ -408,6 +548,9 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "repo-auth") {
 		h.RepoAuth = registryOpts.repoAuth
 	}
+	if flagChanged(cmd, "anonymous") {
+		h.Anonymous = registryOpts.anonymous
+	}
 	if flagChanged(cmd, "blob-chunk") {
 		h.BlobChunk = registryOpts.blobChunk
 	}
@@ -420,6 +563,15 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "req-concurrent") {
 		h.ReqConcurrent = registryOpts.reqConcurrent
 	}
+	if flagChanged(cmd, "req-timeout") {
+		h.ReqTimeout = timejson.Duration(registryOpts.reqTimeout)
+	}
+	if flagChanged(cmd, "retry-backoff") {
+		h.RetryBackoff = registryOpts.retryBackoff
+	}
+	if flagChanged(cmd, "retry-jitter") {
+		h.RetryJitter = registryOpts.retryJitter
+	}
 	if flagChanged(cmd, "api-opts") {
 		if h.APIOpts == nil {
 			h.APIOpts = map[string]string{}
@@ -436,6 +588,42 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 		}
 	}
 
+	if flagChanged(cmd, "header") {
+		if h.Headers == nil {
+			h.Headers = map[string]string{}
+		}
+		for _, kv := range registryOpts.headers {
+			kvArr := strings.SplitN(kv, "=", 2)
+			if len(kvArr) != 2 || kvArr[0] == "" {
+				return fmt.Errorf("header must be in the format name=value: %s%.0w", kv, errs.ErrParsingFailed)
+			}
+			if config.HeaderReserved(kvArr[0]) {
+				return fmt.Errorf("header %s cannot be configured%.0w", kvArr[0], errs.ErrUnsupported)
+			}
+			if kvArr[1] != "" {
+				h.Headers[kvArr[0]] = kvArr[1]
+			} else {
+				delete(h.Headers, kvArr[0])
+			}
+		}
+	}
+
+	if flagChanged(cmd, "pin-cert") {
+		pins := []string{}
+		for _, pin := range registryOpts.pinCert {
+			if pin == "" {
+				pins = []string{}
+				continue
+			}
+			pin = strings.ReplaceAll(strings.TrimPrefix(strings.ToLower(pin), "sha256:"), ":", "")
+			if b, err := hex.DecodeString(pin); err != nil || len(b) != 32 {
+				return fmt.Errorf("pinned certificate must be a sha256 fingerprint: %s%.0w", pin, errs.ErrParsingFailed)
+			}
+			pins = append(pins, pin)
+		}
+		h.PinnedCertSHA256 = pins
+	}
+
 	err = c.ConfigSave()
 	if err != nil {
 		return err
@@ -2,11 +2,13 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/olareg/olareg"
@@ -116,13 +118,39 @@ func TestRegistry(t *testing.T) {
 		{
 			name:      "query unauth host",
 			args:      []string{"registry", "config", tsUnauthHost, "--format", "{{.User}}"},
-			expectOut: `testunauthuser`,
+			expectOut: ``,
 		},
 		{
 			name:      "query bad host",
 			args:      []string{"registry", "config", tsBadHost, "--format", "{{.User}}"},
 			expectOut: `testbaduser`,
 		},
+		// login with a token
+		{
+			name:      "login token",
+			args:      []string{"registry", "login", tsGoodHost, "--token", "testtoken", "--repo-auth"},
+			expectOut: "",
+		},
+		{
+			name:      "query token",
+			args:      []string{"registry", "config", tsGoodHost, "--format", "{{.User}}:{{.RepoAuth}}"},
+			expectOut: `:true`,
+		},
+		{
+			name:      "login identity token",
+			args:      []string{"registry", "login", tsGoodHost, "--identity-token", "testtoken"},
+			expectOut: "",
+		},
+		{
+			name:      "login token unauth host",
+			args:      []string{"registry", "login", tsUnauthHost, "--token", "testtoken"},
+			expectErr: errs.ErrHTTPUnauthorized,
+		},
+		{
+			name:      "login identity token unauth host",
+			args:      []string{"registry", "login", tsUnauthHost, "--identity-token", "testtoken"},
+			expectErr: errs.ErrHTTPUnauthorized,
+		},
 		// logout
 		{
 			name:        "logout good host",
@@ -179,3 +207,448 @@ func TestRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryDefaultPlatform(t *testing.T) {
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	srcRef := tsHost + "/testrepo:v3"
+	indexDigest, err := cobraTest(t, nil, "image", "digest", "ocidir://../../testdata/testrepo:v3")
+	if err != nil {
+		t.Fatalf("failed to get index digest: %v", err)
+	}
+	amd64Digest, err := cobraTest(t, nil, "image", "digest", "ocidir://../../testdata/testrepo:v3", "--platform", "linux/amd64")
+	if err != nil {
+		t.Fatalf("failed to get amd64 digest: %v", err)
+	}
+	arm64Digest, err := cobraTest(t, nil, "image", "digest", "ocidir://../../testdata/testrepo:v3", "--platform", "linux/arm64")
+	if err != nil {
+		t.Fatalf("failed to get arm64 digest: %v", err)
+	}
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr error
+		expectOut string
+	}{
+		{
+			name: "set default platform",
+			args: []string{"registry", "set", tsHost, "--tls", "disabled", "--default-platform", "linux/arm64"},
+		},
+		{
+			name:      "query default platform",
+			args:      []string{"registry", "config", tsHost, "--format", "{{.DefaultPlatform}}"},
+			expectOut: "linux/arm64",
+		},
+		{
+			name:      "inspect with default platform",
+			args:      []string{"image", "inspect", srcRef, "--format", "{{.Architecture}}"},
+			expectOut: "arm64",
+		},
+		{
+			name:      "inspect with explicit platform",
+			args:      []string{"image", "inspect", srcRef, "--platform", "linux/amd64", "--format", "{{.Architecture}}"},
+			expectOut: "amd64",
+		},
+		{
+			name:      "manifest get with default platform",
+			args:      []string{"manifest", "get", srcRef, "--format", "{{.GetDescriptor.Digest}}"},
+			expectOut: arm64Digest,
+		},
+		{
+			name:      "manifest get with explicit platform",
+			args:      []string{"manifest", "get", srcRef, "--platform", "linux/amd64", "--format", "{{.GetDescriptor.Digest}}"},
+			expectOut: amd64Digest,
+		},
+		{
+			name:      "manifest get require list",
+			args:      []string{"manifest", "get", srcRef, "--require-list", "--format", "{{.IsList}}"},
+			expectOut: "true",
+		},
+		{
+			name:      "manifest head with default platform",
+			args:      []string{"manifest", "head", srcRef},
+			expectOut: arm64Digest,
+		},
+		{
+			name:      "manifest head require list",
+			args:      []string{"manifest", "head", srcRef, "--require-list"},
+			expectOut: indexDigest,
+		},
+		{
+			name:      "artifact list with default platform",
+			args:      []string{"artifact", "list", srcRef, "--format", "{{.Subject.Digest}}"},
+			expectOut: arm64Digest,
+		},
+		{
+			name:      "set invalid platform",
+			args:      []string{"registry", "set", tsHost, "--default-platform", "linux/arm 64"},
+			expectErr: errs.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestRegistryAnonymous(t *testing.T) {
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	token := "anonymous-token"
+	var mu sync.Mutex
+	credSent := false
+	tokenReqs := 0
+	var tsURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if r.URL.Path == "/token" {
+			_ = r.ParseForm()
+			mu.Lock()
+			tokenReqs++
+			if auth != "" || r.Form.Get("username") != "" || r.Form.Get("password") != "" {
+				credSent = true
+			}
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token":"` + token + `"}`))
+			return
+		}
+		if auth != "Bearer "+token {
+			if auth != "" {
+				mu.Lock()
+				credSent = true
+				mu.Unlock()
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+tsURL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL = ts.URL
+	tsU, _ := url.Parse(ts.URL)
+	tsHost := tsU.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	hostFlag := "reg=" + tsHost + ",user=user,pass=secret,tls=disabled"
+	reset := func() {
+		mu.Lock()
+		credSent = false
+		tokenReqs = 0
+		mu.Unlock()
+	}
+	check := func(t *testing.T, expectCred bool) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if tokenReqs == 0 {
+			t.Errorf("token was not requested")
+		}
+		if credSent != expectCred {
+			t.Errorf("unexpected credentials sent, expected %t, received %t", expectCred, credSent)
+		}
+	}
+
+	t.Run("credentials", func(t *testing.T) {
+		reset()
+		_, err := cobraTest(t, nil, "--host", hostFlag, "tag", "ls", tsHost+"/testrepo")
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		check(t, true)
+	})
+	t.Run("global anonymous", func(t *testing.T) {
+		reset()
+		_, err := cobraTest(t, nil, "--anonymous", "--host", hostFlag, "tag", "ls", tsHost+"/testrepo")
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		check(t, false)
+	})
+	t.Run("host anonymous", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--anonymous", "--skip-check")
+		if err != nil {
+			t.Fatalf("failed to set registry: %v", err)
+		}
+		reset()
+		_, err = cobraTest(t, nil, "--host", hostFlag, "tag", "ls", tsHost+"/testrepo")
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		check(t, false)
+	})
+}
+
+func TestRegistryHeaders(t *testing.T) {
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	var mu sync.Mutex
+	tenants := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		mu.Unlock()
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	tt := []struct {
+		name         string
+		args         []string
+		expectErr    error
+		expectOut    string
+		expectTenant string
+	}{
+		{
+			name: "set header",
+			args: []string{"registry", "set", tsHost, "--tls", "disabled", "--skip-check", "--header", "X-Tenant=acme"},
+		},
+		{
+			name:      "query header",
+			args:      []string{"registry", "config", tsHost, "--format", "{{index .Headers \"X-Tenant\"}}"},
+			expectOut: "acme",
+		},
+		{
+			name:         "request with header",
+			args:         []string{"tag", "ls", tsHost + "/testrepo", "--limit", "1"},
+			expectOut:    "a-docker",
+			expectTenant: "acme",
+		},
+		{
+			name:      "set authorization",
+			args:      []string{"registry", "set", tsHost, "--skip-check", "--header", "authorization=Basic abc"},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "set invalid header",
+			args:      []string{"registry", "set", tsHost, "--skip-check", "--header", "X-Tenant"},
+			expectErr: errs.ErrParsingFailed,
+		},
+		{
+			name: "remove header",
+			args: []string{"registry", "set", tsHost, "--skip-check", "--header", "X-Tenant="},
+		},
+		{
+			name:      "request without header",
+			args:      []string{"tag", "ls", tsHost + "/testrepo", "--limit", "1"},
+			expectOut: "a-docker",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			mu.Lock()
+			tenants = []string{}
+			mu.Unlock()
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, tenant := range tenants {
+				if tenant != tc.expectTenant {
+					t.Errorf("unexpected header, expected %s, received %s", tc.expectTenant, tenant)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryPinCert(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	pin := strings.Repeat("ab", 32)
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr error
+		expectOut string
+	}{
+		{
+			name: "set pin",
+			args: []string{"registry", "set", "registry.example.org", "--skip-check", "--pin-cert", "sha256:" + strings.ToUpper(pin)},
+		},
+		{
+			name:      "query pin",
+			args:      []string{"registry", "config", "registry.example.org", "--format", "{{ range .PinnedCertSHA256 }}{{ println . }}{{ end }}"},
+			expectOut: pin,
+		},
+		{
+			name:      "set invalid pin",
+			args:      []string{"registry", "set", "registry.example.org", "--skip-check", "--pin-cert", "abcd"},
+			expectErr: errs.ErrParsingFailed,
+		},
+		{
+			name: "remove pin",
+			args: []string{"registry", "set", "registry.example.org", "--skip-check", "--pin-cert", ""},
+		},
+		{
+			name:      "query removed pin",
+			args:      []string{"registry", "config", "registry.example.org", "--format", "{{ len .PinnedCertSHA256 }}"},
+			expectOut: "0",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestRegistryPriority(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr error
+		expectOut string
+	}{
+		{
+			name: "set priority",
+			args: []string{"registry", "set", "mirror.example.org", "--skip-check", "--priority", "5"},
+		},
+		{
+			name:      "query priority",
+			args:      []string{"registry", "config", "mirror.example.org", "--format", "{{ .Priority }}"},
+			expectOut: "5",
+		},
+		{
+			name:      "set negative priority",
+			args:      []string{"registry", "set", "mirror.example.org", "--skip-check", "--priority", "-1"},
+			expectErr: fmt.Errorf(`invalid argument "-1" for "--priority" flag: strconv.ParseUint: parsing "-1": invalid syntax`),
+		},
+		{
+			name:      "query unchanged priority",
+			args:      []string{"registry", "config", "mirror.example.org", "--format", "{{ .Priority }}"},
+			expectOut: "5",
+		},
+		{
+			name: "reset priority",
+			args: []string{"registry", "set", "mirror.example.org", "--skip-check", "--priority", "0"},
+		},
+		{
+			name:      "query reset priority",
+			args:      []string{"registry", "config", "mirror.example.org", "--format", "{{ .Priority }}"},
+			expectOut: "0",
+		},
+		{
+			name: "set mirrors",
+			args: []string{"registry", "set", "registry.example.org", "--skip-check", "--mirror", "mirror-c.example.org", "--mirror", "mirror-a.example.org", "--mirror", "mirror.example.org", "--mirror", "mirror-a.example.org"},
+		},
+		{
+			name: "set mirror priority",
+			args: []string{"registry", "set", "mirror.example.org", "--skip-check", "--priority", "2"},
+		},
+		{
+			name:      "resolve registry",
+			args:      []string{"registry", "config", "--resolve", "registry.example.org", "--format", "{{range .}}{{println .Name}}{{end}}"},
+			expectOut: "mirror-c.example.org\nmirror-a.example.org\nregistry.example.org\nmirror.example.org",
+		},
+		{
+			name:      "resolve image",
+			args:      []string{"registry", "config", "--resolve", "registry.example.org/repo:v1", "--format", "{{range .}}{{println .Name}}{{end}}"},
+			expectOut: "mirror-c.example.org\nmirror-a.example.org\nregistry.example.org\nmirror.example.org",
+		},
+		{
+			name:      "resolve unknown registry",
+			args:      []string{"registry", "config", "--resolve", "other.example.org", "--format", "{{range .}}{{println .Name}}{{end}}"},
+			expectOut: "other.example.org",
+		},
+		{
+			name:      "resolve missing arg",
+			args:      []string{"registry", "config", "--resolve"},
+			expectErr: errs.ErrMissingName,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
@@ -1,20 +1,26 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
 )
 
 type repoCmd struct {
-	rootOpts *rootCmd
-	last     string
-	limit    int
-	format   string
+	rootOpts   *rootCmd
+	last       string
+	limit      int
+	format     string
+	outputFile string
+	tagPrefix  string
+	tagSuffix  string
 }
 
 func NewRepoCmd(rootOpts *rootCmd) *cobra.Command {
@@ -36,23 +42,107 @@ Note: Docker Hub does not support this API request.`,
 regctl repo ls registry.example.org
 
 # list the next 5 repositories after repo1
-regctl repo ls --last repo1 --limit 5 registry.example.org`,
+regctl repo ls --last repo1 --limit 5 registry.example.org
+
+# save a snapshot of the repositories to a file
+regctl repo ls registry.example.org --format '{{range .Repositories}}{{println .}}{{end}}' \
+  --output-file repos.txt`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              repoOpts.runRepoLs,
+		Annotations:       map[string]string{annotationOutput: "true"},
 	}
 
 	repoLsCmd.Flags().StringVarP(&repoOpts.last, "last", "", "", "Specify the last repo from a previous request for pagination")
 	repoLsCmd.Flags().IntVarP(&repoOpts.limit, "limit", "", 0, "Specify the number of repos to retrieve")
 	repoLsCmd.Flags().StringVarP(&repoOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	repoLsCmd.Flags().StringVar(&repoOpts.outputFile, "output-file", "", "Write the output to a file, replaced only when the listing succeeds")
 	_ = repoLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	_ = repoLsCmd.RegisterFlagCompletionFunc("limit", completeArgNone)
 	_ = repoLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
+	var repoCopyCmd = &cobra.Command{
+		Use:     "copy <src_repo> <dst_repo>",
+		Aliases: []string{"cp"},
+		Short:   "copy every tag in a repository",
+		Long: `Copy every tagged image from the source repository to the destination repository.
+Tags may be renamed with a prefix or suffix, and every resulting tag is validated before any images are copied.
+Digest tags, used for referrers and signatures, are copied without being renamed.
+A failed copy is reported and the remaining tags are still copied.`,
+		Example: `
+# mirror a repository
+regctl repo copy registry.example.org/repo registry.example.com/mirror
+
+# add a prefix and suffix to each copied tag
+regctl repo copy --tag-prefix upstream- --tag-suffix -mirror \
+  registry.example.org/repo registry.example.com/mirror`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgList([]completeFunc{rootOpts.completeArgTag, rootOpts.completeArgTag}),
+		RunE:              repoOpts.runRepoCopy,
+	}
+	repoCopyCmd.Flags().StringVar(&repoOpts.tagPrefix, "tag-prefix", "", "Prefix to add to each tag in the destination repository")
+	repoCopyCmd.Flags().StringVar(&repoOpts.tagSuffix, "tag-suffix", "", "Suffix to add to each tag in the destination repository")
+	_ = repoCopyCmd.RegisterFlagCompletionFunc("tag-prefix", completeArgNone)
+	_ = repoCopyCmd.RegisterFlagCompletionFunc("tag-suffix", completeArgNone)
+
+	repoTopCmd.AddCommand(repoCopyCmd)
 	repoTopCmd.AddCommand(repoLsCmd)
 	return repoTopCmd
 }
 
+func (repoOpts *repoCmd) runRepoCopy(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rSrc, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rTgt, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	rc := repoOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, rSrc)
+	defer rc.Close(ctx, rTgt)
+	tl, err := rc.TagList(ctx, rSrc)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	// validate every renamed tag before copying
+	tgtList := make([]ref.Ref, len(tl.Tags))
+	for i, t := range tl.Tags {
+		tgtTag := t
+		// digest tags reference the source digest and must keep their name to be found
+		if !scheme.TagIsDigest(t) {
+			tgtTag = repoOpts.tagPrefix + t + repoOpts.tagSuffix
+		}
+		rTgtTag := rTgt.SetTag(tgtTag)
+		if rParse, err := ref.New(rTgtTag.CommonName()); err != nil || rParse.Tag != tgtTag {
+			return fmt.Errorf("invalid tag \"%s\" for source tag \"%s\"%.0w", tgtTag, t, errs.ErrInvalidReference)
+		}
+		tgtList[i] = rTgtTag
+	}
+	errList := []error{}
+	for i, t := range tl.Tags {
+		rSrcTag := rSrc.SetTag(t)
+		repoOpts.rootOpts.log.Info("Copy image",
+			slog.String("source", rSrcTag.CommonName()),
+			slog.String("target", tgtList[i].CommonName()),
+			slog.Int("count", i+1),
+			slog.Int("total", len(tl.Tags)))
+		err = rc.ImageCopy(ctx, rSrcTag, tgtList[i])
+		if err != nil {
+			repoOpts.rootOpts.log.Warn("Failed to copy image",
+				slog.String("source", rSrcTag.CommonName()),
+				slog.String("target", tgtList[i].CommonName()),
+				slog.String("err", err.Error()))
+			errList = append(errList, fmt.Errorf("failed to copy %s to %s: %w", rSrcTag.CommonName(), tgtList[i].CommonName(), err))
+			continue
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), tgtList[i].CommonName())
+	}
+	return errors.Join(errList...)
+}
+
 func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	host := args[0]
@@ -87,5 +177,9 @@ func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 	case "rawHeaders", "raw-headers", "headers":
 		repoOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), repoOpts.format, rl)
+	if repoOpts.rootOpts.outputStructured() {
+		// output the list of repositories instead of the raw response
+		return repoOpts.rootOpts.resultOutput(cmd, repoOpts.outputFile, "", rl.RepoRegistryList)
+	}
+	return templateOutput(cmd, repoOpts.outputFile, repoOpts.format, rl)
 }
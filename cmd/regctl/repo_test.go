@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestRepoCopy(t *testing.T) {
+	srcRepo := "ocidir://../../testdata/testrepo"
+	srcTagsOut, err := cobraTest(t, nil, "tag", "ls", srcRepo)
+	if err != nil {
+		t.Fatalf("failed to list source tags: %v", err)
+	}
+	srcTags := strings.Split(srcTagsOut, "\n")
+
+	t.Run("prefix and suffix", func(t *testing.T) {
+		tgtRepo := "ocidir://" + t.TempDir() + "/mirror"
+		out, err := cobraTest(t, nil, "repo", "copy", "--tag-prefix", "up-", "--tag-suffix", "-m", srcRepo, tgtRepo)
+		if err != nil {
+			t.Fatalf("failed to copy repo: %v", err)
+		}
+		if len(strings.Split(out, "\n")) != len(srcTags) {
+			t.Errorf("unexpected output, expected %d refs, received: %s", len(srcTags), out)
+		}
+		tgtTagsOut, err := cobraTest(t, nil, "tag", "ls", tgtRepo)
+		if err != nil {
+			t.Fatalf("failed to list target tags: %v", err)
+		}
+		tgtTags := map[string]bool{}
+		for _, tag := range strings.Split(tgtTagsOut, "\n") {
+			tgtTags[tag] = true
+		}
+		for _, tag := range srcTags {
+			// digest tags are copied without being renamed
+			if scheme.TagIsDigest(tag) {
+				if !tgtTags[tag] || tgtTags["up-"+tag+"-m"] {
+					t.Errorf("digest tag %s was renamed, target tags: %s", tag, tgtTagsOut)
+				}
+				continue
+			}
+			if !tgtTags["up-"+tag+"-m"] {
+				t.Errorf("missing renamed tag for %s, target tags: %s", tag, tgtTagsOut)
+			}
+			if tgtTags[tag] {
+				t.Errorf("original tag %s found in target", tag)
+			}
+		}
+	})
+	t.Run("continue after failure", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		brokenRepo := "ocidir://" + tmpDir + "/broken"
+		tgtRepo := "ocidir://" + tmpDir + "/mirror"
+		for _, tag := range []string{"b1", "v1"} {
+			_, err := cobraTest(t, nil, "image", "copy", srcRepo+":"+tag, brokenRepo+":"+tag)
+			if err != nil {
+				t.Fatalf("failed to copy %s: %v", tag, err)
+			}
+		}
+		dig, err := cobraTest(t, nil, "image", "digest", brokenRepo+":b1")
+		if err != nil {
+			t.Fatalf("failed to get digest: %v", err)
+		}
+		d, err := digest.Parse(dig)
+		if err != nil {
+			t.Fatalf("failed to parse digest %s: %v", dig, err)
+		}
+		err = os.Remove(filepath.Join(tmpDir, "broken", "blobs", d.Algorithm().String(), d.Encoded()))
+		if err != nil {
+			t.Fatalf("failed to remove manifest: %v", err)
+		}
+		out, err := cobraTest(t, nil, "repo", "copy", brokenRepo, tgtRepo)
+		if err == nil || !strings.Contains(err.Error(), brokenRepo+":b1") {
+			t.Errorf("expected error copying b1, received %v", err)
+		}
+		if !strings.Contains(out, tgtRepo+":v1") {
+			t.Errorf("v1 was not copied after a failure, output: %s", out)
+		}
+	})
+	t.Run("invalid tag", func(t *testing.T) {
+		tgtRepo := "ocidir://" + t.TempDir() + "/mirror"
+		_, err := cobraTest(t, nil, "repo", "copy", "--tag-prefix", "-bad", srcRepo, tgtRepo)
+		if !errors.Is(err, errs.ErrInvalidReference) {
+			t.Fatalf("unexpected error, expected %v, received %v", errs.ErrInvalidReference, err)
+		}
+		_, err = cobraTest(t, nil, "tag", "ls", tgtRepo)
+		if err == nil {
+			t.Errorf("target repo created after a validation failure")
+		}
+	})
+	t.Run("tag too long", func(t *testing.T) {
+		tgtRepo := "ocidir://" + t.TempDir() + "/mirror"
+		_, err := cobraTest(t, nil, "repo", "copy", "--tag-suffix", strings.Repeat("x", 128), srcRepo, tgtRepo)
+		if !errors.Is(err, errs.ErrInvalidReference) {
+			t.Fatalf("unexpected error, expected %v, received %v", errs.ErrInvalidReference, err)
+		}
+	})
+}
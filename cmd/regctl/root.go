@@ -1,36 +1,56 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/conffile"
 	"github.com/regclient/regclient/internal/strparse"
 	"github.com/regclient/regclient/internal/version"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
 )
 
 const (
 	progressFreq = time.Millisecond * 250
+	// tagConcurrent limits the number of tags queried in parallel
+	tagConcurrent = 5
 	// UserAgent sets the header on http requests
 	UserAgent = "regclient/regctl"
+	// annotationOutput is set on commands that support --output json and yaml
+	annotationOutput = "regctl.output"
 )
 
 type rootCmd struct {
 	name      string
+	anonymous bool
 	verbosity string
 	logopts   []string
 	log       *slog.Logger
 	format    string // for Go template formatting of various commands
+	output    string // json, yaml, or text output of structured results
 	hosts     []string
 	userAgent string
+	timeout   time.Duration
+	tmpDir    string             // directory for staging files, see the tmp-dir flag
+	cancel    context.CancelFunc // cancels the timeout context when set
+	debugHTTP bool
+	debugBody int64
+	debugW    io.Writer // output for debugHTTP
 }
 
 func NewRootCmd() (*cobra.Command, *rootCmd) {
@@ -57,7 +77,13 @@ regctl tag ls ghcr.io/regclient/regctl -v debug
 regctl image ratelimit --logopt json alpine
 
 # override registry config for a single command
-regctl image digest --host reg=localhost:5000,tls=disabled localhost:5000/repo:v1`,
+regctl image digest --host reg=localhost:5000,tls=disabled localhost:5000/repo:v1
+
+# dump the HTTP requests and responses, including the first 4k of each body
+regctl manifest get --debug-http --debug-http-body 4096 localhost:5000/repo:v1
+
+# stop a copy that has not finished after 10 minutes
+regctl image copy --timeout 10m ghcr.io/regclient/regctl:latest registry.example.org/regctl:latest`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
@@ -72,22 +98,34 @@ regctl version
 
 # retrieve the version number
 regctl version --format '{{.VCSTag}}'`,
-		Args: cobra.ExactArgs(0),
-		RunE: rootOpts.runVersion,
+		Args:        cobra.ExactArgs(0),
+		RunE:        rootOpts.runVersion,
+		Annotations: map[string]string{annotationOutput: "true"},
 	}
 
 	rootOpts.log = slog.New(slog.NewTextHandler(rootTopCmd.ErrOrStderr(), &slog.HandlerOptions{Level: slog.LevelWarn}))
 
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.verbosity, "verbosity", "v", slog.LevelWarn.String(), "Log level (debug, info, warn, error, fatal, panic)")
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.logopts, "logopt", []string{}, "Log options")
+	rootTopCmd.PersistentFlags().BoolVar(&rootOpts.anonymous, "anonymous", false, "Ignore all credentials and only use anonymous access")
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.hosts, "host", []string{}, "Registry hosts to add (reg=registry,user=username,pass=password,tls=enabled)")
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.userAgent, "user-agent", "", "", "Override user agent")
+	rootTopCmd.PersistentFlags().StringVar(&rootOpts.output, "output", "", "Output structured results as json or yaml instead of the format template, or text for the default")
+	rootTopCmd.PersistentFlags().DurationVar(&rootOpts.timeout, "timeout", 0, "Stop the command after the duration (e.g. 5m), 0 to disable")
+	rootTopCmd.PersistentFlags().BoolVar(&rootOpts.debugHTTP, "debug-http", false, "Output each HTTP request and response to stderr (auth and cookie headers are censored)")
+	rootTopCmd.PersistentFlags().StringVar(&rootOpts.tmpDir, "tmp-dir", "", "Directory for temporary files, defaults to the system temp directory")
+	rootTopCmd.PersistentFlags().Int64Var(&rootOpts.debugBody, "debug-http-body", 0, "Include up to this many bytes of each body with --debug-http, bodies may contain sensitive data")
 
 	_ = rootTopCmd.RegisterFlagCompletionFunc("verbosity", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"debug", "info", "warn", "error", "fatal", "panic"}, cobra.ShellCompDirectiveNoFileComp
 	})
 	_ = rootTopCmd.RegisterFlagCompletionFunc("logopt", completeArgNone)
 	_ = rootTopCmd.RegisterFlagCompletionFunc("host", completeArgNone)
+	_ = rootTopCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "yaml", "text"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = rootTopCmd.RegisterFlagCompletionFunc("timeout", completeArgNone)
+	_ = rootTopCmd.RegisterFlagCompletionFunc("debug-http-body", completeArgNone)
 
 	versionCmd.Flags().StringVarP(&rootOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = versionCmd.RegisterFlagCompletionFunc("format", completeArgNone)
@@ -104,6 +142,7 @@ regctl version --format '{{.VCSTag}}'`,
 		NewIndexCmd(&rootOpts),
 		NewManifestCmd(&rootOpts),
 		NewRefCmd(&rootOpts),
+		NewReferrerCmd(&rootOpts),
 		NewRegistryCmd(&rootOpts),
 		NewRepoCmd(&rootOpts),
 		NewTagCmd(&rootOpts),
@@ -133,12 +172,41 @@ func (rootOpts *rootCmd) rootPreRun(cmd *cobra.Command, args []string) error {
 	} else {
 		rootOpts.log = slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), &slog.HandlerOptions{Level: lvl}))
 	}
+	switch rootOpts.output {
+	case "", "text":
+	case "json", "yaml":
+		if cmd.Annotations[annotationOutput] != "true" {
+			return fmt.Errorf("--output %s is not supported by \"%s\"%.0w", rootOpts.output, cmd.CommandPath(), errs.ErrUnsupported)
+		}
+	default:
+		return fmt.Errorf("unsupported output %s, expected json, yaml, or text%.0w", rootOpts.output, errs.ErrUnsupported)
+	}
+	if rootOpts.debugBody > 0 && !rootOpts.debugHTTP {
+		return fmt.Errorf("--debug-http-body requires --debug-http%.0w", errs.ErrUnsupported)
+	}
+	if rootOpts.debugHTTP {
+		rootOpts.debugW = cmd.ErrOrStderr()
+	}
+	if rootOpts.timeout > 0 {
+		// the deadline is added to the existing context to preserve the signal handler cancel
+		var ctx context.Context
+		ctx, rootOpts.cancel = context.WithTimeout(cmd.Context(), rootOpts.timeout)
+		cmd.SetContext(ctx)
+	}
 	return nil
 }
 
+// close releases resources from the root command after it has run.
+func (rootOpts *rootCmd) close() {
+	if rootOpts.cancel != nil {
+		rootOpts.cancel()
+		rootOpts.cancel = nil
+	}
+}
+
 func (rootOpts *rootCmd) runVersion(cmd *cobra.Command, args []string) error {
 	info := version.GetInfo()
-	return template.Writer(cmd.OutOrStdout(), rootOpts.format, info)
+	return rootOpts.resultOutput(cmd, "", rootOpts.format, info)
 }
 
 func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
@@ -150,7 +218,11 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 			conf = ConfigNew()
 		}
 	}
+	return rootOpts.newRegClientConf(conf)
+}
 
+// newRegClientConf returns a regclient using the provided config instead of loading the config file.
+func (rootOpts *rootCmd) newRegClientConf(conf *Config) *regclient.RegClient {
 	rcOpts := []regclient.Opt{
 		regclient.WithSlog(rootOpts.log),
 		regclient.WithRegOpts(reg.WithCache(time.Minute*5, 500)),
@@ -165,16 +237,29 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 			rcOpts = append(rcOpts, regclient.WithUserAgent(UserAgent+" ("+info.VCSRef+")"))
 		}
 	}
+	if rootOpts.debugW != nil {
+		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithDebugHTTP(rootOpts.debugW, rootOpts.debugBody)))
+	}
+	if rootOpts.tmpDir != "" {
+		rcOpts = append(rcOpts, regclient.WithTempDir(rootOpts.tmpDir))
+	}
 	if conf.BlobLimit != 0 {
 		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithBlobLimit(conf.BlobLimit)))
 	}
-	if conf.IncDockerCred == nil || *conf.IncDockerCred {
+	if (conf.IncDockerCred == nil || *conf.IncDockerCred) && !rootOpts.anonymous {
 		rcOpts = append(rcOpts, regclient.WithDockerCreds())
 	}
 	if conf.IncDockerCert == nil || *conf.IncDockerCert {
 		rcOpts = append(rcOpts, regclient.WithDockerCerts())
 	}
-	if conf.HostDefault != nil {
+	if rootOpts.anonymous {
+		hostDefault := config.Host{}
+		if conf.HostDefault != nil {
+			hostDefault = *conf.HostDefault
+		}
+		hostDefault.Anonymous = true
+		rcOpts = append(rcOpts, regclient.WithConfigHostDefault(hostDefault))
+	} else if conf.HostDefault != nil {
 		rcOpts = append(rcOpts, regclient.WithConfigHostDefault(*conf.HostDefault))
 	}
 
@@ -209,6 +294,11 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 		}
 		rcHosts = append(rcHosts, host)
 	}
+	if rootOpts.anonymous {
+		for i := range rcHosts {
+			rcHosts[i].Anonymous = true
+		}
+	}
 	if len(rcHosts) > 0 {
 		rcOpts = append(rcOpts, regclient.WithConfigHost(rcHosts...))
 	}
@@ -216,6 +306,25 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 	return regclient.New(rcOpts...)
 }
 
+// platformDefault returns the default platform of the reference registry when a platform is not provided.
+func (rootOpts *rootCmd) platformDefault(r ref.Ref, p string) string {
+	if p != "" || r.Scheme != "reg" {
+		return p
+	}
+	conf, err := ConfigLoadDefault()
+	if err != nil {
+		return p
+	}
+	h, ok := conf.Hosts[config.HostNewName(r.Registry).Name]
+	if !ok || h.DefaultPlatform == "" {
+		return p
+	}
+	rootOpts.log.Debug("Using default platform for registry",
+		slog.String("registry", r.Registry),
+		slog.String("platform", h.DefaultPlatform))
+	return h.DefaultPlatform
+}
+
 func flagChanged(cmd *cobra.Command, name string) bool {
 	flag := cmd.Flags().Lookup(name)
 	if flag == nil {
@@ -223,3 +332,74 @@ func flagChanged(cmd *cobra.Command, name string) bool {
 	}
 	return flag.Changed
 }
+
+// outputEnvelope wraps the result of a command for --output json and yaml.
+type outputEnvelope struct {
+	Command string      `json:"command"`
+	Result  interface{} `json:"result"`
+}
+
+// outputStructured returns true when --output requests json or yaml.
+func (rootOpts *rootCmd) outputStructured() bool {
+	return rootOpts.output != "" && rootOpts.output != "text"
+}
+
+// resultOutput writes the structured result of a command.
+// With --output json or yaml, the result is wrapped in an [outputEnvelope] and the format template is ignored.
+// Otherwise, this is the same as [templateOutput].
+func (rootOpts *rootCmd) resultOutput(cmd *cobra.Command, outputFile, format string, data interface{}) error {
+	if !rootOpts.outputStructured() {
+		return templateOutput(cmd, outputFile, format, data)
+	}
+	env := outputEnvelope{
+		Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Result:  data,
+	}
+	b, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	if rootOpts.output == "yaml" {
+		// convert from json to use the same field names and custom marshalers
+		var v interface{}
+		err = json.Unmarshal(b, &v)
+		if err != nil {
+			return fmt.Errorf("failed to convert output: %w", err)
+		}
+		b, err = yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+	} else {
+		b = append(b, '\n')
+	}
+	return writeOutput(cmd, outputFile, bytes.NewBuffer(b))
+}
+
+// templateOutput formats the data to stdout, or to the output file when set.
+// The output file is replaced atomically, and only after the template succeeds.
+func templateOutput(cmd *cobra.Command, outputFile, format string, data interface{}) error {
+	if outputFile == "" {
+		return template.Writer(cmd.OutOrStdout(), format, data)
+	}
+	buf := &bytes.Buffer{}
+	err := template.Writer(buf, format, data)
+	if err != nil {
+		return err
+	}
+	return writeOutput(cmd, outputFile, buf)
+}
+
+// writeOutput copies the buffer to stdout, or replaces the output file when set.
+func writeOutput(cmd *cobra.Command, outputFile string, buf *bytes.Buffer) error {
+	if outputFile == "" {
+		_, err := io.Copy(cmd.OutOrStdout(), buf)
+		return err
+	}
+	cf := conffile.New(conffile.WithFullname(outputFile), conffile.WithPerms(0644))
+	err := cf.Write(buf)
+	if err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", outputFile, err)
+	}
+	return nil
+}
@@ -1,7 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 )
 
 func TestRootConfigDir(t *testing.T) {
@@ -18,3 +32,182 @@ func TestRootConfigDir(t *testing.T) {
 		t.Errorf("missing output")
 	}
 }
+
+func TestRootTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	// serve a registry that never responds to the tag listing
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/testrepo/tags/list" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		close(done)
+		ts.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	start := time.Now()
+	_, err = cobraTest(t, nil, "tag", "ls", "--timeout", "250ms", tsHost+"/testrepo")
+	if err == nil {
+		t.Fatalf("tag listing did not fail")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error, expected %v, received %v", context.DeadlineExceeded, err)
+	}
+	if time.Since(start) > time.Second*10 {
+		t.Errorf("timeout was not enforced, command ran for %s", time.Since(start).String())
+	}
+}
+
+func TestRootOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	tt := []struct {
+		name      string
+		args      []string
+		expectCmd string
+		expectErr error
+		check     func(t *testing.T, result json.RawMessage)
+	}{
+		{
+			name:      "tag ls",
+			args:      []string{"tag", "ls", "--output", "json", "--format", "{{.Name}}", "ocidir://../../testdata/testrepo"},
+			expectCmd: "tag ls",
+			check: func(t *testing.T, result json.RawMessage) {
+				tcl := tagCreatedList{}
+				err := json.Unmarshal(result, &tcl)
+				if err != nil {
+					t.Fatalf("failed to parse result: %v", err)
+				}
+				found := false
+				for _, tc := range tcl.Tags {
+					found = found || tc.Tag == "v1"
+				}
+				if !found {
+					t.Errorf("tag v1 not found in %v", tcl.Tags)
+				}
+			},
+		},
+		{
+			name:      "manifest get",
+			args:      []string{"manifest", "get", "--output", "json", srcRef},
+			expectCmd: "manifest get",
+			check: func(t *testing.T, result json.RawMessage) {
+				m, err := manifest.New(manifest.WithRaw(result))
+				if err != nil {
+					t.Fatalf("failed to parse result: %v", err)
+				}
+				if !m.IsList() {
+					t.Errorf("result is not an index: %s", result)
+				}
+			},
+		},
+		{
+			name:      "image copy",
+			args:      []string{"image", "copy", "--output", "json", srcRef, "ocidir://" + tempDir + "/copy:v1"},
+			expectCmd: "image copy",
+			check: func(t *testing.T, result json.RawMessage) {
+				ico := imageCopyOutput{}
+				err := json.Unmarshal(result, &ico)
+				if err != nil {
+					t.Fatalf("failed to parse result: %v", err)
+				}
+				if ico.Source != srcRef || len(ico.Targets) != 1 || ico.Targets[0] != "ocidir://"+tempDir+"/copy:v1" {
+					t.Errorf("unexpected result: %v", ico)
+				}
+			},
+		},
+		{
+			name:      "image inspect",
+			args:      []string{"image", "inspect", "--output", "json", "--platform", "linux/amd64", srcRef},
+			expectCmd: "image inspect",
+			check: func(t *testing.T, result json.RawMessage) {
+				img := v1.Image{}
+				err := json.Unmarshal(result, &img)
+				if err != nil {
+					t.Fatalf("failed to parse result: %v", err)
+				}
+				if img.Architecture != "amd64" {
+					t.Errorf("unexpected result: %s", result)
+				}
+			},
+		},
+		{
+			name:      "image inspect diff base",
+			args:      []string{"image", "inspect", "--output", "json", "--diff-base", "ocidir://../../testdata/testrepo:b1", "ocidir://../../testdata/testrepo:v3"},
+			expectCmd: "image inspect",
+			check: func(t *testing.T, result json.RawMessage) {
+				idb := imageDiffBase{}
+				err := json.Unmarshal(result, &idb)
+				if err != nil {
+					t.Fatalf("failed to parse result: %v", err)
+				}
+				if idb.Base != "ocidir://../../testdata/testrepo:b1" {
+					t.Errorf("unexpected result: %s", result)
+				}
+			},
+		},
+		{
+			name:      "artifact get output directory",
+			args:      []string{"artifact", "get", "--output", filepath.Join(tempDir, "missing"), srcRef},
+			expectErr: fs.ErrNotExist,
+		},
+		{
+			name:      "unsupported command",
+			args:      []string{"ref", "--output", "json", srcRef},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "unknown output",
+			args:      []string{"tag", "ls", "--output", "xml", "ocidir://../../testdata/testrepo"},
+			expectErr: errs.ErrUnsupported,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run command: %v", err)
+			}
+			env := struct {
+				Command string          `json:"command"`
+				Result  json.RawMessage `json:"result"`
+			}{}
+			err = json.Unmarshal([]byte(out), &env)
+			if err != nil {
+				t.Fatalf("failed to parse output: %v, %s", err, out)
+			}
+			if env.Command != tc.expectCmd {
+				t.Errorf("unexpected command, expected %s, received %s", tc.expectCmd, env.Command)
+			}
+			tc.check(t, env.Result)
+		})
+	}
+	t.Run("yaml", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "ls", "--output", "yaml", "ocidir://../../testdata/testrepo")
+		if err != nil {
+			t.Fatalf("failed to run command: %v", err)
+		}
+		if !strings.HasPrefix(out, "command: tag ls\nresult:\n") || !strings.Contains(out, "- tag: v1\n") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+}
@@ -1,24 +1,48 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/semver"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+	"github.com/regclient/regclient/types/tag"
 )
 
 type tagCmd struct {
-	rootOpts *rootCmd
-	limit    int
-	last     string
-	include  []string
-	exclude  []string
-	format   string
+	rootOpts      *rootCmd
+	abortOnError  bool
+	allDigestTags bool
+	dryRun        bool
+	limit         int
+	last          string
+	include       []string
+	exclude       []string
+	format        string
+	outputFile    string
+	created       bool
+	digestTags    string
+	regex         string
+	semver        string
+	sort          string
 }
 
 func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
@@ -30,17 +54,34 @@ func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
 		Short: "manage tags",
 	}
 	var tagDeleteCmd = &cobra.Command{
-		Use:     "delete <image_ref>",
+		Use:     "delete <image_ref | repository>",
 		Aliases: []string{"del", "rm", "remove"},
 		Short:   "delete a tag in a repo",
 		Long: `Delete a tag in a repository.
 This avoids deleting the manifest when multiple tags reference the same image.
 For registries that do not support the OCI tag delete API, this is implemented
 by pushing a unique dummy manifest and deleting that by digest.
-If the registry does not support the delete API, the dummy manifest will remain.`,
+If the registry does not support the delete API, the dummy manifest will remain.
+Use --all-digest-tags to also delete the digest tags (e.g. "sha256-<hex>.sig")
+created for the image by tools that do not use the referrers API.
+Digest tags are shared by every tag of the same image, so they are kept with a
+warning when another tag in the repository references the same digest, or when
+the digest of another tag cannot be retrieved.
+Use --regex with a repository to delete every tag matching the expression.
+Each deleted tag is output, and failures are reported after the remaining
+tags are attempted unless --abort-on-error is set.`,
 		Example: `
 # delete a tag
-regctl tag delete registry.example.org/repo:v42`,
+regctl tag delete registry.example.org/repo:v42
+
+# delete a tag along with the signatures and attestations pushed as digest tags
+regctl tag delete --all-digest-tags registry.example.org/repo:v42
+
+# show the CI tags that would be deleted
+regctl tag delete --regex 'ci-.*' --dry-run registry.example.org/repo
+
+# delete the CI tags
+regctl tag delete --regex 'ci-.*' registry.example.org/repo`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              tagOpts.runTagDelete,
@@ -57,21 +98,53 @@ For an OCI Layout, the index is available as Index (--format "{{.Index}}").`,
 regctl tag ls registry.example.org/repo
 
 # exclude tags starting with sha256- from the listing
-regctl tag ls registry.example.org/repo --exclude 'sha256-.*'`,
-		Args:      cobra.ExactArgs(1),
-		ValidArgs: []string{},
-		RunE:      tagOpts.runTagLs,
+regctl tag ls registry.example.org/repo --exclude 'sha256-.*'
+
+# exclude digest tags, like the sha256-<hex>.sig tags from cosign
+regctl tag ls registry.example.org/repo --digest-tags exclude
+
+# list the 1.x releases from v1.2 onward
+regctl tag ls registry.example.org/repo --semver '>=1.2, <2'
+
+# show the created time of each tag, oldest first
+regctl tag ls registry.example.org/repo --sort created
+
+# save a snapshot of the tags to a file
+regctl tag ls registry.example.org/repo --format '{{range .Tags}}{{println .}}{{end}}' \
+  --output-file tags.txt`,
+		Args:        cobra.ExactArgs(1),
+		ValidArgs:   []string{},
+		RunE:        tagOpts.runTagLs,
+		Annotations: map[string]string{annotationOutput: "true"},
 	}
 
+	tagDeleteCmd.Flags().BoolVar(&tagOpts.abortOnError, "abort-on-error", false, "Stop on the first failed delete with --regex")
+	tagDeleteCmd.Flags().BoolVar(&tagOpts.allDigestTags, "all-digest-tags", false, "Delete digest tags of the image, output each deleted tag")
+	tagDeleteCmd.Flags().BoolVar(&tagOpts.dryRun, "dry-run", false, "Output the tags matching --regex without deleting them")
+	tagDeleteCmd.Flags().StringVar(&tagOpts.regex, "regex", "", "Regexp of tags to delete in the repository (expression is bound to beginning and ending of tag)")
+	_ = tagDeleteCmd.RegisterFlagCompletionFunc("regex", completeArgNone)
+
 	tagLsCmd.Flags().StringVarP(&tagOpts.last, "last", "", "", "Specify the last tag from a previous request for pagination (depends on registry support)")
 	tagLsCmd.Flags().IntVarP(&tagOpts.limit, "limit", "", 0, "Specify the number of tags to retrieve (depends on registry support)")
 	tagLsCmd.Flags().StringArrayVar(&tagOpts.include, "include", []string{}, "Regexp of tags to include (expression is bound to beginning and ending of tag)")
 	tagLsCmd.Flags().StringArrayVar(&tagOpts.exclude, "exclude", []string{}, "Regexp of tags to exclude (expression is bound to beginning and ending of tag)")
+	tagLsCmd.Flags().BoolVar(&tagOpts.created, "created", false, "Include the created time of each image (queries every tag)")
+	tagLsCmd.Flags().StringVar(&tagOpts.digestTags, "digest-tags", "", "Digest tags (sha256-<hex>) to list: \"all\", \"exclude\", or \"only\"")
 	tagLsCmd.Flags().StringVarP(&tagOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	tagLsCmd.Flags().StringVar(&tagOpts.semver, "semver", "", "Only include tags that are a semantic version matching a comma separated list of comparisons, e.g. \">=1.2, <2\"")
+	tagLsCmd.Flags().StringVar(&tagOpts.outputFile, "output-file", "", "Write the output to a file, replaced only when the listing succeeds")
+	tagLsCmd.Flags().StringVar(&tagOpts.sort, "sort", "", "Sort tags by \"name\" or \"created\" (oldest first, implies --created)")
 	_ = tagLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("limit", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = tagLsCmd.RegisterFlagCompletionFunc("semver", completeArgNone)
+	_ = tagLsCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"name", "created"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = tagLsCmd.RegisterFlagCompletionFunc("digest-tags", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"all", "exclude", "only"}, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	tagTopCmd.AddCommand(tagDeleteCmd)
 	tagTopCmd.AddCommand(tagLsCmd)
@@ -84,17 +157,162 @@ func (tagOpts *tagCmd) runTagDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if tagOpts.regex != "" {
+		return tagOpts.runTagDeleteRegex(cmd, r)
+	}
+	if tagOpts.dryRun || tagOpts.abortOnError {
+		return fmt.Errorf("--dry-run and --abort-on-error require --regex%.0w", errs.ErrUnsupported)
+	}
 	rc := tagOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 	tagOpts.rootOpts.log.Debug("Delete tag",
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository),
 		slog.String("tag", r.Tag))
-	err = rc.TagDelete(ctx, r)
+	if !tagOpts.allDigestTags {
+		return rc.TagDelete(ctx, r)
+	}
+
+	// resolve the digest and find the digest tags before the tag is removed
+	mh, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get manifest digest: %w", err)
+	}
+	prefix, err := referrer.FallbackTag(r.SetDigest(mh.GetDescriptor().Digest.String()))
+	if err != nil {
+		return fmt.Errorf("failed to compute fallback tag: %w", err)
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags := []string{r.Tag}
+	for _, t := range tl.Tags {
+		if strings.HasPrefix(t, prefix.Tag) && t != r.Tag {
+			tags = append(tags, t)
+		}
+	}
+	// keep the digest tags when they are still used by another tag of the same image
+	if len(tags) > 1 {
+		known := tagListDigests(tl)
+		unknown := []string{}
+		shared := ""
+		for _, t := range tl.Tags {
+			if t == r.Tag || scheme.TagIsDigest(t) {
+				continue
+			}
+			if dig, ok := known[t]; !ok {
+				unknown = append(unknown, t)
+			} else if dig == mh.GetDescriptor().Digest {
+				shared = t
+				break
+			}
+		}
+		// only query tags missing from the listing, stopping on the first match
+		for i := 0; shared == "" && i < len(unknown); i++ {
+			rOther := r.SetTag(unknown[i])
+			mhOther, err := rc.ManifestHead(ctx, rOther, regclient.WithManifestRequireDigest())
+			if err != nil {
+				tagOpts.rootOpts.log.Warn("Failed to get manifest digest, assuming the digest tags are shared",
+					slog.String("ref", rOther.CommonName()),
+					slog.String("err", err.Error()))
+				shared = unknown[i]
+			} else if mhOther.GetDescriptor().Digest == mh.GetDescriptor().Digest {
+				shared = unknown[i]
+			}
+		}
+		if shared != "" {
+			tagOpts.rootOpts.log.Warn("Digest tags are not deleted, the image may have other tags",
+				slog.String("ref", r.CommonName()),
+				slog.String("tag", shared))
+			tags = tags[:1]
+		}
+	}
+	errList := []error{}
+	for _, t := range tags {
+		rTag := r.SetTag(t)
+		err = rc.TagDelete(ctx, rTag)
+		if err != nil {
+			errList = append(errList, fmt.Errorf("failed to delete %s: %w", rTag.CommonName(), err))
+			continue
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), rTag.CommonName())
 	}
-	return nil
+	return errors.Join(errList...)
+}
+
+// tagListDigests returns the digest of each tag included in the tag listing.
+// OCI Layouts include the index, and gcr.io includes the tags of each manifest.
+func tagListDigests(tl *tag.List) map[string]digest.Digest {
+	digs := map[string]digest.Digest{}
+	for _, d := range tl.LayoutList.Index.Manifests {
+		if name := d.Annotations[types.AnnotationRefName]; name != "" {
+			digs[name] = d.Digest
+		}
+	}
+	for dig, info := range tl.Manifests {
+		d, err := digest.Parse(dig)
+		if err != nil {
+			continue
+		}
+		for _, t := range info.Tags {
+			digs[t] = d
+		}
+	}
+	return digs
+}
+
+// runTagDeleteRegex deletes every tag in the repository matching the regexp.
+func (tagOpts *tagCmd) runTagDeleteRegex(cmd *cobra.Command, r ref.Ref) error {
+	ctx := cmd.Context()
+	if tagOpts.allDigestTags {
+		return fmt.Errorf("--all-digest-tags cannot be combined with --regex%.0w", errs.ErrUnsupported)
+	}
+	re, err := regexp.Compile("^" + tagOpts.regex + "$")
+	if err != nil {
+		return fmt.Errorf("failed to parse regexp \"%s\": %w", tagOpts.regex, err)
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags := []string{}
+	for _, t := range tl.Tags {
+		if re.MatchString(t) {
+			tags = append(tags, t)
+		}
+	}
+	if tagOpts.dryRun {
+		for _, t := range tags {
+			fmt.Fprintln(cmd.OutOrStdout(), r.SetTag(t).CommonName())
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Matched %d tags\n", len(tags))
+		return nil
+	}
+	deleted := 0
+	errList := []error{}
+	for _, t := range tags {
+		rTag := r.SetTag(t)
+		tagOpts.rootOpts.log.Debug("Delete tag",
+			slog.String("host", rTag.Registry),
+			slog.String("repository", rTag.Repository),
+			slog.String("tag", rTag.Tag))
+		err = rc.TagDelete(ctx, rTag)
+		if err != nil {
+			err = fmt.Errorf("failed to delete %s: %w", rTag.CommonName(), err)
+			if tagOpts.abortOnError {
+				return err
+			}
+			errList = append(errList, err)
+			continue
+		}
+		deleted++
+		fmt.Fprintln(cmd.OutOrStdout(), rTag.CommonName())
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Deleted %d of %d matching tags\n", deleted, len(tags))
+	return errors.Join(errList...)
 }
 
 func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
@@ -103,6 +321,23 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	switch tagOpts.sort {
+	case "", "name":
+	case "created":
+		tagOpts.created = true
+	default:
+		return fmt.Errorf("unsupported sort %s, expected \"name\" or \"created\"", tagOpts.sort)
+	}
+	digestFilter := scheme.TagDigestAll
+	switch tagOpts.digestTags {
+	case "", "all":
+	case "exclude":
+		digestFilter = scheme.TagDigestExclude
+	case "only":
+		digestFilter = scheme.TagDigestOnly
+	default:
+		return fmt.Errorf("unsupported digest-tags %s, expected \"all\", \"exclude\", or \"only\"", tagOpts.digestTags)
+	}
 	reInclude := []*regexp.Regexp{}
 	reExclude := []*regexp.Regexp{}
 	for _, expr := range tagOpts.include {
@@ -119,6 +354,14 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 		}
 		reExclude = append(reExclude, re)
 	}
+	var semverCons semver.Constraints
+	if tagOpts.semver != "" {
+		var err error
+		semverCons, err = semver.ParseConstraints(tagOpts.semver)
+		if err != nil {
+			return fmt.Errorf("failed to parse semver \"%s\": %w", tagOpts.semver, err)
+		}
+	}
 	rc := tagOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 	tagOpts.rootOpts.log.Debug("Listing tags",
@@ -131,6 +374,9 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	if tagOpts.last != "" {
 		opts = append(opts, scheme.WithTagLast(tagOpts.last))
 	}
+	if digestFilter != scheme.TagDigestAll {
+		opts = append(opts, scheme.WithTagDigestFilter(digestFilter))
+	}
 	tl, err := rc.TagList(ctx, r, opts...)
 	if err != nil {
 		return err
@@ -160,6 +406,43 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 		}
 		tl.Tags = filtered
 	}
+	if semverCons != nil {
+		filtered := []string{}
+		for _, tag := range tl.Tags {
+			if semverCons.MatchString(tag) {
+				filtered = append(filtered, tag)
+			}
+		}
+		tl.Tags = filtered
+	}
+	if tagOpts.sort == "name" {
+		sort.Strings(tl.Tags)
+	}
+	if tagOpts.created {
+		tcl := tagOpts.tagCreated(ctx, rc, r, tl.Tags)
+		if tagOpts.sort == "created" {
+			sort.SliceStable(tcl.Tags, func(i, j int) bool {
+				ci, cj := tcl.Tags[i].Created, tcl.Tags[j].Created
+				if ci == nil || cj == nil {
+					// tags without a created time are listed last
+					return ci != nil && cj == nil
+				}
+				if !ci.Equal(*cj) {
+					return ci.Before(*cj)
+				}
+				return tcl.Tags[i].Tag < tcl.Tags[j].Tag
+			})
+		}
+		return tagOpts.rootOpts.resultOutput(cmd, tagOpts.outputFile, tagOpts.format, tcl)
+	}
+	if tagOpts.rootOpts.outputStructured() {
+		// output the same list used with --created instead of the raw response
+		tcl := tagCreatedList{Tags: make([]tagCreatedEntry, len(tl.Tags))}
+		for i, t := range tl.Tags {
+			tcl.Tags[i].Tag = t
+		}
+		return tagOpts.rootOpts.resultOutput(cmd, tagOpts.outputFile, "", tcl)
+	}
 	switch tagOpts.format {
 	case "raw":
 		tagOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -168,5 +451,105 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	case "rawHeaders", "raw-headers", "headers":
 		tagOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), tagOpts.format, tl)
+	return templateOutput(cmd, tagOpts.outputFile, tagOpts.format, tl)
+}
+
+type tagCreatedList struct {
+	Tags []tagCreatedEntry `json:"tags"`
+}
+
+type tagCreatedEntry struct {
+	Tag     string     `json:"tag"`
+	Digest  string     `json:"digest,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// MarshalPretty is used for printPretty template formatting.
+func (tcl tagCreatedList) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	for _, tc := range tcl.Tags {
+		created := "-"
+		if tc.Created != nil {
+			created = tc.Created.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", tc.Tag, created)
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+// tagCreated looks up the created time for each tag with a bounded number of concurrent requests.
+// Lookups are cached by digest, and failures are logged and leave the created time unset.
+func (tagOpts *tagCmd) tagCreated(ctx context.Context, rc *regclient.RegClient, r ref.Ref, tags []string) tagCreatedList {
+	type cacheEntry struct {
+		once    sync.Once
+		created *time.Time
+	}
+	var mu sync.Mutex
+	cache := map[string]*cacheEntry{}
+	tcl := tagCreatedList{Tags: make([]tagCreatedEntry, len(tags))}
+	sem := make(chan struct{}, tagConcurrent)
+	var wg sync.WaitGroup
+	for i, t := range tags {
+		tcl.Tags[i].Tag = t
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rTag := r.SetTag(tcl.Tags[i].Tag)
+			mh, err := rc.ManifestHead(ctx, rTag, regclient.WithManifestRequireDigest())
+			if err != nil {
+				tagOpts.rootOpts.log.Warn("Failed to get manifest",
+					slog.String("tag", tcl.Tags[i].Tag),
+					slog.String("err", err.Error()))
+				return
+			}
+			dig := mh.GetDescriptor().Digest
+			tcl.Tags[i].Digest = dig.String()
+			mu.Lock()
+			ce, ok := cache[dig.String()]
+			if !ok {
+				ce = &cacheEntry{}
+				cache[dig.String()] = ce
+			}
+			mu.Unlock()
+			ce.once.Do(func() {
+				ce.created, err = tagOpts.tagCreatedGet(ctx, rc, rTag.SetDigest(dig.String()))
+				if err != nil {
+					tagOpts.rootOpts.log.Warn("Failed to get created time",
+						slog.String("tag", tcl.Tags[i].Tag),
+						slog.String("err", err.Error()))
+				}
+			})
+			tcl.Tags[i].Created = ce.created
+		}(i)
+	}
+	wg.Wait()
+	return tcl
+}
+
+// tagCreatedGet returns the created time from the manifest annotation, falling back to the image config.
+func (tagOpts *tagCmd) tagCreatedGet(ctx context.Context, rc *regclient.RegClient, r ref.Ref) (*time.Time, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if ma, ok := m.(manifest.Annotator); ok {
+		annot, err := ma.GetAnnotations()
+		if err == nil && annot[types.AnnotationCreated] != "" {
+			t, err := time.Parse(time.RFC3339, annot[types.AnnotationCreated])
+			if err == nil {
+				return &t, nil
+			}
+		}
+	}
+	conf, err := rc.ImageConfig(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return conf.GetConfig().Created, nil
 }
@@ -4,9 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+
+	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/errs"
 )
 
@@ -45,6 +55,31 @@ func TestTagList(t *testing.T) {
 			expectOut:   "sha256-",
 			outContains: true,
 		},
+		{
+			name:      "List tags exclude digest tags",
+			args:      []string{"tag", "ls", "--digest-tags", "exclude", "--include", "[a-c].*", "ocidir://../../testdata/testrepo"},
+			expectOut: "a-docker\na-example\na1\na2\nai\nb1\nb2\nb3\nchild",
+		},
+		{
+			name:      "List tags only digest tags",
+			args:      []string{"tag", "ls", "--digest-tags", "only", "--include", ".*\\.meta", "ocidir://../../testdata/testrepo"},
+			expectOut: "sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta",
+		},
+		{
+			name:      "List tags invalid digest tags",
+			args:      []string{"tag", "ls", "--digest-tags", "invalid", "ocidir://../../testdata/testrepo"},
+			expectErr: fmt.Errorf(`unsupported digest-tags invalid, expected "all", "exclude", or "only"`),
+		},
+		{
+			name:      "List tags semver",
+			args:      []string{"tag", "ls", "--semver", ">=2, <4", "ocidir://../../testdata/testrepo"},
+			expectOut: "v2\nv3",
+		},
+		{
+			name:      "List tags invalid semver",
+			args:      []string{"tag", "ls", "--semver", ">=2.x", "ocidir://../../testdata/testrepo"},
+			expectErr: errs.ErrParsingFailed,
+		},
 		{
 			name:        "List tags limited",
 			args:        []string{"tag", "ls", "--include", "v.*", "--limit", "5", "ocidir://../../testdata/testrepo"},
@@ -78,3 +113,331 @@ func TestTagList(t *testing.T) {
 		})
 	}
 }
+
+func TestTagListOutputFile(t *testing.T) {
+	tempDir := t.TempDir()
+	outFile := filepath.Join(tempDir, "tags.txt")
+	// serve a paginated tag listing that can fail on the second page
+	var failPage atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/testrepo/tags/list" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/testrepo/tags/list?last=v2>; rel="next"`)
+			_, _ = w.Write([]byte(`{"name":"testrepo","tags":["v1","v2"]}`))
+			return
+		}
+		if failPage.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"testrepo","tags":["v3"]}`))
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(ts.Close)
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled", "--skip-check")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	format := "{{range .Tags}}{{println .}}{{end}}"
+	out, err := cobraTest(t, nil, "tag", "ls", "--format", format, "--output-file", outFile, tsHost+"/testrepo")
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output to stdout: %s", out)
+	}
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if string(b) != "v1\nv2\nv3\n" {
+		t.Errorf("unexpected output file content: %s", string(b))
+	}
+	// an error on the second page must leave the previous file unchanged
+	failPage.Store(true)
+	_, err = cobraTest(t, nil, "tag", "ls", "--format", format, "--output-file", outFile, tsHost+"/testrepo")
+	if err == nil {
+		t.Errorf("tag listing did not fail")
+	}
+	b, err = os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if string(b) != "v1\nv2\nv3\n" {
+		t.Errorf("output file changed after a failed listing: %s", string(b))
+	}
+	// a failed listing to a new file must not create the file
+	newFile := filepath.Join(tempDir, "new.txt")
+	_, err = cobraTest(t, nil, "tag", "ls", "--format", format, "--output-file", newFile, tsHost+"/testrepo")
+	if err == nil {
+		t.Errorf("tag listing did not fail")
+	}
+	if _, err := os.Stat(newFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("output file created after a failed listing: %v", err)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("failed to read temp dir: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name() != "tags.txt" && e.Name() != "config.json" {
+			t.Errorf("unexpected file left in output directory: %s", e.Name())
+		}
+	}
+}
+
+func TestTagListCreated(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := "ocidir://" + tempDir + "/repo"
+	for _, img := range []struct{ tag, created string }{
+		{tag: "b", created: "2021-06-01T00:00:00Z"},
+		{tag: "c", created: "2020-01-01T00:00:00Z"},
+		{tag: "a", created: "2022-03-04T05:06:07Z"},
+	} {
+		_, err := cobraTest(t, nil, "image", "create", "--created", img.created, repo+":"+img.tag)
+		if err != nil {
+			t.Fatalf("failed to create image %s: %v", img.tag, err)
+		}
+	}
+	// a second tag for the same digest uses the cached lookup
+	_, err := cobraTest(t, nil, "image", "copy", repo+":c", repo+":d")
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	tt := []struct {
+		name      string
+		args      []string
+		expectOut string
+		expectErr bool
+	}{
+		{
+			name:      "created",
+			args:      []string{"tag", "ls", "--created", "--sort", "name", repo},
+			expectOut: "a 2022-03-04T05:06:07Z\nb 2021-06-01T00:00:00Z\nc 2020-01-01T00:00:00Z\nd 2020-01-01T00:00:00Z",
+		},
+		{
+			name:      "sort created",
+			args:      []string{"tag", "ls", "--sort", "created", repo},
+			expectOut: "c 2020-01-01T00:00:00Z\nd 2020-01-01T00:00:00Z\nb 2021-06-01T00:00:00Z\na 2022-03-04T05:06:07Z",
+		},
+		{
+			name:      "format",
+			args:      []string{"tag", "ls", "--sort", "created", "--format", "{{range .Tags}}{{.Tag}} {{.Created.Year}}\n{{end}}", repo},
+			expectOut: "c 2020\nd 2020\nb 2021\na 2022",
+		},
+		{
+			name:      "invalid sort",
+			args:      []string{"tag", "ls", "--sort", "size", repo},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestTagDelete(t *testing.T) {
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempdir: %v", err)
+	}
+	repo := "ocidir://" + tempDir + "/testrepo"
+	digestTag := "sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta"
+	out, err := cobraTest(t, nil, "tag", "rm", repo+":v2")
+	if err != nil {
+		t.Fatalf("failed to delete tag: %v", err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "tag", "rm", "--all-digest-tags", repo+":v1")
+	if err != nil {
+		t.Fatalf("failed to delete tag with digest tags: %v", err)
+	}
+	expect := repo + ":v1\n" + repo + ":" + digestTag
+	if out != expect {
+		t.Errorf("unexpected output, expected %s, received %s", expect, out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	for _, tag := range []string{"v1", "v2", digestTag} {
+		if sliceHasStr(strings.Split(out, "\n"), tag) {
+			t.Errorf("tag was not deleted: %s", tag)
+		}
+	}
+	if !sliceHasStr(strings.Split(out, "\n"), "v3") {
+		t.Errorf("unrelated tag was deleted: %s", out)
+	}
+}
+
+func TestTagDeleteSharedDigestTags(t *testing.T) {
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempdir: %v", err)
+	}
+	repo := "ocidir://" + tempDir + "/testrepo"
+	digestTag := "sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta"
+	_, err = cobraTest(t, nil, "image", "copy", repo+":v1", repo+":v1-alias")
+	if err != nil {
+		t.Fatalf("failed to copy tag: %v", err)
+	}
+	// the digest tags are kept while v1-alias references the same image
+	out, err := cobraTest(t, nil, "tag", "rm", "--all-digest-tags", repo+":v1")
+	if err != nil {
+		t.Fatalf("failed to delete tag with digest tags: %v", err)
+	}
+	if !strings.Contains(out, "Digest tags are not deleted") || !strings.HasSuffix(out, "\n"+repo+":v1") {
+		t.Errorf("unexpected output, expected a warning and %s, received %s", repo+":v1", out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags := strings.Split(out, "\n")
+	if sliceHasStr(tags, "v1") {
+		t.Errorf("tag was not deleted: v1")
+	}
+	if !sliceHasStr(tags, digestTag) || !sliceHasStr(tags, "v1-alias") {
+		t.Errorf("shared tags were deleted: %s", out)
+	}
+	// the last tag of the image deletes the digest tags
+	out, err = cobraTest(t, nil, "tag", "rm", "--all-digest-tags", repo+":v1-alias")
+	if err != nil {
+		t.Fatalf("failed to delete tag with digest tags: %v", err)
+	}
+	expect := repo + ":v1-alias\n" + repo + ":" + digestTag
+	if out != expect {
+		t.Errorf("unexpected output, expected %s, received %s", expect, out)
+	}
+}
+
+func TestTagDeleteDigestTagsHeadFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	boolT := true
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+		API: oConfig.ConfigAPI{
+			DeleteEnabled: &boolT,
+		},
+	})
+	// fail requests for the manifests of every other tag
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/v2/testrepo/manifests/"); ok && (r.Method == http.MethodHead || r.Method == http.MethodGet) &&
+			name != "v1" && !strings.HasPrefix(name, "sha256:") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	repo := tsHost + "/testrepo"
+	digestTag := "sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta"
+	out, err := cobraTest(t, nil, "tag", "rm", "--all-digest-tags", repo+":v1")
+	if err != nil {
+		t.Fatalf("failed to delete tag with digest tags: %v", err)
+	}
+	if !strings.Contains(out, "Failed to get manifest digest") || !strings.HasSuffix(out, "\n"+repo+":v1") {
+		t.Errorf("unexpected output, expected a warning and %s, received %s", repo+":v1", out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags := strings.Split(out, "\n")
+	if sliceHasStr(tags, "v1") {
+		t.Errorf("tag was not deleted: v1")
+	}
+	if !sliceHasStr(tags, digestTag) {
+		t.Errorf("digest tag was deleted after a failed digest lookup: %s", out)
+	}
+}
+
+func TestTagDeleteRegex(t *testing.T) {
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempdir: %v", err)
+	}
+	repo := "ocidir://" + tempDir + "/testrepo"
+	matched := strings.Join([]string{repo + ":b1", repo + ":b2", repo + ":b3"}, "\n")
+	out, err := cobraTest(t, nil, "tag", "rm", "--regex", "b[0-9]", "--dry-run", repo)
+	if err != nil {
+		t.Fatalf("failed to run dry-run: %v", err)
+	}
+	expect := matched + "\nMatched 3 tags"
+	if out != expect {
+		t.Errorf("unexpected dry-run output, expected %s, received %s", expect, out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if !sliceHasStr(strings.Split(out, "\n"), "b1") {
+		t.Errorf("dry-run deleted a tag: %s", out)
+	}
+	out, err = cobraTest(t, nil, "tag", "rm", "--regex", "b[0-9]", repo)
+	if err != nil {
+		t.Fatalf("failed to delete tags: %v", err)
+	}
+	expect = matched + "\nDeleted 3 of 3 matching tags"
+	if out != expect {
+		t.Errorf("unexpected output, expected %s, received %s", expect, out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	for _, tag := range []string{"b1", "b2", "b3"} {
+		if sliceHasStr(strings.Split(out, "\n"), tag) {
+			t.Errorf("tag was not deleted: %s", tag)
+		}
+	}
+	if !sliceHasStr(strings.Split(out, "\n"), "v1") {
+		t.Errorf("unmatched tag was deleted: %s", out)
+	}
+	_, err = cobraTest(t, nil, "tag", "rm", "--regex", "b[", repo)
+	if err == nil {
+		t.Errorf("invalid regexp did not fail")
+	}
+	_, err = cobraTest(t, nil, "tag", "rm", "--dry-run", repo+":v1")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("dry-run without regex did not fail, received %v", err)
+	}
+}
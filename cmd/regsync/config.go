@@ -35,10 +35,14 @@ type ConfigDefaults struct {
 	Backup          string                 `yaml:"backup" json:"backup"`
 	Interval        time.Duration          `yaml:"interval" json:"interval"`
 	Schedule        string                 `yaml:"schedule" json:"schedule"`
+	MaxDuration     time.Duration          `yaml:"maxDuration" json:"maxDuration"`
 	RateLimit       ConfigRateLimit        `yaml:"ratelimit" json:"ratelimit"`
 	Parallel        int                    `yaml:"parallel" json:"parallel"`
 	DigestTags      *bool                  `yaml:"digestTags" json:"digestTags"`
+	DigestTagsSkip  *bool                  `yaml:"digestTagsSkip" json:"digestTagsSkip"`
 	Referrers       *bool                  `yaml:"referrers" json:"referrers"`
+	DeepReferrers   *bool                  `yaml:"deepReferrers" json:"deepReferrers"`
+	ReferrerDepth   int                    `yaml:"referrerDepth" json:"referrerDepth"`
 	ReferrerFilters []ConfigReferrerFilter `yaml:"referrerFilters" json:"referrerFilters"`
 	ReferrerSrc     string                 `yaml:"referrerSource" json:"referrerSource"`
 	ReferrerTgt     string                 `yaml:"referrerTarget" json:"referrerTarget"`
@@ -51,6 +55,7 @@ type ConfigDefaults struct {
 	BlobLimit      int64         `yaml:"blobLimit" json:"blobLimit"`
 	CacheCount     int           `yaml:"cacheCount" json:"cacheCount"`
 	CacheTime      time.Duration `yaml:"cacheTime" json:"cacheTime"`
+	MetricsAddr    string        `yaml:"metricsAddr" json:"metricsAddr"`
 	SkipDockerConf bool          `yaml:"skipDockerConfig" json:"skipDockerConfig"`
 	UserAgent      string        `yaml:"userAgent" json:"userAgent"`
 }
@@ -69,7 +74,10 @@ type ConfigSync struct {
 	Tags            AllowDeny              `yaml:"tags" json:"tags"`
 	Repos           AllowDeny              `yaml:"repos" json:"repos"`
 	DigestTags      *bool                  `yaml:"digestTags" json:"digestTags"`
+	DigestTagsSkip  *bool                  `yaml:"digestTagsSkip" json:"digestTagsSkip"`
 	Referrers       *bool                  `yaml:"referrers" json:"referrers"`
+	DeepReferrers   *bool                  `yaml:"deepReferrers" json:"deepReferrers"`
+	ReferrerDepth   int                    `yaml:"referrerDepth" json:"referrerDepth"`
 	ReferrerFilters []ConfigReferrerFilter `yaml:"referrerFilters" json:"referrerFilters"`
 	ReferrerSrc     string                 `yaml:"referrerSource" json:"referrerSource"`
 	ReferrerTgt     string                 `yaml:"referrerTarget" json:"referrerTarget"`
@@ -81,6 +89,7 @@ type ConfigSync struct {
 	Backup          string                 `yaml:"backup" json:"backup"`
 	Interval        time.Duration          `yaml:"interval" json:"interval"`
 	Schedule        string                 `yaml:"schedule" json:"schedule"`
+	MaxDuration     time.Duration          `yaml:"maxDuration" json:"maxDuration"`
 	RateLimit       ConfigRateLimit        `yaml:"ratelimit" json:"ratelimit"`
 	MediaTypes      []string               `yaml:"mediaTypes" json:"mediaTypes"`
 	Hooks           ConfigHooks            `yaml:"hooks" json:"hooks"`
@@ -246,6 +255,9 @@ func syncSetDefaults(s *ConfigSync, d ConfigDefaults) {
 	if s.Interval == 0 && s.Schedule == "" && d.Interval != 0 {
 		s.Interval = d.Interval
 	}
+	if s.MaxDuration == 0 && d.MaxDuration != 0 {
+		s.MaxDuration = d.MaxDuration
+	}
 	if s.RateLimit.Min == 0 && d.RateLimit.Min != 0 {
 		s.RateLimit.Min = d.RateLimit.Min
 	}
@@ -265,10 +277,21 @@ func syncSetDefaults(s *ConfigSync, d ConfigDefaults) {
 		b := (d.DigestTags != nil && *d.DigestTags)
 		s.DigestTags = &b
 	}
+	if s.DigestTagsSkip == nil {
+		b := (d.DigestTagsSkip != nil && *d.DigestTagsSkip)
+		s.DigestTagsSkip = &b
+	}
 	if s.Referrers == nil {
 		b := (d.Referrers != nil && *d.Referrers)
 		s.Referrers = &b
 	}
+	if s.DeepReferrers == nil {
+		b := (d.DeepReferrers != nil && *d.DeepReferrers)
+		s.DeepReferrers = &b
+	}
+	if s.ReferrerDepth == 0 {
+		s.ReferrerDepth = d.ReferrerDepth
+	}
 	if s.ReferrerFilters == nil {
 		s.ReferrerFilters = d.ReferrerFilters
 	}
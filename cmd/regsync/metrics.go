@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/regclient/regclient/types"
+)
+
+// metrics tracks the results of each sync entry for the server mode http endpoint.
+// All methods are safe to call on a nil metrics, which disables tracking.
+type metrics struct {
+	mu      sync.Mutex
+	entries map[metricsKey]*metricsEntry
+}
+
+type metricsKey struct {
+	source, target, syncType string
+}
+
+type metricsEntry struct {
+	attempted, succeeded, failed int64
+	bytes                        int64
+	lastRun, lastSuccess         time.Time
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		entries: map[metricsKey]*metricsEntry{},
+	}
+}
+
+// entry returns the tracked values for a sync entry, the lock must be held.
+func (m *metrics) entry(s ConfigSync) *metricsEntry {
+	k := metricsKey{source: s.Source, target: s.Target, syncType: s.Type}
+	e, ok := m.entries[k]
+	if !ok {
+		e = &metricsEntry{}
+		m.entries[k] = e
+	}
+	return e
+}
+
+// syncDone records the result of running a sync entry.
+func (m *metrics) syncDone(s ConfigSync, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entry(s)
+	e.attempted++
+	e.lastRun = start
+	if err != nil {
+		e.failed++
+	} else {
+		e.succeeded++
+		e.lastSuccess = start
+	}
+}
+
+// callback returns an image copy callback that counts the bytes copied for a sync entry.
+func (m *metrics) callback(s ConfigSync) func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+	return func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+		if m == nil || state != types.CallbackFinished {
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.entry(s).bytes += total
+	}
+}
+
+// ServeHTTP handles the /healthz and /metrics endpoints.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	case "/metrics":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// write outputs the metrics in the Prometheus text format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]metricsKey, 0, len(m.entries))
+	for k := range m.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source != keys[j].source {
+			return keys[i].source < keys[j].source
+		}
+		if keys[i].target != keys[j].target {
+			return keys[i].target < keys[j].target
+		}
+		return keys[i].syncType < keys[j].syncType
+	})
+	list := []struct {
+		name, help, metricType string
+		value                  func(e *metricsEntry) string
+	}{
+		{
+			name:       "regsync_sync_attempted_total",
+			help:       "Number of times the sync entry has run.",
+			metricType: "counter",
+			value:      func(e *metricsEntry) string { return fmt.Sprintf("%d", e.attempted) },
+		},
+		{
+			name:       "regsync_sync_succeeded_total",
+			help:       "Number of times the sync entry has succeeded.",
+			metricType: "counter",
+			value:      func(e *metricsEntry) string { return fmt.Sprintf("%d", e.succeeded) },
+		},
+		{
+			name:       "regsync_sync_failed_total",
+			help:       "Number of times the sync entry has failed.",
+			metricType: "counter",
+			value:      func(e *metricsEntry) string { return fmt.Sprintf("%d", e.failed) },
+		},
+		{
+			name:       "regsync_copied_bytes_total",
+			help:       "Bytes of manifests and blobs copied by the sync entry.",
+			metricType: "counter",
+			value:      func(e *metricsEntry) string { return fmt.Sprintf("%d", e.bytes) },
+		},
+		{
+			name:       "regsync_last_run_timestamp_seconds",
+			help:       "Unix time the sync entry last started.",
+			metricType: "gauge",
+			value:      func(e *metricsEntry) string { return metricsTime(e.lastRun) },
+		},
+		{
+			name:       "regsync_last_success_timestamp_seconds",
+			help:       "Unix time the sync entry last started a successful run.",
+			metricType: "gauge",
+			value:      func(e *metricsEntry) string { return metricsTime(e.lastSuccess) },
+		},
+	}
+	for _, metric := range list {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.metricType)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s{source=\"%s\",target=\"%s\",type=\"%s\"} %s\n",
+				metric.name, metricsLabel(k.source), metricsLabel(k.target), metricsLabel(k.syncType), metric.value(m.entries[k]))
+		}
+	}
+}
+
+var metricsLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsLabel escapes a label value.
+func metricsLabel(s string) string {
+	return metricsLabelReplacer.Replace(s)
+}
+
+// metricsTime outputs a time in unix seconds, or 0 when the time is not set.
+func metricsTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return fmt.Sprintf("%.3f", float64(t.UnixMilli())/1000)
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +29,8 @@ import (
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -217,6 +222,25 @@ defaults:
 			},
 			expErr: nil,
 		},
+		{
+			name: "RepoCopySkipDigestTags",
+			sync: ConfigSync{
+				Source:         tsHost + "/testrepo",
+				Target:         tsHost + "/test-skip-digest",
+				Type:           "repository",
+				DigestTagsSkip: &boolT,
+			},
+			action: actionCopy,
+			expect: map[string]digest.Digest{
+				tsHost + "/test-skip-digest:v1": d1,
+				tsHost + "/test-skip-digest:v2": d2,
+			},
+			missing: []string{
+				tsHost + "/test-skip-digest:sha256-0514ce64171e869a0b065fa1ce1b533e82808c9228d5b97ea6e3ef2e026d9aed",
+				tsHost + "/test-skip-digest:sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta",
+			},
+			expErr: nil,
+		},
 		{
 			name: "RepoCopy",
 			sync: ConfigSync{
@@ -232,6 +256,38 @@ defaults:
 			},
 			expErr: nil,
 		},
+		{
+			name: "Max Duration",
+			sync: ConfigSync{
+				Source:      tsHost + "/testrepo",
+				Target:      tsHost + "/test-window",
+				Type:        "repository",
+				MaxDuration: time.Nanosecond,
+			},
+			action: actionCopy,
+			missing: []string{
+				tsHost + "/test-window:v1",
+				tsHost + "/test-window:v2",
+				tsHost + "/test-window:v3",
+			},
+			expErr: nil,
+		},
+		{
+			name: "Max Duration Resume",
+			sync: ConfigSync{
+				Source:      tsHost + "/testrepo",
+				Target:      tsHost + "/test-window",
+				Type:        "repository",
+				MaxDuration: time.Hour,
+			},
+			action: actionMissing,
+			expect: map[string]digest.Digest{
+				tsHost + "/test-window:v1": d1,
+				tsHost + "/test-window:v2": d2,
+				tsHost + "/test-window:v3": d3,
+			},
+			expErr: nil,
+		},
 		{
 			name: "Overwrite",
 			sync: ConfigSync{
@@ -863,6 +919,273 @@ func TestProcessRef(t *testing.T) {
 	}
 }
 
+func TestProcessDeepReferrers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boolT := true
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copyfs to tempdir: %v", err)
+	}
+	rc := regclient.New()
+	r2, err := ref.New("ocidir://" + tempDir + "/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rl, err := rc.ReferrerList(ctx, r2, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactType: "application/example.sbom"}))
+	if err != nil || len(rl.Descriptors) == 0 {
+		t.Fatalf("failed to get SBOM for v2: %v", err)
+	}
+	dSBOM := rl.Descriptors[0]
+	rl, err = rc.ReferrerList(ctx, r2, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactType: "application/example.signature"}))
+	if err != nil || len(rl.Descriptors) == 0 {
+		t.Fatalf("failed to get signature for v2: %v", err)
+	}
+	dSig := rl.Descriptors[0]
+	// build a chain of referrers: v2 <- sbom <- sbom signature <- attestation of the signature
+	emptyDesc, err := rc.BlobPut(ctx, r2, descriptor.Descriptor{MediaType: mediatype.OCI1Empty}, bytes.NewReader(descriptor.EmptyData))
+	if err != nil {
+		t.Fatalf("failed to put empty blob: %v", err)
+	}
+	emptyDesc.MediaType = mediatype.OCI1Empty
+	pushReferrer := func(artifactType string, subject descriptor.Descriptor) descriptor.Descriptor {
+		t.Helper()
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned:    v1.ManifestSchemaVersion,
+			MediaType:    mediatype.OCI1Manifest,
+			ArtifactType: artifactType,
+			Config:       emptyDesc,
+			Layers:       []descriptor.Descriptor{emptyDesc},
+			Subject:      &subject,
+		}))
+		if err != nil {
+			t.Fatalf("failed to create referrer: %v", err)
+		}
+		err = rc.ManifestPut(ctx, r2.SetDigest(m.GetDescriptor().Digest.String()), m)
+		if err != nil {
+			t.Fatalf("failed to put referrer: %v", err)
+		}
+		return m.GetDescriptor()
+	}
+	dSBOMSig := pushReferrer("application/example.signature", dSBOM)
+	dSBOMAtt := pushReferrer("application/example.attestation", dSBOMSig)
+	// build a referrer index: v2 <- sbom index containing per-platform sboms <- signature of each platform sbom
+	pushChild := func(name string) descriptor.Descriptor {
+		t.Helper()
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned:    v1.ManifestSchemaVersion,
+			MediaType:    mediatype.OCI1Manifest,
+			ArtifactType: "application/example.sbom",
+			Config:       emptyDesc,
+			Layers:       []descriptor.Descriptor{emptyDesc},
+			Annotations:  map[string]string{"name": name},
+		}))
+		if err != nil {
+			t.Fatalf("failed to create child: %v", err)
+		}
+		err = rc.ManifestPut(ctx, r2.SetDigest(m.GetDescriptor().Digest.String()), m, regclient.WithManifestChild())
+		if err != nil {
+			t.Fatalf("failed to put child: %v", err)
+		}
+		return m.GetDescriptor()
+	}
+	dChildAMD := pushChild("amd64")
+	dChildARM := pushChild("arm64")
+	mV2, err := rc.ManifestHead(ctx, r2, regclient.WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head v2: %v", err)
+	}
+	dV2 := mV2.GetDescriptor()
+	mIdx, err := manifest.New(manifest.WithOrig(v1.Index{
+		Versioned:    v1.IndexSchemaVersion,
+		MediaType:    mediatype.OCI1ManifestList,
+		ArtifactType: "application/example.sbom",
+		Manifests:    []descriptor.Descriptor{dChildAMD, dChildARM},
+		Subject:      &dV2,
+	}))
+	if err != nil {
+		t.Fatalf("failed to create referrer index: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r2.SetDigest(mIdx.GetDescriptor().Digest.String()), mIdx)
+	if err != nil {
+		t.Fatalf("failed to put referrer index: %v", err)
+	}
+	dSBOMIdx := mIdx.GetDescriptor()
+	dChildAMDSig := pushReferrer("application/example.signature", dChildAMD)
+	dChildARMSig := pushReferrer("application/example.signature", dChildARM)
+
+	rootOpts := rootCmd{
+		rc:       rc,
+		conf:     &Config{},
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+	}
+	tt := []struct {
+		name    string
+		deep    bool
+		depth   int
+		exists  []digest.Digest
+		missing []digest.Digest
+	}{
+		{
+			name:    "shallow",
+			exists:  []digest.Digest{dSBOM.Digest, dSBOMIdx.Digest, dChildAMD.Digest, dChildARM.Digest},
+			missing: []digest.Digest{dSig.Digest, dSBOMSig.Digest, dSBOMAtt.Digest, dChildAMDSig.Digest, dChildARMSig.Digest},
+		},
+		{
+			name:    "deep",
+			deep:    true,
+			exists:  []digest.Digest{dSBOM.Digest, dSBOMSig.Digest, dSBOMAtt.Digest, dSBOMIdx.Digest, dChildAMD.Digest, dChildARM.Digest, dChildAMDSig.Digest, dChildARMSig.Digest},
+			missing: []digest.Digest{dSig.Digest},
+		},
+		{
+			name:    "depth1",
+			deep:    true,
+			depth:   1,
+			exists:  []digest.Digest{dSBOM.Digest, dSBOMIdx.Digest, dChildAMD.Digest, dChildARM.Digest},
+			missing: []digest.Digest{dSig.Digest, dSBOMSig.Digest, dSBOMAtt.Digest, dChildAMDSig.Digest, dChildARMSig.Digest},
+		},
+		{
+			name:    "depth2",
+			deep:    true,
+			depth:   2,
+			exists:  []digest.Digest{dSBOM.Digest, dSBOMSig.Digest, dSBOMIdx.Digest, dChildAMD.Digest, dChildARM.Digest, dChildAMDSig.Digest, dChildARMSig.Digest},
+			missing: []digest.Digest{dSig.Digest, dSBOMAtt.Digest},
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tgt := "ocidir://" + tempDir + "/test-" + tc.name
+			cs := ConfigSync{
+				Source:    r2.CommonName(),
+				Target:    tgt + ":v2",
+				Type:      "image",
+				Referrers: &boolT,
+				ReferrerFilters: []ConfigReferrerFilter{
+					{ArtifactType: "application/example.sbom"},
+				},
+			}
+			if tc.deep {
+				cs.DeepReferrers = &boolT
+			}
+			cs.ReferrerDepth = tc.depth
+			syncSetDefaults(&cs, ConfigDefaults{})
+			err := rootOpts.process(ctx, cs, actionCopy)
+			if err != nil {
+				t.Fatalf("failed to sync: %v", err)
+			}
+			for _, d := range tc.exists {
+				r, err := ref.New(tgt + "@" + d.String())
+				if err != nil {
+					t.Fatalf("failed to parse ref: %v", err)
+				}
+				if _, err := rc.ManifestHead(ctx, r); err != nil {
+					t.Errorf("ref does not exist: %s", r.CommonName())
+				}
+			}
+			for _, d := range tc.missing {
+				r, err := ref.New(tgt + "@" + d.String())
+				if err != nil {
+					t.Fatalf("failed to parse ref: %v", err)
+				}
+				if _, err := rc.ManifestHead(ctx, r); err == nil {
+					t.Errorf("ref exists that should be missing: %s", r.CommonName())
+				}
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copyfs to tempdir: %v", err)
+	}
+	csGood := ConfigSync{
+		Source: "ocidir://" + tempDir + "/testrepo:v1",
+		Target: "ocidir://" + tempDir + "/testdest:v1",
+		Type:   "image",
+	}
+	syncSetDefaults(&csGood, ConfigDefaults{})
+	csBad := ConfigSync{
+		Source: "ocidir://" + tempDir + "/testrepo:missing",
+		Target: "ocidir://" + tempDir + "/testdest:missing",
+		Type:   "image",
+	}
+	syncSetDefaults(&csBad, ConfigDefaults{})
+	rootOpts := rootCmd{
+		rc:       regclient.New(),
+		conf:     &Config{Sync: []ConfigSync{csGood, csBad}},
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		metrics:  newMetrics(),
+	}
+	if err := rootOpts.process(ctx, csGood, actionCopy); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if err := rootOpts.process(ctx, csBad, actionCopy); err == nil {
+		t.Fatalf("sync of missing image did not fail")
+	}
+	ts := httptest.NewServer(rootOpts.metrics)
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("failed to get healthz: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected healthz status: %d", resp.StatusCode)
+	}
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	values := map[string]string{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i < 0 {
+			t.Fatalf("invalid metrics line: %s", line)
+		}
+		values[line[:i]] = line[i+1:]
+	}
+	labelsGood := fmt.Sprintf(`{source="%s",target="%s",type="image"}`, csGood.Source, csGood.Target)
+	labelsBad := fmt.Sprintf(`{source="%s",target="%s",type="image"}`, csBad.Source, csBad.Target)
+	for name, expect := range map[string]string{
+		"regsync_sync_attempted_total" + labelsGood:          "1",
+		"regsync_sync_succeeded_total" + labelsGood:          "1",
+		"regsync_sync_failed_total" + labelsGood:             "0",
+		"regsync_sync_attempted_total" + labelsBad:           "1",
+		"regsync_sync_succeeded_total" + labelsBad:           "0",
+		"regsync_sync_failed_total" + labelsBad:              "1",
+		"regsync_copied_bytes_total" + labelsBad:             "0",
+		"regsync_last_success_timestamp_seconds" + labelsBad: "0",
+	} {
+		if values[name] != expect {
+			t.Errorf("unexpected value for %s, expected %s, received %s", name, expect, values[name])
+		}
+	}
+	if v, ok := values["regsync_copied_bytes_total"+labelsGood]; !ok || v == "0" {
+		t.Errorf("bytes copied not tracked: %s", v)
+	}
+	if v, ok := values["regsync_last_run_timestamp_seconds"+labelsGood]; !ok || v == "0" {
+		t.Errorf("last run not tracked: %s", v)
+	}
+}
+
 func TestConfigRead(t *testing.T) {
 	t.Parallel()
 	// CAUTION: the below yaml is space indented and will not parse with tabs
@@ -872,6 +1195,7 @@ func TestConfigRead(t *testing.T) {
       - registry: registry:5000
         tls: disabled
       - registry: docker.io
+        reqTimeout: 5m
     defaults:
       ratelimit:
         min: 100
@@ -916,5 +1240,17 @@ func TestConfigRead(t *testing.T) {
 	if c.Sync[2].Target != "registry:5000/gcr/example/repo" {
 		t.Errorf("template sync-gcr mismatch, expected: %s, received: %s", "registry:5000/gcr/example/repo", c.Sync[2].Target)
 	}
+	if time.Duration(c.Creds[1].ReqTimeout) != 5*time.Minute {
+		t.Errorf("reqTimeout mismatch, expected: %s, received: %s", 5*time.Minute, time.Duration(c.Creds[1].ReqTimeout))
+	}
+	// the duration is written back as a string
+	out := &bytes.Buffer{}
+	err = ConfigWrite(c, out)
+	if err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if !strings.Contains(out.String(), "reqTimeout: 5m0s") {
+		t.Errorf("reqTimeout not found in the written config: %s", out.String())
+	}
 	// TODO: test remainder of templates and parsing
 }
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
@@ -60,6 +62,7 @@ type rootCmd struct {
 	missing   bool
 	conf      *Config
 	rc        *regclient.RegClient
+	metrics   *metrics
 	throttle  *pqueue.Queue[throttle]
 }
 
@@ -230,6 +233,28 @@ func (rootOpts *rootCmd) runServer(cmd *cobra.Command, args []string) error {
 	var wg sync.WaitGroup
 	// TODO: switch to joining array of errors once 1.20 is the minimum version
 	var mainErr error
+	if rootOpts.conf.Defaults.MetricsAddr != "" {
+		rootOpts.metrics = newMetrics()
+		ln, err := net.Listen("tcp", rootOpts.conf.Defaults.MetricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", rootOpts.conf.Defaults.MetricsAddr, err)
+		}
+		srv := &http.Server{
+			Handler:           rootOpts.metrics,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		rootOpts.log.Info("Serving metrics",
+			slog.String("addr", ln.Addr().String()))
+		go func() {
+			err := srv.Serve(ln)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				rootOpts.log.Error("Metrics server failed",
+					slog.String("addr", ln.Addr().String()),
+					slog.String("err", err.Error()))
+			}
+		}()
+		defer srv.Close()
+	}
 	c := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DefaultLogger),
 	))
@@ -396,34 +421,75 @@ func (rootOpts *rootCmd) loadConf() error {
 	return nil
 }
 
+// syncWindow limits the time a sync step may start new copies.
+// Once the deadline passes, remaining entries are skipped and running copies are allowed to finish.
+type syncWindow struct {
+	deadline time.Time
+	mu       sync.Mutex
+	images   int
+	repos    int
+}
+
+type syncWindowKey struct{}
+
+func syncWindowGet(ctx context.Context) *syncWindow {
+	sw, _ := ctx.Value(syncWindowKey{}).(*syncWindow)
+	return sw
+}
+
+// expired returns true when the window is defined and the deadline has passed.
+func (sw *syncWindow) expired() bool {
+	return sw != nil && time.Now().After(sw.deadline)
+}
+
+// skip counts the entries that were not processed.
+func (sw *syncWindow) skip(images, repos int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.images += images
+	sw.repos += repos
+}
+
 // process a sync step
 func (rootOpts *rootCmd) process(ctx context.Context, s ConfigSync, action actionType) error {
+	var err error
+	start := time.Now()
+	var sw *syncWindow
+	if s.MaxDuration > 0 && action != actionCheck {
+		sw = &syncWindow{deadline: start.Add(s.MaxDuration)}
+		ctx = context.WithValue(ctx, syncWindowKey{}, sw)
+	}
 	switch s.Type {
 	case "registry":
-		if err := rootOpts.processRegistry(ctx, s, s.Source, s.Target, action); err != nil {
-			return err
-		}
+		err = rootOpts.processRegistry(ctx, s, s.Source, s.Target, action)
 	case "repository":
-		if err := rootOpts.processRepo(ctx, s, s.Source, s.Target, action); err != nil {
-			return err
-		}
+		err = rootOpts.processRepo(ctx, s, s.Source, s.Target, action)
 	case "image":
-		if err := rootOpts.processImage(ctx, s, s.Source, s.Target, action); err != nil {
-			return err
-		}
+		err = rootOpts.processImage(ctx, s, s.Source, s.Target, action)
 	default:
 		rootOpts.log.Error("Type not recognized, must be one of: registry, repository, or image",
 			slog.Any("step", s),
 			slog.String("type", s.Type))
-		return ErrInvalidInput
-	}
-	return nil
+		err = ErrInvalidInput
+	}
+	// a sync stopped by the max duration is not an error, the next run continues with the skipped entries
+	if sw != nil && (sw.images > 0 || sw.repos > 0) {
+		rootOpts.log.Warn("Max duration exceeded, skipped remaining entries",
+			slog.String("source", s.Source),
+			slog.String("target", s.Target),
+			slog.Duration("maxDuration", s.MaxDuration),
+			slog.Int("images", sw.images),
+			slog.Int("repos", sw.repos))
+	}
+	rootOpts.metrics.syncDone(s, start, err)
+	return err
 }
 
 func (rootOpts *rootCmd) processRegistry(ctx context.Context, s ConfigSync, src, tgt string, action actionType) error {
 	last := ""
 	var retErr error
-	for {
+	sw := syncWindowGet(ctx)
+	for !sw.expired() {
 		repoOpts := []scheme.RepoOpts{}
 		if last != "" {
 			repoOpts = append(repoOpts, scheme.WithRepoLast(last))
@@ -456,7 +522,11 @@ func (rootOpts *rootCmd) processRegistry(ctx context.Context, s ConfigSync, src,
 				slog.String("error", err.Error()))
 			return err
 		}
-		for _, repo := range sRepoList {
+		for i, repo := range sRepoList {
+			if sw.expired() {
+				sw.skip(0, len(sRepoList)-i)
+				break
+			}
 			if err := rootOpts.processRepo(ctx, s, fmt.Sprintf("%s/%s", src, repo), fmt.Sprintf("%s/%s", tgt, repo), action); err != nil {
 				retErr = err
 			}
@@ -473,7 +543,11 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 			slog.String("error", err.Error()))
 		return err
 	}
-	sTags, err := rootOpts.rc.TagList(ctx, sRepoRef)
+	tagOpts := []scheme.TagOpts{}
+	if s.DigestTagsSkip != nil && *s.DigestTagsSkip {
+		tagOpts = append(tagOpts, scheme.WithTagDigestFilter(scheme.TagDigestExclude))
+	}
+	sTags, err := rootOpts.rc.TagList(ctx, sRepoRef, tagOpts...)
 	if err != nil {
 		rootOpts.log.Error("Failed getting source tags",
 			slog.String("source", sRepoRef.CommonName()),
@@ -551,7 +625,12 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 		}
 	}
 	var retErr error
-	for _, tag := range sTagList {
+	sw := syncWindowGet(ctx)
+	for i, tag := range sTagList {
+		if sw.expired() {
+			sw.skip(len(sTagList)-i, 0)
+			break
+		}
 		if err := rootOpts.processImage(ctx, s, fmt.Sprintf("%s:%s", src, tag), fmt.Sprintf("%s:%s", tgt, tag), action); err != nil {
 			retErr = err
 		}
@@ -603,7 +682,7 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	}
 	fastCheck := (s.FastCheck != nil && *s.FastCheck)
 	forceRecursive := (s.ForceRecursive != nil && *s.ForceRecursive)
-	referrers := (s.Referrers != nil && *s.Referrers)
+	referrers := (s.Referrers != nil && *s.Referrers) || (s.DeepReferrers != nil && *s.DeepReferrers)
 	digestTags := (s.DigestTags != nil && *s.DigestTags)
 	mTgt, err := rootOpts.rc.ManifestHead(ctx, tgt, regclient.WithManifestRequireDigest())
 	tgtExists := (err == nil)
@@ -733,6 +812,14 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 		return ErrCanceled
 	default:
 	}
+	// skip new copies once the max duration is exceeded
+	if sw := syncWindowGet(ctx); sw.expired() {
+		sw.skip(1, 0)
+		rootOpts.log.Debug("Max duration exceeded, skipping image",
+			slog.String("source", src.CommonName()),
+			slog.String("target", tgt.CommonName()))
+		return nil
+	}
 
 	// run backup
 	if tgtExists && !tgtMatches && s.Backup != "" {
@@ -787,7 +874,13 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	if s.DigestTags != nil && *s.DigestTags {
 		opts = append(opts, regclient.ImageWithDigestTags())
 	}
-	if s.Referrers != nil && *s.Referrers {
+	if (s.Referrers != nil && *s.Referrers) || (s.DeepReferrers != nil && *s.DeepReferrers) {
+		if s.DeepReferrers != nil && *s.DeepReferrers {
+			opts = append(opts, regclient.ImageWithReferrersDeep())
+		}
+		if s.ReferrerDepth > 0 {
+			opts = append(opts, regclient.ImageWithReferrerDepth(s.ReferrerDepth))
+		}
 		if len(s.ReferrerFilters) == 0 {
 			opts = append(opts, regclient.ImageWithReferrers())
 		} else {
@@ -833,6 +926,9 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	if len(s.Platforms) > 0 {
 		opts = append(opts, regclient.ImageWithPlatforms(s.Platforms))
 	}
+	if rootOpts.metrics != nil {
+		opts = append(opts, regclient.ImageWithCallback(rootOpts.metrics.callback(s)))
+	}
 
 	// Copy the image
 	rootOpts.log.Debug("Image sync running",
@@ -94,4 +94,29 @@ func (ch *credHelper) list() ([]Host, error) {
 	return hostList, nil
 }
 
-// TODO: store method not implemented
+// store saves a credential with the helper for a given host.
+func (ch *credHelper) store(host *Host, cred Cred) error {
+	hostname := host.Hostname
+	if host.CredHost != "" {
+		hostname = host.CredHost
+	}
+	credIn := credStore{
+		ServerURL: hostname,
+		Username:  cred.User,
+		Secret:    cred.Password,
+	}
+	if cred.Token != "" {
+		credIn.Username = tokenUser
+		credIn.Secret = cred.Token
+	}
+	inB, err := json.Marshal(credIn)
+	if err != nil {
+		return fmt.Errorf("error encoding credentials: %w", err)
+	}
+	outB, err := ch.run("store", bytes.NewReader(inB))
+	if err != nil {
+		outS := strings.TrimSpace(string(outB))
+		return fmt.Errorf("error storing credentials, output: %s, error: %w", outS, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -87,3 +88,84 @@ func TestCredHelper(t *testing.T) {
 		})
 	}
 }
+
+func TestCredHelperStore(t *testing.T) {
+	// cannot run cred helper in parallel because of OS working directory race conditions
+	tests := []struct {
+		name        string
+		host        string
+		credHost    string
+		cred        Cred
+		expectStore credStore
+	}{
+		{
+			name: "user/pass",
+			host: "storehost.example.com",
+			cred: Cred{User: "hello", Password: "world"},
+			expectStore: credStore{
+				ServerURL: "storehost.example.com",
+				Username:  "hello",
+				Secret:    "world",
+			},
+		},
+		{
+			name: "token",
+			host: "storetoken.example.com",
+			cred: Cred{Token: "deadbeefcafe"},
+			expectStore: credStore{
+				ServerURL: "storetoken.example.com",
+				Username:  tokenUser,
+				Secret:    "deadbeefcafe",
+			},
+		},
+		{
+			name:     DockerRegistry,
+			host:     DockerRegistryDNS,
+			credHost: DockerRegistryAuth,
+			cred:     Cred{User: "hubuser", Password: "password123"},
+			expectStore: credStore{
+				ServerURL: DockerRegistryAuth,
+				Username:  "hubuser",
+				Secret:    "password123",
+			},
+		},
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed checking current directory: %v", err)
+	}
+	curPath := os.Getenv("PATH")
+	t.Setenv("PATH", filepath.Join(cwd, "testdata")+string(os.PathListSeparator)+curPath)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeFile := filepath.Join(t.TempDir(), "store.json")
+			t.Setenv("CRED_STORE_FILE", storeFile)
+			h := HostNewName(tt.host)
+			h.CredHelper = "docker-credential-teststore"
+			h.CredHost = tt.credHost
+			err := h.CredStore(tt.cred)
+			if err != nil {
+				t.Fatalf("error running store: %v", err)
+			}
+			b, err := os.ReadFile(storeFile)
+			if err != nil {
+				t.Fatalf("failed to read stored credential: %v", err)
+			}
+			stored := credStore{}
+			err = json.Unmarshal(b, &stored)
+			if err != nil {
+				t.Fatalf("failed to parse stored credential: %v", err)
+			}
+			if stored != tt.expectStore {
+				t.Errorf("stored credential mismatch: expected %v, received %v", tt.expectStore, stored)
+			}
+		})
+	}
+	t.Run("no helper", func(t *testing.T) {
+		h := HostNewName("nohelper.example.com")
+		err := h.CredStore(Cred{User: "hello", Password: "world"})
+		if err == nil {
+			t.Errorf("store without a credential helper did not fail")
+		}
+	})
+}
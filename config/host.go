@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"strings"
 	"time"
 
@@ -98,30 +99,38 @@ func (t *TLSConf) UnmarshalText(b []byte) error {
 
 // Host defines settings for connecting to a registry.
 type Host struct {
-	Name          string            `json:"-" yaml:"registry,omitempty"`                  // Name of the registry (required) (yaml configs pass this as a field, json provides this from the object key)
-	TLS           TLSConf           `json:"tls,omitempty" yaml:"tls"`                     // TLS setting: enabled (default), disabled, insecure
-	RegCert       string            `json:"regcert,omitempty" yaml:"regcert"`             // public pem cert of registry
-	ClientCert    string            `json:"clientCert,omitempty" yaml:"clientCert"`       // public pem cert for client (mTLS)
-	ClientKey     string            `json:"clientKey,omitempty" yaml:"clientKey"`         // private pem cert for client (mTLS)
-	Hostname      string            `json:"hostname,omitempty" yaml:"hostname"`           // hostname of registry, default is the registry name
-	User          string            `json:"user,omitempty" yaml:"user"`                   // username, not used with credHelper
-	Pass          string            `json:"pass,omitempty" yaml:"pass"`                   // password, not used with credHelper
-	Token         string            `json:"token,omitempty" yaml:"token"`                 // token, experimental for specific APIs
-	CredHelper    string            `json:"credHelper,omitempty" yaml:"credHelper"`       // credential helper command for requesting logins
-	CredExpire    timejson.Duration `json:"credExpire,omitempty" yaml:"credExpire"`       // time until credential expires
-	CredHost      string            `json:"credHost,omitempty" yaml:"credHost"`           // used when a helper hostname doesn't match Hostname
-	PathPrefix    string            `json:"pathPrefix,omitempty" yaml:"pathPrefix"`       // used for mirrors defined within a repository namespace
-	Mirrors       []string          `json:"mirrors,omitempty" yaml:"mirrors"`             // list of other Host Names to use as mirrors
-	Priority      uint              `json:"priority,omitempty" yaml:"priority"`           // priority when sorting mirrors, higher priority attempted first
-	RepoAuth      bool              `json:"repoAuth,omitempty" yaml:"repoAuth"`           // tracks a separate auth per repo
-	API           string            `json:"api,omitempty" yaml:"api"`                     // Deprecated: registry API to use
-	APIOpts       map[string]string `json:"apiOpts,omitempty" yaml:"apiOpts"`             // options for APIs
-	BlobChunk     int64             `json:"blobChunk,omitempty" yaml:"blobChunk"`         // size of each blob chunk
-	BlobMax       int64             `json:"blobMax,omitempty" yaml:"blobMax"`             // threshold to switch to chunked upload, -1 to disable, 0 for regclient.blobMaxPut
-	ReqPerSec     float64           `json:"reqPerSec,omitempty" yaml:"reqPerSec"`         // requests per second
-	ReqConcurrent int64             `json:"reqConcurrent,omitempty" yaml:"reqConcurrent"` // concurrent requests, default is defaultConcurrent(3)
-	Scheme        string            `json:"scheme,omitempty" yaml:"scheme"`               // Deprecated: use TLS instead
-	credRefresh   time.Time         `json:"-" yaml:"-"`                                   // internal use, when to refresh credentials
+	Name             string            `json:"-" yaml:"registry,omitempty"`                        // Name of the registry (required) (yaml configs pass this as a field, json provides this from the object key)
+	TLS              TLSConf           `json:"tls,omitempty" yaml:"tls"`                           // TLS setting: enabled (default), disabled, insecure
+	RegCert          string            `json:"regcert,omitempty" yaml:"regcert"`                   // public pem cert of registry
+	PinnedCertSHA256 []string          `json:"pinnedCertSHA256,omitempty" yaml:"pinnedCertSHA256"` // sha256 fingerprints, one must match a certificate in the verified chain, or the leaf when insecure
+	ClientCert       string            `json:"clientCert,omitempty" yaml:"clientCert"`             // public pem cert for client (mTLS)
+	ClientKey        string            `json:"clientKey,omitempty" yaml:"clientKey"`               // private pem cert for client (mTLS)
+	Hostname         string            `json:"hostname,omitempty" yaml:"hostname"`                 // hostname of registry, default is the registry name
+	User             string            `json:"user,omitempty" yaml:"user"`                         // username, not used with credHelper
+	Pass             string            `json:"pass,omitempty" yaml:"pass"`                         // password, not used with credHelper
+	Token            string            `json:"token,omitempty" yaml:"token"`                       // token, experimental for specific APIs
+	CredHelper       string            `json:"credHelper,omitempty" yaml:"credHelper"`             // credential helper command for requesting logins
+	CredExpire       timejson.Duration `json:"credExpire,omitempty" yaml:"credExpire"`             // time until credential expires
+	CredHost         string            `json:"credHost,omitempty" yaml:"credHost"`                 // used when a helper hostname doesn't match Hostname
+	Anonymous        bool              `json:"anonymous,omitempty" yaml:"anonymous"`               // ignore any credentials and only use anonymous access
+	PathPrefix       string            `json:"pathPrefix,omitempty" yaml:"pathPrefix"`             // used for mirrors defined within a repository namespace
+	Mirrors          []string          `json:"mirrors,omitempty" yaml:"mirrors"`                   // list of other Host Names to use as mirrors
+	Priority         uint              `json:"priority,omitempty" yaml:"priority"`                 // priority when sorting mirrors, lower values attempted first
+	DefaultPlatform  string            `json:"defaultPlatform,omitempty" yaml:"defaultPlatform"`   // platform used when a command does not specify one
+	RepoAuth         bool              `json:"repoAuth,omitempty" yaml:"repoAuth"`                 // tracks a separate auth per repo
+	RedirectDeny     bool              `json:"redirectDeny,omitempty" yaml:"redirectDeny"`         // fail on redirects to a different host instead of following them without auth
+	API              string            `json:"api,omitempty" yaml:"api"`                           // Deprecated: registry API to use
+	APIOpts          map[string]string `json:"apiOpts,omitempty" yaml:"apiOpts"`                   // options for APIs
+	Headers          map[string]string `json:"headers,omitempty" yaml:"headers"`                   // headers added to every request, Authorization and Host cannot be set
+	BlobChunk        int64             `json:"blobChunk,omitempty" yaml:"blobChunk"`               // size of each blob chunk
+	BlobMax          int64             `json:"blobMax,omitempty" yaml:"blobMax"`                   // threshold to switch to chunked upload, -1 to disable, 0 for regclient.blobMaxPut
+	ReqPerSec        float64           `json:"reqPerSec,omitempty" yaml:"reqPerSec"`               // requests per second
+	ReqConcurrent    int64             `json:"reqConcurrent,omitempty" yaml:"reqConcurrent"`       // concurrent requests, default is defaultConcurrent(3)
+	ReqTimeout       timejson.Duration `json:"reqTimeout,omitempty" yaml:"reqTimeout"`             // limit for each request attempt including the response body, retries start a new limit
+	RetryBackoff     float64           `json:"retryBackoff,omitempty" yaml:"retryBackoff"`         // multiplier of the delay for each retry, default is 2
+	RetryJitter      float64           `json:"retryJitter,omitempty" yaml:"retryJitter"`           // fraction of the retry delay that is randomized, 0 to 1
+	Scheme           string            `json:"scheme,omitempty" yaml:"scheme"`                     // Deprecated: use TLS instead
+	credRefresh      time.Time         `json:"-" yaml:"-"`                                         // internal use, when to refresh credentials
 }
 
 // Cred defines a user credential for accessing a registry.
@@ -168,11 +177,19 @@ func HostNewDefName(def *Host, name string) *Host {
 				h.APIOpts[k] = v
 			}
 		}
+		if len(h.Headers) > 0 {
+			h.Headers = copyMapString(h.Headers)
+		}
 		if h.Mirrors != nil {
 			orig := h.Mirrors
 			h.Mirrors = make([]string, len(orig))
 			copy(h.Mirrors, orig)
 		}
+		if h.PinnedCertSHA256 != nil {
+			orig := h.PinnedCertSHA256
+			h.PinnedCertSHA256 = make([]string, len(orig))
+			copy(h.PinnedCertSHA256, orig)
+		}
 	}
 	// configure host
 	origName := name
@@ -211,7 +228,11 @@ func HostNewName(name string) *Host {
 }
 
 // GetCred returns the credential, fetching from a credential helper if needed.
+// An empty credential is returned when the host is configured for anonymous access.
 func (host *Host) GetCred() Cred {
+	if host.Anonymous {
+		return Cred{}
+	}
 	// refresh from credHelper if needed
 	if host.CredHelper != "" && (host.credRefresh.IsZero() || time.Now().After(host.credRefresh)) {
 		host.refreshHelper()
@@ -219,6 +240,21 @@ func (host *Host) GetCred() Cred {
 	return Cred{User: host.User, Password: host.Pass, Token: host.Token}
 }
 
+// CredStore saves a credential with the credential helper of the host.
+// The next call to [Host.GetCred] will fetch the updated credential from the helper.
+func (host *Host) CredStore(cred Cred) error {
+	if host.CredHelper == "" {
+		return fmt.Errorf("credential helper is not configured for %s", host.Name)
+	}
+	ch := newCredHelper(host.CredHelper, map[string]string{})
+	err := ch.store(host, cred)
+	if err != nil {
+		return err
+	}
+	host.credRefresh = time.Time{}
+	return nil
+}
+
 func (host *Host) refreshHelper() {
 	if host.CredHelper == "" {
 		return
@@ -241,6 +277,7 @@ func (host Host) IsZero() bool {
 	if host.Name != "" ||
 		(host.TLS != TLSUndefined && host.TLS != TLSEnabled) ||
 		host.RegCert != "" ||
+		len(host.PinnedCertSHA256) != 0 ||
 		host.ClientCert != "" ||
 		host.ClientKey != "" ||
 		host.Hostname != "" ||
@@ -250,15 +287,22 @@ func (host Host) IsZero() bool {
 		host.CredHelper != "" ||
 		host.CredExpire != 0 ||
 		host.CredHost != "" ||
+		host.Anonymous ||
 		host.PathPrefix != "" ||
 		len(host.Mirrors) != 0 ||
 		host.Priority != 0 ||
+		host.DefaultPlatform != "" ||
 		host.RepoAuth ||
+		host.RedirectDeny ||
 		len(host.APIOpts) != 0 ||
+		len(host.Headers) != 0 ||
 		host.BlobChunk != 0 ||
 		host.BlobMax != 0 ||
 		(host.ReqPerSec != 0 && host.ReqPerSec != float64(defaultReqPerSec)) ||
 		(host.ReqConcurrent != 0 && host.ReqConcurrent != int64(defaultConcurrent)) ||
+		host.ReqTimeout != 0 ||
+		host.RetryBackoff != 0 ||
+		host.RetryJitter != 0 ||
 		!host.credRefresh.IsZero() {
 		return false
 	}
@@ -371,6 +415,16 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.RegCert = newHost.RegCert
 	}
 
+	if len(newHost.PinnedCertSHA256) > 0 {
+		if len(host.PinnedCertSHA256) > 0 && !stringSliceEq(host.PinnedCertSHA256, newHost.PinnedCertSHA256) {
+			log.Warn("Changing pinned certificate settings for registry",
+				slog.Any("orig", host.PinnedCertSHA256),
+				slog.Any("new", newHost.PinnedCertSHA256),
+				slog.String("host", name))
+		}
+		host.PinnedCertSHA256 = newHost.PinnedCertSHA256
+	}
+
 	if newHost.ClientCert != "" {
 		if host.ClientCert != "" && host.ClientCert != newHost.ClientCert {
 			log.Warn("Changing client certificate settings for registry",
@@ -430,10 +484,28 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.Priority = newHost.Priority
 	}
 
+	if newHost.DefaultPlatform != "" {
+		if host.DefaultPlatform != "" && host.DefaultPlatform != newHost.DefaultPlatform {
+			log.Warn("Changing default platform settings for registry",
+				slog.String("orig", host.DefaultPlatform),
+				slog.String("new", newHost.DefaultPlatform),
+				slog.String("host", name))
+		}
+		host.DefaultPlatform = newHost.DefaultPlatform
+	}
+
+	if newHost.Anonymous {
+		host.Anonymous = newHost.Anonymous
+	}
+
 	if newHost.RepoAuth {
 		host.RepoAuth = newHost.RepoAuth
 	}
 
+	if newHost.RedirectDeny {
+		host.RedirectDeny = newHost.RedirectDeny
+	}
+
 	// TODO: eventually delete
 	if newHost.API != "" {
 		log.Warn("API field has been deprecated",
@@ -460,6 +532,27 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		}
 	}
 
+	if len(newHost.Headers) > 0 {
+		merged := copyMapString(host.Headers)
+		for k, v := range newHost.Headers {
+			if HeaderReserved(k) {
+				log.Warn("Ignoring reserved header for registry",
+					slog.String("header", k),
+					slog.String("host", name))
+				continue
+			}
+			if host.Headers[k] != "" && host.Headers[k] != v {
+				log.Warn("Changing header setting for registry",
+					slog.String("orig", host.Headers[k]),
+					slog.String("new", v),
+					slog.String("header", k),
+					slog.String("host", name))
+			}
+			merged[k] = v
+		}
+		host.Headers = merged
+	}
+
 	if newHost.BlobChunk > 0 {
 		if host.BlobChunk != 0 && host.BlobChunk != newHost.BlobChunk {
 			log.Warn("Changing blobChunk settings for registry",
@@ -500,9 +593,58 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.ReqConcurrent = newHost.ReqConcurrent
 	}
 
+	if newHost.ReqTimeout != 0 {
+		if host.ReqTimeout != 0 && host.ReqTimeout != newHost.ReqTimeout {
+			log.Warn("Changing reqTimeout settings for registry",
+				slog.Any("orig", host.ReqTimeout),
+				slog.Any("new", newHost.ReqTimeout),
+				slog.String("host", name))
+		}
+		host.ReqTimeout = newHost.ReqTimeout
+	}
+
+	if newHost.RetryBackoff != 0 {
+		if host.RetryBackoff != 0 && host.RetryBackoff != newHost.RetryBackoff {
+			log.Warn("Changing retryBackoff settings for registry",
+				slog.Float64("orig", host.RetryBackoff),
+				slog.Float64("new", newHost.RetryBackoff),
+				slog.String("host", name))
+		}
+		host.RetryBackoff = newHost.RetryBackoff
+	}
+
+	if newHost.RetryJitter != 0 {
+		if host.RetryJitter != 0 && host.RetryJitter != newHost.RetryJitter {
+			log.Warn("Changing retryJitter settings for registry",
+				slog.Float64("orig", host.RetryJitter),
+				slog.Float64("new", newHost.RetryJitter),
+				slog.String("host", name))
+		}
+		host.RetryJitter = newHost.RetryJitter
+	}
+
 	return nil
 }
 
+// HostLess reports whether host a is attempted before host b when sorting the mirrors of the upstream host.
+// Lower priorities are attempted first, and the upstream is attempted after any mirror with the same priority.
+// With a stable sort, mirrors with the same priority keep the order they are listed.
+func HostLess(a, b *Host, upstream string) bool {
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.Name != upstream && b.Name == upstream
+}
+
+// HeaderReserved returns true for headers managed by regclient that cannot be set in Headers.
+func HeaderReserved(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Host":
+		return true
+	}
+	return false
+}
+
 func copyMapString(src map[string]string) map[string]string {
 	copy := map[string]string{}
 	for k, v := range src {
@@ -117,6 +117,7 @@ func TestConfig(t *testing.T) {
 		"pathPrefix": "hub",
 		"mirrors": ["host1.example.com","host2.example.com"],
 		"priority": 42,
+		"defaultPlatform": "linux/arm64",
 		"apiOpts": {"disableHead": "true"},
 		"blobChunk": 123456,
 		"blobMax": 999999
@@ -134,9 +135,11 @@ func TestConfig(t *testing.T) {
 		"pathPrefix": "hub3",
 		"mirrors": ["testhost.example.com"],
 		"priority": 42,
+		"defaultPlatform": "linux/amd64",
 		"apiOpts": {"disableHead": "false", "unknownOpt": "3"},
 		"blobChunk": 333333,
-		"blobMax": 333333
+		"blobMax": 333333,
+		"reqTimeout": "30s"
 	}
 	`
 	exJSONCredHelper := `
@@ -187,6 +190,11 @@ func TestConfig(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to merge ex cred helper with host: %v", err)
 	}
+	exMergeAnonymous := exMergeHelperHost
+	err = (&exMergeAnonymous).Merge(Host{Name: exMergeHelperHost.Name, Anonymous: true}, nil)
+	if err != nil {
+		t.Errorf("failed to merge anonymous with host: %v", err)
+	}
 
 	// verify fields in each
 	tt := []struct {
@@ -262,16 +270,17 @@ func TestConfig(t *testing.T) {
 			name: "exHost",
 			host: exHost,
 			hostExpect: Host{
-				TLS:        TLSEnabled,
-				Hostname:   "host.example.com",
-				User:       "user-ex",
-				Pass:       "secret",
-				Priority:   42,
-				BlobChunk:  123456,
-				BlobMax:    999999,
-				APIOpts:    map[string]string{"disableHead": "true"},
-				PathPrefix: "hub",
-				Mirrors:    []string{"host1.example.com", "host2.example.com"},
+				TLS:             TLSEnabled,
+				Hostname:        "host.example.com",
+				User:            "user-ex",
+				Pass:            "secret",
+				Priority:        42,
+				DefaultPlatform: "linux/arm64",
+				BlobChunk:       123456,
+				BlobMax:         999999,
+				APIOpts:         map[string]string{"disableHead": "true"},
+				PathPrefix:      "hub",
+				Mirrors:         []string{"host1.example.com", "host2.example.com"},
 			},
 			credExpect: Cred{
 				User:     "user-ex",
@@ -282,19 +291,21 @@ func TestConfig(t *testing.T) {
 			name: "exHost2",
 			host: exHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:             TLSDisabled,
+				Hostname:        "host2.example.com",
+				User:            "user-ex3",
+				Pass:            "secret3",
+				RegCert:         caCert,
+				ClientCert:      clientCert,
+				ClientKey:       clientKey,
+				PathPrefix:      "hub3",
+				Mirrors:         []string{"testhost.example.com"},
+				Priority:        42,
+				DefaultPlatform: "linux/amd64",
+				APIOpts:         map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				BlobChunk:       333333,
+				BlobMax:         333333,
+				ReqTimeout:      timejson.Duration(30 * time.Second),
 			},
 			credExpect: Cred{
 				User:     "user-ex3",
@@ -320,16 +331,17 @@ func TestConfig(t *testing.T) {
 			name: "mergeBlank",
 			host: exMergeBlank,
 			hostExpect: Host{
-				TLS:        TLSEnabled,
-				Hostname:   "host.example.com",
-				User:       "user-ex",
-				Pass:       "secret",
-				Priority:   42,
-				BlobChunk:  123456,
-				BlobMax:    999999,
-				APIOpts:    map[string]string{"disableHead": "true"},
-				PathPrefix: "hub",
-				Mirrors:    []string{"host1.example.com", "host2.example.com"},
+				TLS:             TLSEnabled,
+				Hostname:        "host.example.com",
+				User:            "user-ex",
+				Pass:            "secret",
+				Priority:        42,
+				DefaultPlatform: "linux/arm64",
+				BlobChunk:       123456,
+				BlobMax:         999999,
+				APIOpts:         map[string]string{"disableHead": "true"},
+				PathPrefix:      "hub",
+				Mirrors:         []string{"host1.example.com", "host2.example.com"},
 			},
 			credExpect: Cred{
 				User:     "user-ex",
@@ -340,19 +352,21 @@ func TestConfig(t *testing.T) {
 			name: "mergeHost2",
 			host: exMergeHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:             TLSDisabled,
+				Hostname:        "host2.example.com",
+				User:            "user-ex3",
+				Pass:            "secret3",
+				RegCert:         caCert,
+				ClientCert:      clientCert,
+				ClientKey:       clientKey,
+				PathPrefix:      "hub3",
+				Mirrors:         []string{"testhost.example.com"},
+				Priority:        42,
+				DefaultPlatform: "linux/amd64",
+				APIOpts:         map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				BlobChunk:       333333,
+				BlobMax:         333333,
+				ReqTimeout:      timejson.Duration(30 * time.Second),
 			},
 			credExpect: Cred{
 				User:     "user-ex3",
@@ -378,16 +392,17 @@ func TestConfig(t *testing.T) {
 			name: "exMergeHostHelper",
 			host: exMergeHostHelper,
 			hostExpect: Host{
-				TLS:        TLSInsecure,
-				Hostname:   "testhost.example.com",
-				CredHelper: "docker-credential-test",
-				CredExpire: timejson.Duration(time.Hour),
-				Priority:   42,
-				BlobChunk:  123456,
-				BlobMax:    999999,
-				APIOpts:    map[string]string{"disableHead": "true"},
-				PathPrefix: "hub",
-				Mirrors:    []string{"host1.example.com", "host2.example.com"},
+				TLS:             TLSInsecure,
+				Hostname:        "testhost.example.com",
+				CredHelper:      "docker-credential-test",
+				CredExpire:      timejson.Duration(time.Hour),
+				Priority:        42,
+				DefaultPlatform: "linux/arm64",
+				BlobChunk:       123456,
+				BlobMax:         999999,
+				APIOpts:         map[string]string{"disableHead": "true"},
+				PathPrefix:      "hub",
+				Mirrors:         []string{"host1.example.com", "host2.example.com"},
 			},
 			credExpect: Cred{
 				User:     "hello",
@@ -398,22 +413,42 @@ func TestConfig(t *testing.T) {
 			name: "exMergeHelperHost",
 			host: exMergeHelperHost,
 			hostExpect: Host{
-				TLS:        TLSEnabled,
-				Hostname:   "host.example.com",
-				User:       "user-ex",
-				Pass:       "secret",
-				Priority:   42,
-				BlobChunk:  123456,
-				BlobMax:    999999,
-				APIOpts:    map[string]string{"disableHead": "true"},
-				PathPrefix: "hub",
-				Mirrors:    []string{"host1.example.com", "host2.example.com"},
+				TLS:             TLSEnabled,
+				Hostname:        "host.example.com",
+				User:            "user-ex",
+				Pass:            "secret",
+				Priority:        42,
+				DefaultPlatform: "linux/arm64",
+				BlobChunk:       123456,
+				BlobMax:         999999,
+				APIOpts:         map[string]string{"disableHead": "true"},
+				PathPrefix:      "hub",
+				Mirrors:         []string{"host1.example.com", "host2.example.com"},
 			},
 			credExpect: Cred{
 				User:     "user-ex",
 				Password: "secret",
 			},
 		},
+		{
+			name: "exMergeAnonymous",
+			host: exMergeAnonymous,
+			hostExpect: Host{
+				TLS:             TLSEnabled,
+				Hostname:        "host.example.com",
+				User:            "user-ex",
+				Pass:            "secret",
+				Anonymous:       true,
+				Priority:        42,
+				DefaultPlatform: "linux/arm64",
+				BlobChunk:       123456,
+				BlobMax:         999999,
+				APIOpts:         map[string]string{"disableHead": "true"},
+				PathPrefix:      "hub",
+				Mirrors:         []string{"host1.example.com", "host2.example.com"},
+			},
+			credExpect: Cred{},
+		},
 	}
 
 	for _, tc := range tt {
@@ -454,18 +489,27 @@ func TestConfig(t *testing.T) {
 			if tc.host.CredExpire != tc.hostExpect.CredExpire {
 				t.Errorf("credExCredExpire field mismatch, expected %s, found %s", time.Duration(tc.hostExpect.CredExpire).String(), time.Duration(tc.host.CredExpire).String())
 			}
+			if tc.host.Anonymous != tc.hostExpect.Anonymous {
+				t.Errorf("anonymous field mismatch, expected %t, found %t", tc.hostExpect.Anonymous, tc.host.Anonymous)
+			}
 			if tc.host.PathPrefix != tc.hostExpect.PathPrefix {
 				t.Errorf("pathPrefix field mismatch, expected %s, found %s", tc.hostExpect.PathPrefix, tc.host.PathPrefix)
 			}
 			if tc.host.Priority != tc.hostExpect.Priority {
 				t.Errorf("priority field mismatch, expected %d, found %d", tc.hostExpect.Priority, tc.host.Priority)
 			}
+			if tc.host.DefaultPlatform != tc.hostExpect.DefaultPlatform {
+				t.Errorf("defaultPlatform field mismatch, expected %s, found %s", tc.hostExpect.DefaultPlatform, tc.host.DefaultPlatform)
+			}
 			if tc.host.BlobChunk != tc.hostExpect.BlobChunk {
 				t.Errorf("blobChunk field mismatch, expected %d, found %d", tc.hostExpect.BlobChunk, tc.host.BlobChunk)
 			}
 			if tc.host.BlobMax != tc.hostExpect.BlobMax {
 				t.Errorf("blobMax field mismatch, expected %d, found %d", tc.hostExpect.BlobMax, tc.host.BlobMax)
 			}
+			if tc.host.ReqTimeout != tc.hostExpect.ReqTimeout {
+				t.Errorf("reqTimeout field mismatch, expected %s, found %s", time.Duration(tc.hostExpect.ReqTimeout).String(), time.Duration(tc.host.ReqTimeout).String())
+			}
 			if len(tc.host.Mirrors) != len(tc.hostExpect.Mirrors) {
 				t.Errorf("mirrors length mismatch, expected %v, found %v", tc.hostExpect.Mirrors, tc.host.Mirrors)
 			} else {
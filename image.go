@@ -10,7 +10,9 @@ import (
 	"io"
 	"log/slog"
 	"net/url"
+	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +23,9 @@ import (
 
 	digest "github.com/opencontainers/go-digest"
 
+	"github.com/regclient/regclient/internal/blobcache"
+	"github.com/regclient/regclient/internal/pqueue"
+	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
@@ -43,6 +48,10 @@ const (
 	ociLayoutFilename      = "oci-layout"
 	annotationRefName      = "org.opencontainers.image.ref.name"
 	annotationImageName    = "io.containerd.image.name"
+	dockerReferenceType    = "vnd.docker.reference.type"
+	dockerReferenceDigest  = "vnd.docker.reference.digest"
+	// imageCopyMaxDepthDefault limits nested manifests in a copy when ImageWithMaxDepth is not set
+	imageCopyMaxDepthDefault = 32
 )
 
 // used by import/export to match docker tar expected format
@@ -58,6 +67,8 @@ type tarFileHandler func(header *tar.Header, trd *tarReadData) error
 type tarReadData struct {
 	tr          *tar.Reader
 	name        string
+	importAll   bool
+	importFn    func(ref.Ref)
 	handleAdded bool
 	handlers    map[string]tarFileHandler
 	links       map[string][]string
@@ -81,27 +92,78 @@ type tarWriteData struct {
 }
 
 type imageOpt struct {
+	blobCache       BlobCache
 	callback        func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
 	checkBaseDigest string
 	checkBaseRef    string
 	checkSkipConfig bool
 	child           bool
+	concurrency     int
+	blobQueue       *pqueue.Queue[reqmeta.Data]
+	descRewrite     func(descriptor.Descriptor) descriptor.Descriptor
+	dryRun          bool
 	exportCompress  bool
 	exportRef       ref.Ref
 	fastCheck       bool
 	forceRecursive  bool
+	importAll       bool
+	importFn        func(ref.Ref)
 	importName      string
 	includeExternal bool
 	digestTags      bool
+	digestTagsRefs  bool
+	maxDepth        int
+	noMount         bool
 	platform        string
+	platformFilter  []platform.Platform
 	platforms       []string
 	referrerConfs   []scheme.ReferrerConfig
+	referrerDeep    bool
+	referrerDepth   int
+	referrerGraph   map[digest.Digest]bool
+	referrerLevel   map[digest.Digest]int
 	referrerSrc     ref.Ref
 	referrerTgt     ref.Ref
+	srcManifest     manifest.Manifest
+	srcCache        *imageSrcCache
 	tagList         []string
 	mu              sync.Mutex
 	seen            map[string]*imageSeen
+	rewritten       map[digest.Digest]descriptor.Descriptor
 	finalFn         []func(context.Context) error
+	result          *ImageCopyResult
+}
+
+// ImageCopyAction describes how a manifest or blob was handled by an image copy.
+type ImageCopyAction string
+
+const (
+	// ImageCopyCopied indicates the content was pushed to the target.
+	ImageCopyCopied ImageCopyAction = "copied"
+	// ImageCopyMounted indicates a blob was mounted from the source repository on the same registry.
+	ImageCopyMounted ImageCopyAction = "mounted"
+	// ImageCopySkipped indicates the content already existed on the target.
+	ImageCopySkipped ImageCopyAction = "skipped"
+	// ImageCopyFailed indicates the copy failed, see [ImageCopyEntry.Err] for details.
+	ImageCopyFailed ImageCopyAction = "failed"
+	// ImageCopyPending indicates the content is missing on the target and would be copied, see [ImageWithDryRun].
+	ImageCopyPending ImageCopyAction = "pending"
+)
+
+// ImageCopyResult contains the manifests and blobs processed by [RegClient.ImageCopyDetailed].
+// Entries are listed in the order each copy completed.
+type ImageCopyResult struct {
+	Manifests []ImageCopyEntry `json:"manifests"`
+	Blobs     []ImageCopyEntry `json:"blobs"`
+}
+
+// ImageCopyEntry is the result of processing a single manifest or blob.
+// The duration of a manifest includes the time to copy any content it references.
+type ImageCopyEntry struct {
+	Descriptor descriptor.Descriptor `json:"descriptor"`
+	Action     ImageCopyAction       `json:"action"`
+	Duration   time.Duration         `json:"duration"`
+	Err        error                 `json:"-"`
 }
 
 type imageSeen struct {
@@ -109,9 +171,59 @@ type imageSeen struct {
 	err  error
 }
 
+// imageSrcCache shares the source manifests between the concurrent copies of [RegClient.ImageCopyMulti].
+type imageSrcCache struct {
+	mu      sync.Mutex
+	entries map[digest.Digest]*imageSrcEntry
+}
+
+type imageSrcEntry struct {
+	done chan struct{}
+	m    manifest.Manifest
+	err  error
+}
+
+// get returns the cached manifest for the digest, calling fn once to pull the manifest on the first request.
+// A nil cache or an empty digest always calls fn.
+func (c *imageSrcCache) get(ctx context.Context, dig digest.Digest, fn func() (manifest.Manifest, error)) (manifest.Manifest, error) {
+	if c == nil || dig == "" {
+		return fn()
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[dig]
+	if !ok {
+		entry = &imageSrcEntry{done: make(chan struct{})}
+		c.entries[dig] = entry
+		c.mu.Unlock()
+		entry.m, entry.err = fn()
+		if entry.err != nil {
+			// allow a later copy to retry the request
+			c.mu.Lock()
+			delete(c.entries, dig)
+			c.mu.Unlock()
+		}
+		close(entry.done)
+		return entry.m, entry.err
+	}
+	c.mu.Unlock()
+	select {
+	case <-entry.done:
+		return entry.m, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // ImageOpts define options for the Image* commands.
 type ImageOpts func(*imageOpt)
 
+// ImageWithBlobCache reuses blobs from a local cache when copying, see [BlobWithCache].
+func ImageWithBlobCache(cache BlobCache) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.blobCache = cache
+	}
+}
+
 // ImageWithCallback provides progress data to a callback function.
 func ImageWithCallback(callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)) ImageOpts {
 	return func(opts *imageOpt) {
@@ -147,6 +259,35 @@ func ImageWithChild() ImageOpts {
 	}
 }
 
+// ImageWithConcurrency limits the number of concurrent blob copies in ImageCopy.
+// The limit applies to the entire copy, including every platform of an index and referrers.
+// Requests to each registry are also limited by the ReqConcurrent setting of the host.
+// Values less than or equal to 0 leave the copy limited only by the host setting.
+func ImageWithConcurrency(n int) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.concurrency = n
+	}
+}
+
+// ImageWithDescriptorRewrite calls fn on each descriptor in a manifest during an ImageCopy, with the returned descriptor written to the target manifest.
+// This may be used to change media types, remove external URLs, or add annotations and data.
+// Blobs are copied unchanged, so the digest and size of a config or layer must not be modified.
+// When a manifest is changed, it is pushed to the target by its new digest, and any index referencing it is updated.
+// Referrers and digest tags of a changed manifest continue to reference the source digest.
+func ImageWithDescriptorRewrite(fn func(d descriptor.Descriptor) descriptor.Descriptor) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.descRewrite = fn
+	}
+}
+
+// ImageWithDryRun checks the target for each manifest and blob without pushing any content.
+// Use [RegClient.ImageCopyDetailed] to get the content that would be copied, reported with [ImageCopyPending].
+func ImageWithDryRun() ImageOpts {
+	return func(opts *imageOpt) {
+		opts.dryRun = true
+	}
+}
+
 // ImageWithExportCompress adds gzip compression to tar export output in ImageExport.
 func ImageWithExportCompress() ImageOpts {
 	return func(opts *imageOpt) {
@@ -175,6 +316,17 @@ func ImageWithForceRecursive() ImageOpts {
 	}
 }
 
+// ImageWithImportAll imports every tagged manifest from the index.json of an OCI layout in ImageImport.
+// Each entry with an "org.opencontainers.image.ref.name" annotation is pushed to the repository of the reference with that tag.
+// Entries with a missing or invalid tag annotation are skipped with a warning.
+// When fn is not nil, it is called with the reference of each tag after it is pushed.
+func ImageWithImportAll(fn func(r ref.Ref)) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.importAll = true
+		opts.importFn = fn
+	}
+}
+
 // ImageWithImportName selects the name of the image to import when multiple images are included in ImageImport.
 func ImageWithImportName(name string) ImageOpts {
 	return func(opts *imageOpt) {
@@ -189,6 +341,16 @@ func ImageWithIncludeExternal() ImageOpts {
 	}
 }
 
+// ImageWithMaxDepth limits how deeply nested manifests are followed in ImageCopy.
+// The depth increases with each index entry, referrer, and digest tag below the copied image.
+// Copies that exceed the limit fail rather than following a malicious or looping graph.
+// Values less than or equal to 0 use the default limit of 32.
+func ImageWithMaxDepth(n int) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.maxDepth = n
+	}
+}
+
 // ImageWithDigestTags looks for "sha-<digest>.*" tags in the repo to copy with any manifest in ImageCopy.
 // These are used by some artifact systems like sigstore/cosign.
 func ImageWithDigestTags() ImageOpts {
@@ -197,6 +359,25 @@ func ImageWithDigestTags() ImageOpts {
 	}
 }
 
+// ImageWithDigestTagsAsReferrers converts "sha-<digest>.*" tags to referrers of the manifest in ImageCopy.
+// Each digest tag is pushed as an OCI manifest with a subject pointing to the copied manifest, and the tag is not copied.
+// The artifact type is set from the tag suffix, e.g. ".sig" becomes "application/vnd.dev.cosign.artifact.sig.v1+json".
+// The "sha-<digest>" tag used by the referrers fallback is skipped, see [ImageWithReferrers] to copy those referrers.
+// This is used to migrate artifacts from the sigstore/cosign tag schema to the OCI referrers API.
+func ImageWithDigestTagsAsReferrers() ImageOpts {
+	return func(opts *imageOpt) {
+		opts.digestTags = true
+		opts.digestTagsRefs = true
+	}
+}
+
+// ImageWithoutMount disables cross repository blob mounts when copying blobs, always uploading the full blob content.
+func ImageWithoutMount() ImageOpts {
+	return func(opts *imageOpt) {
+		opts.noMount = true
+	}
+}
+
 // ImageWithPlatform requests specific platforms from a manifest list in ImageCheckBase.
 func ImageWithPlatform(p string) ImageOpts {
 	return func(opts *imageOpt) {
@@ -213,6 +394,16 @@ func ImageWithPlatforms(p []string) ImageOpts {
 	}
 }
 
+// ImageWithPlatformFilter only copies the matching platforms from a manifest list in ImageCopy.
+// The target index is rebuilt to reference the copied platforms, which changes the digest.
+// Attestations with a "vnd.docker.reference.digest" annotation are kept when they reference a copied platform,
+// and entries without a platform are not filtered.
+func ImageWithPlatformFilter(p []platform.Platform) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.platformFilter = p
+	}
+}
+
 // ImageWithReferrers recursively recursively includes referrer images in ImageCopy.
 func ImageWithReferrers(rOpts ...scheme.ReferrerOpts) ImageOpts {
 	return func(opts *imageOpt) {
@@ -227,6 +418,29 @@ func ImageWithReferrers(rOpts ...scheme.ReferrerOpts) ImageOpts {
 	}
 }
 
+// ImageWithReferrersDeep copies the full graph of referrers, including referrers of referrers, in ImageCopy.
+// Filters from [ImageWithReferrers] only select the referrers of the copied image,
+// every referrer of a selected referrer is copied.
+// This requires a referrers query for every manifest in the graph, even when the target is up to date.
+func ImageWithReferrersDeep() ImageOpts {
+	return func(opts *imageOpt) {
+		if opts.referrerConfs == nil {
+			opts.referrerConfs = []scheme.ReferrerConfig{}
+		}
+		opts.referrerDeep = true
+	}
+}
+
+// ImageWithReferrerDepth limits the levels of referrers copied in ImageCopy.
+// Referrers of the copied image are level 1, their referrers are level 2,
+// and the entries of a referrer index are at the same level as the index.
+// Values less than or equal to 0 do not limit the referrers beyond [ImageWithMaxDepth].
+func ImageWithReferrerDepth(n int) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.referrerDepth = n
+	}
+}
+
 // ImageWithReferrerSrc specifies an alternate repository to pull referrers from.
 func ImageWithReferrerSrc(src ref.Ref) ImageOpts {
 	return func(opts *imageOpt) {
@@ -241,6 +455,15 @@ func ImageWithReferrerTgt(tgt ref.Ref) ImageOpts {
 	}
 }
 
+// ImageWithSourceManifest provides the source manifest to ImageCopy, skipping the request to get it from the source.
+// Blobs and child manifests are still copied from the source repository.
+// The digest of the manifest must match the digest of the source reference when one is set.
+func ImageWithSourceManifest(m manifest.Manifest) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.srcManifest = m
+	}
+}
+
 // ImageCheckBase returns nil if the base image is unchanged.
 // A base image mismatch returns an error that wraps errs.ErrMismatch.
 func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...ImageOpts) error {
@@ -509,15 +732,129 @@ func (rc *RegClient) ImageConfig(ctx context.Context, r ref.Ref, opts ...ImageOp
 // This will retag an image in the same repository, only pushing and pulling the top level manifest.
 // On the same registry, it will attempt to use cross-repository blob mounts to avoid pulling blobs.
 // Blobs are only pulled when they don't exist on the target and a blob mount fails.
+// Each blob is copied once per target repository, even when it is shared between platforms of an index.
+// Between two OCI Layouts, blobs are hardlinked when possible, falling back to a file copy.
+// The order of entries in an index is preserved, leaving the digest unchanged unless descriptors are rewritten.
 // Referrers are optionally copied recursively.
 func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) error {
+	return rc.imageCopy(ctx, refSrc, refTgt, nil, opts...)
+}
+
+// ImageCopyDetailed copies an image the same as [RegClient.ImageCopy] and returns the result of each manifest and blob processed.
+// The result is returned with any entries processed before an error.
+func (rc *RegClient) ImageCopyDetailed(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) (*ImageCopyResult, error) {
+	result := &ImageCopyResult{
+		Manifests: []ImageCopyEntry{},
+		Blobs:     []ImageCopyEntry{},
+	}
+	err := rc.imageCopy(ctx, refSrc, refTgt, result, opts...)
+	return result, err
+}
+
+// ImageCopyTargetError is returned by [RegClient.ImageCopyMulti] for each target that failed.
+type ImageCopyTargetError struct {
+	Target ref.Ref
+	Err    error
+}
+
+func (e *ImageCopyTargetError) Error() string {
+	return fmt.Sprintf("failed to copy to %s: %v", e.Target.CommonName(), e.Err)
+}
+
+func (e *ImageCopyTargetError) Unwrap() error {
+	return e.Err
+}
+
+// imageCopyMultiCacheSize is the size limit of the temporary blob cache used by [RegClient.ImageCopyMulti].
+const imageCopyMultiCacheSize = 1024 * 1024 * 1024
+
+// ImageCopyMulti copies an image from one source to multiple targets.
+// The targets are copied concurrently, and the limit from [ImageWithConcurrency] applies to the blob copies of every target combined.
+// Source manifests are pulled once and shared between the targets.
+// Blobs pulled from the source are stored in a temporary cache so each blob is normally only read from the source once.
+// The temporary cache is limited to 1GiB, removing the least recently used blobs, which may need to be pulled again by a slower target.
+// The cache from [ImageWithBlobCache] is used instead of the temporary cache when provided.
+// A failure copying to one target does not stop the copy to the remaining targets.
+// The returned error joins an [ImageCopyTargetError] for each failed target.
+func (rc *RegClient) ImageCopyMulti(ctx context.Context, refSrc ref.Ref, refTgts []ref.Ref, opts ...ImageOpts) error {
+	if len(refTgts) == 0 {
+		return fmt.Errorf("no targets provided to copy %s%.0w", refSrc.CommonName(), errs.ErrInvalidReference)
+	}
+	opt := imageOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	opts = opts[:len(opts):len(opts)]
+	if opt.blobCache == nil && len(refTgts) > 1 {
+		dir, err := os.MkdirTemp(rc.tmpDir, "regclient-copy-")
+		if err != nil {
+			return fmt.Errorf("failed to create blob cache: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		cache, err := blobcache.New(dir, imageCopyMultiCacheSize)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, ImageWithBlobCache(cache))
+	}
+	// pull the source manifest once for every target
+	if opt.srcManifest == nil {
+		m, err := rc.ManifestGet(ctx, refSrc)
+		if err != nil {
+			return fmt.Errorf("failed to get source %s: %w", refSrc.CommonName(), err)
+		}
+		opts = append(opts, ImageWithSourceManifest(m))
+	}
+	srcCache := &imageSrcCache{entries: map[digest.Digest]*imageSrcEntry{}}
+	var blobQueue *pqueue.Queue[reqmeta.Data]
+	if opt.concurrency > 0 {
+		blobQueue = pqueue.New(pqueue.Opts[reqmeta.Data]{Max: opt.concurrency})
+	}
+	opts = append(opts, func(opt *imageOpt) {
+		opt.srcCache = srcCache
+		opt.blobQueue = blobQueue
+	})
+	errList := make([]error, len(refTgts))
+	var wg sync.WaitGroup
+	for i, refTgt := range refTgts {
+		wg.Add(1)
+		go func(i int, refTgt ref.Ref) {
+			defer wg.Done()
+			err := rc.imageCopy(ctx, refSrc, refTgt, nil, opts...)
+			if err != nil {
+				errList[i] = &ImageCopyTargetError{Target: refTgt, Err: err}
+				if ctx.Err() != nil {
+					return
+				}
+				rc.slog.Warn("Failed to copy image",
+					slog.String("source", refSrc.CommonName()),
+					slog.String("target", refTgt.CommonName()),
+					slog.String("err", err.Error()))
+			}
+		}(i, refTgt)
+	}
+	wg.Wait()
+	return errors.Join(errList...)
+}
+
+func (rc *RegClient) imageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, result *ImageCopyResult, opts ...ImageOpts) error {
 	opt := imageOpt{
-		seen:    map[string]*imageSeen{},
-		finalFn: []func(context.Context) error{},
+		seen:          map[string]*imageSeen{},
+		rewritten:     map[digest.Digest]descriptor.Descriptor{},
+		referrerGraph: map[digest.Digest]bool{},
+		referrerLevel: map[digest.Digest]int{},
+		finalFn:       []func(context.Context) error{},
+		result:        result,
 	}
 	for _, optFn := range opts {
 		optFn(&opt)
 	}
+	if opt.maxDepth <= 0 {
+		opt.maxDepth = imageCopyMaxDepthDefault
+	}
+	if opt.concurrency > 0 && opt.blobQueue == nil {
+		opt.blobQueue = pqueue.New(pqueue.Opts[reqmeta.Data]{Max: opt.concurrency})
+	}
 	// dedup warnings
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
@@ -531,8 +868,19 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 		tgtGCLocker.GCLock(refTgt)
 		defer tgtGCLocker.GCUnlock(refTgt)
 	}
+	// a provided source manifest is used as the descriptor of the top level copy
+	dSrc := descriptor.Descriptor{}
+	if opt.srcManifest != nil {
+		dSrc = opt.srcManifest.GetDescriptor()
+		if dSrc.Digest == "" {
+			return fmt.Errorf("source manifest is missing a digest%.0w", errs.ErrNotFound)
+		}
+		if refSrc.Digest != "" && refSrc.Digest != dSrc.Digest.String() {
+			return fmt.Errorf("source manifest digest %s does not match %s%.0w", dSrc.Digest.String(), refSrc.CommonName(), errs.ErrDigestMismatch)
+		}
+	}
 	// run the copy of manifests and blobs recursively
-	err = rc.imageCopyOpt(ctx, refSrc, refTgt, descriptor.Descriptor{}, opt.child, []digest.Digest{}, &opt)
+	err = rc.imageCopyOpt(ctx, refSrc, refTgt, dSrc, opt.child, []digest.Digest{}, &opt)
 	if err != nil {
 		return err
 	}
@@ -546,6 +894,26 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 	return nil
 }
 
+// resultAdd appends an entry to the copy result when one is being tracked.
+func (opt *imageOpt) resultAdd(kind types.CallbackKind, d descriptor.Descriptor, action ImageCopyAction, start time.Time, err error) {
+	if opt.result == nil {
+		return
+	}
+	entry := ImageCopyEntry{
+		Descriptor: d,
+		Action:     action,
+		Duration:   time.Since(start),
+		Err:        err,
+	}
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+	if kind == types.CallbackManifest {
+		opt.result.Manifests = append(opt.result.Manifests, entry)
+	} else {
+		opt.result.Blobs = append(opt.result.Blobs, entry)
+	}
+}
+
 // imageCopyOpt is a thread safe copy of a manifest and nested content.
 func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor, child bool, parents []digest.Digest, opt *imageOpt) (err error) {
 	var mSrc, mTgt manifest.Manifest
@@ -557,6 +925,28 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			seenCB(err)
 		}
 	}()
+	start := time.Now()
+	resultAction := ImageCopyAction("")
+	resultDesc := d
+	defer func() {
+		// skip results for content handled by another task, or a loop that is retried later
+		if err != nil && (seenCB == nil || errors.Is(err, errs.ErrLoopDetected)) {
+			return
+		}
+		if err != nil {
+			resultAction = ImageCopyFailed
+		}
+		if resultAction == "" {
+			return
+		}
+		if resultDesc.Digest == "" {
+			resultDesc.Digest = sDig
+		}
+		opt.resultAdd(types.CallbackManifest, resultDesc, resultAction, start, err)
+	}()
+	if len(parents) >= opt.maxDepth {
+		return fmt.Errorf("copy of %s exceeds the max depth of %d nested manifests%.0w", refSrc.CommonName(), opt.maxDepth, errs.ErrDepthLimitExceeded)
+	}
 	// if digest is provided and we are already copying it, wait
 	if d.Digest != "" {
 		sDig = d.Digest
@@ -590,6 +980,10 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			if opt.callback != nil {
 				opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, mTgt.GetDescriptor().Size, mTgt.GetDescriptor().Size)
 			}
+			if resultDesc.Digest == "" {
+				resultDesc = mTgt.GetDescriptor()
+			}
+			resultAction = ImageCopySkipped
 			return nil
 		}
 	}
@@ -605,8 +999,12 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		}
 	}
 	// get the source manifest when a copy is needed or recursion into the content is needed
-	if sDig == "" || mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive || mTgt.IsList() {
-		mSrc, err = rc.ManifestGet(ctx, refSrc, WithManifestDesc(d))
+	if opt.srcManifest != nil && sDig != "" && sDig == opt.srcManifest.GetDescriptor().Digest {
+		mSrc = opt.srcManifest
+	} else if sDig == "" || mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive || mTgt.IsList() {
+		mSrc, err = opt.srcCache.get(ctx, d.Digest, func() (manifest.Manifest, error) {
+			return rc.ManifestGet(ctx, refSrc, WithManifestDesc(d))
+		})
 		if err != nil {
 			return fmt.Errorf("copy failed, error getting source: %w", err)
 		}
@@ -626,6 +1024,15 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	if opt.callback != nil {
 		bOpt = append(bOpt, BlobWithCallback(opt.callback))
 	}
+	if opt.blobCache != nil {
+		bOpt = append(bOpt, BlobWithCache(opt.blobCache))
+	}
+	if opt.noMount {
+		bOpt = append(bOpt, BlobWithoutMount())
+	}
+	if opt.dryRun {
+		bOpt = append(bOpt, func(bo *blobOpt) { bo.dryRun = true })
+	}
 	waitCh := make(chan error)
 	waitCount := 0
 	ctx, cancel := context.WithCancel(ctx)
@@ -643,6 +1050,24 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		if err != nil {
 			return err
 		}
+		if len(opt.platformFilter) > 0 {
+			dList = imagePlatformFilter(dList, opt.platformFilter)
+			if len(dList) == 0 {
+				return fmt.Errorf("no platforms in %s match the platform filter%.0w", refSrc.CommonName(), errs.ErrNotFound)
+			}
+		}
+		// entries of an index inherit the referrer graph and level of the index
+		opt.mu.Lock()
+		inGraph, inLevel := opt.referrerGraph[sDig], opt.referrerLevel[sDig]
+		for _, dEntry := range dList {
+			if inGraph {
+				opt.referrerGraph[dEntry.Digest] = true
+			}
+			if inLevel > 0 {
+				opt.referrerLevel[dEntry.Digest] = inLevel
+			}
+		}
+		opt.mu.Unlock()
 		for _, dEntry := range dList {
 			// skip copy of platforms not specifically included
 			if len(opt.platforms) > 0 {
@@ -813,7 +1238,15 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			referrerTags = append(referrerTags, rl.Tags...)
 		}
 		descList := []descriptor.Descriptor{}
-		if len(opt.referrerConfs) == 0 {
+		opt.mu.Lock()
+		inGraph := opt.referrerGraph[sDig]
+		level := opt.referrerLevel[sDig]
+		opt.mu.Unlock()
+		if opt.referrerDepth > 0 && level >= opt.referrerDepth {
+			rc.slog.Debug("Referrer depth reached",
+				slog.String("subject", rSubject.CommonName()),
+				slog.Int("depth", opt.referrerDepth))
+		} else if len(opt.referrerConfs) == 0 || inGraph {
 			descList = rl.Descriptors
 		} else {
 			for _, rConf := range opt.referrerConfs {
@@ -828,6 +1261,12 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			if seen != nil {
 				continue // skip referrers that have been seen
 			}
+			opt.mu.Lock()
+			if opt.referrerDeep {
+				opt.referrerGraph[rDesc.Digest] = true
+			}
+			opt.referrerLevel[rDesc.Digest] = level + 1
+			opt.mu.Unlock()
 			referrerSrc := referrerSrc.SetDigest(rDesc.Digest.String())
 			referrerTgt := referrerTgt.SetDigest(rDesc.Digest.String())
 			rDesc := rDesc
@@ -856,6 +1295,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	}
 
 	// lookup digest tags to include artifacts with image
+	digestTagRefs := []string{}
 	if opt.digestTags {
 		// load tag listing for digest tag copy
 		opt.mu.Lock()
@@ -896,6 +1336,14 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 				if found {
 					continue
 				}
+				if opt.digestTagsRefs {
+					// the referrers fallback tag is only copied with the referrers
+					if tag != prefix {
+						// converted to referrers after the subject is pushed
+						digestTagRefs = append(digestTagRefs, tag)
+					}
+					continue
+				}
 				refTagSrc := refSrc.SetTag(tag)
 				refTagTgt := refTgt.SetTag(tag)
 				tag := tag
@@ -946,9 +1394,37 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		return err
 	}
 
+	// rewrite descriptors in the manifest, pushing any changes by the new digest
+	mPush := mSrc
+	tDig := sDig
+	if (opt.descRewrite != nil || len(opt.platformFilter) > 0) && mSrc != nil && mSrc.IsSet() {
+		mPush, err = imageCopyRewrite(mSrc, opt)
+		if err != nil {
+			return err
+		}
+		if mPush.GetDescriptor().Digest != sDig {
+			tDig = mPush.GetDescriptor().Digest
+			opt.mu.Lock()
+			opt.rewritten[sDig] = mPush.GetDescriptor()
+			opt.mu.Unlock()
+			if refTgt.Digest != "" {
+				refTgt = refTgt.SetDigest(tDig.String())
+			}
+		}
+	}
+
 	// push manifest
-	if mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive {
-		err = rc.ManifestPut(ctx, refTgt, mSrc, mOpts...)
+	if opt.dryRun {
+		if mTgt == nil || tDig != mTgt.GetDescriptor().Digest {
+			resultAction = ImageCopyPending
+		} else {
+			if opt.callback != nil {
+				opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
+			}
+			resultAction = ImageCopySkipped
+		}
+	} else if mTgt == nil || tDig != mTgt.GetDescriptor().Digest || opt.forceRecursive {
+		err = rc.ManifestPut(ctx, refTgt, mPush, mOpts...)
 		if err != nil {
 			if !errors.Is(err, context.Canceled) {
 				rc.slog.Warn("Failed to push manifest",
@@ -960,10 +1436,43 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		if opt.callback != nil {
 			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackFinished, d.Size, d.Size)
 		}
+		resultAction = ImageCopyCopied
 	} else {
 		if opt.callback != nil {
 			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
 		}
+		resultAction = ImageCopySkipped
+	}
+
+	// push digest tags as referrers to the target manifest
+	if len(digestTagRefs) > 0 {
+		var dSubject descriptor.Descriptor
+		if mPush != nil {
+			dSubject = mPush.GetDescriptor()
+		} else {
+			dSubject = mTgt.GetDescriptor()
+		}
+		dSubject = descriptor.Descriptor{
+			MediaType: dSubject.MediaType,
+			Digest:    dSubject.Digest,
+			Size:      dSubject.Size,
+		}
+		for _, tag := range digestTagRefs {
+			err = rc.imageCopyDigestTagReferrer(ctx, refSrc.SetTag(tag), refTgt, dSubject, parentsNew, opt, bOpt...)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					rc.slog.Warn("Failed to convert digest-tag to referrer",
+						slog.String("tag", tag),
+						slog.String("src", refSrc.CommonName()),
+						slog.String("tgt", refTgt.CommonName()),
+						slog.String("err", err.Error()))
+				}
+				return err
+			}
+		}
+	}
+	if resultDesc.Digest == "" && mSrc != nil {
+		resultDesc = mSrc.GetDescriptor()
 	}
 	if seenCB != nil {
 		seenCB(nil)
@@ -973,13 +1482,253 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	return nil
 }
 
+// imageCopyDigestTagReferrer copies the content of a digest tag and pushes the manifest by digest with a subject.
+// Docker media types are converted to the OCI equivalent since only OCI manifests support a subject.
+func (rc *RegClient) imageCopyDigestTagReferrer(ctx context.Context, refTagSrc ref.Ref, refTgt ref.Ref, dSubject descriptor.Descriptor, parents []digest.Digest, opt *imageOpt, bOpt ...BlobOpts) (err error) {
+	start := time.Now()
+	resultAction := ImageCopyAction("")
+	var resultDesc descriptor.Descriptor
+	defer func() {
+		if err != nil {
+			resultAction = ImageCopyFailed
+		}
+		if resultAction != "" {
+			opt.resultAdd(types.CallbackManifest, resultDesc, resultAction, start, err)
+		}
+	}()
+	mTag, err := rc.ManifestGet(ctx, refTagSrc)
+	if err != nil {
+		return fmt.Errorf("failed to get digest tag %s: %w", refTagSrc.CommonName(), err)
+	}
+	var mNew manifest.Manifest
+	children := []descriptor.Descriptor{}
+	blobs := []descriptor.Descriptor{}
+	if mTag.IsList() {
+		ociI, err := manifest.OCIIndexFromAny(mTag.GetOrig())
+		if err != nil {
+			return fmt.Errorf("digest tag %s cannot be converted to a referrer: %w%.0w", refTagSrc.CommonName(), err, errs.ErrUnsupportedMediaType)
+		}
+		ociI.MediaType = mediatype.OCI1ManifestList
+		ociI.Subject = &dSubject
+		children = ociI.Manifests
+		mNew, err = manifest.New(manifest.WithOrig(ociI))
+		if err != nil {
+			return err
+		}
+	} else {
+		ociM, err := manifest.OCIManifestFromAny(mTag.GetOrig())
+		if err != nil {
+			return fmt.Errorf("digest tag %s cannot be converted to a referrer: %w%.0w", refTagSrc.CommonName(), err, errs.ErrUnsupportedMediaType)
+		}
+		ociM.MediaType = mediatype.OCI1Manifest
+		ociM.Config.MediaType = imageMediaTypeToOCI(ociM.Config.MediaType)
+		ociM.Layers = append([]descriptor.Descriptor{}, ociM.Layers...)
+		for i := range ociM.Layers {
+			ociM.Layers[i].MediaType = imageMediaTypeToOCI(ociM.Layers[i].MediaType)
+		}
+		ociM.Subject = &dSubject
+		// an image config is not an artifact type, so it is set from the tag suffix or the layer
+		if ociM.ArtifactType == "" && (ociM.Config.MediaType == mediatype.OCI1ImageConfig || ociM.Config.MediaType == mediatype.OCI1Empty) {
+			ociM.ArtifactType = imageDigestTagArtifactType(refTagSrc.Tag, ociM.Layers)
+		}
+		blobs = append([]descriptor.Descriptor{ociM.Config}, ociM.Layers...)
+		mNew, err = manifest.New(manifest.WithOrig(ociM))
+		if err != nil {
+			return err
+		}
+	}
+	resultDesc = mNew.GetDescriptor()
+	refNew := refTgt.SetDigest(resultDesc.Digest.String())
+	if _, err := rc.ManifestHead(ctx, refNew, WithManifestRequireDigest()); err == nil {
+		resultAction = ImageCopySkipped
+		return nil
+	}
+	// entries of an index are copied unchanged, only the pushed index has a subject
+	for _, dChild := range children {
+		err = rc.imageCopyOpt(ctx, refTagSrc.SetDigest(dChild.Digest.String()), refTgt.SetDigest(dChild.Digest.String()), dChild, true, parents, opt)
+		if err != nil {
+			return err
+		}
+	}
+	for _, d := range blobs {
+		err = rc.imageCopyBlob(ctx, refTagSrc, refTgt, d, opt, bOpt...)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.dryRun {
+		resultAction = ImageCopyPending
+		return nil
+	}
+	err = rc.ManifestPut(ctx, refNew, mNew)
+	if err != nil {
+		return err
+	}
+	if opt.callback != nil {
+		opt.callback(types.CallbackManifest, resultDesc.Digest.String(), types.CallbackFinished, resultDesc.Size, resultDesc.Size)
+	}
+	resultAction = ImageCopyCopied
+	return nil
+}
+
+// imageDigestTagArtifactType returns the artifact type for a digest tag converted to a referrer.
+// A suffix like ".sig" follows the cosign artifact types, otherwise the media type of a single layer is used.
+func imageDigestTagArtifactType(tag string, layers []descriptor.Descriptor) string {
+	if _, suffix, ok := strings.Cut(tag, "."); ok && suffix != "" && !strings.Contains(suffix, ".") {
+		return "application/vnd.dev.cosign.artifact." + suffix + ".v1+json"
+	}
+	if len(layers) == 1 {
+		return layers[0].MediaType
+	}
+	return ""
+}
+
+// imageMediaTypeToOCI returns the OCI media type for Docker image configs and layers.
+func imageMediaTypeToOCI(mt string) string {
+	switch mt {
+	case mediatype.Docker2ImageConfig:
+		return mediatype.OCI1ImageConfig
+	case mediatype.Docker2Layer:
+		return mediatype.OCI1Layer
+	case mediatype.Docker2LayerGzip:
+		return mediatype.OCI1LayerGzip
+	case mediatype.Docker2LayerZstd:
+		return mediatype.OCI1LayerZstd
+	case mediatype.Docker2ForeignLayer:
+		return mediatype.OCI1ForeignLayerGzip
+	}
+	return mt
+}
+
+// imageCopyRewrite returns a copy of the manifest with the descriptor rewrite applied.
+// Index entries are first updated to the digest of any child manifest that was changed.
+// The original manifest is returned when no descriptors are changed.
+func imageCopyRewrite(m manifest.Manifest, opt *imageOpt) (manifest.Manifest, error) {
+	raw, err := m.RawBody()
+	if err != nil {
+		return nil, err
+	}
+	mNew, err := manifest.New(manifest.WithRaw(raw), manifest.WithDesc(m.GetDescriptor()))
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	if mi, ok := mNew.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return nil, err
+		}
+		if len(opt.platformFilter) > 0 {
+			dlFilter := imagePlatformFilter(dl, opt.platformFilter)
+			changed = len(dlFilter) != len(dl)
+			dl = dlFilter
+		}
+		for i, d := range dl {
+			opt.mu.Lock()
+			dChild, ok := opt.rewritten[d.Digest]
+			opt.mu.Unlock()
+			if ok {
+				d.MediaType = dChild.MediaType
+				d.Digest = dChild.Digest
+				d.Size = dChild.Size
+			}
+			dNew := d
+			if opt.descRewrite != nil {
+				dNew = opt.descRewrite(d)
+			}
+			if dNew.Digest != d.Digest || dNew.Size != d.Size {
+				return nil, fmt.Errorf("descriptor rewrite cannot change the digest or size of manifest %s%.0w", d.Digest.String(), errs.ErrMismatch)
+			}
+			if !reflect.DeepEqual(dNew, dl[i]) {
+				dl[i] = dNew
+				changed = true
+			}
+		}
+		if changed {
+			err = mi.SetManifestList(dl)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if mi, ok := mNew.(manifest.Imager); ok && opt.descRewrite != nil {
+		rewriteBlob := func(d descriptor.Descriptor) (descriptor.Descriptor, bool, error) {
+			dNew := opt.descRewrite(d)
+			if dNew.Digest != d.Digest || dNew.Size != d.Size {
+				return d, false, fmt.Errorf("descriptor rewrite cannot change the digest or size of blob %s%.0w", d.Digest.String(), errs.ErrMismatch)
+			}
+			return dNew, !reflect.DeepEqual(dNew, d), nil
+		}
+		cd, err := mi.GetConfig()
+		if err == nil {
+			cdNew, cdChanged, err := rewriteBlob(cd)
+			if err != nil {
+				return nil, err
+			}
+			if cdChanged {
+				err = mi.SetConfig(cdNew)
+				if err != nil {
+					return nil, err
+				}
+				changed = true
+			}
+		}
+		dl, err := mi.GetLayers()
+		if err != nil {
+			return nil, err
+		}
+		layersChanged := false
+		dlNew := make([]descriptor.Descriptor, len(dl))
+		for i, d := range dl {
+			dNew, dChanged, err := rewriteBlob(d)
+			if err != nil {
+				return nil, err
+			}
+			dlNew[i] = dNew
+			layersChanged = layersChanged || dChanged
+		}
+		if layersChanged {
+			err = mi.SetLayers(dlNew)
+			if err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return m, nil
+	}
+	return mNew, nil
+}
+
+// imageCopyBlob copies a blob, tracking the digest for the target repository across the entire copy.
+// Concurrent copies of the same blob wait for the first copy and return its result.
 func (rc *RegClient) imageCopyBlob(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor, opt *imageOpt, bOpt ...BlobOpts) error {
 	seenCB, err := imageSeenOrWait(ctx, opt, refTgt.SetTag("").CommonName(), "", d.Digest, []digest.Digest{})
 	if seenCB == nil {
 		return err
 	}
+	// a nil queue, when the concurrency is not limited, returns immediately
+	done, err := opt.blobQueue.Acquire(ctx, reqmeta.Data{Kind: reqmeta.Blob, Size: d.Size})
+	if err != nil {
+		seenCB(err)
+		return err
+	}
+	defer done()
+	start := time.Now()
+	action := ImageCopyFailed
+	if opt.result != nil {
+		// copy the options to avoid modifying the slice shared with other goroutines
+		bOpt = append(append([]BlobOpts{}, bOpt...), func(bo *blobOpt) {
+			bo.actionFn = func(a ImageCopyAction) { action = a }
+		})
+	}
 	err = rc.BlobCopy(ctx, refSrc, refTgt, d, bOpt...)
 	seenCB(err)
+	if err != nil {
+		action = ImageCopyFailed
+	}
+	opt.resultAdd(types.CallbackBlob, d, action, start, err)
 	return err
 }
 
@@ -1301,6 +2050,8 @@ func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeke
 	}
 	trd := &tarReadData{
 		name:      opt.importName,
+		importAll: opt.importAll,
+		importFn:  opt.importFn,
 		handlers:  map[string]tarFileHandler{},
 		links:     map[string][]string{},
 		processed: map[string]bool{},
@@ -1562,7 +2313,60 @@ func (rc *RegClient) imageImportOCIHandleManifest(ctx context.Context, r ref.Ref
 		return nil
 	}
 
-	if !push {
+	if !push && trd.importAll {
+		mi, ok := m.(manifest.Indexer)
+		if !ok {
+			return fmt.Errorf("manifest doesn't support image methods%.0w", errs.ErrUnsupportedMediaType)
+		}
+		// for root index, add handlers for every tagged entry
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, cur := range dl {
+			name := cur.Annotations[annotationRefName]
+			if name == "" {
+				rc.slog.Warn("Skipping index.json entry without a tag",
+					slog.String("digest", cur.Digest.String()))
+				continue
+			}
+			rTag := r.SetTag(name)
+			if rParse, err := ref.New(rTag.CommonName()); err != nil || rParse.Tag != name {
+				rc.slog.Warn("Skipping index.json entry with an invalid tag",
+					slog.String("tag", name),
+					slog.String("digest", cur.Digest.String()))
+				continue
+			}
+			err = handleManifest(cur, false)
+			if err != nil {
+				rc.slog.Warn("Skipping index.json entry",
+					slog.String("tag", name),
+					slog.String("digest", cur.Digest.String()),
+					slog.String("err", err.Error()))
+				continue
+			}
+			found = true
+			d := cur
+			trd.finish = append(trd.finish, func() error {
+				mRef, ok := trd.manifests[d.Digest]
+				if !ok {
+					return fmt.Errorf("could not find manifest to tag, ref: %s, digest: %s", rTag.CommonName(), d.Digest)
+				}
+				err := rc.ManifestPut(ctx, rTag, mRef)
+				if err != nil {
+					return err
+				}
+				if trd.importFn != nil {
+					trd.importFn(rTag)
+				}
+				return nil
+			})
+		}
+		if !found {
+			return fmt.Errorf("no tagged manifests found in index.json%.0w", errs.ErrNotFound)
+		}
+	} else if !push {
 		mi, ok := m.(manifest.Indexer)
 		if !ok {
 			return fmt.Errorf("manifest doesn't support image methods%.0w", errs.ErrUnsupportedMediaType)
@@ -1728,6 +2532,34 @@ func imagePlatformInList(target *platform.Platform, list []string) (bool, error)
 	return false, nil
 }
 
+// imagePlatformFilter returns the entries of an index that match any platform in the filter.
+// Attestations are included when the referenced entry is included, and entries without a platform are always included.
+func imagePlatformFilter(dl []descriptor.Descriptor, filter []platform.Platform) []descriptor.Descriptor {
+	included := map[digest.Digest]bool{}
+	for _, d := range dl {
+		if d.Platform == nil || d.Platform.OS == "" || d.Platform.OS == "unknown" {
+			continue
+		}
+		for _, p := range filter {
+			if platform.Match(p, *d.Platform) {
+				included[d.Digest] = true
+				break
+			}
+		}
+	}
+	result := make([]descriptor.Descriptor, 0, len(dl))
+	for _, d := range dl {
+		if d.Annotations[dockerReferenceType] != "" && d.Annotations[dockerReferenceDigest] != "" {
+			if included[digest.Digest(d.Annotations[dockerReferenceDigest])] {
+				result = append(result, d)
+			}
+		} else if d.Platform == nil || d.Platform.OS == "" || d.Platform.OS == "unknown" || included[d.Digest] {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
 // tarReadAll processes the tar file in a loop looking for matching filenames in the list of handlers.
 // Handlers for filenames are added at the top level, and by manifest imports.
 func (trd *tarReadData) tarReadAll(rs io.ReadSeeker) error {
@@ -2,24 +2,41 @@ package regclient
 
 import (
 	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/olareg/olareg"
 	oConfig "github.com/olareg/olareg/config"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -428,6 +445,1192 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestCopyDetailed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+		{
+			Name:     "registry.example.org",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithSlog(log),
+	)
+	rSrc, err := ref.New(tsHost + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	// build the list of expected manifests and blobs from the source
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get source manifest: %v", err)
+	}
+	expectManifests := map[digest.Digest]bool{mSrc.GetDescriptor().Digest: true}
+	expectBlobs := map[digest.Digest]bool{}
+	mi, ok := mSrc.(manifest.Indexer)
+	if !ok {
+		t.Fatalf("source is not an index")
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get manifest list: %v", err)
+	}
+	for _, d := range dl {
+		expectManifests[d.Digest] = true
+		m, err := rc.ManifestGet(ctx, rSrc.SetDigest(d.Digest.String()))
+		if err != nil {
+			t.Fatalf("failed to get manifest %s: %v", d.Digest.String(), err)
+		}
+		img, ok := m.(manifest.Imager)
+		if !ok {
+			t.Fatalf("child manifest is not an image: %s", d.Digest.String())
+		}
+		cd, err := img.GetConfig()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		expectBlobs[cd.Digest] = true
+		layers, err := img.GetLayers()
+		if err != nil {
+			t.Fatalf("failed to get layers: %v", err)
+		}
+		for _, l := range layers {
+			expectBlobs[l.Digest] = true
+		}
+	}
+	checkEntries := func(t *testing.T, kind string, entries []ImageCopyEntry, expect map[digest.Digest]bool, action ImageCopyAction) {
+		t.Helper()
+		found := map[digest.Digest]bool{}
+		for _, e := range entries {
+			if !expect[e.Descriptor.Digest] {
+				t.Errorf("unexpected %s: %s", kind, e.Descriptor.Digest.String())
+			}
+			if found[e.Descriptor.Digest] {
+				t.Errorf("duplicate %s: %s", kind, e.Descriptor.Digest.String())
+			}
+			found[e.Descriptor.Digest] = true
+			if e.Action != action {
+				t.Errorf("unexpected action for %s %s, expected %s, received %s", kind, e.Descriptor.Digest.String(), action, e.Action)
+			}
+			if e.Descriptor.Size <= 0 {
+				t.Errorf("size missing for %s %s", kind, e.Descriptor.Digest.String())
+			}
+			if e.Err != nil {
+				t.Errorf("unexpected error for %s %s: %v", kind, e.Descriptor.Digest.String(), e.Err)
+			}
+		}
+		if len(found) != len(expect) {
+			t.Errorf("missing %s entries, expected %d, received %d", kind, len(expect), len(found))
+		}
+	}
+
+	t.Run("mount", func(t *testing.T) {
+		rTgt, err := ref.New(tsHost + "/detail-mount:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, expectManifests, ImageCopyCopied)
+		checkEntries(t, "blob", result.Blobs, expectBlobs, ImageCopyMounted)
+		// a second copy skips the existing image
+		result, err = rc.ImageCopyDetailed(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, map[digest.Digest]bool{mSrc.GetDescriptor().Digest: true}, ImageCopySkipped)
+		checkEntries(t, "blob", result.Blobs, map[digest.Digest]bool{}, ImageCopySkipped)
+	})
+	t.Run("copy", func(t *testing.T) {
+		rTgt, err := ref.New("registry.example.org/detail-copy:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, expectManifests, ImageCopyCopied)
+		checkEntries(t, "blob", result.Blobs, expectBlobs, ImageCopyCopied)
+	})
+	t.Run("skip blobs", func(t *testing.T) {
+		// forcing a recursive copy to the same target reports the existing blobs as skipped
+		rTgt, err := ref.New("registry.example.org/detail-copy:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt, ImageWithForceRecursive())
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, expectManifests, ImageCopyCopied)
+		checkEntries(t, "blob", result.Blobs, expectBlobs, ImageCopySkipped)
+	})
+	t.Run("dry run", func(t *testing.T) {
+		rTgt, err := ref.New("registry.example.org/detail-dry-run:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt, ImageWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, expectManifests, ImageCopyPending)
+		checkEntries(t, "blob", result.Blobs, expectBlobs, ImageCopyPending)
+		if _, err := rc.ManifestHead(ctx, rTgt); err == nil {
+			t.Errorf("manifest pushed by a dry run")
+		}
+		// the existing image is reported as skipped
+		rTgt, err = ref.New("registry.example.org/detail-copy:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err = rc.ImageCopyDetailed(ctx, rSrc, rTgt, ImageWithDryRun(), ImageWithForceRecursive())
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		checkEntries(t, "manifest", result.Manifests, expectManifests, ImageCopySkipped)
+		checkEntries(t, "blob", result.Blobs, expectBlobs, ImageCopySkipped)
+	})
+	t.Run("missing", func(t *testing.T) {
+		rTgt, err := ref.New(tsHost + "/detail-missing:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		result, err := rc.ImageCopyDetailed(ctx, rSrc.SetTag("missing"), rTgt)
+		if err == nil {
+			t.Fatalf("copy of a missing image did not fail")
+		}
+		if len(result.Manifests) != 1 || result.Manifests[0].Action != ImageCopyFailed || result.Manifests[0].Err == nil {
+			t.Errorf("failure was not reported: %v", result.Manifests)
+		}
+	})
+}
+
+func TestCopyOCIDir(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(WithSlog(log))
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rA, err := ref.New("ocidir://" + tempDir + "/a:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rB, err := ref.New("ocidir://" + tempDir + "/b:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rSrc, rA)
+	if err != nil {
+		t.Fatalf("failed to copy from testdata: %v", err)
+	}
+	result, err := rc.ImageCopyDetailed(ctx, rA, rB)
+	if err != nil {
+		t.Fatalf("failed to copy between layouts: %v", err)
+	}
+	if len(result.Blobs) == 0 {
+		t.Fatalf("no blobs copied")
+	}
+	linked := map[string]bool{}
+	for _, e := range result.Blobs {
+		if e.Action != ImageCopyMounted {
+			t.Errorf("unexpected action for blob %s: %s", e.Descriptor.Digest.String(), e.Action)
+		}
+		linked[e.Descriptor.Digest.Encoded()] = true
+	}
+	// verify the digests match
+	mSrc, err := rc.ManifestHead(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to head source: %v", err)
+	}
+	mB, err := rc.ManifestHead(ctx, rB)
+	if err != nil {
+		t.Fatalf("failed to head target: %v", err)
+	}
+	if mSrc.GetDescriptor().Digest != mB.GetDescriptor().Digest {
+		t.Errorf("digest mismatch, expected %s, received %s", mSrc.GetDescriptor().Digest.String(), mB.GetDescriptor().Digest.String())
+	}
+	// verify the target content is valid and blobs are linked to the first layout
+	files, err := os.ReadDir(filepath.Join(tempDir, "b", "blobs", "sha256"))
+	if err != nil {
+		t.Fatalf("failed to read target blobs: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no blobs found in target")
+	}
+	for _, f := range files {
+		fileB := filepath.Join(tempDir, "b", "blobs", "sha256", f.Name())
+		b, err := os.ReadFile(fileB)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fileB, err)
+		}
+		if dig := digest.FromBytes(b); dig.Encoded() != f.Name() {
+			t.Errorf("blob content does not match digest, expected %s, received %s", f.Name(), dig.Encoded())
+		}
+		if !linked[f.Name()] {
+			continue // manifests are written by the copy
+		}
+		fiA, errA := os.Stat(filepath.Join(tempDir, "a", "blobs", "sha256", f.Name()))
+		fiB, errB := os.Stat(fileB)
+		if errA != nil || errB != nil {
+			t.Errorf("failed to stat blob %s: %v, %v", f.Name(), errA, errB)
+		} else if !os.SameFile(fiA, fiB) {
+			t.Errorf("blob %s was not linked", f.Name())
+		}
+	}
+}
+
+func TestCopySharedBlobs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+		},
+	})
+	// count the uploads of each blob to the target repository
+	var mu sync.Mutex
+	uploads := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/shared-tgt/blobs/uploads/") && r.URL.Query().Has("digest") &&
+			(r.Method == http.MethodPut || r.Method == http.MethodPost) {
+			mu.Lock()
+			uploads[r.URL.Query().Get("digest")]++
+			mu.Unlock()
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+		{
+			Name:     "registry.example.org",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithSlog(log),
+	)
+	rSrc, err := ref.New(tsHost + "/shared-src:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	// push an index with two platforms that share a layer
+	layerBody := []byte("shared layer content")
+	layerDesc, err := rc.BlobPut(ctx, rSrc, descriptor.Descriptor{}, bytes.NewReader(layerBody))
+	if err != nil {
+		t.Fatalf("failed to push layer: %v", err)
+	}
+	layerDesc.MediaType = mediatype.OCI1Layer
+	platforms := []platform.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+	}
+	dl := []descriptor.Descriptor{}
+	for _, p := range platforms {
+		p := p
+		confBody := []byte(fmt.Sprintf(`{"architecture":"%s","os":"%s","rootfs":{"type":"layers","diff_ids":["%s"]}}`, p.Architecture, p.OS, layerDesc.Digest.String()))
+		confDesc, err := rc.BlobPut(ctx, rSrc, descriptor.Descriptor{}, bytes.NewReader(confBody))
+		if err != nil {
+			t.Fatalf("failed to push config: %v", err)
+		}
+		confDesc.MediaType = mediatype.OCI1ImageConfig
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned: v1.ManifestSchemaVersion,
+			MediaType: mediatype.OCI1Manifest,
+			Config:    confDesc,
+			Layers:    []descriptor.Descriptor{layerDesc},
+		}))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, rSrc.SetDigest(m.GetDescriptor().Digest.String()), m, WithManifestChild())
+		if err != nil {
+			t.Fatalf("failed to push manifest: %v", err)
+		}
+		d := m.GetDescriptor()
+		d.Platform = &p
+		dl = append(dl, d)
+	}
+	mi, err := manifest.New(manifest.WithOrig(v1.Index{
+		Versioned: v1.IndexSchemaVersion,
+		MediaType: mediatype.OCI1ManifestList,
+		Manifests: dl,
+	}))
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	err = rc.ManifestPut(ctx, rSrc, mi)
+	if err != nil {
+		t.Fatalf("failed to push index: %v", err)
+	}
+
+	// copy to a separate registry name to prevent blob mounts
+	rTgt, err := ref.New("registry.example.org/shared-tgt:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	mu.Lock()
+	layerUploads := uploads[layerDesc.Digest.String()]
+	mu.Unlock()
+	if layerUploads != 1 {
+		t.Errorf("shared layer uploaded %d times, expected 1", layerUploads)
+	}
+	layerResults := 0
+	for _, e := range result.Blobs {
+		if e.Descriptor.Digest == layerDesc.Digest {
+			layerResults++
+			if e.Action != ImageCopyCopied {
+				t.Errorf("unexpected action for shared layer, expected %s, received %s", ImageCopyCopied, e.Action)
+			}
+		}
+	}
+	if layerResults != 1 {
+		t.Errorf("shared layer reported %d times in the result, expected 1", layerResults)
+	}
+	if len(result.Blobs) != len(platforms)+1 {
+		t.Errorf("unexpected number of blobs copied, expected %d, received %d", len(platforms)+1, len(result.Blobs))
+	}
+}
+
+func TestCopyMulti(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+	})
+	// count the blob and manifest reads from the source
+	var mu sync.Mutex
+	reads := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (strings.HasPrefix(r.URL.Path, "/v2/testrepo/blobs/") || strings.HasPrefix(r.URL.Path, "/v2/testrepo/manifests/")) && r.Method == http.MethodGet {
+			mu.Lock()
+			reads[strings.TrimPrefix(r.URL.Path, "/v2/testrepo/")]++
+			mu.Unlock()
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	tempDir := t.TempDir()
+	rSrc, err := ref.New(tsHost + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgts := []ref.Ref{}
+	for _, name := range []string{"tgt1", "tgt2", "tgt4"} {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/" + name + ":v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgts = append(rTgts, rTgt)
+	}
+	t.Run("copy", func(t *testing.T) {
+		err := rc.ImageCopyMulti(ctx, rSrc, rTgts, ImageWithConcurrency(2))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		mSrc, err := rc.ManifestHead(ctx, rSrc, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head source: %v", err)
+		}
+		for _, rTgt := range rTgts {
+			mTgt, err := rc.ManifestHead(ctx, rTgt)
+			if err != nil {
+				t.Errorf("failed to head %s: %v", rTgt.CommonName(), err)
+			} else if mTgt.GetDescriptor().Digest != mSrc.GetDescriptor().Digest {
+				t.Errorf("digest mismatch on %s, expected %s, received %s", rTgt.CommonName(), mSrc.GetDescriptor().Digest, mTgt.GetDescriptor().Digest)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		blobReads := 0
+		for name, count := range reads {
+			if strings.HasPrefix(name, "blobs/") {
+				blobReads++
+			}
+			if count != 1 {
+				t.Errorf("%s read %d times", name, count)
+			}
+		}
+		if blobReads == 0 {
+			t.Errorf("no blobs read from the source")
+		}
+	})
+	t.Run("partial failure", func(t *testing.T) {
+		// a file in place of the directory causes the copy to that target to fail
+		err := os.WriteFile(filepath.Join(tempDir, "blocked"), []byte("not a directory"), 0o644)
+		if err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		rBlocked, err := ref.New("ocidir://" + tempDir + "/blocked/repo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgt3, err := ref.New("ocidir://" + tempDir + "/tgt3:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopyMulti(ctx, rSrc, []ref.Ref{rBlocked, rTgt3})
+		if err == nil {
+			t.Fatalf("copy did not fail")
+		}
+		var tgtErr *ImageCopyTargetError
+		if !errors.As(err, &tgtErr) || tgtErr.Target.CommonName() != rBlocked.CommonName() {
+			t.Errorf("unexpected error: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt3)
+		if err != nil {
+			t.Errorf("copy to remaining target failed: %v", err)
+		}
+	})
+	t.Run("temp dir", func(t *testing.T) {
+		stageDir := t.TempDir()
+		rcTmp := New(
+			WithConfigHost(config.Host{
+				Name:     tsHost,
+				Hostname: tsHost,
+				TLS:      config.TLSDisabled,
+			}),
+			WithSlog(log),
+			WithTempDir(stageDir),
+		)
+		var staged atomic.Bool
+		cb := func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+			if entries, err := os.ReadDir(stageDir); err == nil && len(entries) > 0 {
+				staged.Store(true)
+			}
+		}
+		tgtDir := t.TempDir()
+		rTgt1, err := ref.New("ocidir://" + tgtDir + "/tgt1:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgt2, err := ref.New("ocidir://" + tgtDir + "/tgt2:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rcTmp.ImageCopyMulti(ctx, rSrc, []ref.Ref{rTgt1, rTgt2}, ImageWithCallback(cb))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		if !staged.Load() {
+			t.Errorf("no files staged in %s", stageDir)
+		}
+		entries, err := os.ReadDir(stageDir)
+		if err != nil || len(entries) > 0 {
+			t.Errorf("temp dir not cleaned up, entries %v, err %v", entries, err)
+		}
+		// cleanup also runs when a target fails
+		rBlocked, err := ref.New("ocidir://" + tempDir + "/blocked/repo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rcTmp.ImageCopyMulti(ctx, rSrc, []ref.Ref{rBlocked, rTgt1})
+		if err == nil {
+			t.Errorf("copy did not fail")
+		}
+		entries, err = os.ReadDir(stageDir)
+		if err != nil || len(entries) > 0 {
+			t.Errorf("temp dir not cleaned up after failure, entries %v, err %v", entries, err)
+		}
+	})
+	t.Run("no targets", func(t *testing.T) {
+		err := rc.ImageCopyMulti(ctx, rSrc, []ref.Ref{})
+		if !errors.Is(err, errs.ErrInvalidReference) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCopyConcurrency(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+	})
+	// track the number of concurrent blob pulls from the source repository
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/v2/testrepo/blobs/") {
+			regHandler.ServeHTTP(w, r)
+			return
+		}
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond * 10)
+		regHandler.ServeHTTP(w, r)
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []config.Host{
+		{
+			Name:          tsHost,
+			Hostname:      tsHost,
+			TLS:           config.TLSDisabled,
+			ReqConcurrent: 10,
+		},
+		{
+			Name:          "registry.example.org",
+			Hostname:      tsHost,
+			TLS:           config.TLSDisabled,
+			ReqConcurrent: 10,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithSlog(log),
+	)
+	rSrc, err := ref.New(tsHost + "/testrepo:v3")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	for _, n := range []int{0, 1, 2} {
+		// copy to a separate registry name to prevent blob mounts
+		rTgt, err := ref.New(fmt.Sprintf("registry.example.org/testconcurrency%d:v3", n))
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		mu.Lock()
+		maxActive = 0
+		mu.Unlock()
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithConcurrency(n))
+		if err != nil {
+			t.Fatalf("failed to copy with concurrency %d: %v", n, err)
+		}
+		mu.Lock()
+		curMax := maxActive
+		mu.Unlock()
+		if curMax == 0 {
+			t.Errorf("no blobs pulled with concurrency %d", n)
+		}
+		if n > 0 && curMax > n {
+			t.Errorf("concurrency exceeded, limit %d, received %d", n, curMax)
+		}
+	}
+}
+
+func TestCopyDescriptorRewrite(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(WithSlog(log))
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://" + tempDir + "/rewrite:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mtNew := "application/vnd.example.layer.v1.tar+gzip"
+	rewrite := func(d descriptor.Descriptor) descriptor.Descriptor {
+		if d.MediaType == mediatype.OCI1LayerGzip {
+			d.MediaType = mtNew
+		}
+		return d
+	}
+	t.Run("media type", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rSrc, rTgt, ImageWithDescriptorRewrite(rewrite))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		mSrc, err := rc.ManifestGet(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		mTgt, err := rc.ManifestGet(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to get target: %v", err)
+		}
+		if mTgt.GetDescriptor().Digest == mSrc.GetDescriptor().Digest {
+			t.Errorf("index digest was not changed")
+		}
+		dlSrc, err := mSrc.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get source manifest list: %v", err)
+		}
+		dlTgt, err := mTgt.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get target manifest list: %v", err)
+		}
+		if len(dlSrc) != len(dlTgt) {
+			t.Fatalf("manifest list length mismatch, expected %d, received %d", len(dlSrc), len(dlTgt))
+		}
+		for i, d := range dlTgt {
+			if d.Platform == nil || dlSrc[i].Platform == nil || d.Platform.String() != dlSrc[i].Platform.String() {
+				t.Errorf("platform changed for entry %d", i)
+			}
+			mChild, err := rc.ManifestGet(ctx, rTgt.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get child %s: %v", d.Digest.String(), err)
+			}
+			mChildSrc, err := rc.ManifestGet(ctx, rSrc.SetDigest(dlSrc[i].Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get source child %s: %v", dlSrc[i].Digest.String(), err)
+			}
+			mi, ok := mChild.(manifest.Imager)
+			if !ok {
+				continue
+			}
+			layers, err := mi.GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			layersSrc, err := mChildSrc.(manifest.Imager).GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get source layers: %v", err)
+			}
+			if len(layers) != len(layersSrc) {
+				t.Fatalf("layer count mismatch, expected %d, received %d", len(layersSrc), len(layers))
+			}
+			changed := false
+			for j, l := range layers {
+				expect := rewrite(layersSrc[j]).MediaType
+				changed = changed || expect != layersSrc[j].MediaType
+				if l.MediaType != expect {
+					t.Errorf("layer %s has unexpected media type, expected %s, received %s", l.Digest.String(), expect, l.MediaType)
+				}
+				_, err = rc.BlobHead(ctx, rTgt, l)
+				if err != nil {
+					t.Errorf("layer %s missing from target: %v", l.Digest.String(), err)
+				}
+			}
+			if changed == (d.Digest == dlSrc[i].Digest) {
+				t.Errorf("unexpected child digest %s, source %s, changed %t", d.Digest.String(), dlSrc[i].Digest.String(), changed)
+			}
+		}
+	})
+	t.Run("digest change", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rSrc, rTgt.SetTag("bad"), ImageWithDescriptorRewrite(func(d descriptor.Descriptor) descriptor.Descriptor {
+			if d.MediaType == mediatype.OCI1LayerGzip {
+				d.Size++
+			}
+			return d
+		}))
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Errorf("unexpected error changing the size: %v", err)
+		}
+	})
+}
+
+func TestCopyPlatformFilter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(WithSlog(log))
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://" + tempDir + "/filter:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	pAMD64, err := platform.Parse("linux/amd64")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	pS390x, err := platform.Parse("linux/s390x")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithPlatformFilter([]platform.Platform{pAMD64, pS390x}))
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	mTgt, err := rc.ManifestGet(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to get target: %v", err)
+	}
+	if mTgt.GetDescriptor().Digest == mSrc.GetDescriptor().Digest {
+		t.Errorf("index digest was not changed")
+	}
+	annot, err := mTgt.(manifest.Annotator).GetAnnotations()
+	if err != nil || annot["org.example.version"] != "v1" {
+		t.Errorf("index annotations were not preserved: %v, %v", annot, err)
+	}
+	dlSrc, err := mSrc.(manifest.Indexer).GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get source manifest list: %v", err)
+	}
+	dlTgt, err := mTgt.(manifest.Indexer).GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get target manifest list: %v", err)
+	}
+	// the source entries are amd64, arm64, and an attestation for each
+	if len(dlSrc) != 4 || len(dlTgt) != 2 {
+		t.Fatalf("unexpected manifest lists, source %d entries, target %d entries", len(dlSrc), len(dlTgt))
+	}
+	if !reflect.DeepEqual(dlTgt[0], dlSrc[0]) || !reflect.DeepEqual(dlTgt[1], dlSrc[2]) {
+		t.Errorf("unexpected target entries, expected %v, received %v", []descriptor.Descriptor{dlSrc[0], dlSrc[2]}, dlTgt)
+	}
+	for _, d := range dlTgt {
+		_, err = rc.ManifestHead(ctx, rTgt.SetDigest(d.Digest.String()))
+		if err != nil {
+			t.Errorf("entry %s missing from target: %v", d.Digest.String(), err)
+		}
+	}
+	_, err = rc.ManifestHead(ctx, rTgt.SetDigest(dlSrc[1].Digest.String()))
+	if err == nil {
+		t.Errorf("filtered platform was copied: %s", dlSrc[1].Digest.String())
+	}
+	// copying again leaves the target unchanged
+	err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithPlatformFilter([]platform.Platform{pAMD64}))
+	if err != nil {
+		t.Fatalf("failed to copy again: %v", err)
+	}
+	mTgt2, err := rc.ManifestHead(ctx, rTgt, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to get target: %v", err)
+	}
+	if mTgt2.GetDescriptor().Digest != mTgt.GetDescriptor().Digest {
+		t.Errorf("target digest changed, expected %s, received %s", mTgt.GetDescriptor().Digest.String(), mTgt2.GetDescriptor().Digest.String())
+	}
+	// no matching platforms
+	err = rc.ImageCopy(ctx, rSrc, rTgt.SetTag("none"), ImageWithPlatformFilter([]platform.Platform{pS390x}))
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("copy without matching platforms did not fail: %v", err)
+	}
+}
+
+func TestCopyIndexOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	tempDir := t.TempDir()
+	rTestdata, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rSrc, err := ref.New("ocidir://" + tempDir + "/src:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rTestdata, rSrc)
+	if err != nil {
+		t.Fatalf("failed to copy source: %v", err)
+	}
+	// push an index with the entries reversed from the original order
+	m, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok {
+		t.Fatalf("source is not an index")
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get manifest list: %v", err)
+	}
+	dlRev := make([]descriptor.Descriptor, len(dl))
+	for i, d := range dl {
+		dlRev[len(dl)-1-i] = d
+	}
+	err = mi.SetManifestList(dlRev)
+	if err != nil {
+		t.Fatalf("failed to set manifest list: %v", err)
+	}
+	rSrc = rSrc.SetTag("reversed")
+	err = rc.ManifestPut(ctx, rSrc, m)
+	if err != nil {
+		t.Fatalf("failed to put reversed index: %v", err)
+	}
+	rawSrc, err := m.RawBody()
+	if err != nil {
+		t.Fatalf("failed to get raw body: %v", err)
+	}
+	platformList := func(t *testing.T, r ref.Ref) []string {
+		t.Helper()
+		m, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", r.CommonName(), err)
+		}
+		dl, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		list := make([]string, len(dl))
+		for i, d := range dl {
+			list[i] = d.Digest.String()
+			if d.Platform != nil {
+				list[i] = d.Platform.String()
+			}
+		}
+		return list
+	}
+	expectOrder := platformList(t, rSrc)
+	for _, tgt := range []string{"ocidir://" + tempDir + "/tgt:reversed", tsHost + "/tgt:reversed"} {
+		rTgt, err := ref.New(tgt)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		t.Run(rTgt.Scheme, func(t *testing.T) {
+			err := rc.ImageCopy(ctx, rSrc, rTgt)
+			if err != nil {
+				t.Fatalf("failed to copy: %v", err)
+			}
+			mTgt, err := rc.ManifestGet(ctx, rTgt)
+			if err != nil {
+				t.Fatalf("failed to get target: %v", err)
+			}
+			rawTgt, err := mTgt.RawBody()
+			if err != nil {
+				t.Fatalf("failed to get raw body: %v", err)
+			}
+			if !bytes.Equal(rawSrc, rawTgt) {
+				t.Errorf("index was modified, expected %s, received %s", rawSrc, rawTgt)
+			}
+			if mTgt.GetDescriptor().Digest != m.GetDescriptor().Digest {
+				t.Errorf("digest mismatch, expected %s, received %s", m.GetDescriptor().Digest, mTgt.GetDescriptor().Digest)
+			}
+		})
+	}
+	t.Run("rewrite", func(t *testing.T) {
+		// an index that must be regenerated keeps the source order
+		rTgt, err := ref.New("ocidir://" + tempDir + "/tgt:rewrite")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithDescriptorRewrite(func(d descriptor.Descriptor) descriptor.Descriptor {
+			if d.MediaType == mediatype.OCI1LayerGzip {
+				d.MediaType = "application/vnd.example.layer.v1.tar+gzip"
+			}
+			return d
+		}))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		order := platformList(t, rTgt)
+		if strings.Join(order, ",") != strings.Join(expectOrder, ",") {
+			t.Errorf("index order changed, expected %v, received %v", expectOrder, order)
+		}
+	})
+}
+
+func TestCopyMaxDepth(t *testing.T) {
+	t.Parallel()
+	// addIndex stores an index in the memScheme
+	addIndex := func(mem *memScheme, annotations map[string]string, entries ...descriptor.Descriptor) descriptor.Descriptor {
+		t.Helper()
+		raw, err := json.Marshal(v1.Index{
+			Versioned:   v1.IndexSchemaVersion,
+			MediaType:   mediatype.OCI1ManifestList,
+			Manifests:   entries,
+			Annotations: annotations,
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal index: %v", err)
+		}
+		d := digest.FromBytes(raw)
+		desc := descriptor.Descriptor{
+			MediaType: mediatype.OCI1ManifestList,
+			Digest:    d,
+			Size:      int64(len(raw)),
+		}
+		mem.mu.Lock()
+		mem.manifests[d.String()] = desc
+		mem.raw[d.String()] = raw
+		mem.mu.Unlock()
+		return desc
+	}
+	addTag := func(mem *memScheme, repo, tag string, d descriptor.Descriptor) {
+		mem.mu.Lock()
+		if mem.tags[repo] == nil {
+			mem.tags[repo] = map[string]string{}
+		}
+		mem.tags[repo][tag] = d.Digest.String()
+		mem.mu.Unlock()
+	}
+	// refs are parsed after the client registers the src and tgt schemes
+	parseRefs := func() (ref.Ref, ref.Ref) {
+		t.Helper()
+		rSrc, err := ref.New("src://example/repo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgt, err := ref.New("tgt://example/repo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		return rSrc, rTgt
+	}
+	t.Run("loop", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		mem := newMemScheme()
+		rc := New(WithScheme("src", mem), WithScheme("tgt", newMemScheme()))
+		rSrc, rTgt := parseRefs()
+		// digest tags on each manifest point to the other manifest
+		dA := addIndex(mem, map[string]string{"name": "a"})
+		dB := addIndex(mem, map[string]string{"name": "b"})
+		addTag(mem, rSrc.Path, rSrc.Tag, dA)
+		addTag(mem, rSrc.Path, fmt.Sprintf("%s-%s", dA.Digest.Algorithm(), dA.Digest.Encoded()), dB)
+		addTag(mem, rSrc.Path, fmt.Sprintf("%s-%s", dB.Digest.Algorithm(), dB.Digest.Encoded()), dA)
+		err := rc.ImageCopy(ctx, rSrc, rTgt, ImageWithDigestTags())
+		if err != nil {
+			t.Errorf("copy of looping digest tags failed: %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("copy did not finish before the timeout: %v", ctx.Err())
+		}
+		tl, err := rc.TagList(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, _ := tl.GetTags()
+		if len(tags) != 3 {
+			t.Errorf("unexpected tags on target: %v", tags)
+		}
+		// applying a depth limit fails on the first digest tag
+		err = rc.ImageCopy(ctx, rSrc, rTgt.SetTag("v2"), ImageWithDigestTags(), ImageWithMaxDepth(1))
+		if !errors.Is(err, errs.ErrDepthLimitExceeded) {
+			t.Errorf("copy beyond the max depth did not fail: %v", err)
+		}
+	})
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		mem := newMemScheme()
+		rc := New(WithScheme("src", mem), WithScheme("tgt", newMemScheme()))
+		rSrc, rTgt := parseRefs()
+		d := addIndex(mem, nil)
+		for i := 0; i < 4; i++ {
+			d = addIndex(mem, nil, d)
+		}
+		addTag(mem, rSrc.Path, rSrc.Tag, d)
+		err := rc.ImageCopy(ctx, rSrc, rTgt, ImageWithMaxDepth(3))
+		if !errors.Is(err, errs.ErrDepthLimitExceeded) {
+			t.Errorf("copy beyond the max depth did not fail: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithMaxDepth(5))
+		if err != nil {
+			t.Errorf("copy within the max depth failed: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Errorf("copy with the default max depth failed: %v", err)
+		}
+	})
+}
+
+func TestCopyDigestTagReferrers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	rc := New()
+	rTestdata, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rSrc, err := ref.New("ocidir://" + tempDir + "/src:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://" + tempDir + "/tgt:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rTestdata, rSrc)
+	if err != nil {
+		t.Fatalf("failed to copy from testdata: %v", err)
+	}
+	mSrc, err := rc.ManifestHead(ctx, rSrc, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head source: %v", err)
+	}
+	dSrc := mSrc.GetDescriptor()
+	// push a signature with docker media types to a digest tag
+	confDesc, err := rc.BlobPut(ctx, rSrc, descriptor.Descriptor{MediaType: mediatype.Docker2ImageConfig}, bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatalf("failed to put config: %v", err)
+	}
+	sigDesc, err := rc.BlobPut(ctx, rSrc, descriptor.Descriptor{MediaType: "application/vnd.dev.cosign.simplesigning.v1+json"}, bytes.NewReader([]byte(`{"critical":{}}`)))
+	if err != nil {
+		t.Fatalf("failed to put signature: %v", err)
+	}
+	mSig, err := manifest.New(manifest.WithOrig(schema2.Manifest{
+		Versioned: schema2.ManifestSchemaVersion,
+		Config:    confDesc,
+		Layers:    []descriptor.Descriptor{sigDesc},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create signature manifest: %v", err)
+	}
+	sigTag := fmt.Sprintf("%s-%s.sig", dSrc.Digest.Algorithm(), dSrc.Digest.Encoded())
+	err = rc.ManifestPut(ctx, rSrc.SetTag(sigTag), mSig)
+	if err != nil {
+		t.Fatalf("failed to put signature manifest: %v", err)
+	}
+	result, err := rc.ImageCopyDetailed(ctx, rSrc, rTgt, ImageWithDigestTagsAsReferrers())
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	// the digest tag is replaced by a referrer
+	tl, err := rc.TagList(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags, _ := tl.GetTags()
+	for _, tag := range tags {
+		if tag == sigTag {
+			t.Errorf("digest tag copied to the target: %v", tags)
+		}
+	}
+	rl, err := rc.ReferrerList(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if len(rl.Descriptors) != 1 {
+		t.Fatalf("unexpected referrers: %v", rl.Descriptors)
+	}
+	found := false
+	for _, e := range result.Manifests {
+		if e.Descriptor.Digest == rl.Descriptors[0].Digest && e.Action == ImageCopyCopied {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("converted referrer missing from result: %v", result.Manifests)
+	}
+	mRef, err := rc.ManifestGet(ctx, rTgt.SetDigest(rl.Descriptors[0].Digest.String()))
+	if err != nil {
+		t.Fatalf("failed to get referrer: %v", err)
+	}
+	om, ok := mRef.GetOrig().(v1.Manifest)
+	if !ok {
+		t.Fatalf("referrer is not an OCI manifest: %T", mRef.GetOrig())
+	}
+	if om.Subject == nil || om.Subject.Digest != dSrc.Digest || om.Subject.MediaType != dSrc.MediaType || om.Subject.Size != dSrc.Size {
+		t.Errorf("unexpected subject, expected %v, received %v", dSrc, om.Subject)
+	}
+	if om.Config.MediaType != mediatype.OCI1ImageConfig || len(om.Layers) != 1 || om.Layers[0].Digest != sigDesc.Digest || om.Layers[0].MediaType != sigDesc.MediaType {
+		t.Errorf("unexpected referrer content: %v", om)
+	}
+	if om.ArtifactType != "application/vnd.dev.cosign.artifact.sig.v1+json" || rl.Descriptors[0].ArtifactType != om.ArtifactType {
+		t.Errorf("unexpected artifact type, manifest %s, referrer %s", om.ArtifactType, rl.Descriptors[0].ArtifactType)
+	}
+	_, err = rc.BlobHead(ctx, rTgt, sigDesc)
+	if err != nil {
+		t.Errorf("signature blob missing on target: %v", err)
+	}
+	// a second copy skips the converted referrer
+	result, err = rc.ImageCopyDetailed(ctx, rSrc, rTgt, ImageWithDigestTagsAsReferrers())
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	for _, e := range result.Manifests {
+		if e.Action != ImageCopySkipped {
+			t.Errorf("unexpected action for %s: %s", e.Descriptor.Digest.String(), e.Action)
+		}
+	}
+}
+
 func TestExportImport(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -530,4 +1733,109 @@ func TestExportImport(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to import: %v", err)
 	}
+
+	// create an OCI layout tar with multiple tags, and entries with a missing or invalid tag
+	indexRaw, err := os.ReadFile(filepath.Join(tempDir, "testrepo", ociIndexFilename))
+	if err != nil {
+		t.Fatalf("failed to read index.json: %v", err)
+	}
+	indexSrc := v1.Index{}
+	err = json.Unmarshal(indexRaw, &indexSrc)
+	if err != nil {
+		t.Fatalf("failed to parse index.json: %v", err)
+	}
+	indexAll := v1.Index{
+		Versioned: v1.IndexSchemaVersion,
+		MediaType: mediatype.OCI1ManifestList,
+	}
+	for _, d := range indexSrc.Manifests {
+		switch d.Annotations[annotationRefName] {
+		case "v1":
+			indexAll.Manifests = append(indexAll.Manifests, d)
+			dNoTag := d
+			dNoTag.Annotations = nil
+			indexAll.Manifests = append(indexAll.Manifests, dNoTag)
+		case "v2":
+			indexAll.Manifests = append(indexAll.Manifests, d)
+			dBadTag := d
+			dBadTag.Annotations = map[string]string{annotationRefName: "invalid tag!"}
+			indexAll.Manifests = append(indexAll.Manifests, dBadTag)
+		case "v3":
+			indexAll.Manifests = append(indexAll.Manifests, d)
+		}
+	}
+	indexAllRaw, err := json.Marshal(indexAll)
+	if err != nil {
+		t.Fatalf("failed to marshal index: %v", err)
+	}
+	fileAll, err := os.Create(filepath.Join(tempDir, "all.tar"))
+	if err != nil {
+		t.Fatalf("failed to create tar: %v", err)
+	}
+	tw = tar.NewWriter(fileAll)
+	tarAdd := func(name string, b []byte) {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(b))})
+		if err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		_, err = tw.Write(b)
+		if err != nil {
+			t.Fatalf("failed to write tar file %s: %v", name, err)
+		}
+	}
+	tarAdd(ociLayoutFilename, []byte(`{"imageLayoutVersion":"`+ociLayoutVersion+`"}`))
+	tarAdd(ociIndexFilename, indexAllRaw)
+	blobDir := filepath.Join(tempDir, "testrepo", "blobs", "sha256")
+	blobList, err := os.ReadDir(blobDir)
+	if err != nil {
+		t.Fatalf("failed to list blobs: %v", err)
+	}
+	for _, entry := range blobList {
+		b, err := os.ReadFile(filepath.Join(blobDir, entry.Name()))
+		if err != nil {
+			t.Fatalf("failed to read blob: %v", err)
+		}
+		tarAdd("blobs/sha256/"+entry.Name(), b)
+	}
+	err = tw.Close()
+	if err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	fileAll.Close()
+
+	// import every tag
+	fileInAll, err := os.Open(filepath.Join(tempDir, "all.tar"))
+	if err != nil {
+		t.Fatalf("failed to open tar: %v", err)
+	}
+	defer fileInAll.Close()
+	rOutAll, err := ref.New("ocidir://" + tempDir + "/testall")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	imported := []string{}
+	err = rc.ImageImport(ctx, rOutAll, fileInAll, ImageWithImportAll(func(r ref.Ref) {
+		imported = append(imported, r.Tag)
+	}))
+	if err != nil {
+		t.Fatalf("failed to import all: %v", err)
+	}
+	sort.Strings(imported)
+	if strings.Join(imported, ",") != "v1,v2,v3" {
+		t.Errorf("unexpected tags imported: %v", imported)
+	}
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		mSrc, err := rc.ManifestHead(ctx, rIn1.SetTag(tag))
+		if err != nil {
+			t.Fatalf("failed to head source %s: %v", tag, err)
+		}
+		mTgt, err := rc.ManifestHead(ctx, rOutAll.SetTag(tag))
+		if err != nil {
+			t.Errorf("failed to head imported %s: %v", tag, err)
+			continue
+		}
+		if mSrc.GetDescriptor().Digest != mTgt.GetDescriptor().Digest {
+			t.Errorf("digest mismatch for %s, expected %s, received %s", tag, mSrc.GetDescriptor().Digest, mTgt.GetDescriptor().Digest)
+		}
+	}
 }
@@ -587,7 +587,15 @@ func (b *bearerHandler) GenerateAuth() (string, error) {
 	}
 
 	// attempt to post with oauth form, this also uses refresh tokens
-	if err := b.tryPost(); err == nil {
+	err := b.tryPost()
+	if err == ErrUnauthorized && b.token.RefreshToken != "" {
+		// a refresh token from the server may expire before the identity token or password, retry with the credential
+		b.slog.Debug("Refresh token rejected, retrying with credentials",
+			slog.String("host", b.host))
+		b.token.RefreshToken = ""
+		err = b.tryPost()
+	}
+	if err == nil {
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	} else if err != ErrUnauthorized {
 		return "", fmt.Errorf("failed to request auth token (post): %w%.0w", err, errs.ErrHTTPUnauthorized)
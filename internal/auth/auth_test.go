@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -484,3 +485,112 @@ func TestBearer(t *testing.T) {
 		t.Errorf("token2 (push) expires early, expected %d, received %d", minTokenLife, bearer.token.ExpiresIn)
 	}
 }
+
+func TestBearerIdentityToken(t *testing.T) {
+	t.Parallel()
+	useragent := "regclient/test"
+	identityToken := "identity-token-value"
+	tokenResp, _ := json.Marshal(bearerToken{
+		AccessToken:  "access1",
+		ExpiresIn:    900,
+		RefreshToken: "refresh-stale",
+	})
+	tokenIdentityForm := url.Values{}
+	tokenIdentityForm.Set("scope", "repository:reponame:pull")
+	tokenIdentityForm.Set("service", "test")
+	tokenIdentityForm.Set("client_id", useragent)
+	tokenIdentityForm.Set("grant_type", "refresh_token")
+	tokenIdentityForm.Set("refresh_token", identityToken)
+	tokenStaleForm := url.Values{}
+	tokenStaleForm.Set("scope", "repository:reponame:pull")
+	tokenStaleForm.Set("service", "test")
+	tokenStaleForm.Set("client_id", useragent)
+	tokenStaleForm.Set("grant_type", "refresh_token")
+	tokenStaleForm.Set("refresh_token", "refresh-stale")
+	var mu sync.Mutex
+	reqCount := 0
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "req identity token",
+				Method: "POST",
+				Path:   "/tokens",
+				Body:   []byte(tokenIdentityForm.Encode()),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: 200,
+				Body:   tokenResp,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "req stale refresh token",
+				Method: "POST",
+				Path:   "/tokens",
+				Body:   []byte(tokenStaleForm.Encode()),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusUnauthorized,
+			},
+		},
+	}
+	handler := reqresp.NewHandler(t, rrs)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqCount++
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	bearer := NewBearerHandler(&http.Client{}, useragent, tsURL.Host,
+		func(h string) Cred { return Cred{Token: identityToken} },
+		slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+	).(*bearerHandler)
+	c, err := parseAuthHeader(
+		`Bearer realm="` + tsURL.String() +
+			`/tokens",service="test"` +
+			`,scope="repository:reponame:pull"`)
+	if err != nil {
+		t.Fatalf("failed on parse challenge: %v", err)
+	}
+	err = bearer.ProcessChallenge(c[0])
+	if err != nil {
+		t.Fatalf("failed on response to challenge: %v", err)
+	}
+	// the identity token is exchanged for an access token
+	resp, err := bearer.GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if resp != "Bearer access1" {
+		t.Errorf("unexpected auth, expected %s, received %s", "Bearer access1", resp)
+	}
+	// the access token is reused until it expires
+	resp, err = bearer.GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if resp != "Bearer access1" {
+		t.Errorf("unexpected auth, expected %s, received %s", "Bearer access1", resp)
+	}
+	mu.Lock()
+	if reqCount != 1 {
+		t.Errorf("unexpected token requests, expected 1, received %d", reqCount)
+	}
+	mu.Unlock()
+	// after expiring, the rejected refresh token falls back to the identity token
+	bearer.token.IssuedAt = time.Now().Add(-900 * time.Second)
+	resp, err = bearer.GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth after expiring: %v", err)
+	}
+	if resp != "Bearer access1" {
+		t.Errorf("unexpected auth, expected %s, received %s", "Bearer access1", resp)
+	}
+	mu.Lock()
+	if reqCount != 3 {
+		t.Errorf("unexpected token requests, expected 3, received %d", reqCount)
+	}
+	mu.Unlock()
+}
@@ -0,0 +1,164 @@
+// Package blobcache stores blobs in a local directory for reuse between copies.
+package blobcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Dir is a blob cache in a directory, with each blob stored in a file named by the digest.
+// When a max size is set, the least recently used blobs are removed after adding a blob.
+type Dir struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// New returns a cache in the directory, creating the directory if needed.
+// A maxSize of 0 or less disables the size limit.
+func New(dir string, maxSize int64) (*Dir, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	return &Dir{
+		dir:     dir,
+		maxSize: maxSize,
+	}, nil
+}
+
+// Get returns a reader for the cached blob.
+func (c *Dir) Get(ctx context.Context, d descriptor.Descriptor) (io.ReadCloser, error) {
+	file, err := c.filename(d)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fh, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("blob %s not in cache%.0w", d.Digest.String(), errs.ErrNotFound)
+		}
+		return nil, err
+	}
+	fi, err := fh.Stat()
+	if err != nil || (d.Size > 0 && fi.Size() != d.Size) {
+		_ = fh.Close()
+		_ = os.Remove(file)
+		return nil, fmt.Errorf("blob %s in cache has an invalid size%.0w", d.Digest.String(), errs.ErrNotFound)
+	}
+	// track the last access for pruning
+	now := time.Now()
+	_ = os.Chtimes(file, now, now)
+	return fh, nil
+}
+
+// Put adds a blob to the cache, verifying the digest before it is made available.
+func (c *Dir) Put(ctx context.Context, d descriptor.Descriptor, rdr io.Reader) error {
+	file, err := c.filename(d)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0o755)
+	if err != nil {
+		return err
+	}
+	fh, err := os.CreateTemp(filepath.Dir(file), ".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpName := fh.Name()
+	defer func() {
+		_ = fh.Close()
+		_ = os.Remove(tmpName)
+	}()
+	digester := d.Digest.Algorithm().Digester()
+	size, err := io.Copy(io.MultiWriter(fh, digester.Hash()), rdr)
+	if err != nil {
+		return err
+	}
+	if digester.Digest() != d.Digest {
+		return fmt.Errorf("blob digest mismatch, expected %s, received %s%.0w", d.Digest.String(), digester.Digest().String(), errs.ErrDigestMismatch)
+	}
+	if d.Size > 0 && size != d.Size {
+		return fmt.Errorf("blob size mismatch, expected %d, received %d%.0w", d.Size, size, errs.ErrMismatch)
+	}
+	err = fh.Close()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err = os.Rename(tmpName, file)
+	if err != nil {
+		return err
+	}
+	return c.pruneLocked(file)
+}
+
+// filename returns the path to a blob in the cache.
+func (c *Dir) filename(d descriptor.Descriptor) (string, error) {
+	if err := d.Digest.Validate(); err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, d.Digest.Algorithm().String(), d.Digest.Encoded()), nil
+}
+
+// pruneLocked deletes the least recently used blobs until the cache is within the max size.
+// The keep file is never deleted, and the lock must be held.
+func (c *Dir) pruneLocked(keep string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	type entry struct {
+		name string
+		size int64
+		mod  time.Time
+	}
+	entries := []entry{}
+	total := int64(0)
+	err := filepath.WalkDir(c.dir, func(name string, de fs.DirEntry, err error) error {
+		if err != nil || de.IsDir() || filepath.Base(name)[0] == '.' {
+			return err
+		}
+		fi, err := de.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{name: name, size: fi.Size(), mod: fi.ModTime()})
+		total += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].mod.Before(entries[j].mod)
+	})
+	for _, e := range entries {
+		if total <= c.maxSize {
+			break
+		}
+		if e.name == keep {
+			continue
+		}
+		// skip files that cannot be removed, e.g. open on Windows
+		if err := os.Remove(e.name); err != nil {
+			continue
+		}
+		total -= e.size
+	}
+	return nil
+}
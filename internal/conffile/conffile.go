@@ -108,7 +108,7 @@ func (f *File) Write(rdr io.Reader) error {
 	}
 
 	// adjust file ownership/permissions
-	mode := os.FileMode(0600)
+	mode := os.FileMode(f.perms)
 	uid := os.Getuid()
 	gid := os.Getgid()
 	// adjust defaults based on existing file if available
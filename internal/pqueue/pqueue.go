@@ -65,7 +65,7 @@ func (q *Queue[T]) Acquire(ctx context.Context, e T) (func(), error) {
 		q.mu.Lock()
 		for i := range q.queued {
 			if q.queued[i] == &e {
-				if len(q.queued) >= i+1 {
+				if len(q.queued) == i+1 {
 					q.queued = q.queued[:i]
 					q.wait = q.wait[:i]
 				} else {
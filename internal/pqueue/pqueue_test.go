@@ -142,6 +142,43 @@ func TestQueue(t *testing.T) {
 	done4()
 }
 
+func TestQueueCancel(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	q := New(Opts[testData]{Max: 1})
+	done0, err := q.Acquire(ctx, testData{pref: 0})
+	if err != nil {
+		t.Fatalf("failed to acquire queue 0: %v", err)
+	}
+	// queue two entries, canceling the first while the second is still waiting
+	ctxCancel, cancel := context.WithCancel(ctx)
+	finished := make(chan int)
+	for i, c := range []context.Context{ctxCancel, ctx} {
+		go func(i int, c context.Context) {
+			done, err := q.Acquire(c, testData{pref: i + 1})
+			if err == nil {
+				done()
+			}
+			finished <- i + 1
+		}(i, c)
+		sleepMS(2)
+	}
+	cancel()
+	if i := <-finished; i != 1 {
+		t.Fatalf("unexpected finished entry, expected 1, received %d", i)
+	}
+	// the remaining queued entry must still be released
+	done0()
+	select {
+	case i := <-finished:
+		if i != 2 {
+			t.Errorf("unexpected finished entry, expected 2, received %d", i)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("queued entry was not released after another entry was canceled")
+	}
+}
+
 func TestMulti(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -3,14 +3,21 @@ package reghttp
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -23,7 +30,6 @@ import (
 	"time"
 
 	// crypto libraries included for go-digest
-	_ "crypto/sha256"
 	_ "crypto/sha512"
 
 	"github.com/regclient/regclient/config"
@@ -37,11 +43,19 @@ import (
 
 var defaultDelayInit, _ = time.ParseDuration("0.1s")
 var defaultDelayMax, _ = time.ParseDuration("30s")
+var defaultRetryAfterMax, _ = time.ParseDuration("5m")
 var warnRegexp = regexp.MustCompile(`^299\s+-\s+"([^"]+)"`)
 
+// errRedirectDenied is returned when a host is configured to deny redirects to a different host.
+var errRedirectDenied = errors.New("redirect to a different host denied")
+
+// errCertPinned is returned when none of the registry certificates match the pinned fingerprints.
+var errCertPinned = errors.New("certificate does not match pinned fingerprint")
+
 const (
-	DefaultRetryLimit = 5 // number of times a request will be retried
-	backoffResetCount = 5 // number of successful requests needed to reduce the backoff
+	DefaultRetryLimit    = 5 // number of times a request will be retried
+	backoffResetCount    = 5 // number of successful requests needed to reduce the backoff
+	defaultBackoffFactor = 2 // multiplier of the delay for each backoff
 )
 
 // Client is an HTTP client wrapper.
@@ -55,8 +69,19 @@ type Client struct {
 	retryLimit    int                       // number of retries before failing a request, this applies to each host, and each request
 	delayInit     time.Duration             // how long to initially delay requests on a failure
 	delayMax      time.Duration             // maximum time to delay a request
+	backoffFactor float64                   // multiplier of the delay for each backoff
+	backoffJitter float64                   // fraction of the delay that is randomized
+	retryAfterMax time.Duration             // limit on the delay requested by a Retry-After header
+	rand          *rand.Rand                // random source for the jitter, wrap access with randMu
+	randMu        sync.Mutex                // mutex for the random source
 	slog          *slog.Logger              // logging for tracing and failures
 	userAgent     string                    // user agent to specify in http request headers
+	headTimeout   time.Duration             // limit for each head request attempt
+	acceptEnc     string                    // Accept-Encoding header for manifest and query requests
+	xferTimeout   time.Duration             // limit for a transfer without any progress
+	debugW        io.Writer                 // output for dumps of each request and response
+	debugBodyMax  int64                     // number of bytes from each body included in the dump
+	debugMu       sync.Mutex                // serialize writes to debugW
 	mu            sync.Mutex                // mutex to prevent data races
 }
 
@@ -69,6 +94,7 @@ type clientHost struct {
 	backoffCur   int                         // current count of backoffs for this host
 	backoffLast  time.Time                   // time the last request was released, this may be in the future if there is a queue, or zero if no delay is needed
 	backoffReset int                         // count of successful requests when a backoff is experienced, once [backoffResetCount] is reached, [backoffCur] is reduced by one and this is reset to 0
+	retryAfter   time.Time                   // time requested by a Retry-After header before the next request, or zero if not set
 	reqFreq      time.Duration               // how long between submitting requests for this host
 	reqNext      time.Time                   // time to release the next request
 	throttle     *pqueue.Queue[reqmeta.Data] // limit concurrent requests to the host
@@ -82,6 +108,7 @@ type Req struct {
 	Method      string                        // http method to call
 	DirectURL   *url.URL                      // url to query, overrides repository, path, and query
 	Repository  string                        // repository to scope the request
+	Scope       string                        // auth scope to request, overrides the default repository scope
 	Path        string                        // path of the request within a repository
 	Query       url.Values                    // url query parameters
 	BodyLen     int64                         // length of body to send
@@ -91,6 +118,7 @@ type Req struct {
 	NoPrefix    bool                          // do not include the repository prefix
 	NoMirrors   bool                          // do not send request to a mirror
 	ExpectLen   int64                         // expected size of the returned body
+	ReadOffset  int64                         // offset to start reading the body, requested with a range header and tracked on retries
 	TransactLen int64                         // size of an overall transaction for the priority queue
 	IgnoreErr   bool                          // ignore http errors and do not trigger backoffs
 }
@@ -105,8 +133,16 @@ type Resp struct {
 	done             bool
 	reader           io.Reader
 	readCur, readMax int64
+	etag             string // strong ETag of the first response, used with If-Range when resuming
+	lastMod          string // strong Last-Modified of the first response, used with If-Range without an ETag
+	digest           string // Docker-Content-Digest of the first response, compared when resuming
+	changed          bool   // set when a resumed request detected the content changed on the server
+	err              error  // set when the response cannot be read any further
 	retryCount       int
 	throttleDone     func()
+	reqCtx           context.Context // context of the current attempt
+	reqCancel        func()          // releases the context of the current attempt
+	idle             *time.Timer     // cancels a transfer without progress
 }
 
 // Opts is used to configure client options.
@@ -115,11 +151,14 @@ type Opts func(*Client)
 // NewClient returns a client for handling requests.
 func NewClient(opts ...Opts) *Client {
 	c := Client{
-		httpClient: &http.Client{},
-		host:       map[string]*clientHost{},
-		retryLimit: DefaultRetryLimit,
-		delayInit:  defaultDelayInit,
-		delayMax:   defaultDelayMax,
+		httpClient:    &http.Client{},
+		host:          map[string]*clientHost{},
+		retryLimit:    DefaultRetryLimit,
+		delayInit:     defaultDelayInit,
+		delayMax:      defaultDelayMax,
+		retryAfterMax: defaultRetryAfterMax,
+		//#nosec G404 jitter does not need a cryptographically secure random source
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
 		slog:       slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
 		rootCAPool: [][]byte{},
 		rootCADirs: []string{},
@@ -186,6 +225,34 @@ func WithDelay(delayInit time.Duration, delayMax time.Duration) Opts {
 	}
 }
 
+// WithAcceptEncoding sets the Accept-Encoding header on manifest and query GET requests, e.g. "gzip" or "identity".
+// By default, the header is managed by the [http.Transport].
+// Responses with a gzip Content-Encoding are decompressed regardless of this setting.
+func WithAcceptEncoding(enc string) Opts {
+	return func(c *Client) {
+		c.acceptEnc = enc
+	}
+}
+
+// WithDebugHTTP writes the request line, headers, and response status and headers of every HTTP request to w.
+// The Authorization, Proxy-Authorization, Cookie, and Set-Cookie headers are censored, along with any headers configured for the host.
+// When bodyMax is greater than 0, up to that many bytes of each request and response body are included.
+// Form encoded request bodies and token responses are censored, but other bodies may contain sensitive data.
+func WithDebugHTTP(w io.Writer, bodyMax int64) Opts {
+	return func(c *Client) {
+		c.debugW = w
+		c.debugBodyMax = bodyMax
+	}
+}
+
+// WithHeadTimeout limits the time for each attempt of a HEAD request.
+// This allows a stuck HEAD request to fail fast without limiting the time of a large transfer.
+func WithHeadTimeout(d time.Duration) Opts {
+	return func(c *Client) {
+		c.headTimeout = d
+	}
+}
+
 // WithHTTPClient uses a specific http client with retryable requests.
 func WithHTTPClient(hc *http.Client) Opts {
 	return func(c *Client) {
@@ -193,6 +260,39 @@ func WithHTTPClient(hc *http.Client) Opts {
 	}
 }
 
+// WithRetryBackoff configures the growth and randomization of the delay between retries.
+// Each backoff multiplies the delay by factor (defaults to 2, must be at least 1), limited by the max delay from [WithDelay].
+// Jitter is the fraction of the delay that is randomly removed, from 0 (default) to 1, to spread out retries from multiple clients.
+// The [config.Host] RetryBackoff and RetryJitter settings override these values for a registry.
+func WithRetryBackoff(factor, jitter float64) Opts {
+	return func(c *Client) {
+		if factor >= 1 {
+			c.backoffFactor = factor
+		}
+		if jitter >= 0 && jitter <= 1 {
+			c.backoffJitter = jitter
+		}
+	}
+}
+
+// WithRetryAfterMax limits the delay requested by a Retry-After header (defaults to 5 minutes).
+// This prevents a registry from stalling requests with a large value.
+func WithRetryAfterMax(d time.Duration) Opts {
+	return func(c *Client) {
+		if d > 0 {
+			c.retryAfterMax = d
+		}
+	}
+}
+
+// WithRetrySeed sets the seed for the random jitter, providing repeatable delays for testing.
+func WithRetrySeed(seed int64) Opts {
+	return func(c *Client) {
+		//#nosec G404 jitter does not need a cryptographically secure random source
+		c.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
 // WithRetryLimit restricts the number of retries (defaults to 5).
 func WithRetryLimit(rl int) Opts {
 	return func(c *Client) {
@@ -216,6 +316,14 @@ func WithTransport(t *http.Transport) Opts {
 	}
 }
 
+// WithTransferTimeout cancels a request other than a HEAD that has no progress for the duration.
+// The timer is reset each time data is sent or received, so a slow transfer that is progressing will continue.
+func WithTransferTimeout(d time.Duration) Opts {
+	return func(c *Client) {
+		c.xferTimeout = d
+	}
+}
+
 // WithUserAgent sets a user agent header.
 func WithUserAgent(ua string) Opts {
 	return func(c *Client) {
@@ -229,7 +337,7 @@ func (c *Client) Do(ctx context.Context, req *Req) (*Resp, error) {
 		ctx:     ctx,
 		client:  c,
 		req:     req,
-		readCur: 0,
+		readCur: req.ReadOffset,
 		readMax: req.ExpectLen,
 	}
 	err := resp.next()
@@ -237,8 +345,12 @@ func (c *Client) Do(ctx context.Context, req *Req) (*Resp, error) {
 }
 
 // next sends requests until a mirror responds or all requests fail.
-func (resp *Resp) next() error {
-	var err error
+func (resp *Resp) next() (err error) {
+	defer func() {
+		if err != nil {
+			resp.attemptDone()
+		}
+	}()
 	c := resp.client
 	req := resp.req
 	// lookup reqHost entry
@@ -247,11 +359,14 @@ func (resp *Resp) next() error {
 	hosts := make([]*clientHost, 0, 1+len(reqHost.config.Mirrors))
 	if !req.NoMirrors {
 		for _, m := range reqHost.config.Mirrors {
-			hosts = append(hosts, c.getHost(m))
+			mHost := c.getHost(m)
+			if !hostInList(hosts, mHost) && mHost != reqHost {
+				hosts = append(hosts, mHost)
+			}
 		}
 	}
 	hosts = append(hosts, reqHost)
-	sort.Slice(hosts, sortHostsCmp(hosts, reqHost.config.Name))
+	sort.SliceStable(hosts, sortHostsCmp(hosts, reqHost.config.Name))
 	// loop over requests to mirrors and retries
 	curHost := 0
 	for {
@@ -271,6 +386,9 @@ func (resp *Resp) next() error {
 		resp.mirror = h.config.Name
 		// there is an intentional extra retry in this check to allow for auth requests
 		if resp.retryCount > c.retryLimit {
+			if err != nil {
+				return fmt.Errorf("%w: %w", errs.ErrRetryLimitExceeded, err)
+			}
 			return errs.ErrRetryLimitExceeded
 		}
 		resp.retryCount++
@@ -344,8 +462,9 @@ func (resp *Resp) next() error {
 				case <-time.After(sleepTime):
 				}
 			}
+			resp.attemptStart()
 			var httpReq *http.Request
-			httpReq, err = http.NewRequestWithContext(resp.ctx, req.Method, u.String(), nil)
+			httpReq, err = http.NewRequestWithContext(resp.reqCtx, req.Method, u.String(), nil)
 			if err != nil {
 				dropHost = true
 				return err
@@ -356,8 +475,14 @@ func (resp *Resp) next() error {
 					dropHost = true
 					return err
 				}
-				httpReq.Body = body
-				httpReq.GetBody = req.BodyFunc
+				httpReq.Body = resp.idleWrap(body)
+				httpReq.GetBody = func() (io.ReadCloser, error) {
+					body, err := req.BodyFunc()
+					if err != nil {
+						return nil, err
+					}
+					return resp.idleWrap(body), nil
+				}
 				httpReq.ContentLength = req.BodyLen
 			} else if len(req.BodyBytes) > 0 {
 				body := io.NopCloser(bytes.NewReader(req.BodyBytes))
@@ -368,12 +493,25 @@ func (resp *Resp) next() error {
 			if len(req.Headers) > 0 {
 				httpReq.Header = req.Headers.Clone()
 			}
+			for k, v := range h.config.Headers {
+				if !config.HeaderReserved(k) && httpReq.Header.Get(k) == "" {
+					httpReq.Header.Set(k, v)
+				}
+			}
+			if c.acceptEnc != "" && req.Method == "GET" && (req.MetaKind == reqmeta.Manifest || req.MetaKind == reqmeta.Query) && httpReq.Header.Get("Accept-Encoding") == "" {
+				httpReq.Header.Set("Accept-Encoding", c.acceptEnc)
+			}
 			if c.userAgent != "" && httpReq.Header.Get("User-Agent") == "" {
 				httpReq.Header.Add("User-Agent", c.userAgent)
 			}
 			if resp.readCur > 0 && resp.readMax > 0 {
 				if req.Headers.Get("Range") == "" {
 					httpReq.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", resp.readCur, resp.readMax))
+					if resp.etag != "" {
+						httpReq.Header.Set("If-Range", resp.etag)
+					} else if resp.lastMod != "" {
+						httpReq.Header.Set("If-Range", resp.lastMod)
+					}
 				} else {
 					// TODO: support Seek within a range request
 					dropHost = true
@@ -384,11 +522,14 @@ func (resp *Resp) next() error {
 			hAuth := h.getAuth(req.Repository)
 			if hAuth != nil {
 				// include docker generated scope to emulate docker clients
-				if req.Repository != "" {
-					scope := "repository:" + req.Repository + ":pull"
+				scope := req.Scope
+				if scope == "" && req.Repository != "" {
+					scope = "repository:" + req.Repository + ":pull"
 					if req.Method != "HEAD" && req.Method != "GET" {
 						scope = scope + ",push"
 					}
+				}
+				if scope != "" {
 					_ = hAuth.AddScope(h.config.Hostname, scope)
 				}
 				// add auth headers
@@ -424,15 +565,24 @@ func (resp *Resp) next() error {
 			resp.resp, err = hc.Do(httpReq)
 
 			if err != nil {
+				if cause := context.Cause(resp.reqCtx); cause != nil && resp.ctx.Err() == nil {
+					err = fmt.Errorf("%w: %w", cause, err)
+				}
 				c.slog.Debug("Request failed",
 					slog.String("URL", u.String()),
 					slog.String("err", err.Error()))
-				backoff = true
+				if errors.Is(err, errRedirectDenied) || errors.Is(err, errCertPinned) {
+					dropHost = true
+				} else {
+					backoff = true
+				}
 				return err
 			}
 
 			statusCode := resp.resp.StatusCode
-			if statusCode < 200 || statusCode >= 300 {
+			// a not modified response to a conditional request is returned to the caller
+			notModified := statusCode == http.StatusNotModified && httpReq.Header.Get("If-None-Match") != ""
+			if (statusCode < 200 || statusCode >= 300) && !notModified {
 				switch statusCode {
 				case http.StatusUnauthorized:
 					// if auth can be done, retry same host without delay, otherwise drop/backoff
@@ -463,7 +613,7 @@ func (resp *Resp) next() error {
 				case http.StatusRequestedRangeNotSatisfiable:
 					// if range request error (blob push), drop mirror for this req, but other requests don't need backoff
 					dropHost = true
-				case http.StatusTooManyRequests, http.StatusRequestTimeout, http.StatusGatewayTimeout, http.StatusBadGateway, http.StatusInternalServerError:
+				case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusRequestTimeout, http.StatusGatewayTimeout, http.StatusBadGateway, http.StatusInternalServerError:
 					// server is likely overloaded, backoff but still retry
 					backoff = true
 				default:
@@ -472,6 +622,9 @@ func (resp *Resp) next() error {
 					dropHost = true
 				}
 				errHTTP := HTTPError(resp.resp.StatusCode)
+				if ra := c.retryAfter(resp.resp); ra > 0 {
+					errHTTP = &RetryAfterError{Delay: ra, err: errHTTP}
+				}
 				errBody, _ := io.ReadAll(resp.resp.Body)
 				_ = resp.resp.Body.Close()
 				return fmt.Errorf("request failed: %w: %s", errHTTP, errBody)
@@ -479,6 +632,36 @@ func (resp *Resp) next() error {
 
 			resp.reader = resp.resp.Body
 			resp.done = false
+			// decompress a gzip encoded response that was not handled by the transport
+			if !resp.resp.Uncompressed && req.Method != "HEAD" && httpReq.Header.Get("Range") == "" &&
+				strings.EqualFold(resp.resp.Header.Get("Content-Encoding"), "gzip") {
+				gzr, gzErr := gzip.NewReader(resp.resp.Body)
+				if gzErr != nil {
+					_ = resp.resp.Body.Close()
+					backoff = true
+					return fmt.Errorf("failed to decompress gzip encoded response: %w", gzErr)
+				}
+				resp.reader = gzr
+				// match the transport, the length of the decompressed response is unknown
+				resp.resp.Header.Del("Content-Encoding")
+				resp.resp.Header.Del("Content-Length")
+				resp.resp.ContentLength = -1
+				resp.resp.Uncompressed = true
+			}
+			// a full response to an If-Range request, or a different digest, indicates the content changed
+			if resp.readCur > 0 && httpReq.Header.Get("Range") != "" &&
+				((httpReq.Header.Get("If-Range") != "" && resp.resp.StatusCode == http.StatusOK && resp.resp.Header.Get("Content-Range") == "") ||
+					(resp.digest != "" && resp.resp.Header.Get("Docker-Content-Digest") != "" && resp.resp.Header.Get("Docker-Content-Digest") != resp.digest)) {
+				c.slog.Debug("Content changed while resuming",
+					slog.String("URL", u.String()),
+					slog.String("ifRange", httpReq.Header.Get("If-Range")),
+					slog.String("etagNew", resp.resp.Header.Get("ETag")),
+					slog.String("digest", resp.digest),
+					slog.String("digestNew", resp.resp.Header.Get("Docker-Content-Digest")))
+				_ = resp.resp.Body.Close()
+				resp.changed = true
+				return nil
+			}
 			// set variables from headers if found
 			clHeader := resp.resp.Header.Get("Content-Length")
 			if resp.readCur == 0 && clHeader != "" {
@@ -501,6 +684,15 @@ func (resp *Resp) next() error {
 				_ = resp.resp.Body.Close()
 				return fmt.Errorf("range request not supported by server")
 			}
+			// track validators of the full response to detect changes when resuming, weak validators cannot be used with If-Range
+			if resp.readCur == 0 && httpReq.Header.Get("Range") == "" {
+				if etag := resp.resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+					resp.etag = etag
+				} else if lastModStrong(resp.resp.Header) {
+					resp.lastMod = resp.resp.Header.Get("Last-Modified")
+				}
+				resp.digest = resp.resp.Header.Get("Docker-Content-Digest")
+			}
 			return nil
 		}()
 		// return on success
@@ -548,7 +740,13 @@ func (resp *Resp) HTTPResponse() *http.Response {
 }
 
 // Read provides a retryable read from the body of the response.
+// Short reads are resumed with a range request.
+// If the content changes on the server while resuming, Read returns an error wrapping [errs.ErrMismatch] on this and every later call,
+// and the caller must discard any content already read and send the request again.
 func (resp *Resp) Read(b []byte) (int, error) {
+	if resp.err != nil {
+		return 0, resp.err
+	}
 	if resp.done {
 		return 0, io.EOF
 	}
@@ -558,6 +756,14 @@ func (resp *Resp) Read(b []byte) (int, error) {
 	// perform the read
 	i, err := resp.reader.Read(b)
 	resp.readCur += int64(i)
+	if i > 0 {
+		resp.idleReset()
+	}
+	if err != nil && resp.reqCtx != nil && resp.ctx.Err() == nil {
+		if cause := context.Cause(resp.reqCtx); cause != nil {
+			err = fmt.Errorf("%w: %w", cause, err)
+		}
+	}
 	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		if resp.resp.Request.Method == "HEAD" || resp.readCur >= resp.readMax {
 			resp.backoffReset()
@@ -579,6 +785,11 @@ func (resp *Resp) Read(b []byte) (int, error) {
 				resp.done = true
 				return i, err
 			}
+			// content changed on the server, bytes already read cannot be used
+			if resp.changed {
+				resp.fail(fmt.Errorf("content changed while resuming the request, the request must be sent again%.0w", errs.ErrMismatch))
+				return i, resp.err
+			}
 			// retry successful, no EOF
 			return i, nil
 		}
@@ -603,11 +814,78 @@ func (resp *Resp) Close() error {
 		resp.backoffReset()
 	}
 	resp.done = true
-	return resp.resp.Body.Close()
+	err := resp.resp.Body.Close()
+	resp.attemptDone()
+	return err
+}
+
+// attemptStart creates the context for a request attempt, applying any timeouts.
+func (resp *Resp) attemptStart() {
+	resp.attemptDone()
+	c := resp.client
+	ctx, cancel := context.WithCancelCause(resp.ctx)
+	resp.reqCtx = ctx
+	resp.reqCancel = func() { cancel(nil) }
+	if resp.req.Method == "HEAD" && c.headTimeout > 0 {
+		d := c.headTimeout
+		resp.idle = time.AfterFunc(d, func() {
+			cancel(fmt.Errorf("head request exceeded %s: %w", d, context.DeadlineExceeded))
+		})
+	} else if resp.req.Method != "HEAD" && c.xferTimeout > 0 {
+		d := c.xferTimeout
+		resp.idle = time.AfterFunc(d, func() {
+			cancel(fmt.Errorf("transfer had no progress for %s: %w", d, context.DeadlineExceeded))
+		})
+	}
+}
+
+// attemptDone releases the context and timer of the current request attempt.
+func (resp *Resp) attemptDone() {
+	if resp.idle != nil {
+		resp.idle.Stop()
+		resp.idle = nil
+	}
+	if resp.reqCancel != nil {
+		resp.reqCancel()
+		resp.reqCancel = nil
+	}
+}
+
+// idleReset restarts the transfer timeout after progress is made.
+func (resp *Resp) idleReset() {
+	if resp.idle != nil && resp.req.Method != "HEAD" {
+		resp.idle.Reset(resp.client.xferTimeout)
+	}
+}
+
+// idleWrap resets the transfer timeout as the request body is sent.
+func (resp *Resp) idleWrap(rc io.ReadCloser) io.ReadCloser {
+	if resp.idle == nil || resp.req.Method == "HEAD" {
+		return rc
+	}
+	return &idleReadCloser{ReadCloser: rc, timer: resp.idle, d: resp.client.xferTimeout}
+}
+
+type idleReadCloser struct {
+	io.ReadCloser
+	timer *time.Timer
+	d     time.Duration
+}
+
+func (irc *idleReadCloser) Read(p []byte) (int, error) {
+	n, err := irc.ReadCloser.Read(p)
+	if n > 0 {
+		irc.timer.Reset(irc.d)
+	}
+	return n, err
 }
 
 // Seek provides a limited ability seek within the request response.
+// The same as [Resp.Read], a change to the content on the server returns an error wrapping [errs.ErrMismatch], and the request must be sent again.
 func (resp *Resp) Seek(offset int64, whence int) (int64, error) {
+	if resp.err != nil {
+		return resp.readCur, resp.err
+	}
 	newOffset := resp.readCur
 	switch whence {
 	case io.SeekStart:
@@ -632,51 +910,104 @@ func (resp *Resp) Seek(offset int64, whence int) (int64, error) {
 		if err != nil {
 			return resp.readCur, err
 		}
+		if resp.changed {
+			resp.fail(fmt.Errorf("content changed while seeking in the request, the request must be sent again%.0w", errs.ErrMismatch))
+			return resp.readCur, resp.err
+		}
 	}
 	return resp.readCur, nil
 }
 
+// fail stops any further reads from the response, returning err instead.
+func (resp *Resp) fail(err error) {
+	resp.err = err
+	resp.done = true
+	resp.reader = nil
+}
+
+// lastModStrong returns true when the Last-Modified header may be used as a strong validator with If-Range.
+// RFC 9110 requires the last modified time to be at least one second before the Date of the response.
+func lastModStrong(h http.Header) bool {
+	lm, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	d, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return false
+	}
+	return d.Sub(lm) >= time.Second
+}
+
 func (resp *Resp) backoffGet() time.Time {
 	c := resp.client
 	ch := c.getHost(resp.mirror)
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
+	now := time.Now()
+	// reset a stale "retry-after" time
+	if !ch.retryAfter.IsZero() && ch.retryAfter.Before(now) {
+		ch.retryAfter = time.Time{}
+	}
 	if ch.backoffCur > 0 {
-		delay := c.delayInit << ch.backoffCur
-		if delay > c.delayMax {
-			delay = c.delayMax
-		}
-		next := ch.backoffLast.Add(delay)
-		now := time.Now()
+		next := ch.backoffLast.Add(c.backoffDelay(ch.config, ch.backoffCur))
 		if now.After(next) {
 			next = now
 		}
+		// use the larger of the computed backoff and the registry requested delay
+		if ch.retryAfter.After(next) {
+			next = ch.retryAfter
+		}
 		ch.backoffLast = next
 		return next
 	}
-	// reset a stale "retry-after" time
-	if !ch.backoffLast.IsZero() && ch.backoffLast.Before(time.Now()) {
+	if !ch.backoffLast.IsZero() && ch.backoffLast.Before(now) {
 		ch.backoffLast = time.Time{}
 	}
+	if ch.retryAfter.After(ch.backoffLast) {
+		return ch.retryAfter
+	}
 	return ch.backoffLast
 }
 
+// backoffDelay returns the delay after count backoffs, using the host settings when defined.
+func (c *Client) backoffDelay(conf *config.Host, count int) time.Duration {
+	factor := c.backoffFactor
+	jitter := c.backoffJitter
+	if conf != nil && conf.RetryBackoff >= 1 {
+		factor = conf.RetryBackoff
+	}
+	if conf != nil && conf.RetryJitter > 0 && conf.RetryJitter <= 1 {
+		jitter = conf.RetryJitter
+	}
+	if factor < 1 {
+		factor = defaultBackoffFactor
+	}
+	delay := float64(c.delayMax)
+	if grow := float64(c.delayInit) * math.Pow(factor, float64(count)); grow < delay {
+		delay = grow
+	}
+	if jitter > 0 {
+		c.randMu.Lock()
+		delay -= delay * jitter * c.rand.Float64()
+		c.randMu.Unlock()
+	}
+	return time.Duration(delay)
+}
+
 func (resp *Resp) backoffSet() error {
 	c := resp.client
 	ch := c.getHost(resp.mirror)
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
-	// check rate limit header and use that directly if possible
-	if resp.resp != nil && resp.resp.Header.Get("Retry-After") != "" {
-		ras := resp.resp.Header.Get("Retry-After")
-		ra, _ := time.ParseDuration(ras + "s")
-		if ra > 0 {
-			next := time.Now().Add(ra)
-			if ch.backoffLast.Before(next) {
-				ch.backoffLast = next
-			}
-			return nil
+	// check rate limit header and use that instead of increasing the backoff
+	// the next request waits for the larger of this and any current backoff
+	if ra := c.retryAfter(resp.resp); ra > 0 {
+		next := time.Now().Add(ra)
+		if ch.retryAfter.Before(next) {
+			ch.retryAfter = next
 		}
+		return nil
 	}
 	// Else track the number of backoffs and fail when the limit is exceeded.
 	// New requests always get at least one try, but fail fast if the server has been throwing errors.
@@ -744,11 +1075,15 @@ func (c *Client) getHost(host string) *clientHost {
 	// copy the http client and configure registry specific settings
 	hc := *c.httpClient
 	h.httpClient = &hc
+	if h.config.ReqTimeout > 0 {
+		// the client timeout applies to each attempt, so retries are not limited by an earlier attempt
+		h.httpClient.Timeout = time.Duration(h.config.ReqTimeout)
+	}
 	if h.httpClient.Transport == nil {
 		h.httpClient.Transport = http.DefaultTransport.(*http.Transport).Clone()
 	}
 	// configure transport for insecure requests and root certs
-	if h.config.TLS == config.TLSInsecure || len(c.rootCAPool) > 0 || len(c.rootCADirs) > 0 || h.config.RegCert != "" || (h.config.ClientCert != "" && h.config.ClientKey != "") {
+	if h.config.TLS == config.TLSInsecure || len(c.rootCAPool) > 0 || len(c.rootCADirs) > 0 || h.config.RegCert != "" || (h.config.ClientCert != "" && h.config.ClientKey != "") || len(h.config.PinnedCertSHA256) > 0 {
 		t, ok := h.httpClient.Transport.(*http.Transport)
 		if ok {
 			var tlsc *tls.Config
@@ -778,12 +1113,19 @@ func (c *Client) getHost(host string) *clientHost {
 					tlsc.Certificates = []tls.Certificate{cert}
 				}
 			}
+			if len(h.config.PinnedCertSHA256) > 0 {
+				tlsc.VerifyConnection = verifyPinned(h.config.Hostname, h.config.PinnedCertSHA256, h.config.TLS == config.TLSInsecure)
+			}
 			t.TLSClientConfig = tlsc
 			h.httpClient.Transport = t
 		}
 	}
 	// wrap the transport for logging and to handle warning headers
-	h.httpClient.Transport = &wrapTransport{c: c, orig: h.httpClient.Transport}
+	censor := []string{"Authorization", "Proxy-Authorization", "Cookie"}
+	for k := range h.config.Headers {
+		censor = append(censor, k)
+	}
+	h.httpClient.Transport = &wrapTransport{c: c, orig: h.httpClient.Transport, censor: censor}
 
 	c.host[conf.Name] = h
 	if conf.Name != host {
@@ -808,6 +1150,16 @@ func (ch *clientHost) checkRedirect(repo string, orig func(req *http.Request, vi
 		if len(via) >= 10 {
 			return errors.New("stopped after 10 redirects")
 		}
+		// auth and configured headers for the registry are never sent to a different host, e.g. blob storage
+		if len(via) > 0 && !sameOrigin(req.URL, via[0].URL) {
+			if ch.config.RedirectDeny {
+				return fmt.Errorf("redirect to a different host denied, from %s to %s%.0w", via[0].URL.Host, req.URL.Host, errRedirectDenied)
+			}
+			req.Header.Del("Authorization")
+			for k := range ch.config.Headers {
+				req.Header.Del(k)
+			}
+		}
 		// add auth headers if appropriate for the target host
 		hAuth := ch.getAuth(repo)
 		err := hAuth.UpdateRequest(req)
@@ -822,6 +1174,11 @@ func (ch *clientHost) checkRedirect(repo string, orig func(req *http.Request, vi
 	}
 }
 
+// sameOrigin returns true when both URLs have the same scheme, host, and port.
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
 // getAuth returns an auth, which may be repository specific.
 func (ch *clientHost) getAuth(repo string) *auth.Auth {
 	ch.mu.Lock()
@@ -851,16 +1208,29 @@ func (ch *clientHost) AuthCreds() func(h string) auth.Cred {
 }
 
 type wrapTransport struct {
-	c    *Client
-	orig http.RoundTripper
+	c      *Client
+	orig   http.RoundTripper
+	censor []string // request headers censored in logs and debug output
 }
 
 func (wt *wrapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody *debugBody
+	if wt.c.debugW != nil && wt.c.debugBodyMax > 0 && req.Body != nil && req.Body != http.NoBody {
+		// capture the start of the request body as it is sent
+		reqBody = &debugBody{ReadCloser: req.Body, max: wt.c.debugBodyMax}
+		req = req.Clone(req.Context())
+		req.Body = reqBody
+	}
 	resp, err := wt.orig.RoundTrip(req)
-	// copy headers to censor auth field
+	// copy headers to censor auth fields
 	reqHead := req.Header.Clone()
-	if reqHead.Get("Authorization") != "" {
-		reqHead.Set("Authorization", "[censored]")
+	for _, k := range wt.censor {
+		if reqHead.Get(k) != "" {
+			reqHead.Set(k, "[censored]")
+		}
+	}
+	if wt.c.debugW != nil {
+		wt.c.debugDump(req, reqHead, reqBody, resp, err)
 	}
 	if err != nil {
 		wt.c.slog.Debug("reg http request",
@@ -886,6 +1256,123 @@ func (wt *wrapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return resp, err
 }
 
+// debugBody captures the start of a body for a debug dump.
+type debugBody struct {
+	io.ReadCloser
+	max   int64
+	total int64
+	buf   bytes.Buffer
+	mu    sync.Mutex
+}
+
+func (db *debugBody) Read(p []byte) (int, error) {
+	n, err := db.ReadCloser.Read(p)
+	db.mu.Lock()
+	if remain := db.max - int64(db.buf.Len()); remain > 0 && n > 0 {
+		db.buf.Write(p[:min(int64(n), remain)])
+	}
+	db.total += int64(n)
+	db.mu.Unlock()
+	return n, err
+}
+
+// captured returns the start of the body and true if more of the body was read.
+func (db *debugBody) captured() ([]byte, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return bytes.Clone(db.buf.Bytes()), db.total > int64(db.buf.Len())
+}
+
+// debugReadCloser returns the peeked bytes of a response body before the remaining body.
+type debugReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// debugDump writes the request and response to the debug writer.
+func (c *Client) debugDump(req *http.Request, reqHead http.Header, reqBody *debugBody, resp *http.Response, err error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	fmt.Fprintf(buf, "> Host: %s\n", req.URL.Host)
+	debugHeaders(buf, "> ", reqHead)
+	if reqBody != nil {
+		if strings.HasPrefix(reqHead.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			debugBodyWrite(buf, "> ", []byte("[censored]"), false)
+		} else {
+			b, truncated := reqBody.captured()
+			debugBodyWrite(buf, "> ", b, truncated)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(buf, "< error: %s\n", err.Error())
+	} else {
+		fmt.Fprintf(buf, "< %s %s\n", resp.Proto, resp.Status)
+		respHead := resp.Header
+		if respHead.Get("Set-Cookie") != "" {
+			respHead = respHead.Clone()
+			respHead.Set("Set-Cookie", "[censored]")
+		}
+		debugHeaders(buf, "< ", respHead)
+		if c.debugBodyMax > 0 && resp.Body != nil && resp.Body != http.NoBody {
+			// peek at the start of the response body and restore it for the caller
+			b, errRead := io.ReadAll(io.LimitReader(resp.Body, c.debugBodyMax))
+			resp.Body = debugReadCloser{Reader: io.MultiReader(bytes.NewReader(b), resp.Body), Closer: resp.Body}
+			if errRead != nil {
+				fmt.Fprintf(buf, "< body read error: %s\n", errRead.Error())
+			} else if debugTokenResp(b) {
+				debugBodyWrite(buf, "< ", []byte("[censored]"), false)
+			} else {
+				debugBodyWrite(buf, "< ", b, int64(len(b)) == c.debugBodyMax && resp.ContentLength != int64(len(b)))
+			}
+		}
+	}
+	buf.WriteString("\n")
+	c.debugMu.Lock()
+	_, _ = c.debugW.Write(buf.Bytes())
+	c.debugMu.Unlock()
+}
+
+// debugHeaders writes each header value sorted by name.
+func debugHeaders(w io.Writer, prefix string, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, k, v)
+		}
+	}
+}
+
+// debugBodyWrite writes the body after a blank line.
+func debugBodyWrite(w io.Writer, prefix string, b []byte, truncated bool) {
+	if len(b) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", strings.TrimSpace(prefix))
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+	if truncated {
+		fmt.Fprintf(w, "%s[truncated]\n", prefix)
+	}
+}
+
+// debugTokenResp returns true if the body is a JSON response from a token server.
+func debugTokenResp(b []byte) bool {
+	tokenResp := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(b, &tokenResp); err != nil {
+		// a truncated body may still contain a token
+		return bytes.Contains(b, []byte(`"token"`)) || bytes.Contains(b, []byte(`"access_token"`))
+	}
+	return tokenResp.Token != "" || tokenResp.AccessToken != ""
+}
+
 // HTTPError returns an error based on the status code.
 func HTTPError(statusCode int) error {
 	switch statusCode {
@@ -902,6 +1389,101 @@ func HTTPError(statusCode int) error {
 	}
 }
 
+// RetryAfterError is returned when the registry responds with a Retry-After header.
+type RetryAfterError struct {
+	Delay time.Duration // delay requested by the registry
+	err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v (retry after %s)", e.err, e.Delay)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.err
+}
+
+// retryAfter returns the delay from a Retry-After header on a 429 or 503 response, limited by [WithRetryAfterMax].
+// 0 is returned if the header is not set or cannot be parsed.
+func (c *Client) retryAfter(resp *http.Response) time.Duration {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0
+	}
+	ra := retryAfterParse(resp.Header.Get("Retry-After"), time.Now())
+	if c.retryAfterMax > 0 && ra > c.retryAfterMax {
+		ra = c.retryAfterMax
+	}
+	return ra
+}
+
+// retryAfterParse converts a Retry-After value in seconds or an HTTP-date into a delay from now.
+func retryAfterParse(val string, now time.Time) time.Duration {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		if sec <= 0 {
+			return 0
+		}
+		if sec > int64(math.MaxInt64/time.Second) {
+			return time.Duration(math.MaxInt64)
+		}
+		return time.Duration(sec) * time.Second
+	}
+	t, err := http.ParseTime(val)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
+
+// verifyPinned returns a TLS connection check that requires a certificate to match one of the pins.
+// The check is only applied to the registry hostname, connections after a redirect to another host are not pinned.
+// With verification enabled, only certificates in the verified chains are checked since the server may send extra certificates.
+// When TLS is set to insecure, there is no verified chain and only the leaf certificate is checked.
+func verifyPinned(hostname string, pins []string, insecure bool) func(tls.ConnectionState) error {
+	if h, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = h
+	}
+	hostname = strings.TrimSuffix(hostname, ".")
+	pinMap := map[string]bool{}
+	for _, pin := range pins {
+		pinMap[pinNormalize(pin)] = true
+	}
+	match := func(cert *x509.Certificate) bool {
+		sum := sha256.Sum256(cert.Raw)
+		return pinMap[hex.EncodeToString(sum[:])]
+	}
+	return func(cs tls.ConnectionState) error {
+		// ServerName is empty when connecting to an IP, those connections are always checked
+		if cs.ServerName != "" && !strings.EqualFold(cs.ServerName, hostname) {
+			return nil
+		}
+		if insecure {
+			if len(cs.PeerCertificates) > 0 && match(cs.PeerCertificates[0]) {
+				return nil
+			}
+		} else {
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					if match(cert) {
+						return nil
+					}
+				}
+			}
+		}
+		return fmt.Errorf("no certificate from %s matches the pinned fingerprints%.0w", hostname, errCertPinned)
+	}
+}
+
+// pinNormalize converts a sha256 fingerprint to lower case hex, removing any "sha256:" prefix and colon separators.
+func pinNormalize(pin string) string {
+	pin = strings.ToLower(strings.TrimSpace(pin))
+	pin = strings.TrimPrefix(pin, "sha256:")
+	return strings.ReplaceAll(pin, ":", "")
+}
+
 func makeRootPool(rootCAPool [][]byte, rootCADirs []string, hostname string, hostcert string) (*x509.CertPool, error) {
 	pool, err := x509.SystemCertPool()
 	if err != nil {
@@ -954,16 +1536,33 @@ func makeRootPool(rootCAPool [][]byte, rootCADirs []string, hostname string, hos
 }
 
 // sortHostCmp to sort host list of mirrors.
+// This is used with a stable sort so mirrors with the same priority keep their configured order.
 func sortHostsCmp(hosts []*clientHost, upstream string) func(i, j int) bool {
 	now := time.Now()
-	// sort by backoff first, then priority decending, then upstream name last
+	// sort by backoff first, then priority ascending, then upstream name last
 	return func(i, j int) bool {
-		if now.Before(hosts[i].backoffLast) || now.Before(hosts[j].backoffLast) {
-			return hosts[i].backoffLast.Before(hosts[j].backoffLast)
+		iNext, jNext := hosts[i].backoffNext(), hosts[j].backoffNext()
+		if now.Before(iNext) || now.Before(jNext) {
+			return iNext.Before(jNext)
 		}
-		if hosts[i].config.Priority != hosts[j].config.Priority {
-			return hosts[i].config.Priority < hosts[j].config.Priority
+		return config.HostLess(hosts[i].config, hosts[j].config, upstream)
+	}
+}
+
+// backoffNext returns the later of the backoff and Retry-After times for the host.
+func (ch *clientHost) backoffNext() time.Time {
+	if ch.retryAfter.After(ch.backoffLast) {
+		return ch.retryAfter
+	}
+	return ch.backoffLast
+}
+
+// hostInList returns true if the host is already in the list, used to skip duplicate mirrors.
+func hostInList(hosts []*clientHost, h *clientHost) bool {
+	for _, cur := range hosts {
+		if cur == h {
+			return true
 		}
-		return hosts[i].config.Name != upstream
 	}
+	return false
 }
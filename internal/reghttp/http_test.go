@@ -3,14 +3,22 @@ package reghttp
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +26,7 @@ import (
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/internal/timejson"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/warning"
 )
@@ -47,6 +56,12 @@ func TestRegHttp(t *testing.T) {
 	retryBody3 := []byte("retry body 3\n")
 	retryBody := bytes.Join([][]byte{retryBody1, retryBody2, retryBody3}, []byte{})
 	retryDigest := digest.FromBytes(retryBody)
+	changedBody := []byte("changed body\n")
+	changedDigest := digest.FromBytes(changedBody)
+	lastModTime := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	lastMod := lastModTime.Format(http.TimeFormat)
+	lastModNew := lastModTime.Add(time.Hour).Format(http.TimeFormat)
+	lastModDate := lastModTime.Add(2 * time.Hour).Format(http.TimeFormat)
 	user := "user"
 	pass := "testpass"
 	userAuth := base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
@@ -689,6 +704,122 @@ func TestRegHttp(t *testing.T) {
 				},
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "changed 2 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-changed",
+				Headers: http.Header{
+					"Range":    []string{fmt.Sprintf("bytes=%d-%d", len(retryBody1), len(retryBody))},
+					"If-Range": []string{`"retry-v1"`},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   changedBody,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(changedBody))},
+					"Content-Type":   []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"ETag":           []string{`"retry-v2"`},
+					"Accept-Ranges":  []string{"bytes"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "last modified 2 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-lastmod",
+				Headers: http.Header{
+					"Range":    []string{fmt.Sprintf("bytes=%d-%d", len(retryBody1), len(retryBody))},
+					"If-Range": []string{lastMod},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   changedBody,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(changedBody))},
+					"Content-Type":   []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"Last-Modified":  []string{lastModNew},
+					"Date":           []string{lastModDate},
+					"Accept-Ranges":  []string{"bytes"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "last modified 1 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-lastmod",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   retryBody1,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(retryBody))},
+					"Content-Type":   []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"Last-Modified":  []string{lastMod},
+					"Date":           []string{lastModDate},
+					"Accept-Ranges":  []string{"bytes"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "digest changed 2 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-digest-changed",
+				Headers: http.Header{
+					"Range": []string{fmt.Sprintf("bytes=%d-%d", len(retryBody1), len(retryBody))},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusPartialContent,
+				Body:   changedBody[len(retryBody1):],
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", len(changedBody)-len(retryBody1))},
+					"Content-Range":         {fmt.Sprintf("bytes %d-%d/%d", len(retryBody1), len(changedBody), len(changedBody))},
+					"Content-Type":          []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"Docker-Content-Digest": []string{changedDigest.String()},
+					"Accept-Ranges":         []string{"bytes"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "digest changed 1 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-digest-changed",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   retryBody1,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", len(retryBody))},
+					"Content-Type":          []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"Docker-Content-Digest": []string{retryDigest.String()},
+					"Accept-Ranges":         []string{"bytes"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "changed 1 manifest",
+				Method: "GET",
+				Path:   "/v2/project/manifests/tag-changed",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   retryBody1,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(retryBody))},
+					"Content-Type":   []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"ETag":           []string{`"retry-v1"`},
+					"Accept-Ranges":  []string{"bytes"},
+				},
+			},
+		},
 		{
 			ReqEntry: reqresp.ReqEntry{
 				Name:   "retry 1 manifest",
@@ -1593,6 +1724,83 @@ func TestRegHttp(t *testing.T) {
 			t.Errorf("error closing request: %v", err)
 		}
 	})
+	t.Run("Retry changed", func(t *testing.T) {
+		changedReq := &Req{
+			Host:       "retry." + tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-changed",
+			Headers:    headers,
+		}
+		resp, err := hc.Do(ctx, changedReq)
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Fatalf("unexpected error, expected %v, received %v", errs.ErrMismatch, err)
+		}
+		if !bytes.Equal(body, retryBody1) {
+			t.Errorf("unexpected body before the change, expected %s, received %s", retryBody1, body)
+		}
+		// later reads continue to fail, the new content is never returned
+		body, err = io.ReadAll(resp)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Errorf("unexpected error on second read, expected %v, received %v", errs.ErrMismatch, err)
+		}
+		if len(body) > 0 {
+			t.Errorf("unexpected body after the change: %s", body)
+		}
+		err = resp.Close()
+		if err != nil {
+			t.Errorf("error closing request: %v", err)
+		}
+	})
+	t.Run("Retry changed last modified", func(t *testing.T) {
+		lastModReq := &Req{
+			Host:       "retry." + tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-lastmod",
+			Headers:    headers,
+		}
+		resp, err := hc.Do(ctx, lastModReq)
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Fatalf("unexpected error, expected %v, received %v", errs.ErrMismatch, err)
+		}
+		if !bytes.Equal(body, retryBody1) {
+			t.Errorf("unexpected body before the change, expected %s, received %s", retryBody1, body)
+		}
+		err = resp.Close()
+		if err != nil {
+			t.Errorf("error closing request: %v", err)
+		}
+	})
+	t.Run("Retry changed digest", func(t *testing.T) {
+		digestReq := &Req{
+			Host:       "retry." + tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-digest-changed",
+			Headers:    headers,
+		}
+		resp, err := hc.Do(ctx, digestReq)
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		_, err = io.ReadAll(resp)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Fatalf("unexpected error, expected %v, received %v", errs.ErrMismatch, err)
+		}
+		err = resp.Close()
+		if err != nil {
+			t.Errorf("error closing request: %v", err)
+		}
+	})
 	t.Run("Warning", func(t *testing.T) {
 		getReq := &Req{
 			Host:       tsHost,
@@ -1713,3 +1921,997 @@ func TestRegHttp(t *testing.T) {
 	})
 	// TODO: test various TLS configs (custom root for all hosts, custom root for one host, insecure)
 }
+
+func TestRedirectHost(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("blob content from storage")
+	blobDigest := digest.FromBytes(blobBody)
+	tokenValue := "redirectTokenValue"
+	// storage server records any auth header received
+	var storageAuth []string
+	tsStorage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			storageAuth = append(storageAuth, auth)
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blobBody)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(blobBody)
+	}))
+	defer tsStorage.Close()
+	// registry server requires a bearer token and redirects blobs to the storage server
+	var tsRegURL string
+	tsReg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenResp, _ := json.Marshal(testBearerToken{Token: tokenValue, ExpiresIn: 900, IssuedAt: time.Now()})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(tokenResp)
+		case r.Header.Get("Authorization") != "Bearer "+tokenValue:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+tsRegURL+`/token",service=test,scope="repository:project:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Header().Set("Location", tsStorage.URL+"/storage/"+blobDigest.Encoded()+"?sig=signed")
+			w.WriteHeader(http.StatusTemporaryRedirect)
+		}
+	}))
+	defer tsReg.Close()
+	tsRegURL = tsReg.URL
+	tsURL, _ := url.Parse(tsReg.URL)
+	tsHost := tsURL.Host
+	configHosts := map[string]*config.Host{
+		tsHost: {
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+		"deny." + tsHost: {
+			Name:         "deny." + tsHost,
+			Hostname:     tsHost,
+			TLS:          config.TLSDisabled,
+			RedirectDeny: true,
+		},
+	}
+	delayInit, _ := time.ParseDuration("0.0005s")
+	delayMax, _ := time.ParseDuration("0.0010s")
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(delayInit, delayMax),
+	)
+	t.Run("allowed", func(t *testing.T) {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "blobs/" + blobDigest.String(),
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Fatalf("body read failure: %v", err)
+		} else if !bytes.Equal(body, blobBody) {
+			t.Errorf("body read mismatch, expected %s, received %s", blobBody, body)
+		}
+		if len(storageAuth) > 0 {
+			t.Errorf("auth header sent to storage server: %v", storageAuth)
+		}
+	})
+	t.Run("denied", func(t *testing.T) {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       "deny." + tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "blobs/" + blobDigest.String(),
+		})
+		if err == nil {
+			_ = resp.Close()
+			t.Fatalf("redirect to a different host was not denied")
+		}
+		if !errors.Is(err, errRedirectDenied) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestHostHeaders(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("blob content")
+	blobDigest := digest.FromBytes(blobBody)
+	newServer := func(received *[]http.Header) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*received = append(*received, r.Header.Clone())
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blobBody)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(blobBody)
+		}))
+	}
+	var tenantHeaders, otherHeaders []http.Header
+	tsTenant := newServer(&tenantHeaders)
+	defer tsTenant.Close()
+	tsOther := newServer(&otherHeaders)
+	defer tsOther.Close()
+	tsTenantURL, _ := url.Parse(tsTenant.URL)
+	tsOtherURL, _ := url.Parse(tsOther.URL)
+	configHosts := map[string]*config.Host{
+		tsTenantURL.Host: {
+			Name:     tsTenantURL.Host,
+			Hostname: tsTenantURL.Host,
+			TLS:      config.TLSDisabled,
+			Headers: map[string]string{
+				"X-Tenant":      "acme",
+				"authorization": "Basic invalid",
+			},
+		},
+		tsOtherURL.Host: {
+			Name:     tsOtherURL.Host,
+			Hostname: tsOtherURL.Host,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+	)
+	for _, host := range []string{tsTenantURL.Host, tsOtherURL.Host} {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       host,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "blobs/" + blobDigest.String(),
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		_, err = io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Fatalf("body read failure: %v", err)
+		}
+	}
+	if len(tenantHeaders) != 1 || len(otherHeaders) != 1 {
+		t.Fatalf("unexpected number of requests, tenant %d, other %d", len(tenantHeaders), len(otherHeaders))
+	}
+	if v := tenantHeaders[0].Get("X-Tenant"); v != "acme" {
+		t.Errorf("configured header not sent, expected acme, received %s", v)
+	}
+	if v := tenantHeaders[0].Get("Authorization"); v != "" {
+		t.Errorf("reserved header was sent: %s", v)
+	}
+	if v := otherHeaders[0].Get("X-Tenant"); v != "" {
+		t.Errorf("header sent to a different host: %s", v)
+	}
+	// a redirect to a different host does not include the configured headers
+	var redirectHeaders []http.Header
+	tsRedirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirectHeaders = append(redirectHeaders, r.Header.Clone())
+		http.Redirect(w, r, tsOther.URL+"/storage/"+blobDigest.String(), http.StatusTemporaryRedirect)
+	}))
+	defer tsRedirect.Close()
+	tsRedirectURL, _ := url.Parse(tsRedirect.URL)
+	configHosts[tsRedirectURL.Host] = &config.Host{
+		Name:     tsRedirectURL.Host,
+		Hostname: tsRedirectURL.Host,
+		TLS:      config.TLSDisabled,
+		Headers: map[string]string{
+			"X-Tenant": "acme",
+		},
+	}
+	resp, err := hc.Do(ctx, &Req{
+		Host:       tsRedirectURL.Host,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "blobs/" + blobDigest.String(),
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_, err = io.ReadAll(resp)
+	_ = resp.Close()
+	if err != nil {
+		t.Fatalf("body read failure: %v", err)
+	}
+	if len(redirectHeaders) != 1 || len(otherHeaders) != 2 {
+		t.Fatalf("unexpected number of requests, redirect %d, other %d", len(redirectHeaders), len(otherHeaders))
+	}
+	if v := redirectHeaders[0].Get("X-Tenant"); v != "acme" {
+		t.Errorf("configured header not sent, expected acme, received %s", v)
+	}
+	if v := otherHeaders[1].Get("X-Tenant"); v != "" {
+		t.Errorf("header sent to the redirect host: %s", v)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	chunk := []byte("0123456789")
+	chunkCount := 8
+	chunkDelay := time.Millisecond * 50
+	stop := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			// a stuck head request
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+		case r.URL.Path == "/v2/project/blobs/slow":
+			// a slow transfer that continues to make progress
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(chunk)*chunkCount))
+			w.WriteHeader(http.StatusOK)
+			for i := 0; i < chunkCount; i++ {
+				_, _ = w.Write(chunk)
+				w.(http.Flusher).Flush()
+				time.Sleep(chunkDelay)
+			}
+		case r.URL.Path == "/v2/project/blobs/stall":
+			// a transfer that stops making progress
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(chunk)*chunkCount))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(chunk)
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	defer close(stop)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	configHosts := map[string]*config.Host{
+		tsHost: {
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	delayInit, _ := time.ParseDuration("0.0005s")
+	delayMax, _ := time.ParseDuration("0.0010s")
+	headTimeout := time.Millisecond * 100
+	xferTimeout := time.Millisecond * 200
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(delayInit, delayMax),
+		WithRetryLimit(1),
+		WithHeadTimeout(headTimeout),
+		WithTransferTimeout(xferTimeout),
+	)
+	t.Run("head timeout", func(t *testing.T) {
+		start := time.Now()
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "HEAD",
+			Repository: "project",
+			Path:       "blobs/slow",
+		})
+		if err == nil {
+			_ = resp.Close()
+			t.Fatalf("head request did not fail")
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if time.Since(start) > time.Second {
+			t.Errorf("head request did not fail fast, duration %s", time.Since(start))
+		}
+	})
+	t.Run("slow transfer", func(t *testing.T) {
+		start := time.Now()
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "blobs/slow",
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Fatalf("body read failure: %v", err)
+		}
+		if len(body) != len(chunk)*chunkCount {
+			t.Errorf("unexpected body length, expected %d, received %d", len(chunk)*chunkCount, len(body))
+		}
+		if time.Since(start) < xferTimeout {
+			t.Errorf("transfer was not slower than the timeout, duration %s", time.Since(start))
+		}
+	})
+	t.Run("stalled transfer", func(t *testing.T) {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "blobs/stall",
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		_, err = io.ReadAll(resp)
+		_ = resp.Close()
+		if err == nil {
+			t.Fatalf("stalled transfer did not fail")
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestPinnedCert(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("blob content")
+	blobDigest := digest.FromBytes(blobBody)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blobBody)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(blobBody)
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	sum := sha256.Sum256(ts.Certificate().Raw)
+	pin := hex.EncodeToString(sum[:])
+	pinColons := []string{}
+	for i := 0; i < len(pin); i += 2 {
+		pinColons = append(pinColons, strings.ToUpper(pin[i:i+2]))
+	}
+	regCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	mismatch := strings.Repeat("0", 64)
+	configHosts := map[string]*config.Host{
+		"pinned": {
+			Name:             "pinned",
+			Hostname:         tsURL.Host,
+			RegCert:          regCert,
+			PinnedCertSHA256: []string{mismatch, pin},
+		},
+		"pinned-format": {
+			Name:             "pinned-format",
+			Hostname:         tsURL.Host,
+			RegCert:          regCert,
+			PinnedCertSHA256: []string{"sha256:" + strings.Join(pinColons, ":")},
+		},
+		"pinned-insecure": {
+			Name:             "pinned-insecure",
+			Hostname:         tsURL.Host,
+			TLS:              config.TLSInsecure,
+			PinnedCertSHA256: []string{pin},
+		},
+		"mismatch": {
+			Name:             "mismatch",
+			Hostname:         tsURL.Host,
+			RegCert:          regCert,
+			PinnedCertSHA256: []string{mismatch},
+		},
+		"mismatch-insecure": {
+			Name:             "mismatch-insecure",
+			Hostname:         tsURL.Host,
+			TLS:              config.TLSInsecure,
+			PinnedCertSHA256: []string{mismatch},
+		},
+	}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(time.Millisecond*10, time.Millisecond*100),
+	)
+	tt := []struct {
+		host      string
+		expectErr error
+	}{
+		{host: "pinned"},
+		{host: "pinned-format"},
+		{host: "pinned-insecure"},
+		{host: "mismatch", expectErr: errCertPinned},
+		{host: "mismatch-insecure", expectErr: errCertPinned},
+	}
+	for _, tc := range tt {
+		t.Run(tc.host, func(t *testing.T) {
+			resp, err := hc.Do(ctx, &Req{
+				Host:       tc.host,
+				Method:     "GET",
+				Repository: "project",
+				Path:       "blobs/" + blobDigest.String(),
+			})
+			if tc.expectErr != nil {
+				if err == nil {
+					_ = resp.Close()
+					t.Fatalf("request did not fail")
+				}
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil {
+				t.Fatalf("body read failure: %v", err)
+			}
+			if !bytes.Equal(body, blobBody) {
+				t.Errorf("unexpected body: %s", body)
+			}
+		})
+	}
+}
+
+func TestPinnedCertChain(t *testing.T) {
+	t.Parallel()
+	pinned := &x509.Certificate{Raw: []byte("pinned certificate")}
+	attacker := &x509.Certificate{Raw: []byte("attacker certificate")}
+	ca := &x509.Certificate{Raw: []byte("ca certificate")}
+	sum := sha256.Sum256(pinned.Raw)
+	pins := []string{hex.EncodeToString(sum[:])}
+	tt := []struct {
+		name      string
+		insecure  bool
+		cs        tls.ConnectionState
+		expectErr error
+	}{
+		{
+			name: "verified chain",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{pinned, ca},
+				VerifiedChains:   [][]*x509.Certificate{{pinned, ca}},
+			},
+		},
+		{
+			name: "appended to verified chain",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{attacker, ca, pinned},
+				VerifiedChains:   [][]*x509.Certificate{{attacker, ca}},
+			},
+			expectErr: errCertPinned,
+		},
+		{
+			name:     "insecure leaf",
+			insecure: true,
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{pinned},
+			},
+		},
+		{
+			name:     "insecure appended",
+			insecure: true,
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{attacker, pinned},
+			},
+			expectErr: errCertPinned,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := verifyPinned("registry.example.org", pins, tc.insecure)(tc.cs)
+			if tc.expectErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if tc.expectErr != nil && !errors.Is(err, tc.expectErr) {
+				t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSortHosts(t *testing.T) {
+	t.Parallel()
+	newHost := func(name string, priority uint) *clientHost {
+		return &clientHost{config: &config.Host{Name: name, Priority: priority}}
+	}
+	tt := []struct {
+		name   string
+		hosts  []*clientHost
+		expect []string
+	}{
+		{
+			name: "priority",
+			hosts: []*clientHost{
+				newHost("mirror-c", 3),
+				newHost("mirror-a", 1),
+				newHost("mirror-b", 2),
+				newHost("upstream", 0),
+			},
+			expect: []string{"upstream", "mirror-a", "mirror-b", "mirror-c"},
+		},
+		{
+			name: "ties keep order",
+			hosts: []*clientHost{
+				newHost("mirror-z", 1),
+				newHost("mirror-y", 1),
+				newHost("mirror-x", 1),
+				newHost("mirror-first", 0),
+				newHost("mirror-w", 1),
+				newHost("upstream", 1),
+			},
+			expect: []string{"mirror-first", "mirror-z", "mirror-y", "mirror-x", "mirror-w", "upstream"},
+		},
+		{
+			name: "upstream last",
+			hosts: []*clientHost{
+				newHost("mirror-a", 0),
+				newHost("mirror-b", 0),
+				newHost("mirror-c", 0),
+				newHost("mirror-d", 0),
+				newHost("mirror-e", 0),
+				newHost("mirror-f", 0),
+				newHost("mirror-g", 0),
+				newHost("mirror-h", 0),
+				newHost("mirror-i", 0),
+				newHost("mirror-j", 0),
+				newHost("mirror-k", 0),
+				newHost("mirror-l", 0),
+				newHost("mirror-m", 0),
+				newHost("upstream", 0),
+			},
+			expect: []string{"mirror-a", "mirror-b", "mirror-c", "mirror-d", "mirror-e", "mirror-f", "mirror-g", "mirror-h", "mirror-i", "mirror-j", "mirror-k", "mirror-l", "mirror-m", "upstream"},
+		},
+		{
+			name: "backoff",
+			hosts: []*clientHost{
+				{config: &config.Host{Name: "mirror-a", Priority: 1}, backoffLast: time.Now().Add(time.Hour)},
+				newHost("mirror-b", 2),
+				newHost("upstream", 3),
+			},
+			expect: []string{"mirror-b", "upstream", "mirror-a"},
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// repeat the sort to verify the order is deterministic
+			for i := 0; i < 5; i++ {
+				hosts := make([]*clientHost, len(tc.hosts))
+				copy(hosts, tc.hosts)
+				sort.SliceStable(hosts, sortHostsCmp(hosts, "upstream"))
+				names := make([]string, len(hosts))
+				for i, h := range hosts {
+					names[i] = h.config.Name
+				}
+				if strings.Join(names, ",") != strings.Join(tc.expect, ",") {
+					t.Fatalf("unexpected order, expected %v, received %v", tc.expect, names)
+				}
+			}
+		})
+	}
+}
+
+func TestReqTimeout(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var attempts atomic.Int32
+	stop := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/manifests/retry":
+			// the first attempt is stuck, the retry responds immediately
+			if attempts.Add(1) == 1 {
+				select {
+				case <-r.Context().Done():
+				case <-stop:
+				}
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ok"))
+		case "/v2/project/manifests/stuck":
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	defer close(stop)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	reqTimeout := time.Millisecond * 100
+	configHosts := map[string]*config.Host{
+		tsHost: {
+			Name:       tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			ReqTimeout: timejson.Duration(reqTimeout),
+		},
+	}
+	delayInit, _ := time.ParseDuration("0.0005s")
+	delayMax, _ := time.ParseDuration("0.0010s")
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(delayInit, delayMax),
+		WithRetryLimit(3),
+	)
+	t.Run("retry", func(t *testing.T) {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/retry",
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Fatalf("body read failure: %v", err)
+		}
+		if string(body) != "ok" {
+			t.Errorf("unexpected body: %s", body)
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("unexpected number of attempts, expected 2, received %d", attempts.Load())
+		}
+	})
+	t.Run("stuck", func(t *testing.T) {
+		start := time.Now()
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/stuck",
+		})
+		if err == nil {
+			_ = resp.Close()
+			t.Fatalf("stuck request did not fail")
+		}
+		if time.Since(start) > time.Second*2 {
+			t.Errorf("stuck request did not fail fast, duration %s", time.Since(start))
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	attempts := map[string]*atomic.Int32{
+		"seconds":     {},
+		"capped":      {},
+		"unavailable": {},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request to each path is rate limited
+		name := strings.TrimPrefix(r.URL.Path, "/v2/project/manifests/")
+		count, ok := attempts[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if count.Add(1) == 1 {
+			switch name {
+			case "seconds":
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case "capped":
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case "unavailable":
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	newClient := func(opts ...Opts) *Client {
+		configHosts := map[string]*config.Host{
+			tsHost: {
+				Name:     tsHost,
+				Hostname: tsHost,
+				TLS:      config.TLSDisabled,
+			},
+		}
+		opts = append([]Opts{
+			WithConfigHostFn(func(name string) *config.Host {
+				if configHosts[name] == nil {
+					configHosts[name] = config.HostNewName(name)
+				}
+				return configHosts[name]
+			}),
+			WithDelay(time.Millisecond*10, time.Millisecond*50),
+			WithRetryLimit(3),
+		}, opts...)
+		return NewClient(opts...)
+	}
+	tt := []struct {
+		name      string
+		path      string
+		opts      []Opts
+		expectMin time.Duration
+		expectMax time.Duration
+	}{
+		{
+			name:      "seconds",
+			path:      "seconds",
+			expectMin: time.Second * 2,
+			expectMax: time.Second * 4,
+		},
+		{
+			name:      "capped",
+			path:      "capped",
+			opts:      []Opts{WithRetryAfterMax(time.Millisecond * 500)},
+			expectMin: time.Millisecond * 500,
+			expectMax: time.Second * 2,
+		},
+		{
+			name:      "unavailable",
+			path:      "unavailable",
+			expectMin: time.Second,
+			expectMax: time.Second * 3,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			hc := newClient(tc.opts...)
+			start := time.Now()
+			resp, err := hc.Do(ctx, &Req{
+				Host:       tsHost,
+				Method:     "GET",
+				Repository: "project",
+				Path:       "manifests/" + tc.path,
+			})
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil || string(body) != "ok" {
+				t.Errorf("unexpected body: %s, %v", body, err)
+			}
+			elapsed := time.Since(start)
+			if elapsed < tc.expectMin || elapsed > tc.expectMax {
+				t.Errorf("unexpected delay, expected between %s and %s, received %s", tc.expectMin, tc.expectMax, elapsed)
+			}
+		})
+	}
+	t.Run("parse", func(t *testing.T) {
+		now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		for _, pt := range []struct {
+			val    string
+			expect time.Duration
+		}{
+			{val: "", expect: 0},
+			{val: "5", expect: time.Second * 5},
+			{val: " 10 ", expect: time.Second * 10},
+			{val: "-1", expect: 0},
+			{val: "invalid", expect: 0},
+			{val: now.Add(time.Minute).Format(http.TimeFormat), expect: time.Minute},
+			{val: now.Add(-time.Minute).Format(http.TimeFormat), expect: 0},
+		} {
+			if d := retryAfterParse(pt.val, now); d != pt.expect {
+				t.Errorf("unexpected delay for %q, expected %s, received %s", pt.val, pt.expect, d)
+			}
+		}
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	t.Parallel()
+	delayInit := time.Millisecond * 100
+	delayMax := time.Second * 10
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		hc := NewClient(WithDelay(delayInit, delayMax))
+		for i, expect := range []time.Duration{delayInit, delayInit * 2, delayInit * 4, delayInit * 8} {
+			if d := hc.backoffDelay(nil, i); d != expect {
+				t.Errorf("unexpected delay for %d, expected %s, received %s", i, expect, d)
+			}
+		}
+		if d := hc.backoffDelay(nil, 10); d != delayMax {
+			t.Errorf("delay not limited, expected %s, received %s", delayMax, d)
+		}
+	})
+	t.Run("factor", func(t *testing.T) {
+		t.Parallel()
+		hc := NewClient(WithDelay(delayInit, delayMax), WithRetryBackoff(3, 0))
+		for i, expect := range []time.Duration{delayInit, delayInit * 3, delayInit * 9, delayInit * 27} {
+			if d := hc.backoffDelay(nil, i); d != expect {
+				t.Errorf("unexpected delay for %d, expected %s, received %s", i, expect, d)
+			}
+		}
+	})
+	t.Run("jitter", func(t *testing.T) {
+		t.Parallel()
+		jitter := 0.5
+		hc1 := NewClient(WithDelay(delayInit, delayMax), WithRetryBackoff(2, jitter), WithRetrySeed(42))
+		hc2 := NewClient(WithDelay(delayInit, delayMax), WithRetryBackoff(2, jitter), WithRetrySeed(42))
+		varied := false
+		for i := 0; i < 10; i++ {
+			full := min(delayMax, delayInit<<i)
+			d1 := hc1.backoffDelay(nil, i)
+			d2 := hc2.backoffDelay(nil, i)
+			if d1 != d2 {
+				t.Errorf("seeded delays differ for %d: %s, %s", i, d1, d2)
+			}
+			if d1 > full || float64(d1) < float64(full)*(1-jitter) {
+				t.Errorf("delay for %d out of range, full %s, received %s", i, full, d1)
+			}
+			if d1 != full {
+				varied = true
+			}
+		}
+		if !varied {
+			t.Errorf("jitter did not change any delays")
+		}
+	})
+	t.Run("host", func(t *testing.T) {
+		t.Parallel()
+		hc := NewClient(WithDelay(delayInit, delayMax), WithRetryBackoff(3, 0.5))
+		h := config.HostNewName("registry.example.org")
+		h.RetryBackoff = 1.5
+		h.RetryJitter = 0
+		if d, expect := hc.backoffDelay(h, 2), time.Duration(float64(delayInit)*2.25); d > expect || float64(d) < float64(expect)*0.5 {
+			t.Errorf("host factor not used, expected up to %s, received %s", expect, d)
+		}
+		h.RetryJitter = 1
+		hc = NewClient(WithDelay(delayInit, delayMax), WithRetryBackoff(4, 0))
+		if d := hc.backoffDelay(h, 2); d > time.Duration(float64(delayInit)*2.25) {
+			t.Errorf("host settings not used, received %s", d)
+		}
+	})
+}
+
+func TestDebugHTTP(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	respBody := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/manifests/tag-get":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Set-Cookie", "session=cookiesecret")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(respBody)
+		case "/v2/project/blobs/uploads/":
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	newClient := func(w io.Writer, bodyMax int64) *Client {
+		return NewClient(
+			WithConfigHostFn(func(name string) *config.Host {
+				h := config.HostNewName(name)
+				h.TLS = config.TLSDisabled
+				h.Headers = map[string]string{"x-tenant-token": "tenantsecret"}
+				return h
+			}),
+			WithDebugHTTP(w, bodyMax),
+		)
+	}
+	t.Run("headers", func(t *testing.T) {
+		t.Parallel()
+		buf := &bytes.Buffer{}
+		hc := newClient(buf, 0)
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-get",
+			Headers: http.Header{
+				"Authorization":       []string{"Bearer secret"},
+				"Proxy-Authorization": []string{"Basic proxysecret"},
+				"Cookie":              []string{"session=cookiesecret"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil || !bytes.Equal(body, respBody) {
+			t.Errorf("unexpected body: %s, %v", body, err)
+		}
+		out := buf.String()
+		for _, expect := range []string{
+			"> GET /v2/project/manifests/tag-get HTTP/1.1\n",
+			"> Host: " + tsHost + "\n",
+			"> Authorization: [censored]\n",
+			"> Proxy-Authorization: [censored]\n",
+			"> Cookie: [censored]\n",
+			"> X-Tenant-Token: [censored]\n",
+			"< HTTP/1.1 200 OK\n",
+			"< Content-Type: text/plain\n",
+			"< Set-Cookie: [censored]\n",
+		} {
+			if !strings.Contains(out, expect) {
+				t.Errorf("debug output missing %q: %s", expect, out)
+			}
+		}
+		if strings.Contains(out, "secret") || strings.Contains(out, string(respBody)) {
+			t.Errorf("debug output includes censored data or a body: %s", out)
+		}
+		if resp.HTTPResponse().Header.Get("Set-Cookie") != "session=cookiesecret" {
+			t.Errorf("response header was modified: %v", resp.HTTPResponse().Header)
+		}
+	})
+	t.Run("body", func(t *testing.T) {
+		t.Parallel()
+		buf := &bytes.Buffer{}
+		hc := newClient(buf, 10)
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-get",
+		})
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil || !bytes.Equal(body, respBody) {
+			t.Errorf("body was not restored after the dump: %s, %v", body, err)
+		}
+		resp, err = hc.Do(ctx, &Req{
+			Host:       tsHost,
+			Method:     "POST",
+			Repository: "project",
+			Path:       "blobs/uploads/",
+			BodyBytes:  []byte("request body content"),
+		})
+		if err != nil {
+			t.Fatalf("failed to run post: %v", err)
+		}
+		_ = resp.Close()
+		out := buf.String()
+		for _, expect := range []string{
+			"< 0123456789\n< [truncated]\n",
+			"> POST /v2/project/blobs/uploads/ HTTP/1.1\n",
+			"> request bo\n> [truncated]\n",
+			"< HTTP/1.1 202 Accepted\n",
+		} {
+			if !strings.Contains(out, expect) {
+				t.Errorf("debug output missing %q: %s", expect, out)
+			}
+		}
+	})
+}
@@ -0,0 +1,228 @@
+// Package semver parses semantic versions and matches them against a list of constraints.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string // pre-release identifiers, e.g. ["rc", "1"] for "1.2.3-rc.1"
+	Build               string   // build metadata, ignored when comparing versions
+}
+
+// Parse parses a semantic version with an optional "v" prefix.
+// The minor and patch values may be omitted, e.g. "v1" is parsed as "1.0.0".
+func Parse(s string) (Version, error) {
+	v := Version{}
+	rest := strings.TrimPrefix(s, "v")
+	rest, build, hasBuild := strings.Cut(rest, "+")
+	if hasBuild {
+		if !identsValid(build, false) {
+			return v, fmt.Errorf("invalid build metadata in version %q%.0w", s, errs.ErrParsingFailed)
+		}
+		v.Build = build
+	}
+	rest, pre, hasPre := strings.Cut(rest, "-")
+	if hasPre {
+		if !identsValid(pre, true) {
+			return v, fmt.Errorf("invalid pre-release in version %q%.0w", s, errs.ErrParsingFailed)
+		}
+		v.Pre = strings.Split(pre, ".")
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("too many components in version %q%.0w", s, errs.ErrParsingFailed)
+	}
+	nums := [3]int{}
+	for i, p := range parts {
+		if !numValid(p) {
+			return v, fmt.Errorf("invalid number %q in version %q%.0w", p, s, errs.ErrParsingFailed)
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return v, fmt.Errorf("invalid number %q in version %q: %w", p, s, err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// String returns the version without a "v" prefix.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0, or 1 when a is lower than, equal to, or greater than b, following the semver precedence rules.
+func Compare(a, b Version) int {
+	for _, cmp := range [][2]int{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if cmp[0] != cmp[1] {
+			return cmpInt(cmp[0], cmp[1])
+		}
+	}
+	// a version without a pre-release has a higher precedence
+	if len(a.Pre) == 0 || len(b.Pre) == 0 {
+		return cmpInt(len(b.Pre), len(a.Pre))
+	}
+	for i := 0; i < len(a.Pre) && i < len(b.Pre); i++ {
+		if c := cmpIdent(a.Pre[i], b.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmpInt(len(a.Pre), len(b.Pre))
+}
+
+// Constraints is a list of comparisons that must all match a version.
+type Constraints []constraint
+
+type constraint struct {
+	op string
+	v  Version
+}
+
+// ParseConstraints parses a comma separated list of comparisons, e.g. ">=1.2, <2".
+// Supported operators are "=", "!=", ">", ">=", "<", and "<=", defaulting to "=".
+func ParseConstraints(s string) (Constraints, error) {
+	c := Constraints{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		op := "="
+		for _, cmp := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(entry, cmp) {
+				op = cmp
+				entry = strings.TrimSpace(strings.TrimPrefix(entry, cmp))
+				break
+			}
+		}
+		v, err := Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, constraint{op: op, v: v})
+	}
+	return c, nil
+}
+
+// Match returns true when the version satisfies every constraint.
+// A pre-release version only matches when one of the constraints includes a pre-release of the same major, minor, and patch version.
+func (c Constraints) Match(v Version) bool {
+	if len(v.Pre) > 0 {
+		found := false
+		for _, entry := range c {
+			if len(entry.v.Pre) > 0 && entry.v.Major == v.Major && entry.v.Minor == v.Minor && entry.v.Patch == v.Patch {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, entry := range c {
+		cmp := Compare(v, entry.v)
+		ok := false
+		switch entry.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchString parses s as a version and returns true when it satisfies every constraint.
+// Strings that are not a valid version never match.
+func (c Constraints) MatchString(s string) bool {
+	v, err := Parse(s)
+	if err != nil {
+		return false
+	}
+	return c.Match(v)
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// cmpIdent compares pre-release identifiers, numeric identifiers are lower than alphanumeric identifiers.
+func cmpIdent(a, b string) int {
+	aNum, bNum := numValid(a), numValid(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return cmpInt(len(a), len(b))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// numValid returns true for a number without a sign or leading zeros.
+func numValid(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// identsValid verifies a dot separated list of alphanumeric identifiers, rejecting leading zeros when the identifiers are a pre-release.
+func identsValid(s string, pre bool) bool {
+	for _, ident := range strings.Split(s, ".") {
+		if ident == "" {
+			return false
+		}
+		digits := true
+		for _, r := range ident {
+			switch {
+			case r >= '0' && r <= '9':
+			case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '-':
+				digits = false
+			default:
+				return false
+			}
+		}
+		if pre && digits && !numValid(ident) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		str    string
+		expect string
+		err    error
+	}{
+		{
+			name:   "full",
+			str:    "1.2.3",
+			expect: "1.2.3",
+		},
+		{
+			name:   "prefix",
+			str:    "v1.2.3",
+			expect: "1.2.3",
+		},
+		{
+			name:   "major only",
+			str:    "v1",
+			expect: "1.0.0",
+		},
+		{
+			name:   "major minor",
+			str:    "1.2",
+			expect: "1.2.0",
+		},
+		{
+			name:   "pre-release and build",
+			str:    "1.2.3-rc.1+build-5.x",
+			expect: "1.2.3-rc.1+build-5.x",
+		},
+		{
+			name: "empty",
+			str:  "",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "too many components",
+			str:  "1.2.3.4",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "leading zero",
+			str:  "1.02.3",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "signed",
+			str:  "1.+2.3",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "pre-release leading zero",
+			str:  "1.2.3-rc.01",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "empty pre-release",
+			str:  "1.2.3-",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "invalid build",
+			str:  "1.2.3+a..b",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "not a version",
+			str:  "latest",
+			err:  errs.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			v, err := Parse(tc.str)
+			if tc.err != nil {
+				if err == nil {
+					t.Fatalf("parse did not fail, received %s", v.String())
+				} else if !errors.Is(err, tc.err) {
+					t.Fatalf("unexpected error, expected %v, received %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if v.String() != tc.expect {
+				t.Errorf("unexpected version, expected %s, received %s", tc.expect, v.String())
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	// each entry has a lower precedence than the next
+	order := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range order {
+		a, err := Parse(order[i])
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", order[i], err)
+		}
+		if c := Compare(a, a); c != 0 {
+			t.Errorf("compare %s to itself returned %d", order[i], c)
+		}
+		for j := i + 1; j < len(order); j++ {
+			b, err := Parse(order[j])
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", order[j], err)
+			}
+			if c := Compare(a, b); c != -1 {
+				t.Errorf("compare %s to %s, expected -1, received %d", order[i], order[j], c)
+			}
+			if c := Compare(b, a); c != 1 {
+				t.Errorf("compare %s to %s, expected 1, received %d", order[j], order[i], c)
+			}
+		}
+	}
+	// build metadata is ignored
+	a, _ := Parse("1.2.3+a")
+	b, _ := Parse("1.2.3+b")
+	if c := Compare(a, b); c != 0 {
+		t.Errorf("build metadata was compared, received %d", c)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name     string
+		cons     string
+		match    []string
+		noMatch  []string
+		parseErr bool
+	}{
+		{
+			name:    "range",
+			cons:    ">=1.2, <2",
+			match:   []string{"1.2", "v1.2.0", "1.9.9", "1.10.0"},
+			noMatch: []string{"1.1.9", "2.0.0", "v2", "latest", "1.5.0-rc.1"},
+		},
+		{
+			name:    "equal",
+			cons:    "1",
+			match:   []string{"1", "v1.0", "1.0.0+build"},
+			noMatch: []string{"1.0.1", "1.0.0-rc.1"},
+		},
+		{
+			name:    "not equal",
+			cons:    "!=1.2.3",
+			match:   []string{"1.2.2", "1.2.4"},
+			noMatch: []string{"1.2.3"},
+		},
+		{
+			name:    "greater and less",
+			cons:    ">1, <=1.5",
+			match:   []string{"1.0.1", "1.5"},
+			noMatch: []string{"1.0.0", "1.5.1"},
+		},
+		{
+			name:    "pre-release",
+			cons:    ">=1.2.3-rc.1, <1.3",
+			match:   []string{"1.2.3-rc.1", "1.2.3-rc.2", "1.2.3", "1.2.9"},
+			noMatch: []string{"1.2.3-beta", "1.2.4-rc.1"},
+		},
+		{
+			name:     "invalid",
+			cons:     ">=1.x",
+			parseErr: true,
+		},
+		{
+			name:     "empty entry",
+			cons:     ">=1,",
+			parseErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := ParseConstraints(tc.cons)
+			if tc.parseErr {
+				if err == nil {
+					t.Fatalf("parse did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			for _, s := range tc.match {
+				if !c.MatchString(s) {
+					t.Errorf("%s did not match %s", s, tc.cons)
+				}
+			}
+			for _, s := range tc.noMatch {
+				if c.MatchString(s) {
+					t.Errorf("%s unexpectedly matched %s", s, tc.cons)
+				}
+			}
+		})
+	}
+}
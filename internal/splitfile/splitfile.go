@@ -0,0 +1,217 @@
+// Package splitfile writes and reads a stream across multiple size limited files.
+package splitfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Name returns the filename of a part, e.g. "image.tar.000".
+func Name(base string, part int) string {
+	return fmt.Sprintf("%s.%03d", base, part)
+}
+
+// Parts returns the list of existing part filenames for a base filename.
+func Parts(base string) ([]string, error) {
+	names := []string{}
+	for i := 0; ; i++ {
+		name := Name(base, i)
+		_, err := os.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no parts found for %s: %w", base, fs.ErrNotExist)
+	}
+	return names, nil
+}
+
+// Writer creates a new part each time the size limit is reached.
+// Parts are created on the first write, so an empty part is never created.
+type Writer struct {
+	base   string
+	size   int64
+	part   int
+	cur    int64
+	fh     *os.File
+	closed bool
+	// Names are the filenames of every part that was created.
+	Names []string
+}
+
+// NewWriter returns a writer for the base filename with parts limited to size bytes.
+func NewWriter(base string, size int64) (*Writer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("split size must be greater than 0, received %d", size)
+	}
+	return &Writer{
+		base:  base,
+		size:  size,
+		Names: []string{},
+	}, nil
+}
+
+// Write outputs the bytes, moving to the next part when the current part is full.
+func (w *Writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.fh == nil || w.cur >= w.size {
+			err := w.next()
+			if err != nil {
+				return written, err
+			}
+		}
+		chunk := p
+		if int64(len(chunk)) > w.size-w.cur {
+			chunk = chunk[:w.size-w.cur]
+		}
+		n, err := w.fh.Write(chunk)
+		written += n
+		w.cur += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close closes the current part and removes any parts left from a previous write to the same base filename.
+// Without the removal, a shorter output would be read with the stale parts that follow it.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.closePart(); err != nil {
+		return err
+	}
+	for i := w.part; ; i++ {
+		err := os.Remove(Name(w.base, i))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Writer) closePart() error {
+	if w.fh == nil {
+		return nil
+	}
+	err := w.fh.Close()
+	w.fh = nil
+	return err
+}
+
+func (w *Writer) next() error {
+	if w.closed {
+		return fs.ErrClosed
+	}
+	if err := w.closePart(); err != nil {
+		return err
+	}
+	name := Name(w.base, w.part)
+	fh, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w.fh = fh
+	w.cur = 0
+	w.part++
+	w.Names = append(w.Names, name)
+	return nil
+}
+
+// Reader provides a single seekable stream over a list of parts.
+type Reader struct {
+	files []*os.File
+	sizes []int64
+	total int64
+	pos   int64
+}
+
+// Open returns a reader that concatenates the list of files.
+func Open(names ...string) (*Reader, error) {
+	r := &Reader{
+		files: make([]*os.File, 0, len(names)),
+		sizes: make([]int64, 0, len(names)),
+	}
+	for _, name := range names {
+		fh, err := os.Open(name)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		r.files = append(r.files, fh)
+		fi, err := fh.Stat()
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		r.sizes = append(r.sizes, fi.Size())
+		r.total += fi.Size()
+	}
+	return r, nil
+}
+
+// Read reads from the part containing the current position.
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	offset := r.pos
+	for i, size := range r.sizes {
+		if offset >= size {
+			offset -= size
+			continue
+		}
+		if int64(len(p)) > size-offset {
+			p = p[:size-offset]
+		}
+		n, err := r.files[i].ReadAt(p, offset)
+		r.pos += int64(n)
+		if errors.Is(err, io.EOF) && n > 0 {
+			err = nil
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+// Seek moves the position across the concatenated parts.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.total
+	default:
+		return r.pos, fmt.Errorf("unknown whence: %d", whence)
+	}
+	if offset < 0 {
+		return r.pos, fmt.Errorf("seek to a negative offset: %d", offset)
+	}
+	r.pos = offset
+	return r.pos, nil
+}
+
+// Close closes every part.
+func (r *Reader) Close() error {
+	errList := []error{}
+	for _, fh := range r.files {
+		if err := fh.Close(); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return errors.Join(errList...)
+}
@@ -0,0 +1,161 @@
+package splitfile
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitFile(t *testing.T) {
+	t.Parallel()
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	tt := []struct {
+		name        string
+		size        int64
+		writes      []int
+		expectParts int
+	}{
+		{
+			name:        "single part",
+			size:        100,
+			writes:      []int{len(data)},
+			expectParts: 1,
+		},
+		{
+			name:        "exact parts",
+			size:        12,
+			writes:      []int{len(data)},
+			expectParts: 3,
+		},
+		{
+			name:        "small writes",
+			size:        10,
+			writes:      []int{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3},
+			expectParts: 4,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			base := filepath.Join(t.TempDir(), "out.tar")
+			w, err := NewWriter(base, tc.size)
+			if err != nil {
+				t.Fatalf("failed to create writer: %v", err)
+			}
+			cur := 0
+			for _, l := range tc.writes {
+				n, err := w.Write(data[cur : cur+l])
+				if err != nil || n != l {
+					t.Fatalf("failed to write, n=%d, err=%v", n, err)
+				}
+				cur += l
+			}
+			err = w.Close()
+			if err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+			names, err := Parts(base)
+			if err != nil {
+				t.Fatalf("failed to list parts: %v", err)
+			}
+			if len(names) != tc.expectParts || len(w.Names) != tc.expectParts {
+				t.Fatalf("unexpected parts, expected %d, received %v, written %v", tc.expectParts, names, w.Names)
+			}
+			// concatenating the parts must give the original bytes
+			whole := []byte{}
+			for _, name := range names {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if int64(len(b)) > tc.size {
+					t.Errorf("part %s exceeds size: %d", name, len(b))
+				}
+				whole = append(whole, b...)
+			}
+			if !bytes.Equal(whole, data) {
+				t.Errorf("concatenated parts mismatch, expected %s, received %s", data, whole)
+			}
+			r, err := Open(names...)
+			if err != nil {
+				t.Fatalf("failed to open: %v", err)
+			}
+			defer r.Close()
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if !bytes.Equal(b, data) {
+				t.Errorf("read mismatch, expected %s, received %s", data, b)
+			}
+			pos, err := r.Seek(-5, io.SeekEnd)
+			if err != nil || pos != int64(len(data)-5) {
+				t.Fatalf("failed to seek, pos=%d, err=%v", pos, err)
+			}
+			b, err = io.ReadAll(r)
+			if err != nil || !bytes.Equal(b, data[len(data)-5:]) {
+				t.Errorf("read after seek mismatch, received %s, err=%v", b, err)
+			}
+			_, err = r.Seek(11, io.SeekStart)
+			if err != nil {
+				t.Fatalf("failed to seek: %v", err)
+			}
+			b = make([]byte, 4)
+			_, err = io.ReadFull(r, b)
+			if err != nil || !bytes.Equal(b, data[11:15]) {
+				t.Errorf("read across parts mismatch, received %s, err=%v", b, err)
+			}
+		})
+	}
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := Parts(filepath.Join(t.TempDir(), "missing.tar"))
+		if err == nil {
+			t.Errorf("parts found for a missing file")
+		}
+	})
+}
+
+func TestSplitFileStaleParts(t *testing.T) {
+	t.Parallel()
+	base := filepath.Join(t.TempDir(), "out.tar")
+	write := func(data []byte) {
+		w, err := NewWriter(base, 4)
+		if err != nil {
+			t.Fatalf("failed to create writer: %v", err)
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("failed to close: %v", err)
+		}
+	}
+	write([]byte("0123456789abcdef"))
+	// a shorter second write removes the trailing parts of the first
+	write([]byte("abcdef"))
+	names, err := Parts(base)
+	if err != nil {
+		t.Fatalf("failed to list parts: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("unexpected parts: %v", names)
+	}
+	r, err := Open(names...)
+	if err != nil {
+		t.Fatalf("failed to open parts: %v", err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read parts: %v", err)
+	}
+	if string(b) != "abcdef" {
+		t.Errorf("unexpected content, expected abcdef, received %s", b)
+	}
+}
@@ -1,4 +1,4 @@
-// Package timejson extends time methods with marshal/unmarshal for json
+// Package timejson extends time methods with marshal/unmarshal for json and yaml
 package timejson
 
 import (
@@ -39,3 +39,33 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 		return errInvalid
 	}
 }
+
+// MarshalYAML converts a duration to yaml
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML converts yaml to a duration
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case int:
+		*d = Duration(time.Duration(value))
+		return nil
+	case float64:
+		*d = Duration(time.Duration(value))
+		return nil
+	case string:
+		timeDur, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(timeDur)
+		return nil
+	default:
+		return errInvalid
+	}
+}
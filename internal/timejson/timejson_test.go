@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestMarshal(t *testing.T) {
@@ -104,3 +106,70 @@ func TestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestYAML(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		str    string
+		expect Duration
+		expErr error
+	}{
+		{
+			name:   "bool",
+			str:    `d: true`,
+			expErr: errInvalid,
+		},
+		{
+			name:   "invalid duration",
+			str:    `d: 42 years`,
+			expErr: errors.New(`time: unknown unit " years" in duration "42 years"`),
+		},
+		{
+			name:   "minute",
+			str:    `d: 5m`,
+			expect: Duration(time.Minute * 5),
+		},
+		{
+			name:   "quoted",
+			str:    `d: "1h"`,
+			expect: Duration(time.Hour),
+		},
+		{
+			name:   "integer",
+			str:    fmt.Sprintf("d: %d", time.Second),
+			expect: Duration(time.Second),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := struct {
+				D Duration `yaml:"d"`
+			}{}
+			err := yaml.Unmarshal([]byte(tt.str), &v)
+			if tt.expErr != nil {
+				if err == nil {
+					t.Errorf("error not encountered")
+				} else if err != tt.expErr && err.Error() != tt.expErr.Error() {
+					t.Errorf("error mismatch, expected %v, received %v", tt.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed unmarshaling: %v", err)
+			}
+			if v.D != tt.expect {
+				t.Errorf("duration mismatch, expected %s, received %s", time.Duration(tt.expect).String(), time.Duration(v.D).String())
+			}
+			b, err := yaml.Marshal(v)
+			if err != nil {
+				t.Fatalf("failed marshaling: %v", err)
+			}
+			expect := fmt.Sprintf("d: %s\n", time.Duration(tt.expect).String())
+			if string(b) != expect {
+				t.Errorf("mismatch, expected %s, received %s", expect, string(b))
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ package units
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 var decimapAbbrs = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
@@ -55,3 +57,34 @@ func HumanSize(size float64) string {
 func BytesSize(size float64) string {
 	return CustomSize("%5.3f%s", size, 1024.0, binaryAbbrs)
 }
+
+// FromHumanSize returns an integer from a human-readable specification of a size
+// (eg. "2GB", "512MiB", "1.5k"). Decimal units use a base of 1000 and binary
+// units (with an "i") use a base of 1024.
+func FromHumanSize(size string) (int64, error) {
+	s := strings.TrimSpace(size)
+	num := strings.TrimRight(s, "BbIiKkMmGgTtPpEeZzYy ")
+	unit := strings.ToLower(strings.TrimSpace(s[len(num):]))
+	value, err := strconv.ParseFloat(num, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+	unit = strings.TrimSuffix(unit, "b")
+	base := 1000.0
+	if strings.HasSuffix(unit, "i") {
+		unit = strings.TrimSuffix(unit, "i")
+		base = 1024.0
+	}
+	if unit == "" && base == 1000.0 {
+		return int64(value), nil
+	}
+	for i, abbr := range decimapAbbrs[1:] {
+		if unit == strings.ToLower(abbr[:1]) {
+			for ; i >= 0; i-- {
+				value *= base
+			}
+			return int64(value), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size: %q", size)
+}
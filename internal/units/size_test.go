@@ -35,3 +35,46 @@ func TestHuman(t *testing.T) {
 		})
 	}
 }
+
+func TestFromHumanSize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		size      string
+		result    int64
+		expectErr bool
+	}{
+		{size: "0", result: 0},
+		{size: "1024", result: 1024},
+		{size: "100B", result: 100},
+		{size: "2kB", result: 2000},
+		{size: "2k", result: 2000},
+		{size: "1.5MB", result: 1500000},
+		{size: "2GB", result: 2000000000},
+		{size: "2 gb", result: 2000000000},
+		{size: "1KiB", result: 1024},
+		{size: "512MiB", result: 536870912},
+		{size: "2GiB", result: 2147483648},
+		{size: "", expectErr: true},
+		{size: "GB", expectErr: true},
+		{size: "-1", expectErr: true},
+		{size: "1XB", expectErr: true},
+		{size: "1iB", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			result, err := FromHumanSize(tt.size)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("did not receive expected error, result %d", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.result {
+				t.Errorf("expected %d, received %d", tt.result, result)
+			}
+		})
+	}
+}
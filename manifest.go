@@ -3,9 +3,12 @@ package regclient
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"strings"
 
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
@@ -19,6 +22,7 @@ type manifestOpt struct {
 	platform      *platform.Platform
 	schemeOpts    []scheme.ManifestOpts
 	requireDigest bool
+	ifNoneMatch   string
 }
 
 // ManifestOpts define options for the Manifest* commands.
@@ -47,6 +51,15 @@ func WithManifestChild() ManifestOpts {
 	}
 }
 
+// WithManifestContentType overrides the Content-Type header on ManifestPut for registries that require a specific value.
+// The manifest is still parsed and validated with its own media type.
+// This is ignored by schemes without http headers, like ocidir.
+func WithManifestContentType(mt string) ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.schemeOpts = append(opts.schemeOpts, scheme.WithManifestContentType(mt))
+	}
+}
+
 // WithManifestDesc includes the descriptor for ManifestGet.
 // This is used to automatically extract a Data field if available.
 func WithManifestDesc(d descriptor.Descriptor) ManifestOpts {
@@ -55,6 +68,15 @@ func WithManifestDesc(d descriptor.Descriptor) ManifestOpts {
 	}
 }
 
+// WithManifestIfNoneMatch sends a conditional ManifestHead request, typically with the previously received digest.
+// If the resulting manifest matches, [errs.ErrNotModified] is returned.
+// Schemes without conditional request support compare the digest after the request.
+func WithManifestIfNoneMatch(etag string) ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.ifNoneMatch = etag
+	}
+}
+
 // WithManifestPlatform resolves the platform specific manifest on Get and Head requests.
 // This causes an additional GET query to a registry when an Index or Manifest List is encountered.
 // This option is ignored if the retrieved manifest is not an Index or Manifest List.
@@ -141,6 +163,32 @@ func (rc *RegClient) ManifestGet(ctx context.Context, r ref.Ref, opts ...Manifes
 	return m, err
 }
 
+// ManifestGetRaw retrieves a manifest without parsing the content.
+// The returned bytes are identical to those provided by the source, and the descriptor includes the media type, size, and digest of those bytes.
+// This is useful for proxies and mirrors that need to push the identical manifest.
+func (rc *RegClient) ManifestGetRaw(ctx context.Context, r ref.Ref) ([]byte, descriptor.Descriptor, error) {
+	if !r.IsSet() {
+		return nil, descriptor.Descriptor{}, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, descriptor.Descriptor{}, err
+	}
+	if sRaw, ok := schemeAPI.(scheme.ManifestRawGetter); ok {
+		return sRaw.ManifestGetRaw(ctx, r)
+	}
+	// fall back to a parsed manifest for schemes that store the original bytes
+	m, err := schemeAPI.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, descriptor.Descriptor{}, err
+	}
+	raw, err := m.RawBody()
+	if err != nil {
+		return nil, descriptor.Descriptor{}, err
+	}
+	return raw, m.GetDescriptor(), nil
+}
+
 // ManifestHead queries for the existence of a manifest and returns metadata (digest, media-type, size).
 func (rc *RegClient) ManifestHead(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error) {
 	if !r.IsSet() {
@@ -158,7 +206,12 @@ func (rc *RegClient) ManifestHead(ctx context.Context, r ref.Ref, opts ...Manife
 	if err != nil {
 		return nil, err
 	}
-	m, err := schemeAPI.ManifestHead(ctx, r)
+	var m manifest.Manifest
+	if sc, ok := schemeAPI.(scheme.ManifestHeadConditional); ok && opt.ifNoneMatch != "" && opt.platform == nil {
+		m, err = sc.ManifestHeadIfNoneMatch(ctx, r, opt.ifNoneMatch)
+	} else {
+		m, err = schemeAPI.ManifestHead(ctx, r)
+	}
 	if err != nil {
 		return m, err
 	}
@@ -185,6 +238,10 @@ func (rc *RegClient) ManifestHead(ctx context.Context, r ref.Ref, opts ...Manife
 	if opt.requireDigest && m.GetDescriptor().Digest.String() == "" {
 		m, err = schemeAPI.ManifestGet(ctx, r)
 	}
+	// check the digest when the request was not conditional or the registry ignored the header
+	if err == nil && opt.ifNoneMatch != "" && m.GetDescriptor().Digest.String() == strings.Trim(opt.ifNoneMatch, `"`) {
+		return m, fmt.Errorf("manifest unchanged %s: %w", r.CommonName(), errs.ErrNotModified)
+	}
 	return m, err
 }
 
@@ -204,3 +261,52 @@ func (rc *RegClient) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Mani
 	}
 	return schemeAPI.ManifestPut(ctx, r, m, opt.schemeOpts...)
 }
+
+// ManifestPutRaw pushes the manifest bytes from a reader without parsing the content.
+// The descriptor must include the media type, size, and digest of the bytes.
+// A push by tag is only verified before it is sent when the reader is an [io.ReadSeeker].
+// This pairs with [RegClient.ManifestGetRaw] for byte-exact pipelines, and avoids holding a parsed copy of very large indexes in memory.
+// Schemes that cannot stream a manifest fall back to parsing the bytes and [RegClient.ManifestPut].
+// Registries without the referrers API do not have the referrers fallback tag updated, use [RegClient.ManifestPut] for manifests with a subject.
+func (rc *RegClient) ManifestPutRaw(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader, opts ...ManifestOpts) error {
+	if !r.IsSetRepo() {
+		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	if err := d.Digest.Validate(); err != nil {
+		return fmt.Errorf("manifest descriptor digest is invalid: %w%.0w", err, errs.ErrMissingDigest)
+	}
+	if d.MediaType == "" {
+		return fmt.Errorf("manifest descriptor media type is not set%.0w", errs.ErrUnsupportedMediaType)
+	}
+	if d.Size <= 0 {
+		return fmt.Errorf("manifest descriptor size is not set%.0w", errs.ErrParsingFailed)
+	}
+	if r.Digest != "" && r.Digest != d.Digest.String() {
+		return fmt.Errorf("manifest digest mismatch, reference %s, descriptor %s%.0w", r.Digest, d.Digest.String(), errs.ErrDigestMismatch)
+	}
+	opt := manifestOpt{schemeOpts: []scheme.ManifestOpts{}}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return err
+	}
+	if sRaw, ok := schemeAPI.(scheme.ManifestRawPutter); ok {
+		return sRaw.ManifestPutRaw(ctx, r, d, rdr, opt.schemeOpts...)
+	}
+	// fall back to parsing the manifest for schemes that do not support streaming
+	raw, err := io.ReadAll(blob.NewReader(blob.WithDesc(d), blob.WithReader(rdr)))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(d),
+		manifest.WithRaw(raw),
+	)
+	if err != nil {
+		return err
+	}
+	return schemeAPI.ManifestPut(ctx, r, m, opt.schemeOpts...)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -37,6 +38,7 @@ func TestManifest(t *testing.T) {
 	noheadTag := "nohead"
 	nodigestTag := "nodigest"
 	missingTag := "missing"
+	rawTag := "raw"
 	digest1 := digest.FromString("example1")
 	digest2 := digest.FromString("example2")
 	m := schema2.Manifest{
@@ -59,6 +61,10 @@ func TestManifest(t *testing.T) {
 	}
 	mDigest := digest.FromBytes(mBody)
 	mLen := len(mBody)
+	// whitespace and field order that would change if the manifest were reserialized
+	rawBody := []byte(fmt.Sprintf("{\n  \"layers\": [{\"size\": 8, \"digest\": \"%s\", \"mediaType\": \"%s\"}],\n  \"config\": {\"size\": 8, \"digest\": \"%s\", \"mediaType\": \"%s\"},\n  \"mediaType\": \"%s\",\n  \"schemaVersion\": 2\n}\n",
+		digest2.String(), mediatype.Docker2LayerGzip, digest1.String(), mediatype.Docker2ImageConfig, mediatype.Docker2Manifest))
+	rawDigest := digest.FromBytes(rawBody)
 	missingDigest := digest.FromString("missing descriptor")
 	ctx := context.Background()
 	rrs := []reqresp.ReqResp{
@@ -155,6 +161,38 @@ func TestManifest(t *testing.T) {
 				Body: mBody,
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Get raw",
+				Method: "GET",
+				Path:   "/v2/" + repoPath + "/manifests/" + rawTag,
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", len(rawBody))},
+					"Content-Type":          []string{mediatype.Docker2Manifest + "; charset=utf-8"},
+					"Docker-Content-Digest": []string{rawDigest.String()},
+				},
+				Body: rawBody,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Get raw digest",
+				Method: "GET",
+				Path:   "/v2/" + repoPath + "/manifests/" + rawDigest.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", len(rawBody))},
+					"Content-Type":          []string{mediatype.Docker2Manifest},
+					"Docker-Content-Digest": []string{rawDigest.String()},
+				},
+				Body: rawBody,
+			},
+		},
 	}
 	rrs = append(rrs, reqresp.BaseEntries...)
 	// create servers
@@ -226,6 +264,128 @@ func TestManifest(t *testing.T) {
 			t.Errorf("manifest is not set on a get request")
 		}
 	})
+	t.Run("Get Raw", func(t *testing.T) {
+		r, err := ref.New(tsInternalHost + "/" + repoPath + ":" + rawTag)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		raw, d, err := rc.ManifestGetRaw(ctx, r)
+		if err != nil {
+			t.Fatalf("Failed running ManifestGetRaw: %v", err)
+		}
+		if !bytes.Equal(raw, rawBody) {
+			t.Errorf("raw body mismatch, expected %s, received %s", rawBody, raw)
+		}
+		if d.Digest != rawDigest || d.Digest != digest.FromBytes(raw) {
+			t.Errorf("unexpected digest, expected %s, received %s", rawDigest.String(), d.Digest.String())
+		}
+		if d.Size != int64(len(rawBody)) {
+			t.Errorf("unexpected size, expected %d, received %d", len(rawBody), d.Size)
+		}
+		if d.MediaType != mediatype.Docker2Manifest {
+			t.Errorf("unexpected media type, expected %s, received %s", mediatype.Docker2Manifest, d.MediaType)
+		}
+		// verify the content would not survive a round trip through the typed manifest
+		var sm schema2.Manifest
+		err = json.Unmarshal(raw, &sm)
+		if err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		reBody, err := json.Marshal(sm)
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		if digest.FromBytes(reBody) == d.Digest {
+			t.Errorf("reserialized manifest unexpectedly matches the raw digest")
+		}
+		// request by digest
+		raw, d, err = rc.ManifestGetRaw(ctx, r.SetDigest(rawDigest.String()))
+		if err != nil {
+			t.Fatalf("Failed running ManifestGetRaw by digest: %v", err)
+		}
+		if !bytes.Equal(raw, rawBody) || d.Digest != rawDigest {
+			t.Errorf("unexpected result by digest, digest %s, body %s", d.Digest.String(), raw)
+		}
+	})
+	t.Run("Put Raw", func(t *testing.T) {
+		r, err := ref.New(tsOlaregHost + "/" + repoPath + ":" + goodTag)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("Failed running ManifestHead: %v", err)
+		}
+		// build a large index with formatting that would not survive a round trip through the typed manifest
+		entries := 10000
+		idx := v1.Index{
+			Versioned: v1.IndexSchemaVersion,
+			MediaType: mediatype.OCI1ManifestList,
+			Manifests: make([]descriptor.Descriptor, entries),
+		}
+		for i := range idx.Manifests {
+			idx.Manifests[i] = mh.GetDescriptor()
+			idx.Manifests[i].Annotations = map[string]string{"org.example.entry": fmt.Sprintf("%d", i)}
+		}
+		raw, err := json.MarshalIndent(idx, "", "\t")
+		if err != nil {
+			t.Fatalf("failed to marshal index: %v", err)
+		}
+		d := descriptor.Descriptor{
+			MediaType: mediatype.OCI1ManifestList,
+			Size:      int64(len(raw)),
+			Digest:    digest.FromBytes(raw),
+		}
+		rPut := r.SetTag("raw-index")
+		err = rc.ManifestPutRaw(ctx, rPut, d, bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Failed running ManifestPutRaw: %v", err)
+		}
+		rawGet, dGet, err := rc.ManifestGetRaw(ctx, rPut)
+		if err != nil {
+			t.Fatalf("Failed running ManifestGetRaw: %v", err)
+		}
+		if dGet.Digest != d.Digest || !bytes.Equal(rawGet, raw) {
+			t.Errorf("stored index mismatch, expected %s, received %s", d.Digest.String(), dGet.Digest.String())
+		}
+		mGet, err := rc.ManifestGet(ctx, rPut)
+		if err != nil {
+			t.Fatalf("Failed running ManifestGet: %v", err)
+		}
+		mi, ok := mGet.(manifest.Indexer)
+		if !ok {
+			t.Fatalf("manifest is not an index: %s", mGet.GetDescriptor().MediaType)
+		}
+		if ml, err := mi.GetManifestList(); err != nil || len(ml) != entries {
+			t.Errorf("unexpected manifest list, expected %d entries, received %d, %v", entries, len(ml), err)
+		}
+		// a digest mismatch fails before the manifest is stored
+		dBad := d
+		dBad.Digest = digest.FromString("bad")
+		err = rc.ManifestPutRaw(ctx, r.SetTag("raw-bad"), dBad, bytes.NewReader(raw))
+		if !errors.Is(err, errs.ErrDigestMismatch) {
+			t.Errorf("unexpected error for a digest mismatch: %v", err)
+		}
+		if _, err := rc.ManifestHead(ctx, r.SetTag("raw-bad")); err == nil {
+			t.Errorf("manifest with a digest mismatch was stored")
+		}
+		// other schemes fall back to parsing the manifest
+		rDir, err := ref.New("ocidir://" + t.TempDir() + "/raw:raw-index")
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		err = rc.ManifestPutRaw(ctx, rDir, d, bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Failed running ManifestPutRaw to ocidir: %v", err)
+		}
+		rawGet, dGet, err = rc.ManifestGetRaw(ctx, rDir)
+		if err != nil {
+			t.Fatalf("Failed running ManifestGetRaw on ocidir: %v", err)
+		}
+		if dGet.Digest != d.Digest || !bytes.Equal(rawGet, raw) {
+			t.Errorf("stored ocidir index mismatch, expected %s, received %s", d.Digest.String(), dGet.Digest.String())
+		}
+	})
 	t.Run("Head", func(t *testing.T) {
 		r, err := ref.New(tsOlaregHost + "/" + repoPath + ":" + goodTag)
 		if err != nil {
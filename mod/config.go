@@ -57,11 +57,16 @@ func WithBuildArgRm(arg string, value *regexp.Regexp) Opts {
 
 // WithConfigCmd sets the command in the config.
 // For running a shell command, the `cmd` value should be `[]string{"/bin/sh", "-c", command}`.
-func WithConfigCmd(cmd []string) Opts {
+// A nil or empty value clears the command.
+// When platforms are provided, only configs matching one of the platforms are changed.
+func WithConfigCmd(cmd []string, platforms ...platform.Platform) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
+		if len(cmd) == 0 {
+			cmd = nil
+		}
 		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
 			oc := doc.oc.GetConfig()
-			if eqStrSlice(cmd, oc.Config.Cmd) {
+			if !configPlatformMatch(oc.Platform, platforms) || eqStrSlice(cmd, oc.Config.Cmd) {
 				return nil
 			}
 			oc.Config.Cmd = cmd
@@ -108,11 +113,16 @@ func WithConfigDigestAlgo(algo digest.Algorithm) Opts {
 
 // WithConfigEntrypoint sets the entrypoint in the config.
 // For running a shell command, the `entrypoint` value should be `[]string{"/bin/sh", "-c", command}`.
-func WithConfigEntrypoint(entrypoint []string) Opts {
+// A nil or empty value clears the entrypoint.
+// When platforms are provided, only configs matching one of the platforms are changed.
+func WithConfigEntrypoint(entrypoint []string, platforms ...platform.Platform) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
+		if len(entrypoint) == 0 {
+			entrypoint = nil
+		}
 		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
 			oc := doc.oc.GetConfig()
-			if eqStrSlice(entrypoint, oc.Config.Entrypoint) {
+			if !configPlatformMatch(oc.Platform, platforms) || eqStrSlice(entrypoint, oc.Config.Entrypoint) {
 				return nil
 			}
 			oc.Config.Entrypoint = entrypoint
@@ -273,10 +283,30 @@ func WithConfigTimestampMax(t time.Time) Opts {
 }
 
 // WithEnv sets or deletes an environment variable from the image config.
+// An empty value deletes the variable.
 func WithEnv(name, value string) Opts {
+	return withEnv(name, value, value == "")
+}
+
+// WithEnvAdd sets an environment variable in the image config, replacing any existing value.
+// The name may be prefixed with a platform list, e.g. "[linux/amd64,linux/arm64]name".
+func WithEnvAdd(name, value string) Opts {
+	return withEnv(name, value, false)
+}
+
+// WithEnvRm deletes an environment variable from the image config.
+// Deleting a variable that is not defined is a no-op.
+func WithEnvRm(name string) Opts {
+	return withEnv(name, "", true)
+}
+
+func withEnv(name, value string, rm bool) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		// extract the list for platforms to update from the name
 		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("env name is empty")
+		}
 		platforms := []platform.Platform{}
 		if name[0] == '[' && strings.Index(name, "]") > 0 {
 			end := strings.Index(name, "]")
@@ -318,7 +348,7 @@ func WithEnv(name, value string) Opts {
 					continue
 				}
 				found = true
-				if value == "" {
+				if rm {
 					// delete an entry
 					if i < len(oc.Config.Env)-1 {
 						oc.Config.Env = append(oc.Config.Env[:i], oc.Config.Env[i+1:]...)
@@ -333,7 +363,7 @@ func WithEnv(name, value string) Opts {
 				}
 				break
 			}
-			if !found && value != "" {
+			if !found && !rm {
 				// add a new entry
 				oc.Config.Env = append(oc.Config.Env, name+"="+value)
 				changed = true
@@ -505,3 +535,16 @@ func WithVolumeRm(volume string) Opts {
 		return nil
 	}
 }
+
+// configPlatformMatch returns true when the list of platforms is empty or one entry matches the platform.
+func configPlatformMatch(p platform.Platform, platforms []platform.Platform) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, pe := range platforms {
+		if platform.Match(p, pe) {
+			return true
+		}
+	}
+	return false
+}
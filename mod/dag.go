@@ -36,6 +36,9 @@ type dagConfig struct {
 	maxDataSize    int64
 	rTgt           ref.Ref
 	forceLayerWalk bool
+	dryRun         bool
+	rebaseReport   func(RebaseChange)
+	tmpDir         string
 }
 
 type dagManifest struct {
@@ -57,11 +60,12 @@ type dagOCIConfig struct {
 }
 
 type dagLayer struct {
-	mod      changes
-	newDesc  descriptor.Descriptor
-	ucDigest digest.Digest // uncompressed descriptor
-	desc     descriptor.Descriptor
-	rSrc     ref.Ref
+	mod       changes
+	newDesc   descriptor.Descriptor
+	ucDigest  digest.Digest // uncompressed descriptor
+	createdBy string        // history created by field for added layers
+	desc      descriptor.Descriptor
+	rSrc      ref.Ref
 }
 
 func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d descriptor.Descriptor) (*dagManifest, error) {
@@ -210,6 +214,9 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 			return err
 		}
 	} else { // !mm.m.IsList()
+		if _, ok := om.(v1.ArtifactManifest); ok {
+			return fmt.Errorf("OCI artifact manifests cannot be modified, convert to an image manifest with WithManifestNormalizeArtifact%.0w", errs.ErrUnsupportedMediaType)
+		}
 		ociM, err := manifest.OCIManifestFromAny(om)
 		if err != nil {
 			return err
@@ -279,8 +286,9 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 					}
 				}
 				newHistory := v1.History{
-					Created: &timeStart,
-					Comment: "regclient",
+					Created:   &timeStart,
+					CreatedBy: layer.createdBy,
+					Comment:   "regclient",
 				}
 				if iConfig < 0 {
 					// noop
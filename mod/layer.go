@@ -24,10 +24,36 @@ import (
 	"github.com/regclient/regclient/types/ref"
 )
 
+// WithLayerAdd appends a new layer to every image from a tar file, which may be compressed.
+// If media type (mt) is not defined, it will default to Gzip and match Docker or OCI based on the manifest media type.
+// The config history for the new layer includes the filename in the created by field.
+func WithLayerAdd(filename, mt string) Opts {
+	open := func() (io.ReadCloser, error) {
+		//#nosec G304 command is run by a user accessing their own files
+		return os.Open(filename)
+	}
+	return withLayerAdd(open, mt, nil, "regclient layer add "+filepath.Base(filename))
+}
+
 // WithLayerAddTar appends a new layer to the image based on a tar input stream.
+// A compressed tar input is decompressed before the layer is compressed with the media type.
 // If media type (mt) is not defined, it will default to Gzip and match Docker or OCI based on the manifest media type.
 // If the platform slice is empty, the layer is added to all platforms.
+// The config history for the new layer uses "regclient layer add" in the created by field.
 func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Opts {
+	return WithLayerAddTarCreatedBy(rdr, mt, platforms, "regclient layer add")
+}
+
+// WithLayerAddTarCreatedBy appends a new layer from a tar input stream like [WithLayerAddTar].
+// The createdBy value is used in the config history for the new layer, e.g. to include the source filename.
+func WithLayerAddTarCreatedBy(rdr io.Reader, mt string, platforms []platform.Platform, createdBy string) Opts {
+	open := func() (io.ReadCloser, error) {
+		return io.NopCloser(rdr), nil
+	}
+	return withLayerAdd(open, mt, platforms, createdBy)
+}
+
+func withLayerAdd(open func() (io.ReadCloser, error), mt string, platforms []platform.Platform, createdBy string) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		if mt == "" {
 			switch dm.m.GetDescriptor().MediaType {
@@ -76,8 +102,17 @@ func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Op
 				if err != nil {
 					return fmt.Errorf("failed to configure digest algorithm for new layer: %w", err)
 				}
+				rdr, err := open()
+				if err != nil {
+					return fmt.Errorf("failed to open layer: %w", err)
+				}
+				defer rdr.Close()
+				ucRdr, err := archive.Decompress(rdr)
+				if err != nil {
+					return fmt.Errorf("failed to decompress layer: %w", err)
+				}
 				digUC := desc.DigestAlgo().Digester() // uncompressed digest
-				ucDigRdr := io.TeeReader(rdr, digUC.Hash())
+				ucDigRdr := io.TeeReader(ucRdr, digUC.Hash())
 				cRdr, err := archive.Compress(ucDigRdr, comp)
 				if err != nil {
 					return fmt.Errorf("failed to compress layer with %s: %w", comp.String(), err)
@@ -93,9 +128,10 @@ func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Op
 			}
 			// add the layer to the dag
 			dm.layers = append(dm.layers, &dagLayer{
-				mod:      added,
-				desc:     desc,
-				ucDigest: ucDig,
+				mod:       added,
+				desc:      desc,
+				ucDigest:  ucDig,
+				createdBy: createdBy,
 			})
 			return nil
 		})
@@ -104,6 +140,9 @@ func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Op
 }
 
 // WithLayerCompression alters the media type and compression algorithm of the layers.
+// Supported values are [archive.CompressGzip], [archive.CompressZstd], and [archive.CompressNone].
+// To select the algorithm by name ("gzip", "zstd", or "none"), parse it with [archive.CompressType.UnmarshalText].
+// Layers are recompressed with new digests and sizes, the uncompressed diff ids are unchanged.
 func WithLayerCompression(algo archive.CompressType) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		switch algo {
@@ -246,6 +285,52 @@ func WithLayerCompression(algo archive.CompressType) Opts {
 	}
 }
 
+// WithLayerDedup removes a layer when it is identical to the previous layer.
+// Only consecutive layers with the same digest are merged, a repeated layer separated by other layers is kept since removing it may change the resulting filesystem.
+// Images are skipped when the config history or diff_ids do not align with the layers.
+func WithLayerDedup() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.m.IsList() || dm.config == nil || dm.config.oc == nil {
+				return nil
+			}
+			oc := dm.config.oc.GetConfig()
+			origLayers := 0
+			for _, dl := range dm.layers {
+				if dl.mod != added {
+					origLayers++
+				}
+			}
+			historyLayers := 0
+			for _, ch := range oc.History {
+				if !ch.EmptyLayer {
+					historyLayers++
+				}
+			}
+			if (len(oc.History) > 0 && historyLayers != origLayers) || (oc.RootFS.DiffIDs != nil && len(oc.RootFS.DiffIDs) != origLayers) {
+				return nil
+			}
+			var prev *dagLayer
+			for _, dl := range dm.layers {
+				if dl.mod == deleted {
+					continue
+				}
+				if dl.mod != unchanged {
+					prev = nil
+					continue
+				}
+				if prev != nil && prev.desc.Digest == dl.desc.Digest {
+					dl.mod = deleted
+					continue
+				}
+				prev = dl
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithLayerDigestAlgo changes the digester algorithm.
 func WithLayerDigestAlgo(algo digest.Algorithm) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -710,7 +795,7 @@ func WithFileTarTime(name string, optTime OptTime) Opts {
 				return th, tr, unchanged, nil
 			}
 			// read contents into a temporary file, adjusting included timestamps, track if any timestamps are changed
-			tmpFile, err := os.CreateTemp("", "regclient.*")
+			tmpFile, err := os.CreateTemp(dc.tmpDir, "regclient.*")
 			if err != nil {
 				return th, tr, unchanged, err
 			}
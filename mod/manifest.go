@@ -1,6 +1,7 @@
 package mod
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -9,10 +10,12 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -103,67 +106,105 @@ func WithAnnotationOCIBase(rBase ref.Ref, dBase digest.Digest) Opts {
 			if dm.mod == deleted {
 				return nil
 			}
-			annoBaseDig := "org.opencontainers.image.base.digest"
-			annoBaseName := "org.opencontainers.image.base.name"
-			changed := false
-			om := dm.m.GetOrig()
-			if dm.m.IsList() {
-				ociI, err := manifest.OCIIndexFromAny(om)
-				if err != nil {
-					return err
-				}
-				if ociI.Annotations == nil {
-					ociI.Annotations = map[string]string{}
-				}
-				if ociI.Annotations[annoBaseName] != rBase.CommonName() {
-					ociI.Annotations[annoBaseName] = rBase.CommonName()
-					changed = true
-				}
-				if ociI.Annotations[annoBaseDig] != dBase.String() {
-					ociI.Annotations[annoBaseDig] = dBase.String()
-					changed = true
-				}
-				err = manifest.OCIIndexToAny(ociI, &om)
-				if err != nil {
-					return err
-				}
-			} else {
-				ociM, err := manifest.OCIManifestFromAny(om)
-				if err != nil {
-					return err
-				}
-				if ociM.Annotations == nil {
-					ociM.Annotations = map[string]string{}
-				}
-				if ociM.Annotations[annoBaseName] != rBase.CommonName() {
-					ociM.Annotations[annoBaseName] = rBase.CommonName()
-					changed = true
-				}
-				if ociM.Annotations[annoBaseDig] != dBase.String() {
-					ociM.Annotations[annoBaseDig] = dBase.String()
-					changed = true
-				}
-				err = manifest.OCIManifestToAny(ociM, &om)
-				if err != nil {
-					return err
-				}
+			return annotationOCIBaseSet(dm, rBase, dBase)
+		})
+		return nil
+	}
+}
+
+// WithBaseImage adds the base image name and digest annotations used by [WithRebase].
+// When the digest is empty, it is taken from the reference or resolved from the registry.
+func WithBaseImage(rBase ref.Ref, dBase digest.Digest) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if dBase == "" && rBase.Digest != "" {
+			d, err := digest.Parse(rBase.Digest)
+			if err != nil {
+				return fmt.Errorf("failed to parse base digest %s: %w", rBase.Digest, err)
 			}
-			if changed {
-				if dm.mod == unchanged {
-					dm.mod = replaced
-				}
-				err := dm.m.SetOrig(om)
+			dBase = d
+		}
+		// the name annotation must be resolvable as a tag to detect changes to the base image
+		rName := rBase.SetTag(rBase.Tag)
+		if rName.Tag == "" {
+			return fmt.Errorf("base image requires a tag: %s%.0w", rBase.CommonName(), errs.ErrMissingTag)
+		}
+		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted {
+				return nil
+			}
+			if dBase == "" {
+				mh, err := rc.ManifestHead(ctx, rBase, regclient.WithManifestRequireDigest())
 				if err != nil {
-					return err
+					return fmt.Errorf("failed to resolve base image digest for %s: %w", rBase.CommonName(), err)
 				}
-				dm.newDesc = dm.m.GetDescriptor()
+				dBase = mh.GetDescriptor().Digest
 			}
-			return nil
+			return annotationOCIBaseSet(dm, rName, dBase)
 		})
 		return nil
 	}
 }
 
+// annotationOCIBaseSet updates the base image annotations on a manifest.
+func annotationOCIBaseSet(dm *dagManifest, rBase ref.Ref, dBase digest.Digest) error {
+	annoBaseDig := types.AnnotationBaseImageDigest
+	annoBaseName := types.AnnotationBaseImageName
+	changed := false
+	om := dm.m.GetOrig()
+	if dm.m.IsList() {
+		ociI, err := manifest.OCIIndexFromAny(om)
+		if err != nil {
+			return err
+		}
+		if ociI.Annotations == nil {
+			ociI.Annotations = map[string]string{}
+		}
+		if ociI.Annotations[annoBaseName] != rBase.CommonName() {
+			ociI.Annotations[annoBaseName] = rBase.CommonName()
+			changed = true
+		}
+		if ociI.Annotations[annoBaseDig] != dBase.String() {
+			ociI.Annotations[annoBaseDig] = dBase.String()
+			changed = true
+		}
+		err = manifest.OCIIndexToAny(ociI, &om)
+		if err != nil {
+			return err
+		}
+	} else {
+		ociM, err := manifest.OCIManifestFromAny(om)
+		if err != nil {
+			return err
+		}
+		if ociM.Annotations == nil {
+			ociM.Annotations = map[string]string{}
+		}
+		if ociM.Annotations[annoBaseName] != rBase.CommonName() {
+			ociM.Annotations[annoBaseName] = rBase.CommonName()
+			changed = true
+		}
+		if ociM.Annotations[annoBaseDig] != dBase.String() {
+			ociM.Annotations[annoBaseDig] = dBase.String()
+			changed = true
+		}
+		err = manifest.OCIManifestToAny(ociM, &om)
+		if err != nil {
+			return err
+		}
+	}
+	if changed {
+		if dm.mod == unchanged {
+			dm.mod = replaced
+		}
+		err := dm.m.SetOrig(om)
+		if err != nil {
+			return err
+		}
+		dm.newDesc = dm.m.GetDescriptor()
+	}
+	return nil
+}
+
 // WithAnnotationPromoteCommon pulls up common annotations from child images to the index.
 func WithAnnotationPromoteCommon() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -559,6 +600,129 @@ func WithManifestToOCIReferrers() Opts {
 	}
 }
 
+// WithIndexAttestationsRm removes attestation entries from an index.
+// These are the buildkit entries with an unknown platform that reference another entry with the
+// "vnd.docker.reference.type" and "vnd.docker.reference.digest" annotations.
+func WithIndexAttestationsRm() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted {
+				return nil
+			}
+			mi, ok := dm.m.(manifest.Indexer)
+			if !ok {
+				return nil
+			}
+			ml, err := mi.GetManifestList()
+			if err != nil {
+				return fmt.Errorf("failed to get manifest list: %w", err)
+			}
+			changed := false
+			mlI := 0
+			for _, childDM := range dm.manifests {
+				if childDM.mod == added {
+					continue
+				}
+				if mlI >= len(ml) {
+					return fmt.Errorf("could not find descriptor, index=%d, digest=%s", mlI, dm.origDesc.Digest.String())
+				}
+				desc := ml[mlI]
+				mlI++
+				if childDM.mod == deleted || len(desc.Annotations) == 0 || desc.Annotations[dockerReferenceType] == "" || desc.Annotations[dockerReferenceDigest] == "" {
+					continue
+				}
+				childDM.mod = deleted
+				changed = true
+			}
+			if changed && dm.mod == unchanged {
+				dm.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithManifestNormalizeArtifact converts artifacts to the OCI 1.1 image manifest form.
+// OCI artifact manifests are converted to an image manifest with an empty config.
+// Image manifests with a non-image config have the artifactType set from the config media type,
+// and a config containing the empty JSON value is replaced with the empty descriptor.
+// An empty descriptor is added as a layer when the artifact has no layers.
+// Images and Docker manifests are not modified.
+// The conversion is one way, the OCI artifact manifest was removed from the OCI 1.1 release and is not created by this package.
+func WithManifestNormalizeArtifact() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted || dm.m.IsList() {
+				return nil
+			}
+			emptyDesc := descriptor.Descriptor{MediaType: mediatype.OCI1Empty, Digest: descriptor.EmptyDigest, Size: int64(len(descriptor.EmptyData))}
+			changed := false
+			useEmpty := false
+			var ociM v1.Manifest
+			switch om := dm.m.GetOrig().(type) {
+			case v1.ArtifactManifest:
+				ociM = v1.Manifest{
+					Versioned:    v1.ManifestSchemaVersion,
+					MediaType:    mediatype.OCI1Manifest,
+					ArtifactType: om.ArtifactType,
+					Config:       emptyDesc,
+					Layers:       om.Blobs,
+					Subject:      om.Subject,
+					Annotations:  om.Annotations,
+				}
+				changed = true
+				useEmpty = true
+			case v1.Manifest:
+				ociM = om
+				if inListStr(ociM.Config.MediaType, mtKnownConfig) {
+					return nil
+				}
+				if ociM.ArtifactType == "" && ociM.Config.MediaType != mediatype.OCI1Empty {
+					ociM.ArtifactType = ociM.Config.MediaType
+					changed = true
+				}
+				if ociM.Config.MediaType != mediatype.OCI1Empty && ociM.Config.Digest == descriptor.EmptyDigest && ociM.Config.Size == int64(len(descriptor.EmptyData)) {
+					ociM.Config.MediaType = mediatype.OCI1Empty
+					changed = true
+				}
+			default:
+				return nil
+			}
+			if ociM.ArtifactType == "" {
+				return fmt.Errorf("artifactType is required with an empty config, ref %s%.0w", rSrc.CommonName(), errs.ErrUnsupportedMediaType)
+			}
+			if len(ociM.Layers) == 0 {
+				ociM.Layers = []descriptor.Descriptor{emptyDesc}
+				dm.layers = []*dagLayer{{desc: emptyDesc}}
+				changed = true
+				useEmpty = true
+			}
+			if !changed {
+				return nil
+			}
+			if useEmpty {
+				// the empty blob may not exist in the source repository
+				_, err := rc.BlobPut(ctx, rTgt, emptyDesc, bytes.NewReader(descriptor.EmptyData))
+				if err != nil {
+					return fmt.Errorf("failed to push empty blob: %w", err)
+				}
+			}
+			newM, err := manifest.New(manifest.WithOrig(ociM))
+			if err != nil {
+				return err
+			}
+			dm.m = newM
+			dm.newDesc = dm.m.GetDescriptor()
+			if dm.mod == unchanged {
+				dm.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithExternalURLsRm strips external URLs from descriptors and adjusts media type to match.
 func WithExternalURLsRm() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -628,6 +792,23 @@ func WithExternalURLsRm() Opts {
 	}
 }
 
+// RebaseChange describes the base layers swapped in a single image by a rebase.
+type RebaseChange struct {
+	Platform  platform.Platform       // platform of the rebased image
+	BaseOld   ref.Ref                 // old base image, including the digest of the platform specific manifest
+	BaseNew   ref.Ref                 // new base image, including the digest of the platform specific manifest
+	LayersOld []descriptor.Descriptor // layers from the old base that are removed
+	LayersNew []descriptor.Descriptor // layers from the new base that are added
+}
+
+// WithRebaseReport calls fn for each image changed by [WithRebase] or [WithRebaseRefs].
+func WithRebaseReport(fn func(RebaseChange)) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.rebaseReport = fn
+		return nil
+	}
+}
+
 // WithRebase attempts to rebase the image using OCI annotations identifying the base image.
 func WithRebase() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -825,6 +1006,15 @@ func rebaseAddStep(dc *dagConfig, rBaseOld, rBaseNew ref.Ref) error {
 		if len(layersNew) != historyLayers || len(confOCINew.RootFS.DiffIDs) != historyLayers {
 			return fmt.Errorf("new base image config history doesn't match layer count")
 		}
+		if dc.rebaseReport != nil {
+			dc.rebaseReport(RebaseChange{
+				Platform:  p,
+				BaseOld:   rBaseOld.SetDigest(mbOld.GetDescriptor().Digest.String()),
+				BaseNew:   rBaseNew.SetDigest(mbNew.GetDescriptor().Digest.String()),
+				LayersOld: append([]descriptor.Descriptor{}, layersOld...),
+				LayersNew: append([]descriptor.Descriptor{}, layersNew...),
+			})
+		}
 
 		// delete the old layers and config entries from vars and dag
 		pruneNum := len(layersOld)
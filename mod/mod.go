@@ -113,6 +113,10 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 			return rTgt, err
 		}
 	}
+	// a dry run stops before layers are modified or anything is pushed
+	if dc.dryRun {
+		return rTgt, nil
+	}
 	// perform layer changes and copy layers to target repository
 	if len(dc.stepsLayer) > 0 || len(dc.stepsLayerFile) > 0 || !ref.EqualRepository(rSrc, rTgt) || dc.forceLayerWalk {
 		err = dagWalkLayers(dm, func(dl *dagLayer) (*dagLayer, error) {
@@ -176,7 +180,7 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 				// setup tar reader to process layer
 				tr := tar.NewReader(rdr)
 				// create temp file and setup tar writer
-				fh, err := os.CreateTemp("", "regclient-mod-")
+				fh, err := os.CreateTemp(dc.tmpDir, "regclient-mod-")
 				if err != nil {
 					_ = rdr.Close()
 					return nil, err
@@ -349,6 +353,16 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 	return rTgt, nil
 }
 
+// WithDryRun runs the manifest and config changes without modifying layers or pushing any content.
+// The returned ref does not include the digest of the modified manifest.
+// This is used with [WithRebaseReport] to verify a rebase before it is pushed.
+func WithDryRun() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.dryRun = true
+		return nil
+	}
+}
+
 // WithRefTgt sets the target manifest.
 // Apply will default to pushing to the same name by digest.
 func WithRefTgt(rTgt ref.Ref) Opts {
@@ -358,6 +372,15 @@ func WithRefTgt(rTgt ref.Ref) Opts {
 	}
 }
 
+// WithTempDir sets the directory used for temporary files when modifying layers.
+// The default is the directory returned by [os.TempDir].
+func WithTempDir(dir string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.tmpDir = dir
+		return nil
+	}
+}
+
 // WithData sets the descriptor data field max size.
 // This also strips the data field off descriptors above the max size.
 func WithData(maxDataSize int64) Opts {
@@ -2,9 +2,12 @@ package mod
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/http/httptest"
 	"net/url"
 	"os"
@@ -22,9 +25,12 @@ import (
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -49,6 +55,16 @@ func TestMod(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to read testdata/layer.tar: %v", err)
 	}
+	tarGzBytes := &bytes.Buffer{}
+	gzW := gzip.NewWriter(tarGzBytes)
+	_, err = gzW.Write(tarBytes)
+	if err != nil {
+		t.Fatalf("failed to compress layer: %v", err)
+	}
+	err = gzW.Close()
+	if err != nil {
+		t.Fatalf("failed to compress layer: %v", err)
+	}
 	bTrue := true
 	regSrc := olareg.New(oConfig.Config{
 		Storage: oConfig.ConfigStorage{
@@ -80,6 +96,11 @@ func TestMod(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to setup tempDir: %v", err)
 	}
+	tarGzFile := filepath.Join(tempDir, "layer.tar.gz")
+	err = os.WriteFile(tarGzFile, tarGzBytes.Bytes(), 0600)
+	if err != nil {
+		t.Fatalf("failed to write compressed layer: %v", err)
+	}
 
 	// create regclient
 	rcHosts := []config.Host{
@@ -162,6 +183,58 @@ func TestMod(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to parse the platform: %v", err)
 	}
+	// push artifacts that are not in the OCI 1.1 image manifest form
+	artifactData := []byte("artifact data")
+	artifactDesc := descriptor.Descriptor{
+		MediaType: "application/vnd.example.data",
+		Digest:    digest.FromBytes(artifactData),
+		Size:      int64(len(artifactData)),
+	}
+	rLegacy, err := ref.New(tTgtHost + "/testrepo:legacy-artifact")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rArtifact, err := ref.New("ocidir://" + tempDir + "/testrepo:artifact-manifest")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, rLegacy, artifactDesc, bytes.NewReader(artifactData))
+	if err != nil {
+		t.Fatalf("failed to push artifact blob: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, rLegacy, descriptor.Descriptor{MediaType: "application/vnd.example.config", Digest: descriptor.EmptyDigest, Size: 2}, bytes.NewReader(descriptor.EmptyData))
+	if err != nil {
+		t.Fatalf("failed to push artifact config: %v", err)
+	}
+	mLegacy, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: mediatype.OCI1Manifest,
+		Config:    descriptor.Descriptor{MediaType: "application/vnd.example.config", Digest: descriptor.EmptyDigest, Size: 2},
+		Layers:    []descriptor.Descriptor{artifactDesc},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create legacy artifact: %v", err)
+	}
+	err = rc.ManifestPut(ctx, rLegacy, mLegacy)
+	if err != nil {
+		t.Fatalf("failed to push legacy artifact: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, rArtifact, artifactDesc, bytes.NewReader(artifactData))
+	if err != nil {
+		t.Fatalf("failed to push artifact blob: %v", err)
+	}
+	mArtifact, err := manifest.New(manifest.WithOrig(v1.ArtifactManifest{
+		MediaType:    mediatype.OCI1Artifact,
+		ArtifactType: "application/vnd.example.artifact",
+		Blobs:        []descriptor.Descriptor{artifactDesc},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create artifact manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, rArtifact, mArtifact)
+	if err != nil {
+		t.Fatalf("failed to push artifact manifest: %v", err)
+	}
 
 	// define tests
 	tests := []struct {
@@ -210,6 +283,62 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Attestations Rm",
+			opts: []Opts{
+				WithIndexAttestationsRm(),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Attestations Rm Image",
+			opts: []Opts{
+				WithIndexAttestationsRm(),
+			},
+			ref:      r3amd.CommonName(),
+			wantSame: true,
+		},
+		{
+			name: "Normalize Artifact Image",
+			opts: []Opts{
+				WithManifestNormalizeArtifact(),
+			},
+			ref:      tTgtHost + "/testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "Normalize Artifact Canonical",
+			opts: []Opts{
+				WithManifestNormalizeArtifact(),
+			},
+			ref:      tTgtHost + "/testrepo:a1",
+			wantSame: true,
+		},
+		{
+			name: "Normalize Artifact Legacy",
+			opts: []Opts{
+				WithManifestNormalizeArtifact(),
+				WithRefTgt(rTgt1.SetTag("legacy-artifact")),
+			},
+			ref: rLegacy.CommonName(),
+		},
+		{
+			name: "Normalize Artifact Manifest",
+			opts: []Opts{
+				WithManifestNormalizeArtifact(),
+				WithRefTgt(rArtifact.SetTag("artifact-normalized")),
+			},
+			ref: rArtifact.CommonName(),
+		},
+		{
+			name: "Modify Artifact Manifest",
+			opts: []Opts{
+				WithAnnotation("test", "hello"),
+				WithRefTgt(rArtifact.SetTag("artifact-annotated")),
+			},
+			ref:     rArtifact.CommonName(),
+			wantErr: errs.ErrUnsupportedMediaType,
+		},
 		{
 			name: "Add Annotation",
 			opts: []Opts{
@@ -313,6 +442,44 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Env Add",
+			opts: []Opts{
+				WithEnvAdd("[linux/amd64]test", "hello"),
+				WithEnvAdd("empty", ""),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Env Add Replace",
+			opts: []Opts{
+				WithEnvAdd("PATH", "/usr/sbin:/usr/bin:/sbin:/bin"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Env Rm",
+			opts: []Opts{
+				WithEnvRm("[linux/arm64]PATH"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Env Rm Missing",
+			opts: []Opts{
+				WithEnvRm("missing"),
+			},
+			ref:      tTgtHost + "/testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "Env Add Empty Name",
+			opts: []Opts{
+				WithEnvAdd("", "hello"),
+			},
+			ref:     tTgtHost + "/testrepo:v1",
+			wantErr: fmt.Errorf("env name is empty"),
+		},
 		{
 			name: "Delete Missing Label",
 			opts: []Opts{
@@ -569,6 +736,29 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Layer Add File",
+			opts: []Opts{
+				WithLayerAdd("../testdata/layer.tar", mediatype.OCI1Layer),
+			},
+			ref: tTgtHost + "/testrepo:v3",
+		},
+		{
+			name: "Layer Add File Missing",
+			opts: []Opts{
+				WithLayerAdd(filepath.Join(tempDir, "missing.tar"), ""),
+			},
+			ref:     tTgtHost + "/testrepo:v3",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name: "Layer Add File Bad Media Type",
+			opts: []Opts{
+				WithLayerAdd(tarGzFile, mediatype.OCI1ImageConfig),
+			},
+			ref:     tTgtHost + "/testrepo:v3",
+			wantErr: errs.ErrUnsupportedMediaType,
+		},
 		{
 			name: "Layer Uncompressed",
 			opts: []Opts{
@@ -660,6 +850,23 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v3",
 		},
+		{
+			name: "Layer Trim File Temp Dir",
+			opts: []Opts{
+				WithTempDir(t.TempDir()),
+				WithLayerStripFile("/layer2"),
+			},
+			ref: tTgtHost + "/testrepo:v3",
+		},
+		{
+			name: "Layer Trim File Missing Temp Dir",
+			opts: []Opts{
+				WithTempDir(filepath.Join(tempDir, "missing")),
+				WithLayerStripFile("/layer2"),
+			},
+			ref:     tTgtHost + "/testrepo:v3",
+			wantErr: fs.ErrNotExist,
+		},
 		{
 			name: "Layer Trim File With Local Separator",
 			opts: []Opts{
@@ -937,6 +1144,14 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Set Entrypoint Platform",
+			opts: []Opts{
+				WithConfigEntrypoint([]string{"/app-arm64"}, platform.Platform{OS: "linux", Architecture: "arm64"}),
+				WithConfigCmd(nil, platform.Platform{OS: "linux", Architecture: "arm64"}),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
 		{
 			name: "Build arg rm",
 			opts: []Opts{
@@ -1089,6 +1304,342 @@ func TestMod(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Base Image Rebase", func(t *testing.T) {
+		rB1, err := ref.New(tTgtHost + "/testrepo:b1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rB2 := rB1.SetTag("b2")
+		rBase, err := ref.New(tTgtHost + "/baserepo:base")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rB1, rBase)
+		if err != nil {
+			t.Fatalf("failed to copy base: %v", err)
+		}
+		mB1, err := rc.ManifestHead(ctx, rB1, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head base: %v", err)
+		}
+		rStamp := rBase.SetTag("stamped")
+		_, err = Apply(ctx, rc, rB1.SetTag("v2"), WithBaseImage(rBase, ""), WithRefTgt(rStamp))
+		if err != nil {
+			t.Fatalf("failed to set base image: %v", err)
+		}
+		mStamp, err := rc.ManifestGet(ctx, rStamp)
+		if err != nil {
+			t.Fatalf("failed to get stamped image: %v", err)
+		}
+		annot, err := mStamp.(manifest.Annotator).GetAnnotations()
+		if err != nil {
+			t.Fatalf("failed to get annotations: %v", err)
+		}
+		if annot[types.AnnotationBaseImageName] != rBase.CommonName() || annot[types.AnnotationBaseImageDigest] != mB1.GetDescriptor().Digest.String() {
+			t.Errorf("unexpected base annotations: %v", annot)
+		}
+		// move the base tag and verify the rebase follows the annotations
+		err = rc.ImageCopy(ctx, rB2, rBase)
+		if err != nil {
+			t.Fatalf("failed to copy base: %v", err)
+		}
+		rRebase := rBase.SetTag("rebased")
+		_, err = Apply(ctx, rc, rStamp, WithRebase(), WithRefTgt(rRebase))
+		if err != nil {
+			t.Fatalf("failed to rebase: %v", err)
+		}
+		history := map[string][]v1.History{}
+		for _, r := range []ref.Ref{rB2, rRebase} {
+			conf, err := rc.ImageConfig(ctx, r, regclient.ImageWithPlatform("linux/amd64"))
+			if err != nil {
+				t.Fatalf("failed to get config for %s: %v", r.CommonName(), err)
+			}
+			history[r.Tag] = conf.GetConfig().History
+		}
+		if len(history["rebased"]) < len(history["b2"]) {
+			t.Fatalf("rebased image is missing history: %v", history["rebased"])
+		}
+		for i, h := range history["b2"] {
+			if history["rebased"][i].CreatedBy != h.CreatedBy {
+				t.Errorf("history %d was not rebased, expected %s, received %s", i, h.CreatedBy, history["rebased"][i].CreatedBy)
+			}
+		}
+		// a base image without a tag cannot be used to detect changes
+		_, err = Apply(ctx, rc, rStamp, WithBaseImage(rBase.SetDigest(mB1.GetDescriptor().Digest.String()), ""))
+		if !errors.Is(err, errs.ErrMissingTag) {
+			t.Errorf("unexpected error for base without a tag: %v", err)
+		}
+	})
+
+	t.Run("Rebase Dry Run", func(t *testing.T) {
+		rB1, err := ref.New(tTgtHost + "/testrepo:b1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rB3 := rB1.SetTag("b3")
+		rDry, err := ref.New(tTgtHost + "/dryrun:v2")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		mB3, err := rc.ManifestGet(ctx, rB3, regclient.WithManifestPlatform(platform.Platform{OS: "linux", Architecture: "amd64"}))
+		if err != nil {
+			t.Fatalf("failed to get base: %v", err)
+		}
+		layersB3, err := mB3.(manifest.Imager).GetLayers()
+		if err != nil {
+			t.Fatalf("failed to get base layers: %v", err)
+		}
+		changes := []RebaseChange{}
+		_, err = Apply(ctx, rc, rB1.SetTag("v2"), WithRebaseRefs(rB1, rB3), WithRefTgt(rDry), WithDryRun(),
+			WithRebaseReport(func(c RebaseChange) {
+				changes = append(changes, c)
+			}))
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if len(changes) != 3 {
+			t.Fatalf("unexpected number of changes, expected 3, received %d", len(changes))
+		}
+		for _, c := range changes {
+			if c.Platform.String() != "linux/amd64" {
+				continue
+			}
+			if c.BaseNew.Digest != mB3.GetDescriptor().Digest.String() {
+				t.Errorf("unexpected new base, expected %s, received %s", mB3.GetDescriptor().Digest, c.BaseNew.Digest)
+			}
+			if len(c.LayersOld) != 1 || len(c.LayersNew) != len(layersB3) || c.LayersNew[0].Digest != layersB3[0].Digest {
+				t.Errorf("unexpected layers, old %v, new %v", c.LayersOld, c.LayersNew)
+			}
+		}
+		_, err = rc.ManifestHead(ctx, rDry)
+		if err == nil {
+			t.Errorf("manifest pushed by a dry run")
+		}
+	})
+
+	t.Run("Layer Add Compressed File", func(t *testing.T) {
+		rSrc, err := ref.New(tTgtHost + "/testrepo:v3")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rAdd := rSrc.SetTag("layer-add-gz")
+		_, err = Apply(ctx, rc, rSrc, WithLayerAdd(tarGzFile, ""), WithRefTgt(rAdd))
+		if err != nil {
+			t.Fatalf("failed to add layer: %v", err)
+		}
+		// every platform should have the new layer with the diff_id of the uncompressed tar
+		mAdd, err := rc.ManifestGet(ctx, rAdd)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		pl, err := manifest.GetPlatformList(mAdd)
+		if err != nil || len(pl) == 0 {
+			t.Fatalf("failed to get platforms: %v", err)
+		}
+		ucDig := digest.FromBytes(tarBytes)
+		for _, p := range pl {
+			conf, err := rc.ImageConfig(ctx, rAdd, regclient.ImageWithPlatform(p.String()))
+			if err != nil {
+				t.Fatalf("failed to get config for %s: %v", p.String(), err)
+			}
+			oc := conf.GetConfig()
+			if len(oc.RootFS.DiffIDs) == 0 || oc.RootFS.DiffIDs[len(oc.RootFS.DiffIDs)-1] != ucDig {
+				t.Errorf("unexpected diff_ids for %s, expected %s, received %v", p.String(), ucDig, oc.RootFS.DiffIDs)
+			}
+			if len(oc.History) == 0 || oc.History[len(oc.History)-1].CreatedBy != "regclient layer add layer.tar.gz" {
+				t.Errorf("history missing for %s: %v", p.String(), oc.History)
+			}
+		}
+	})
+
+	t.Run("Layer Dedup", func(t *testing.T) {
+		// create an image with a consecutive duplicate layer and a repeated layer
+		mOrig, err := rc.ManifestGet(ctx, r3amd)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		ociM, ok := mOrig.GetOrig().(v1.Manifest)
+		if !ok {
+			t.Fatalf("unexpected manifest type: %T", mOrig.GetOrig())
+		}
+		confOrig, err := rc.BlobGetOCIConfig(ctx, r3amd, ociM.Config)
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		oc := confOrig.GetConfig()
+		layerHist := []v1.History{}
+		emptyHist := []v1.History{}
+		for _, h := range oc.History {
+			if h.EmptyLayer {
+				emptyHist = append(emptyHist, h)
+			} else {
+				layerHist = append(layerHist, h)
+			}
+		}
+		if len(ociM.Layers) < 2 || len(oc.RootFS.DiffIDs) != len(ociM.Layers) || len(layerHist) != len(ociM.Layers) || len(emptyHist) < 1 {
+			t.Fatalf("unexpected source image, layers %d, diff_ids %d, history %d", len(ociM.Layers), len(oc.RootFS.DiffIDs), len(oc.History))
+		}
+		dupIndex := []int{0, 1, 1, 0}
+		ociM.Layers = []descriptor.Descriptor{}
+		oc.RootFS.DiffIDs = []digest.Digest{}
+		oc.History = []v1.History{emptyHist[0]}
+		for _, i := range dupIndex {
+			ociM.Layers = append(ociM.Layers, mOrig.GetOrig().(v1.Manifest).Layers[i])
+			oc.RootFS.DiffIDs = append(oc.RootFS.DiffIDs, confOrig.GetConfig().RootFS.DiffIDs[i])
+			oc.History = append(oc.History, layerHist[i])
+		}
+		confDup, err := json.Marshal(oc)
+		if err != nil {
+			t.Fatalf("failed to marshal config: %v", err)
+		}
+		ociM.Config = descriptor.Descriptor{
+			MediaType: mediatype.OCI1ImageConfig,
+			Digest:    digest.FromBytes(confDup),
+			Size:      int64(len(confDup)),
+		}
+		rDup, err := ref.New(tTgtHost + "/testrepo:dup")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = rc.BlobPut(ctx, rDup, ociM.Config, bytes.NewReader(confDup))
+		if err != nil {
+			t.Fatalf("failed to push config: %v", err)
+		}
+		mDup, err := manifest.New(manifest.WithOrig(ociM))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, rDup, mDup)
+		if err != nil {
+			t.Fatalf("failed to push manifest: %v", err)
+		}
+		// dedup and verify only the consecutive layer was removed
+		rDedup := rDup.SetTag("dedup")
+		_, err = Apply(ctx, rc, rDup, WithLayerDedup(), WithRefTgt(rDedup))
+		if err != nil {
+			t.Fatalf("failed to dedup layers: %v", err)
+		}
+		mDedup, err := rc.ManifestGet(ctx, rDedup)
+		if err != nil {
+			t.Fatalf("failed to get dedup manifest: %v", err)
+		}
+		confDedup, err := rc.ImageConfig(ctx, rDedup)
+		if err != nil {
+			t.Fatalf("failed to get dedup config: %v", err)
+		}
+		dedupLayers := mDedup.GetOrig().(v1.Manifest).Layers
+		dedupConf := confDedup.GetConfig()
+		expectIndex := []int{0, 1, 0}
+		if len(dedupLayers) != len(expectIndex) || len(dedupConf.RootFS.DiffIDs) != len(expectIndex) || len(dedupConf.History) != len(expectIndex)+1 {
+			t.Fatalf("unexpected dedup result, layers %v, diff_ids %v, history %v", dedupLayers, dedupConf.RootFS.DiffIDs, dedupConf.History)
+		}
+		for i, orig := range expectIndex {
+			if dedupLayers[i].Digest != mOrig.GetOrig().(v1.Manifest).Layers[orig].Digest {
+				t.Errorf("unexpected layer %d, expected %s, received %s", i, mOrig.GetOrig().(v1.Manifest).Layers[orig].Digest, dedupLayers[i].Digest)
+			}
+			if dedupConf.RootFS.DiffIDs[i] != confOrig.GetConfig().RootFS.DiffIDs[orig] {
+				t.Errorf("unexpected diff_id %d, expected %s, received %s", i, confOrig.GetConfig().RootFS.DiffIDs[orig], dedupConf.RootFS.DiffIDs[i])
+			}
+			if dedupConf.History[i+1].CreatedBy != layerHist[orig].CreatedBy {
+				t.Errorf("unexpected history %d, expected %s, received %s", i, layerHist[orig].CreatedBy, dedupConf.History[i+1].CreatedBy)
+			}
+		}
+		// an image without duplicates is unchanged
+		rNoDup, err := Apply(ctx, rc, rDedup, WithLayerDedup())
+		if err != nil {
+			t.Fatalf("failed to dedup layers: %v", err)
+		}
+		mNoDup, err := rc.ManifestHead(ctx, rNoDup, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		if mNoDup.GetDescriptor().Digest != mDedup.GetDescriptor().Digest {
+			t.Errorf("image without duplicate layers was changed")
+		}
+	})
+
+	t.Run("Attestations Rm Validate", func(t *testing.T) {
+		rSrc, err := ref.New(tTgtHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		mOrig, err := rc.ManifestGet(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		mlOrig, err := mOrig.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		rOut, err := Apply(ctx, rc, rSrc, WithRefTgt(rSrc.SetTag("no-attestations")), WithIndexAttestationsRm())
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		m, err := rc.ManifestGet(ctx, rOut)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		ml, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		expect := []descriptor.Descriptor{}
+		for _, d := range mlOrig {
+			if d.Annotations[dockerReferenceType] == "" {
+				expect = append(expect, d)
+			}
+		}
+		if len(expect) == len(mlOrig) || len(expect) == 0 {
+			t.Fatalf("source index does not contain attestations and platforms: %v", mlOrig)
+		}
+		if len(ml) != len(expect) {
+			t.Fatalf("unexpected manifest list, expected %v, received %v", expect, ml)
+		}
+		for i := range ml {
+			if ml[i].Digest != expect[i].Digest || ml[i].Platform == nil || ml[i].Platform.String() != expect[i].Platform.String() {
+				t.Errorf("unexpected entry %d, expected %v, received %v", i, expect[i], ml[i])
+			}
+			if len(ml[i].Annotations) > 0 && ml[i].Annotations[dockerReferenceType] != "" {
+				t.Errorf("attestation remains: %v", ml[i])
+			}
+		}
+	})
+
+	t.Run("Normalize Artifact Validate", func(t *testing.T) {
+		for _, tc := range []struct {
+			r            ref.Ref
+			artifactType string
+			layers       int
+		}{
+			{r: rTgt1.SetTag("legacy-artifact"), artifactType: "application/vnd.example.config", layers: 1},
+			{r: rArtifact.SetTag("artifact-normalized"), artifactType: "application/vnd.example.artifact", layers: 1},
+		} {
+			m, err := rc.ManifestGet(ctx, tc.r)
+			if err != nil {
+				t.Fatalf("failed to get %s: %v", tc.r.CommonName(), err)
+			}
+			ociM, ok := m.GetOrig().(v1.Manifest)
+			if !ok {
+				t.Fatalf("unexpected manifest type for %s: %T", tc.r.CommonName(), m.GetOrig())
+			}
+			if ociM.MediaType != mediatype.OCI1Manifest || ociM.ArtifactType != tc.artifactType {
+				t.Errorf("unexpected media type %s or artifactType %s", ociM.MediaType, ociM.ArtifactType)
+			}
+			if ociM.Config.MediaType != mediatype.OCI1Empty || ociM.Config.Digest != descriptor.EmptyDigest {
+				t.Errorf("config is not the empty descriptor: %v", ociM.Config)
+			}
+			if len(ociM.Layers) != tc.layers || ociM.Layers[0].Digest != artifactDesc.Digest {
+				t.Errorf("unexpected layers: %v", ociM.Layers)
+			}
+			for _, d := range append(ociM.Layers, ociM.Config) {
+				_, err = rc.BlobHead(ctx, tc.r, d)
+				if err != nil {
+					t.Errorf("blob %s missing from %s: %v", d.Digest, tc.r.CommonName(), err)
+				}
+			}
+		}
+	})
 }
 
 func TestInList(t *testing.T) {
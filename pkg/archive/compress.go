@@ -114,6 +114,10 @@ func DetectCompression(head []byte) CompressType {
 			return c
 		}
 	}
+	// zstd skippable frames, used for metadata by formats like zstd:chunked, have a range of magic numbers
+	if len(head) >= 4 && head[0]&0xF0 == 0x50 && bytes.Equal(head[1:4], []byte("\x2A\x4D\x18")) {
+		return CompressZstd
+	}
 	return CompressNone
 }
 
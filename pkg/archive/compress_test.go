@@ -70,6 +70,34 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
+func TestDetectZstdSkippable(t *testing.T) {
+	t.Parallel()
+	cr, err := Compress(strings.NewReader("hello world"), CompressZstd)
+	if err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	zBytes, err := io.ReadAll(cr)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	// a skippable frame with magic 0x184D2A5E and a 4 byte payload
+	in := append([]byte{0x5E, 0x2A, 0x4D, 0x18, 0x04, 0x00, 0x00, 0x00, 0, 1, 2, 3}, zBytes...)
+	if ct := DetectCompression(in); ct != CompressZstd {
+		t.Errorf("unexpected compression, expected %s, received %s", CompressZstd, ct)
+	}
+	dr, err := Decompress(bytes.NewReader(in))
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	out, err := io.ReadAll(dr)
+	if err != nil {
+		t.Fatalf("failed to ReadAll: %v", err)
+	}
+	if string(out) != "hello world" {
+		t.Errorf("output mismatch: expected hello world, received %s", out)
+	}
+}
+
 func FuzzRoundTrip(f *testing.F) {
 	f.Add(int(CompressNone), "hello world")
 	f.Fuzz(func(t *testing.T, comp int, s string) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
@@ -22,6 +23,71 @@ func (rc *RegClient) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ..
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
 	}
+	rSubject, schemeAPI, err := rc.referrerSubject(ctx, rSubject, opts)
+	if err != nil {
+		return referrer.ReferrerList{}, err
+	}
+	return schemeAPI.ReferrerList(ctx, rSubject, opts...)
+}
+
+// ReferrerListIter returns an iterator over the referrers to a manifest.
+// Pages from the registry are only requested as the iterator reaches them, and each descriptor is filtered as it is yielded.
+// Any error is yielded as the last value of the iterator.
+// The function signature is compatible with range-over-func loops in Go 1.23 as an iter.Seq2.
+// When a sort is requested with [descriptor.MatchOpt], the full list is retrieved before the first value is yielded.
+func (rc *RegClient) ReferrerListIter(ctx context.Context, rSubject ref.Ref, opts ...scheme.ReferrerOpts) func(yield func(descriptor.Descriptor, error) bool) {
+	return func(yield func(descriptor.Descriptor, error) bool) {
+		if !rSubject.IsSet() {
+			yield(descriptor.Descriptor{}, fmt.Errorf("ref is not set: %s%.0w", rSubject.CommonName(), errs.ErrInvalidReference))
+			return
+		}
+		// dedup warnings
+		if w := warning.FromContext(ctx); w == nil {
+			ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+		}
+		config := scheme.ReferrerConfig{}
+		for _, opt := range opts {
+			opt(&config)
+		}
+		r, schemeAPI, err := rc.referrerSubject(ctx, rSubject, opts)
+		if err != nil {
+			yield(descriptor.Descriptor{}, err)
+			return
+		}
+		sp, ok := schemeAPI.(scheme.ReferrerPager)
+		if !ok || config.MatchOpt.SortAnnotation != "" {
+			rl, err := schemeAPI.ReferrerList(ctx, r, opts...)
+			if err != nil {
+				yield(descriptor.Descriptor{}, err)
+				return
+			}
+			for _, d := range rl.Descriptors {
+				if !yield(d, nil) {
+					return
+				}
+			}
+			return
+		}
+		// each descriptor is checked against the filter in case the scheme only filters on the server
+		err = sp.ReferrerListPage(ctx, r, func(rl referrer.ReferrerList) bool {
+			for _, d := range rl.Descriptors {
+				if !d.Match(config.MatchOpt) {
+					continue
+				}
+				if !yield(d, nil) {
+					return false
+				}
+			}
+			return true
+		}, opts...)
+		if err != nil {
+			yield(descriptor.Descriptor{}, err)
+		}
+	}
+}
+
+// referrerSubject resolves the digest of the subject and returns the scheme used to list the referrers.
+func (rc *RegClient) referrerSubject(ctx context.Context, rSubject ref.Ref, opts []scheme.ReferrerOpts) (ref.Ref, scheme.API, error) {
 	// set the digest on the subject reference
 	config := scheme.ReferrerConfig{}
 	for _, opt := range opts {
@@ -32,13 +98,13 @@ func (rc *RegClient) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ..
 		if config.Platform != "" {
 			p, err := platform.Parse(config.Platform)
 			if err != nil {
-				return referrer.ReferrerList{}, fmt.Errorf("failed to lookup referrer platform: %w", err)
+				return rSubject, nil, fmt.Errorf("failed to lookup referrer platform: %w", err)
 			}
 			mo = append(mo, WithManifestPlatform(p))
 		}
 		m, err := rc.ManifestHead(ctx, rSubject, mo...)
 		if err != nil {
-			return referrer.ReferrerList{}, fmt.Errorf("failed to get digest for subject: %w", err)
+			return rSubject, nil, fmt.Errorf("failed to get digest for subject: %w", err)
 		}
 		rSubject = rSubject.SetDigest(m.GetDescriptor().Digest.String())
 	}
@@ -51,7 +117,7 @@ func (rc *RegClient) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ..
 	}
 	schemeAPI, err := rc.schemeGet(r.Scheme)
 	if err != nil {
-		return referrer.ReferrerList{}, err
+		return rSubject, nil, err
 	}
-	return schemeAPI.ReferrerList(ctx, rSubject, opts...)
+	return rSubject, schemeAPI, nil
 }
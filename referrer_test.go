@@ -199,6 +199,33 @@ func TestReferrerList(t *testing.T) {
 					if tc.firstAT != "" && (len(rl.Descriptors) == 0 || rl.Descriptors[0].ArtifactType != tc.firstAT) {
 						t.Errorf("unexpected first entry, expected %s, received response %v", tc.firstAT, rl.Descriptors)
 					}
+					// the iterator should return the same descriptors
+					dl := []descriptor.Descriptor{}
+					rc.ReferrerListIter(ctx, tc.ref, tc.opts...)(func(d descriptor.Descriptor, err error) bool {
+						if err != nil {
+							t.Errorf("iterator failed: %v", err)
+							return false
+						}
+						dl = append(dl, d)
+						return true
+					})
+					if len(dl) != len(rl.Descriptors) {
+						t.Fatalf("unexpected iterator results, expected %v, received %v", rl.Descriptors, dl)
+					}
+					for i := range dl {
+						if dl[i].Digest != rl.Descriptors[i].Digest {
+							t.Errorf("unexpected iterator entry %d, expected %s, received %s", i, rl.Descriptors[i].Digest, dl[i].Digest)
+						}
+					}
+					// stopping the iterator early should not request more entries
+					count := 0
+					rc.ReferrerListIter(ctx, tc.ref, tc.opts...)(func(d descriptor.Descriptor, err error) bool {
+						count++
+						return false
+					})
+					if count != 1 {
+						t.Errorf("iterator did not stop, called %d times", count)
+					}
 				})
 			}
 		})
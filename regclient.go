@@ -8,11 +8,14 @@ import (
 
 	"fmt"
 
+	"github.com/opencontainers/go-digest"
+
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/version"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/scheme/ocidir"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types/ref"
 )
 
 const (
@@ -35,7 +38,9 @@ type RegClient struct {
 	regOpts     []reg.Opts
 	schemes     map[string]scheme.API
 	slog        *slog.Logger
+	tmpDir      string
 	userAgent   string
+	cacheFill   *blobCacheFill
 }
 
 // Opt functions are used by [New] to create a [*RegClient].
@@ -49,6 +54,7 @@ func New(opts ...Opt) *RegClient {
 		regOpts:   []reg.Opts{},
 		schemes:   map[string]scheme.API{},
 		slog:      slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+		cacheFill: &blobCacheFill{pending: map[digest.Digest]chan struct{}{}},
 	}
 
 	info := version.GetInfo()
@@ -78,6 +84,14 @@ func New(opts ...Opt) *RegClient {
 	)
 
 	// setup scheme's
+	for name := range rc.schemes {
+		if err := ref.RegisterScheme(name); err != nil {
+			rc.slog.Warn("Ignoring custom scheme",
+				slog.String("scheme", name),
+				slog.String("err", err.Error()))
+			delete(rc.schemes, name)
+		}
+	}
 	rc.schemes["reg"] = reg.New(rc.regOpts...)
 	rc.schemes["ocidir"] = ocidir.New(
 		ocidir.WithSlog(rc.slog),
@@ -199,6 +213,16 @@ func WithRetryLimit(retryLimit int) Opt {
 	}
 }
 
+// WithScheme adds a custom scheme handler, e.g. "mystore" for references like "mystore://path/repo:tag".
+// The handler implements [scheme.API], and may optionally implement [scheme.Closer] and [scheme.GCLocker].
+// References for the scheme are parsed by [ref.New] like an OCI Layout, see [ref.RegisterScheme].
+// The built-in "reg" and "ocidir" schemes cannot be replaced.
+func WithScheme(name string, api scheme.API) Opt {
+	return func(rc *RegClient) {
+		rc.schemes[name] = api
+	}
+}
+
 // WithSlog configures the slog Logger.
 func WithSlog(slog *slog.Logger) Opt {
 	return func(rc *RegClient) {
@@ -206,6 +230,14 @@ func WithSlog(slog *slog.Logger) Opt {
 	}
 }
 
+// WithTempDir sets the directory used for temporary files when staging content.
+// The default is the directory returned by [os.TempDir].
+func WithTempDir(dir string) Opt {
+	return func(rc *RegClient) {
+		rc.tmpDir = dir
+	}
+}
+
 // WithUserAgent specifies the User-Agent http header.
 func WithUserAgent(ua string) Opt {
 	return func(rc *RegClient) {
@@ -3,10 +3,14 @@ package regclient
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
 
+	"github.com/regclient/regclient/internal/httplink"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/repo"
 )
 
@@ -31,3 +35,165 @@ func (rc *RegClient) RepoList(ctx context.Context, hostname string, opts ...sche
 	}
 	return rl.RepoList(ctx, hostname, opts...)
 }
+
+// RepoWalkOpts define options for [RegClient.RepoWalk].
+type RepoWalkOpts func(*repoWalkOpt)
+
+type repoWalkOpt struct {
+	concurrency int
+	limit       int
+	repos       []string
+}
+
+// WithRepoWalkConcurrency sets the number of repositories to list tags from concurrently.
+// The default is 3.
+func WithRepoWalkConcurrency(n int) RepoWalkOpts {
+	return func(opt *repoWalkOpt) {
+		if n > 0 {
+			opt.concurrency = n
+		}
+	}
+}
+
+// WithRepoWalkLimit sets the number of repositories requested in each page of the catalog.
+// By default, the registry decides the page size.
+func WithRepoWalkLimit(n int) RepoWalkOpts {
+	return func(opt *repoWalkOpt) {
+		opt.limit = n
+	}
+}
+
+// WithRepoWalkRepos walks a list of repositories instead of the catalog.
+// This is required for registries that do not support the "_catalog" API.
+func WithRepoWalkRepos(repos ...string) RepoWalkOpts {
+	return func(opt *repoWalkOpt) {
+		opt.repos = append(opt.repos, repos...)
+	}
+}
+
+// RepoWalk calls fn with the tags of every repository on a registry.
+// The catalog is paginated using continuation tokens, and tags are listed concurrently.
+// Calls to fn are not concurrent, but the order of repositories is not guaranteed.
+// The walk stops on the first error, including any error returned by fn.
+func (rc *RegClient) RepoWalk(ctx context.Context, host string, fn func(repo string, tags []string) error, opts ...RepoWalkOpts) error {
+	opt := repoWalkOpt{
+		concurrency: 3,
+	}
+	for _, fnOpt := range opts {
+		fnOpt(&opt)
+	}
+	rHost, err := ref.NewHost(host)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	repoCh := make(chan string)
+	var fnMu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < opt.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repoName := range repoCh {
+				r := rHost
+				r.Repository = repoName
+				tl, err := rc.TagList(ctx, r)
+				if err != nil {
+					cancel(fmt.Errorf("failed to list tags for %s: %w", repoName, err))
+					continue
+				}
+				tags, err := tl.GetTags()
+				if err != nil {
+					cancel(fmt.Errorf("failed to list tags for %s: %w", repoName, err))
+					continue
+				}
+				fnMu.Lock()
+				if ctx.Err() == nil {
+					err = fn(repoName, tags)
+				}
+				fnMu.Unlock()
+				if err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+	send := func(repoName string) error {
+		select {
+		case repoCh <- repoName:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	if len(opt.repos) > 0 {
+		for _, repoName := range opt.repos {
+			if err = send(repoName); err != nil {
+				break
+			}
+		}
+	} else {
+		err = rc.repoWalkCatalog(ctx, host, opt.limit, send)
+	}
+	close(repoCh)
+	wg.Wait()
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// repoWalkCatalog sends every repository from the paginated catalog.
+func (rc *RegClient) repoWalkCatalog(ctx context.Context, host string, limit int, send func(string) error) error {
+	last := ""
+	for {
+		repoOpts := []scheme.RepoOpts{}
+		if limit > 0 {
+			repoOpts = append(repoOpts, scheme.WithRepoLimit(limit))
+		}
+		if last != "" {
+			repoOpts = append(repoOpts, scheme.WithRepoLast(last))
+		}
+		rl, err := rc.RepoList(ctx, host, repoOpts...)
+		if err != nil {
+			if last == "" {
+				return fmt.Errorf("failed to list repositories on %s, use WithRepoWalkRepos for registries without the catalog API: %w", host, err)
+			}
+			return fmt.Errorf("failed to list repositories on %s after %s: %w", host, last, err)
+		}
+		repos, err := rl.GetRepos()
+		if err != nil {
+			return err
+		}
+		for _, repoName := range repos {
+			if err := send(repoName); err != nil {
+				return err
+			}
+		}
+		if len(repos) == 0 {
+			return nil
+		}
+		// use the continuation token from the Link header, or the last entry when a full page was returned
+		next := ""
+		if headers, err := rl.RawHeaders(); err == nil {
+			if links, err := httplink.Parse(headers.Values("Link")); err == nil {
+				if link, err := links.Get("rel", "next"); err == nil {
+					if u, err := url.Parse(link.URI); err == nil {
+						next = u.Query().Get("last")
+					}
+					if next == "" {
+						next = repos[len(repos)-1]
+					}
+				}
+			}
+		}
+		if next == "" && limit > 0 && len(repos) >= limit {
+			next = repos[len(repos)-1]
+		}
+		if next == "" || next == last {
+			return nil
+		}
+		last = next
+	}
+}
@@ -2,12 +2,22 @@ package regclient
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types/errs"
 )
 
@@ -25,3 +35,149 @@ func TestRepoList(t *testing.T) {
 		t.Errorf("RepoList unexpected error on hostname with a path: expected %v, received %v", errs.ErrParsingFailed, err)
 	}
 }
+
+func TestRepoWalk(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repoNames := []string{"alpha", "beta", "gamma/one", "gamma/two", "zeta"}
+	newServer := func(catalog, link bool, pageSize int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v2/" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			if r.URL.Path == "/v2/_catalog" {
+				if !catalog {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				n := pageSize
+				if nStr := r.URL.Query().Get("n"); nStr != "" {
+					n, _ = strconv.Atoi(nStr)
+				}
+				last := r.URL.Query().Get("last")
+				page := []string{}
+				for _, name := range repoNames {
+					if name > last && len(page) < n {
+						page = append(page, name)
+					}
+				}
+				if link && len(page) > 0 && page[len(page)-1] != repoNames[len(repoNames)-1] {
+					w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=%s&n=%d>; rel="next"`, url.QueryEscape(page[len(page)-1]), n))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": page})
+				return
+			}
+			if strings.HasSuffix(r.URL.Path, "/tags/list") {
+				name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list")
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "tags": []string{"latest", path.Base(name)}})
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	}
+	tsLink := newServer(true, true, 2)
+	tsNoLink := newServer(true, false, 2)
+	tsNoCatalog := newServer(false, false, 2)
+	t.Cleanup(func() {
+		tsLink.Close()
+		tsNoLink.Close()
+		tsNoCatalog.Close()
+	})
+	hosts := []config.Host{}
+	for _, ts := range []*httptest.Server{tsLink, tsNoLink, tsNoCatalog} {
+		u, _ := url.Parse(ts.URL)
+		hosts = append(hosts, config.Host{Name: u.Host, Hostname: u.Host, TLS: config.TLSDisabled})
+	}
+	rc := New(
+		WithConfigHost(hosts...),
+		WithRegOpts(reg.WithDelay(time.Millisecond*10, time.Millisecond*50)),
+	)
+	tt := []struct {
+		name      string
+		host      string
+		opts      []RepoWalkOpts
+		expect    []string
+		expectErr error
+	}{
+		{
+			name:   "link header",
+			host:   hosts[0].Name,
+			expect: repoNames,
+		},
+		{
+			name:   "link header with limit",
+			host:   hosts[0].Name,
+			opts:   []RepoWalkOpts{WithRepoWalkLimit(3), WithRepoWalkConcurrency(1)},
+			expect: repoNames,
+		},
+		{
+			name:   "full pages without link",
+			host:   hosts[1].Name,
+			opts:   []RepoWalkOpts{WithRepoWalkLimit(2)},
+			expect: repoNames,
+		},
+		{
+			name:      "no catalog",
+			host:      hosts[2].Name,
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:   "no catalog with repos",
+			host:   hosts[2].Name,
+			opts:   []RepoWalkOpts{WithRepoWalkRepos("alpha", "gamma/one")},
+			expect: []string{"alpha", "gamma/one"},
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			found := map[string][]string{}
+			err := rc.RepoWalk(ctx, tc.host, func(repo string, tags []string) error {
+				if _, ok := found[repo]; ok {
+					return fmt.Errorf("repo walked twice: %s", repo)
+				}
+				found[repo] = tags
+				return nil
+			}, tc.opts...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to walk: %v", err)
+			}
+			if len(found) != len(tc.expect) {
+				t.Errorf("unexpected repos, expected %v, received %v", tc.expect, found)
+			}
+			for _, name := range tc.expect {
+				tags, ok := found[name]
+				if !ok {
+					t.Errorf("repo not walked: %s", name)
+				} else if len(tags) != 2 || tags[1] != path.Base(name) {
+					t.Errorf("unexpected tags for %s: %v", name, tags)
+				}
+			}
+		})
+	}
+	t.Run("callback error", func(t *testing.T) {
+		t.Parallel()
+		errStop := errors.New("stop walking")
+		count := 0
+		err := rc.RepoWalk(ctx, hosts[0].Name, func(repo string, tags []string) error {
+			count++
+			return errStop
+		}, WithRepoWalkConcurrency(1))
+		if !errors.Is(err, errStop) {
+			t.Errorf("unexpected error, expected %v, received %v", errStop, err)
+		}
+		if count != 1 {
+			t.Errorf("callback was called %d times after an error", count)
+		}
+	})
+}
@@ -31,3 +31,18 @@ func (rc *RegClient) Close(ctx context.Context, r ref.Ref) error {
 	}
 	return sc.Close(ctx, r)
 }
+
+// GC removes blobs that are not reachable from the manifests in a repository, returning the number of blobs and bytes reclaimed.
+// Referrers and their subjects remain.
+// This is only supported by schemes like ocidir that manage their own storage.
+func (rc *RegClient) GC(ctx context.Context, r ref.Ref) (scheme.GCResult, error) {
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return scheme.GCResult{}, err
+	}
+	sgc, ok := schemeAPI.(scheme.GarbageCollector)
+	if !ok {
+		return scheme.GCResult{}, fmt.Errorf("scheme %s does not support garbage collection%.0w", r.Scheme, errs.ErrUnsupported)
+	}
+	return sgc.GC(ctx, r)
+}
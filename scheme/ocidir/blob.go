@@ -26,7 +26,11 @@ func (o *OCIDir) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descrip
 		return fmt.Errorf("failed to validate digest %s: %w", d.Digest.String(), err)
 	}
 	file := path.Join(r.Path, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
-	return os.Remove(file)
+	err = os.Remove(file)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blob %s not found: %w%.0w", d.Digest.String(), err, errs.ErrNotFound)
+	}
+	return err
 }
 
 // BlobGet retrieves a blob, returning a reader
@@ -87,9 +91,117 @@ func (o *OCIDir) BlobHead(ctx context.Context, r ref.Ref, d descriptor.Descripto
 	return br, nil
 }
 
-// BlobMount attempts to perform a server side copy of the blob
+// BlobMount copies a blob between two OCI Layouts on the local filesystem.
+// A hardlink is attempted first, falling back to a copy of the file.
+// The source is verified against the digest before it is linked, and an invalid blob in the target is replaced.
 func (o *OCIDir) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor) error {
-	return errs.ErrUnsupported
+	if refSrc.Scheme != "ocidir" || refTgt.Scheme != "ocidir" {
+		return errs.ErrUnsupported
+	}
+	err := d.Digest.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate digest %s: %w", d.Digest.String(), err)
+	}
+	t := o.throttleGet(refTgt, false)
+	done, err := t.Acquire(ctx, reqmeta.Data{Kind: reqmeta.Blob, Size: d.Size})
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	srcFile := path.Join(refSrc.Path, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
+	fi, err := os.Stat(srcFile)
+	if err != nil {
+		return err
+	}
+	if d.Size > 0 && fi.Size() != d.Size {
+		return fmt.Errorf("unexpected blob length, expected %d, found %d%.0w", d.Size, fi.Size(), errs.ErrMismatch)
+	}
+	err = o.initIndex(refTgt, false)
+	if err != nil {
+		return err
+	}
+	dir := path.Join(refTgt.Path, "blobs", d.Digest.Algorithm().String())
+	//#nosec G301 defer to user umask settings
+	err = os.MkdirAll(dir, 0777)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("failed creating %s: %w", dir, err)
+	}
+	file := path.Join(dir, d.Digest.Encoded())
+	if blobVerifyFile(file, d) != nil {
+		// verify the source before linking to avoid spreading a corrupt blob to the target
+		err = blobVerifyFile(srcFile, d)
+		if err != nil {
+			return err
+		}
+		err = os.Link(srcFile, file)
+		if err != nil {
+			// hardlinks fail across filesystems or when an invalid target file exists, copy the file to a tmp name and rename
+			err = blobCopyFile(srcFile, dir, file)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	o.slog.Debug("mounted blob",
+		slog.String("src", refSrc.CommonName()),
+		slog.String("tgt", refTgt.CommonName()),
+		slog.String("file", file))
+
+	o.mu.Lock()
+	o.refMod(refTgt)
+	o.mu.Unlock()
+	return nil
+}
+
+// blobVerifyFile returns an error when the file content does not match the descriptor digest and size.
+func blobVerifyFile(file string, d descriptor.Descriptor) error {
+	//#nosec G304 users should validate references they attempt to open
+	fh, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	dv := d.Digest.Verifier()
+	n, err := io.Copy(dv, fh)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if d.Size > 0 && n != d.Size {
+		return fmt.Errorf("unexpected blob length in %s, expected %d, found %d%.0w", file, d.Size, n, errs.ErrMismatch)
+	}
+	if !dv.Verified() {
+		return fmt.Errorf("blob %s does not match digest %s%.0w", file, d.Digest.String(), errs.ErrMismatch)
+	}
+	return nil
+}
+
+// blobCopyFile copies a blob file to a tmp file in the dir and renames it to the target file.
+func blobCopyFile(srcFile, dir, file string) error {
+	//#nosec G304 users should validate references they attempt to open
+	src, err := os.Open(srcFile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmpFile, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed creating blob tmp file: %w", err)
+	}
+	tmpName := tmpFile.Name()
+	_, err = io.Copy(tmpFile, src)
+	errC := tmpFile.Close()
+	if err == nil {
+		err = errC
+	}
+	if err == nil {
+		err = os.Rename(tmpName, file)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to copy blob %s to %s: %w", srcFile, file, err)
+	}
+	return nil
 }
 
 // BlobPut sends a blob to the repository, returns the digest and size when successful
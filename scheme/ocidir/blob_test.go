@@ -3,6 +3,7 @@ package ocidir
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,8 +11,11 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
+
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -148,6 +152,10 @@ func TestBlob(t *testing.T) {
 	if err == nil {
 		t.Errorf("stat of a deleted blob did not fail")
 	}
+	err = o.BlobDelete(ctx, rNew, cd)
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("delete of a missing blob did not return ErrNotFound: %v", err)
+	}
 	// concurrent blob put, without the descriptor to test for races
 	rPut, err := ref.New("ocidir://" + tempDir + "/put@" + dl[0].Digest.String())
 	if err != nil {
@@ -187,3 +195,86 @@ func TestBlob(t *testing.T) {
 		t.Errorf("blob put bytes, expected %s, saw %s", string(bBytes), string(fBytes))
 	}
 }
+
+func TestBlobMount(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	o := New()
+	blobData := []byte("blob mount test")
+	d := descriptor.Descriptor{
+		MediaType: "application/octet-stream",
+		Digest:    digest.FromBytes(blobData),
+		Size:      int64(len(blobData)),
+	}
+	blobFile := func(repo string) string {
+		return filepath.Join(tempDir, repo, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
+	}
+	newRef := func(repo string) ref.Ref {
+		r, err := ref.New("ocidir://" + tempDir + "/" + repo)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		return r
+	}
+	rSrc := newRef("src")
+	_, err := o.BlobPut(ctx, rSrc, d, bytes.NewReader(blobData))
+	if err != nil {
+		t.Fatalf("failed to put blob: %v", err)
+	}
+	t.Run("link", func(t *testing.T) {
+		err := o.BlobMount(ctx, rSrc, newRef("link"), d)
+		if err != nil {
+			t.Fatalf("failed to mount blob: %v", err)
+		}
+		fiSrc, errSrc := os.Stat(blobFile("src"))
+		fiTgt, errTgt := os.Stat(blobFile("link"))
+		if errSrc != nil || errTgt != nil {
+			t.Fatalf("failed to stat blobs: %v, %v", errSrc, errTgt)
+		}
+		if !os.SameFile(fiSrc, fiTgt) {
+			t.Errorf("blob was not linked")
+		}
+	})
+	t.Run("replace invalid target", func(t *testing.T) {
+		err := os.MkdirAll(filepath.Dir(blobFile("invalid")), 0755)
+		if err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		err = os.WriteFile(blobFile("invalid"), []byte("corrupt"), 0644)
+		if err != nil {
+			t.Fatalf("failed to write invalid blob: %v", err)
+		}
+		err = o.BlobMount(ctx, rSrc, newRef("invalid"), d)
+		if err != nil {
+			t.Fatalf("failed to mount blob: %v", err)
+		}
+		b, err := os.ReadFile(blobFile("invalid"))
+		if err != nil {
+			t.Fatalf("failed to read blob: %v", err)
+		}
+		if !bytes.Equal(b, blobData) {
+			t.Errorf("invalid target blob was not replaced, received %s", b)
+		}
+	})
+	t.Run("corrupt source", func(t *testing.T) {
+		rCorrupt := newRef("corrupt-src")
+		_, err := o.BlobPut(ctx, rCorrupt, d, bytes.NewReader(blobData))
+		if err != nil {
+			t.Fatalf("failed to put blob: %v", err)
+		}
+		// same size with different content
+		corrupt := bytes.ToUpper(blobData)
+		err = os.WriteFile(blobFile("corrupt-src"), corrupt, 0644)
+		if err != nil {
+			t.Fatalf("failed to corrupt blob: %v", err)
+		}
+		err = o.BlobMount(ctx, rCorrupt, newRef("corrupt-tgt"), d)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrMismatch, err)
+		}
+		if _, err := os.Stat(blobFile("corrupt-tgt")); err == nil {
+			t.Errorf("corrupt blob was mounted")
+		}
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path"
 
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -24,29 +26,47 @@ func (o *OCIDir) Close(ctx context.Context, r ref.Ref) error {
 		return nil
 	}
 
-	// perform GC
+	_, err := o.gcRun(ctx, r)
+	return err
+}
+
+// GC deletes every blob that is not reachable from the index, returning the count and size of the removed blobs.
+// Manifests, configs, layers, and subjects are followed from each entry in the index, including referrers stored with a fallback tag.
+// GC fails when the path is locked by another operation like a copy.
+func (o *OCIDir) GC(ctx context.Context, r ref.Ref) (scheme.GCResult, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if gc, ok := o.modRefs[r.Path]; ok && gc.locks > 0 {
+		return scheme.GCResult{}, fmt.Errorf("garbage collection of %s is locked by another operation%.0w", r.Path, errs.ErrUnavailable)
+	}
+	return o.gcRun(ctx, r)
+}
+
+// gcRun performs the garbage collection, the caller must hold the lock.
+func (o *OCIDir) gcRun(ctx context.Context, r ref.Ref) (scheme.GCResult, error) {
+	result := scheme.GCResult{}
 	o.slog.Debug("running GC",
 		slog.String("ref", r.CommonName()))
 	dl := map[string]bool{}
 	// recurse through index, manifests, and blob lists, generating a digest list
 	index, err := o.readIndex(r, true)
 	if err != nil {
-		return err
+		return result, err
 	}
 	im, err := manifest.New(manifest.WithOrig(index))
 	if err != nil {
-		return err
+		return result, err
 	}
 	err = o.closeProcManifest(ctx, r, im, &dl)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	// go through filesystem digest list, removing entries not seen in recursive pass
 	blobsPath := path.Join(r.Path, "blobs")
 	blobDirs, err := os.ReadDir(blobsPath)
 	if err != nil {
-		return err
+		return result, err
 	}
 	for _, blobDir := range blobDirs {
 		if !blobDir.IsDir() {
@@ -55,23 +75,29 @@ func (o *OCIDir) Close(ctx context.Context, r ref.Ref) error {
 		}
 		digestFiles, err := os.ReadDir(path.Join(blobsPath, blobDir.Name()))
 		if err != nil {
-			return err
+			return result, err
 		}
 		for _, digestFile := range digestFiles {
 			digest := fmt.Sprintf("%s:%s", blobDir.Name(), digestFile.Name())
 			if !dl[digest] {
 				o.slog.Debug("ocidir garbage collect",
 					slog.String("digest", digest))
+				var size int64
+				if fi, err := digestFile.Info(); err == nil {
+					size = fi.Size()
+				}
 				// delete
 				err = os.Remove(path.Join(blobsPath, blobDir.Name(), digestFile.Name()))
 				if err != nil {
-					return fmt.Errorf("failed to delete %s: %w", path.Join(blobsPath, blobDir.Name(), digestFile.Name()), err)
+					return result, fmt.Errorf("failed to delete %s: %w", path.Join(blobsPath, blobDir.Name(), digestFile.Name()), err)
 				}
+				result.Blobs++
+				result.Bytes += size
 			}
 		}
 	}
 	delete(o.modRefs, r.Path)
-	return nil
+	return result, nil
 }
 
 func (o *OCIDir) closeProcManifest(ctx context.Context, r ref.Ref, m manifest.Manifest, dl *map[string]bool) error {
@@ -83,6 +109,9 @@ func (o *OCIDir) closeProcManifest(ctx context.Context, r ref.Ref, m manifest.Ma
 		}
 		for _, cur := range ml {
 			cr := r.SetDigest(cur.Digest.String())
+			if (*dl)[cr.Digest] {
+				continue // skip manifests that have already been processed
+			}
 			(*dl)[cr.Digest] = true
 			cm, err := o.manifestGet(ctx, cr)
 			if err != nil {
@@ -113,5 +142,21 @@ func (o *OCIDir) closeProcManifest(ctx context.Context, r ref.Ref, m manifest.Ma
 			(*dl)[layer.Digest.String()] = true
 		}
 	}
+	if ms, ok := m.(manifest.Subjecter); ok {
+		// keep the subject of a referrer and any content it references
+		sd, err := ms.GetSubject()
+		if err == nil && sd != nil && sd.Digest != "" && !(*dl)[sd.Digest.String()] {
+			sr := r.SetDigest(sd.Digest.String())
+			(*dl)[sr.Digest] = true
+			sm, err := o.manifestGet(ctx, sr)
+			if err != nil {
+				o.slog.Debug("could not retrieve subject",
+					slog.String("ref", sr.CommonName()),
+					slog.String("err", err.Error()))
+				return nil
+			}
+			return o.closeProcManifest(ctx, sr, sm, dl)
+		}
+	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package ocidir
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io/fs"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -87,3 +89,97 @@ func TestClose(t *testing.T) {
 		}
 	}
 }
+
+func TestGC(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	err := copyfs.Copy(filepath.Join(tempDir, "testrepo"), "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to setup tempDir: %v", err)
+	}
+	o := New(WithGC(false))
+	rStr := "ocidir://" + tempDir + "/testrepo:v2"
+	r, err := ref.New(rStr)
+	if err != nil {
+		t.Fatalf("failed to parse ref %s: %v", rStr, err)
+	}
+	rlBefore, err := o.ReferrerList(ctx, r.SetDigest(mustDigest(ctx, t, o, r)))
+	if err != nil || len(rlBefore.Descriptors) == 0 {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	// clean up any unreferenced blobs from the test data
+	if _, err := o.GC(ctx, r); err != nil {
+		t.Fatalf("failed to run GC: %v", err)
+	}
+	// add an unreferenced blob
+	orphan := []byte("unreferenced blob for gc")
+	dOrphan, err := o.BlobPut(ctx, r, descriptor.Descriptor{}, bytes.NewReader(orphan))
+	if err != nil {
+		t.Fatalf("failed to put blob: %v", err)
+	}
+	fileOrphan := filepath.Join(tempDir, "testrepo/blobs", dOrphan.Digest.Algorithm().String(), dOrphan.Digest.Encoded())
+	if _, err := os.Stat(fileOrphan); err != nil {
+		t.Fatalf("blob not created: %v", err)
+	}
+	t.Run("locked", func(t *testing.T) {
+		o.GCLock(r)
+		_, err := o.GC(ctx, r)
+		o.GCUnlock(r)
+		if !errors.Is(err, errs.ErrUnavailable) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrUnavailable, err)
+		}
+		if _, err := os.Stat(fileOrphan); err != nil {
+			t.Errorf("blob deleted while locked: %v", err)
+		}
+	})
+	t.Run("collect", func(t *testing.T) {
+		result, err := o.GC(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to run GC: %v", err)
+		}
+		if result.Blobs != 1 || result.Bytes != int64(len(orphan)) {
+			t.Errorf("unexpected result, expected 1 blob and %d bytes, received %d blobs and %d bytes", len(orphan), result.Blobs, result.Bytes)
+		}
+		if _, err := os.Stat(fileOrphan); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("blob was not deleted by GC: %v", err)
+		}
+		// referrers stored with a fallback tag are preserved
+		rlAfter, err := o.ReferrerList(ctx, r.SetDigest(mustDigest(ctx, t, o, r)))
+		if err != nil || len(rlAfter.Descriptors) != len(rlBefore.Descriptors) {
+			t.Fatalf("referrers changed after GC: %v", err)
+		}
+		for _, d := range rlAfter.Descriptors {
+			m, err := o.ManifestGet(ctx, r.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Errorf("failed to get referrer %s: %v", d.Digest.String(), err)
+				continue
+			}
+			if mi, ok := m.(manifest.Imager); ok {
+				layers, err := mi.GetLayers()
+				if err != nil {
+					t.Fatalf("failed to get layers: %v", err)
+				}
+				for _, l := range layers {
+					if _, err := o.BlobHead(ctx, r, l); err != nil {
+						t.Errorf("referrer layer %s was deleted: %v", l.Digest.String(), err)
+					}
+				}
+			}
+		}
+		// a second GC removes nothing
+		result, err = o.GC(ctx, r)
+		if err != nil || result.Blobs != 0 || result.Bytes != 0 {
+			t.Errorf("unexpected second GC, result %v, err %v", result, err)
+		}
+	})
+}
+
+func mustDigest(ctx context.Context, t *testing.T, o *OCIDir, r ref.Ref) string {
+	t.Helper()
+	m, err := o.ManifestHead(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to head %s: %v", r.CommonName(), err)
+	}
+	return m.GetDescriptor().Digest.String()
+}
@@ -34,7 +34,11 @@ func (o *OCIDir) referrerList(ctx context.Context, rSubject ref.Ref, opts ...sch
 		r = rSubject.SetDigest(rSubject.Digest)
 	}
 	rl := referrer.ReferrerList{
-		Tags: []string{},
+		Method: referrer.MethodFallbackTag,
+		Tags:   []string{},
+	}
+	if config.SrcRepo.IsSet() {
+		rl.Method = referrer.MethodExternal
 	}
 	if rSubject.Digest == "" {
 		return rl, fmt.Errorf("digest required to query referrers %s", rSubject.CommonName())
@@ -52,6 +52,10 @@ func (o *OCIDir) tagDelete(_ context.Context, r ref.Ref) error {
 
 // TagList returns a list of tags from the repository
 func (o *OCIDir) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
+	var config scheme.TagConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
 	// get index
 	index, err := o.readIndex(r, false)
 	if err != nil {
@@ -91,5 +95,11 @@ func (o *OCIDir) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts)
 	if err != nil {
 		return nil, err
 	}
+	if config.DigestTags != scheme.TagDigestAll {
+		err = t.Filter(func(t string) bool { return scheme.TagMatch(config, t) })
+		if err != nil {
+			return nil, err
+		}
+	}
 	return t, nil
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/regclient/regclient/internal/copyfs"
+	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -43,6 +45,49 @@ func TestTag(t *testing.T) {
 		}
 	})
 
+	t.Run("TagList digest filter", func(t *testing.T) {
+		metaTag := "sha256-190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09.6fe828b32b9b4572.meta"
+		tl, err := o.TagList(ctx, r, scheme.WithTagDigestFilter(scheme.TagDigestExclude))
+		if err != nil {
+			t.Fatalf("failed to retrieve tag list: %v", err)
+		}
+		tlTags, err := tl.GetTags()
+		if err != nil {
+			t.Fatalf("failed to get tags: %v", err)
+		}
+		if !inListStr("v1", tlTags) {
+			t.Errorf("missing tag: v1")
+		}
+		for _, tag := range tlTags {
+			if strings.HasPrefix(tag, "sha256-") {
+				t.Errorf("digest tag not excluded: %s", tag)
+			}
+		}
+		raw, err := tl.RawBody()
+		if err != nil {
+			t.Fatalf("failed to get raw body: %v", err)
+		}
+		if strings.Contains(string(raw), metaTag) {
+			t.Errorf("digest tag not excluded from the raw index: %s", metaTag)
+		}
+		tl, err = o.TagList(ctx, r, scheme.WithTagDigestFilter(scheme.TagDigestOnly))
+		if err != nil {
+			t.Fatalf("failed to retrieve tag list: %v", err)
+		}
+		tlTags, err = tl.GetTags()
+		if err != nil {
+			t.Fatalf("failed to get tags: %v", err)
+		}
+		if !inListStr(metaTag, tlTags) {
+			t.Errorf("missing tag: %s", metaTag)
+		}
+		for _, tag := range tlTags {
+			if !strings.HasPrefix(tag, "sha256-") {
+				t.Errorf("tag not excluded: %s", tag)
+			}
+		}
+	})
+
 	t.Run("TagDelete", func(t *testing.T) {
 		keepTags := []string{"a2", "ai", "b1", "b2", "b3", "child", "loop", "v2", "v3"}
 		rmTags := []string{"mirror", "a1", "v1"}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
+	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
@@ -42,6 +43,9 @@ func (reg *Reg) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descript
 	}
 	resp, err := reg.reghttp.Do(ctx, req)
 	if err != nil {
+		if resp != nil && resp.HTTPResponse() != nil && resp.HTTPResponse().StatusCode == http.StatusMethodNotAllowed {
+			return fmt.Errorf("failed to delete blob, digest %s, ref %s: %w%.0w", d.Digest.String(), r.CommonName(), err, errs.ErrUnsupported)
+		}
 		return fmt.Errorf("failed to delete blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
 	}
 	if resp.HTTPResponse().StatusCode != 202 {
@@ -101,6 +105,73 @@ func (reg *Reg) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descriptor)
 	return b, nil
 }
 
+// BlobGetResume retrieves a blob starting at an offset, used to resume a download after a restart.
+// The descriptor must include the size and digest of the blob.
+// To verify the digest, the first offset bytes must be passed to [blob.BReader.HashPrefix] before reading.
+func (reg *Reg) BlobGetResume(ctx context.Context, r ref.Ref, d descriptor.Descriptor, offset int64) (blob.Reader, error) {
+	if offset == 0 {
+		return reg.BlobGet(ctx, r, d)
+	}
+	if d.Size <= 0 || offset < 0 || offset >= d.Size {
+		return nil, fmt.Errorf("invalid offset %d to resume blob %s with size %d", offset, d.Digest.String(), d.Size)
+	}
+	// verify the registry supports range requests before sending the ranged request
+	bh, err := reg.BlobHead(ctx, r, d)
+	if err != nil {
+		return nil, err
+	}
+	acceptRanges := bh.RawHeaders().Get("Accept-Ranges")
+	_ = bh.Close()
+	if acceptRanges != "bytes" {
+		return nil, fmt.Errorf("registry does not support range requests to resume blob, digest %s, ref %s, accept-ranges %q%.0w", d.Digest.String(), r.CommonName(), acceptRanges, errs.ErrUnsupported)
+	}
+	req := &reghttp.Req{
+		MetaKind:   reqmeta.Blob,
+		Host:       r.Registry,
+		Method:     "GET",
+		Repository: r.Repository,
+		Path:       "blobs/" + d.Digest.String(),
+		ExpectLen:  d.Size,
+		ReadOffset: offset,
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
+	}
+	if resp.HTTPResponse().StatusCode != http.StatusPartialContent {
+		_ = resp.Close()
+		return nil, fmt.Errorf("failed to resume blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+	}
+	err = blobContentRangeCheck(resp.HTTPResponse().Header.Get("Content-Range"), offset, d.Size)
+	if err != nil {
+		_ = resp.Close()
+		return nil, fmt.Errorf("failed to resume blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
+	}
+
+	b := blob.NewReader(
+		blob.WithRef(r),
+		blob.WithReader(resp),
+		blob.WithDesc(d),
+		blob.WithResp(resp.HTTPResponse()),
+		blob.WithOffset(offset),
+	)
+	return b, nil
+}
+
+// blobContentRangeCheck verifies a Content-Range header matches the requested offset to the end of the blob.
+func blobContentRangeCheck(cr string, offset, size int64) error {
+	var start, end int64
+	var total string
+	_, err := fmt.Sscanf(cr, "bytes %d-%d/%s", &start, &end, &total)
+	if err != nil {
+		return fmt.Errorf("failed to parse content-range %q: %w", cr, err)
+	}
+	if start != offset || end != size-1 || (total != "*" && total != strconv.FormatInt(size, 10)) {
+		return fmt.Errorf("unexpected content-range %q, expected bytes %d-%d/%d", cr, offset, size-1, size)
+	}
+	return nil
+}
+
 // BlobHead is used to verify if a blob exists and is accessible
 func (reg *Reg) BlobHead(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.Reader, error) {
 	// build/send request
@@ -164,7 +235,10 @@ func (reg *Reg) BlobMount(ctx context.Context, rSrc ref.Ref, rTgt ref.Ref, d des
 // Descriptor is optional, leave size and digest to zero value if unknown.
 // Reader must also be an [io.Seeker] to support chunked upload fallback.
 //
-// This will attempt an anonymous blob mount first which some registries may support.
+// When the host enables the "blobPostDigest" API option, a known descriptor is first sent
+// in a single POST request, falling back to the steps below if the registry rejects it.
+// This will attempt an anonymous blob mount first which some registries may support,
+// unless the context was created with [scheme.WithBlobNoMount].
 // It will then try doing a full put of the blob without chunking (most widely supported).
 // If the full put fails, it will fall back to a chunked upload (useful for flaky networks).
 func (reg *Reg) BlobPut(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader) (descriptor.Descriptor, error) {
@@ -175,9 +249,38 @@ func (reg *Reg) BlobPut(ctx context.Context, r ref.Ref, d descriptor.Descriptor,
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
 	}
+	host := reg.hostGet(r.Registry)
+	maxPut := host.BlobMax
+	if maxPut == 0 {
+		maxPut = reg.blobMaxPut
+	}
+
+	// attempt a single request upload when enabled for the host
+	if validDesc && (maxPut <= 0 || d.Size <= maxPut) && host.APIOpts != nil {
+		postDigest, errP := strconv.ParseBool(host.APIOpts["blobPostDigest"])
+		if errP == nil && postDigest {
+			err = reg.blobPutPostDigest(ctx, r, d, rdr)
+			if err == nil {
+				return d, nil
+			}
+			// seek back to the start to fall back to a POST then PUT
+			rdrSeek, ok := rdr.(io.ReadSeeker)
+			if !ok {
+				return d, err
+			}
+			offset, errR := rdrSeek.Seek(0, io.SeekStart)
+			if errR != nil || offset != 0 {
+				return d, err
+			}
+			reg.slog.Debug("Single request blob upload failed, falling back to POST and PUT",
+				slog.String("ref", r.CommonName()),
+				slog.String("digest", d.Digest.String()),
+				slog.String("err", err.Error()))
+		}
+	}
 
 	// attempt an anonymous blob mount
-	if validDesc {
+	if validDesc && !scheme.BlobNoMount(ctx) {
 		putURL, _, err = reg.blobMount(ctx, r, d, ref.Ref{})
 		if err == nil {
 			return d, nil
@@ -195,15 +298,8 @@ func (reg *Reg) BlobPut(ctx context.Context, r ref.Ref, d descriptor.Descriptor,
 	}
 	// send upload as one-chunk
 	tryPut := validDesc
-	if tryPut {
-		host := reg.hostGet(r.Registry)
-		maxPut := host.BlobMax
-		if maxPut == 0 {
-			maxPut = reg.blobMaxPut
-		}
-		if maxPut > 0 && d.Size > maxPut {
-			tryPut = false
-		}
+	if tryPut && maxPut > 0 && d.Size > maxPut {
+		tryPut = false
 	}
 	if tryPut {
 		err = reg.blobPutUploadFull(ctx, r, d, putURL, rdr)
@@ -372,6 +468,42 @@ func (reg *Reg) blobMount(ctx context.Context, rTgt ref.Ref, d descriptor.Descri
 	return nil, "", fmt.Errorf("failed to mount blob, digest %s, ref %s: %w", d.Digest.String(), rTgt.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
 }
 
+// blobPutPostDigest sends the full blob with the POST request that would otherwise create the upload session.
+func (reg *Reg) blobPutPostDigest(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader) error {
+	q := url.Values{}
+	q.Set("digest", d.Digest.String())
+	header := http.Header{
+		"Content-Type": {"application/octet-stream"},
+	}
+	req := &reghttp.Req{
+		MetaKind:   reqmeta.Blob,
+		Host:       r.Registry,
+		NoMirrors:  true,
+		Method:     "POST",
+		Repository: r.Repository,
+		Path:       "blobs/uploads/",
+		Query:      q,
+		BodyFunc:   blobBodyFunc(d, rdr),
+		BodyLen:    d.Size,
+		Headers:    header,
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to send blob (post), digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
+	}
+	defer resp.Close()
+	if resp.HTTPResponse().StatusCode != 201 {
+		// a registry without support may have started an upload session instead
+		if location := resp.HTTPResponse().Header.Get("Location"); resp.HTTPResponse().StatusCode == 202 && location != "" {
+			if putURL, errL := resp.HTTPResponse().Request.URL.Parse(location); errL == nil {
+				_ = reg.blobUploadCancel(ctx, r, putURL)
+			}
+		}
+		return fmt.Errorf("failed to send blob (post), digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+	}
+	return nil
+}
+
 func (reg *Reg) blobPutUploadFull(ctx context.Context, r ref.Ref, d descriptor.Descriptor, putURL *url.URL, rdr io.Reader) error {
 	// append digest to request to use the monolithic upload option
 	if putURL.RawQuery != "" {
@@ -380,28 +512,6 @@ func (reg *Reg) blobPutUploadFull(ctx context.Context, r ref.Ref, d descriptor.D
 		putURL.RawQuery = "digest=" + url.QueryEscape(d.Digest.String())
 	}
 
-	// make a reader function for the blob
-	readOnce := false
-	bodyFunc := func() (io.ReadCloser, error) {
-		// handle attempt to reuse blob reader (e.g. on a connection retry or fallback)
-		if readOnce {
-			rdrSeek, ok := rdr.(io.ReadSeeker)
-			if !ok {
-				return nil, fmt.Errorf("blob source is not a seeker%.0w", errs.ErrNotRetryable)
-			}
-			_, err := rdrSeek.Seek(0, io.SeekStart)
-			if err != nil {
-				return nil, fmt.Errorf("seek on blob source failed: %w%.0w", err, errs.ErrNotRetryable)
-			}
-		}
-		readOnce = true
-		return io.NopCloser(rdr), nil
-	}
-	// special case for the empty blob
-	if d.Size == 0 && d.Digest == zeroDig {
-		bodyFunc = nil
-	}
-
 	// build/send request
 	header := http.Header{
 		"Content-Type": {"application/octet-stream"},
@@ -412,7 +522,7 @@ func (reg *Reg) blobPutUploadFull(ctx context.Context, r ref.Ref, d descriptor.D
 		Method:     "PUT",
 		Repository: r.Repository,
 		DirectURL:  putURL,
-		BodyFunc:   bodyFunc,
+		BodyFunc:   blobBodyFunc(d, rdr),
 		BodyLen:    d.Size,
 		Headers:    header,
 		NoMirrors:  true,
@@ -617,6 +727,30 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, d descripto
 	return d, nil
 }
 
+// blobBodyFunc returns a function to read the full blob, seeking back to the start when the request is retried.
+func blobBodyFunc(d descriptor.Descriptor, rdr io.Reader) func() (io.ReadCloser, error) {
+	// special case for the empty blob
+	if d.Size == 0 && d.Digest == zeroDig {
+		return nil
+	}
+	readOnce := false
+	return func() (io.ReadCloser, error) {
+		// handle attempt to reuse blob reader (e.g. on a connection retry or fallback)
+		if readOnce {
+			rdrSeek, ok := rdr.(io.ReadSeeker)
+			if !ok {
+				return nil, fmt.Errorf("blob source is not a seeker%.0w", errs.ErrNotRetryable)
+			}
+			_, err := rdrSeek.Seek(0, io.SeekStart)
+			if err != nil {
+				return nil, fmt.Errorf("seek on blob source failed: %w%.0w", err, errs.ErrNotRetryable)
+			}
+		}
+		readOnce = true
+		return io.NopCloser(rdr), nil
+	}
+}
+
 // blobUploadCancel stops an upload, releasing resources on the server.
 func (reg *Reg) blobUploadCancel(ctx context.Context, r ref.Ref, putURL *url.URL) error {
 	if putURL == nil {
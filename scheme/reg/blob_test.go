@@ -12,6 +12,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +27,98 @@ import (
 	"github.com/regclient/regclient/types/ref"
 )
 
+func TestBlobDelete(t *testing.T) {
+	t.Parallel()
+	blobRepo := "/proj/repo"
+	readOnlyRepo := "/proj/read-only"
+	ctx := context.Background()
+	d1 := digest.FromBytes([]byte("delete me"))
+	dMissing := digest.FromBytes([]byte("missing"))
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "DELETE for d1",
+				Method: "DELETE",
+				Path:   "/v2" + blobRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusAccepted,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "DELETE for missing",
+				Method: "DELETE",
+				Path:   "/v2" + blobRepo + "/blobs/" + dMissing.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "DELETE not allowed",
+				Method: "DELETE",
+				Path:   "/v2" + readOnlyRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusMethodNotAllowed,
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+
+	t.Run("Accepted", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		err = reg.BlobDelete(ctx, r, descriptor.Descriptor{Digest: d1})
+		if err != nil {
+			t.Errorf("Failed running BlobDelete: %v", err)
+		}
+	})
+	t.Run("Missing", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		err = reg.BlobDelete(ctx, r, descriptor.Descriptor{Digest: dMissing})
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Error does not match \"ErrNotFound\": %v", err)
+		}
+	})
+	t.Run("Not allowed", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + readOnlyRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		err = reg.BlobDelete(ctx, r, descriptor.Descriptor{Digest: d1})
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("Error does not match \"ErrUnsupported\": %v", err)
+		}
+	})
+}
+
 func TestBlobGet(t *testing.T) {
 	t.Parallel()
 	blobRepo := "/proj/repo"
@@ -397,6 +491,237 @@ func TestBlobGet(t *testing.T) {
 	})
 }
 
+func TestBlobGetResume(t *testing.T) {
+	t.Parallel()
+	blobRepo := "/proj/repo"
+	noRangeRepo := "/proj/no-range"
+	badRangeRepo := "/proj/bad-range"
+	shortReadRepo := "/proj/short-read"
+	ctx := context.Background()
+	seed := time.Now().UTC().Unix()
+	t.Logf("Using seed %d", seed)
+	blobLen := 1024
+	offset := 300
+	d1, blob1 := reqresp.NewRandomBlob(blobLen, seed)
+	d1Desc := descriptor.Descriptor{
+		MediaType: mediatype.OCI1Layer,
+		Digest:    d1,
+		Size:      int64(blobLen),
+	}
+	headEntry := func(repo string, headers http.Header) reqresp.ReqResp {
+		return reqresp.ReqResp{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "HEAD for " + repo,
+				Method: "HEAD",
+				Path:   "/v2" + repo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: headers,
+			},
+		}
+	}
+	rangeEntry := func(repo, contentRange string) reqresp.ReqResp {
+		return reqresp.ReqResp{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET range for " + repo,
+				Method: "GET",
+				Path:   "/v2" + repo + "/blobs/" + d1.String(),
+				Headers: http.Header{
+					"Range": {fmt.Sprintf("bytes=%d-%d", offset, blobLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusPartialContent,
+				Body:   blob1[offset:],
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobLen-offset)},
+					"Content-Range":  {contentRange},
+					"Content-Type":   {"application/octet-stream"},
+				},
+			},
+		}
+	}
+	rangeHeaders := http.Header{
+		"Accept-Ranges":         {"bytes"},
+		"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+		"Docker-Content-Digest": {d1.String()},
+	}
+	rrs := []reqresp.ReqResp{
+		headEntry(blobRepo, rangeHeaders),
+		rangeEntry(blobRepo, fmt.Sprintf("bytes %d-%d/%d", offset, blobLen-1, blobLen)),
+		headEntry(noRangeRepo, http.Header{
+			"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+			"Docker-Content-Digest": {d1.String()},
+		}),
+		headEntry(badRangeRepo, rangeHeaders),
+		rangeEntry(badRangeRepo, fmt.Sprintf("bytes %d-%d/%d", 0, blobLen-offset-1, blobLen)),
+		headEntry(shortReadRepo, rangeHeaders),
+	}
+	// each response stops early, the retry must continue from the current offset rather than the resume offset
+	shortStarts := []int{offset, offset + 200, offset + 400}
+	for i, start := range shortStarts {
+		end := blobLen
+		if i < len(shortStarts)-1 {
+			end = shortStarts[i+1]
+		}
+		rrs = append(rrs, reqresp.ReqResp{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   fmt.Sprintf("GET short read from %d", start),
+				Method: "GET",
+				Path:   "/v2" + shortReadRepo + "/blobs/" + d1.String(),
+				Headers: http.Header{
+					"Range": {fmt.Sprintf("bytes=%d-%d", start, blobLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusPartialContent,
+				Body:   blob1[start:end],
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobLen-start)},
+					"Content-Range":  {fmt.Sprintf("bytes %d-%d/%d", start, blobLen-1, blobLen)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+			},
+		})
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+
+	t.Run("Resume", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err != nil {
+			t.Fatalf("Failed running BlobGetResume: %v", err)
+		}
+		defer br.Close()
+		err = br.HashPrefix(bytes.NewReader(blob1[:offset]))
+		if err != nil {
+			t.Fatalf("Failed hashing prefix: %v", err)
+		}
+		brBlob, err := io.ReadAll(br)
+		if err != nil {
+			t.Fatalf("Failed reading blob: %v", err)
+		}
+		if !bytes.Equal(blob1[offset:], brBlob) {
+			t.Errorf("Blob does not match")
+		}
+	})
+	t.Run("Short reads", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + shortReadRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err != nil {
+			t.Fatalf("Failed running BlobGetResume: %v", err)
+		}
+		defer br.Close()
+		err = br.HashPrefix(bytes.NewReader(blob1[:offset]))
+		if err != nil {
+			t.Fatalf("Failed hashing prefix: %v", err)
+		}
+		brBlob, err := io.ReadAll(br)
+		if err != nil {
+			t.Fatalf("Failed reading blob: %v", err)
+		}
+		if !bytes.Equal(blob1[offset:], brBlob) {
+			t.Errorf("Blob does not match")
+		}
+	})
+	t.Run("Missing prefix", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err != nil {
+			t.Fatalf("Failed running BlobGetResume: %v", err)
+		}
+		defer br.Close()
+		_, err = io.ReadAll(br)
+		if !errors.Is(err, errs.ErrDigestMismatch) {
+			t.Errorf("Error does not match \"ErrDigestMismatch\": %v", err)
+		}
+	})
+	t.Run("Invalid prefix", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err != nil {
+			t.Fatalf("Failed running BlobGetResume: %v", err)
+		}
+		defer br.Close()
+		err = br.HashPrefix(bytes.NewReader(blob1[1 : offset+1]))
+		if err != nil {
+			t.Fatalf("Failed hashing prefix: %v", err)
+		}
+		_, err = io.ReadAll(br)
+		if !errors.Is(err, errs.ErrDigestMismatch) {
+			t.Errorf("Error does not match \"ErrDigestMismatch\": %v", err)
+		}
+	})
+	t.Run("No range support", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + noRangeRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err == nil {
+			_ = br.Close()
+			t.Fatalf("Unexpected success running BlobGetResume")
+		}
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("Error does not match \"ErrUnsupported\": %v", err)
+		}
+	})
+	t.Run("Invalid content range", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + badRangeRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(offset))
+		if err == nil {
+			_ = br.Close()
+			t.Fatalf("Unexpected success running BlobGetResume")
+		}
+	})
+	t.Run("Invalid offset", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := reg.BlobGetResume(ctx, r, d1Desc, int64(blobLen))
+		if err == nil {
+			_ = br.Close()
+			t.Fatalf("Unexpected success running BlobGetResume")
+		}
+	})
+}
+
 func TestBlobPut(t *testing.T) {
 	t.Parallel()
 	blobRepo := "/proj/repo"
@@ -1562,3 +1887,110 @@ func TestBlobPut(t *testing.T) {
 
 	// TODO: test failed mount (blobGetUploadURL)
 }
+
+func TestBlobPutPostDigest(t *testing.T) {
+	t.Parallel()
+	seed := time.Now().UTC().Unix()
+	t.Logf("Using seed %d", seed)
+	d1, blob1 := reqresp.NewRandomBlob(1024, seed)
+	var mu sync.Mutex
+	reqs := map[string][]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		repo := strings.Split(strings.TrimPrefix(req.URL.Path, "/v2/"), "/blobs/")[0]
+		mu.Lock()
+		reqs[repo] = append(reqs[repo], req.Method+" "+req.URL.Path)
+		mu.Unlock()
+		body, _ := io.ReadAll(req.Body)
+		switch {
+		case req.Method == "POST" && req.URL.Query().Get("digest") != "" && repo == "proj/post":
+			if req.URL.Query().Get("digest") != d1.String() || !bytes.Equal(body, blob1) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/"+d1.String())
+			w.WriteHeader(http.StatusCreated)
+		case req.Method == "POST" && req.URL.Query().Get("digest") != "":
+			// ignore the body and start an upload session
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/ignored")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == "POST":
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/session")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == "DELETE" && strings.HasSuffix(req.URL.Path, "/uploads/ignored"):
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "/uploads/session") && req.URL.Query().Get("digest") == d1.String() && bytes.Equal(body, blob1):
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts:  map[string]string{"blobPostDigest": "true"},
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+	tt := []struct {
+		name   string
+		repo   string
+		expect []string
+	}{
+		{
+			name:   "single request",
+			repo:   "proj/post",
+			expect: []string{"POST /v2/proj/post/blobs/uploads/"},
+		},
+		{
+			name: "fallback",
+			repo: "proj/fallback",
+			expect: []string{
+				"POST /v2/proj/fallback/blobs/uploads/",
+				"DELETE /v2/proj/fallback/blobs/uploads/ignored",
+				"POST /v2/proj/fallback/blobs/uploads/",
+				"PUT /v2/proj/fallback/blobs/uploads/session",
+			},
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			r, err := ref.New(tsHost + "/" + tc.repo)
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			dp, err := reg.BlobPut(ctx, r, descriptor.Descriptor{Digest: d1, Size: int64(len(blob1))}, bytes.NewReader(blob1))
+			if err != nil {
+				t.Fatalf("failed to put blob: %v", err)
+			}
+			if dp.Digest != d1 || dp.Size != int64(len(blob1)) {
+				t.Errorf("unexpected descriptor: %v", dp)
+			}
+			mu.Lock()
+			received := reqs[tc.repo]
+			mu.Unlock()
+			if len(received) != len(tc.expect) {
+				t.Fatalf("unexpected requests, expected %v, received %v", tc.expect, received)
+			}
+			for i := range tc.expect {
+				if received[i] != tc.expect[i] {
+					t.Errorf("unexpected request %d, expected %s, received %s", i, tc.expect[i], received[i])
+				}
+			}
+		})
+	}
+}
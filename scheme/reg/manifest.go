@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/opencontainers/go-digest"
 
@@ -16,6 +17,8 @@ import (
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
@@ -84,17 +87,63 @@ func (reg *Reg) ManifestDelete(ctx context.Context, r ref.Ref, opts ...scheme.Ma
 
 // ManifestGet retrieves a manifest from the registry
 func (reg *Reg) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
-	var tagOrDigest string
 	if r.Digest != "" {
 		rCache := r.SetDigest(r.Digest)
 		if m, err := reg.cacheMan.Get(rCache); err == nil {
 			return m, nil
 		}
+	}
+	rawBody, header, err := reg.manifestGetBody(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := manifest.New(
+		manifest.WithRef(r),
+		manifest.WithHeader(header),
+		manifest.WithRaw(rawBody),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rCache := r.SetDigest(m.GetDescriptor().Digest.String())
+	reg.cacheMan.Set(rCache, m)
+	return m, nil
+}
+
+// ManifestGetRaw retrieves the manifest bytes and descriptor without parsing the manifest.
+func (reg *Reg) ManifestGetRaw(ctx context.Context, r ref.Ref) ([]byte, descriptor.Descriptor, error) {
+	rawBody, header, err := reg.manifestGetBody(ctx, r)
+	if err != nil {
+		return nil, descriptor.Descriptor{}, err
+	}
+	d := descriptor.Descriptor{
+		MediaType: mediatype.Base(header.Get("Content-Type")),
+		Size:      int64(len(rawBody)),
+		Digest:    digest.Canonical.FromBytes(rawBody),
+	}
+	if r.Digest != "" {
+		dr, err := digest.Parse(r.Digest)
+		if err != nil {
+			return nil, descriptor.Descriptor{}, fmt.Errorf("failed to parse digest %s: %w", r.Digest, err)
+		}
+		d.Digest = dr.Algorithm().FromBytes(rawBody)
+		if d.Digest != dr {
+			return nil, descriptor.Descriptor{}, fmt.Errorf("manifest digest mismatch, expected %s, computed %s%.0w", dr.String(), d.Digest.String(), errs.ErrDigestMismatch)
+		}
+	}
+	return rawBody, d, nil
+}
+
+// manifestGetBody sends the manifest GET request and returns the body and headers.
+func (reg *Reg) manifestGetBody(ctx context.Context, r ref.Ref) ([]byte, http.Header, error) {
+	var tagOrDigest string
+	if r.Digest != "" {
 		tagOrDigest = r.Digest
 	} else if r.Tag != "" {
 		tagOrDigest = r.Tag
 	} else {
-		return nil, fmt.Errorf("reference missing tag and digest: %s%.0w", r.CommonName(), errs.ErrMissingTagOrDigest)
+		return nil, nil, fmt.Errorf("reference missing tag and digest: %s%.0w", r.CommonName(), errs.ErrMissingTagOrDigest)
 	}
 
 	// build/send request
@@ -119,17 +168,17 @@ func (reg *Reg) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest,
 	}
 	resp, err := reg.reghttp.Do(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), err)
+		return nil, nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), err)
 	}
 	defer resp.Close()
 	if resp.HTTPResponse().StatusCode != 200 {
-		return nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+		return nil, nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
 	}
 
 	// limit length
 	size, _ := strconv.Atoi(resp.HTTPResponse().Header.Get("Content-Length"))
 	if size > 0 && reg.manifestMaxPull > 0 && int64(size) > reg.manifestMaxPull {
-		return nil, fmt.Errorf("manifest too large, received %d, limit %d: %s%.0w", size, reg.manifestMaxPull, r.CommonName(), errs.ErrSizeLimitExceeded)
+		return nil, nil, fmt.Errorf("manifest too large, received %d, limit %d: %s%.0w", size, reg.manifestMaxPull, r.CommonName(), errs.ErrSizeLimitExceeded)
 	}
 	rdr := &limitread.LimitRead{
 		Reader: resp,
@@ -139,24 +188,23 @@ func (reg *Reg) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest,
 	// read manifest
 	rawBody, err := io.ReadAll(rdr)
 	if err != nil {
-		return nil, fmt.Errorf("error reading manifest for %s: %w", r.CommonName(), err)
-	}
-
-	m, err := manifest.New(
-		manifest.WithRef(r),
-		manifest.WithHeader(resp.HTTPResponse().Header),
-		manifest.WithRaw(rawBody),
-	)
-	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("error reading manifest for %s: %w", r.CommonName(), err)
 	}
-	rCache := r.SetDigest(m.GetDescriptor().Digest.String())
-	reg.cacheMan.Set(rCache, m)
-	return m, nil
+	return rawBody, resp.HTTPResponse().Header, nil
 }
 
 // ManifestHead returns metadata on the manifest from the registry
 func (reg *Reg) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	return reg.manifestHead(ctx, r, "")
+}
+
+// ManifestHeadIfNoneMatch sends a manifest head request with an If-None-Match header.
+// When the registry reports the manifest is unchanged, [errs.ErrNotModified] is returned.
+func (reg *Reg) ManifestHeadIfNoneMatch(ctx context.Context, r ref.Ref, etag string) (manifest.Manifest, error) {
+	return reg.manifestHead(ctx, r, etag)
+}
+
+func (reg *Reg) manifestHead(ctx context.Context, r ref.Ref, etag string) (manifest.Manifest, error) {
 	// build the request
 	var tagOrDigest string
 	if r.Digest != "" {
@@ -183,6 +231,13 @@ func (reg *Reg) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest,
 			mediatype.OCI1Artifact,
 		},
 	}
+	if etag != "" {
+		// etags are quoted strings, registries typically use the digest as the value
+		if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, "W/") && etag != "*" {
+			etag = `"` + etag + `"`
+		}
+		headers.Set("If-None-Match", etag)
+	}
 	req := &reghttp.Req{
 		MetaKind:   reqmeta.Head,
 		Host:       r.Registry,
@@ -196,6 +251,9 @@ func (reg *Reg) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest,
 		return nil, fmt.Errorf("failed to request manifest head %s: %w", r.CommonName(), err)
 	}
 	defer resp.Close()
+	if etag != "" && resp.HTTPResponse().StatusCode == http.StatusNotModified {
+		return nil, fmt.Errorf("manifest unchanged %s: %w", r.CommonName(), errs.ErrNotModified)
+	}
 	if resp.HTTPResponse().StatusCode != 200 {
 		return nil, fmt.Errorf("failed to request manifest head %s: %w", r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
 	}
@@ -237,9 +295,18 @@ func (reg *Reg) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest,
 		return fmt.Errorf("manifest too large, calculated %d, limit %d: %s%.0w", len(mj), reg.manifestMaxPush, r.CommonName(), errs.ErrSizeLimitExceeded)
 	}
 
+	mc := scheme.ManifestConfig{}
+	for _, opt := range opts {
+		opt(&mc)
+	}
+
 	// build/send request
+	contentType := manifest.GetMediaType(m)
+	if mc.ContentType != "" {
+		contentType = mc.ContentType
+	}
 	headers := http.Header{
-		"Content-Type": []string{manifest.GetMediaType(m)},
+		"Content-Type": []string{contentType},
 	}
 	q := url.Values{}
 	if tagOrDigest == r.Tag && m.GetDescriptor().Digest.Algorithm() != digest.Canonical {
@@ -293,3 +360,77 @@ func (reg *Reg) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest,
 
 	return nil
 }
+
+// ManifestPutRaw streams the manifest bytes to a registry without parsing the manifest.
+// A push by tag from a seekable reader is verified against the descriptor before it is sent,
+// a push by digest is verified by the registry.
+// The referrers fallback tag is not updated for a manifest with a subject.
+func (reg *Reg) ManifestPutRaw(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader, opts ...scheme.ManifestOpts) error {
+	var tagOrDigest string
+	if r.Digest != "" {
+		tagOrDigest = r.Digest
+	} else if r.Tag != "" {
+		tagOrDigest = r.Tag
+	} else {
+		reg.slog.Warn("Manifest put requires a tag",
+			slog.String("ref", r.Reference))
+		return errs.ErrMissingTag
+	}
+
+	// limit length
+	if reg.manifestMaxPush > 0 && d.Size > reg.manifestMaxPush {
+		return fmt.Errorf("manifest too large, calculated %d, limit %d: %s%.0w", d.Size, reg.manifestMaxPush, r.CommonName(), errs.ErrSizeLimitExceeded)
+	}
+
+	// the registry only verifies the digest when pushing by digest, so verify seekable content before a push by tag
+	if rdrSeek, ok := rdr.(io.ReadSeeker); ok && tagOrDigest == r.Tag {
+		_, err := io.Copy(io.Discard, blob.NewReader(blob.WithDesc(d), blob.WithReader(rdrSeek)))
+		if err != nil {
+			return fmt.Errorf("failed to verify manifest %s: %w", r.CommonName(), err)
+		}
+		_, err = rdrSeek.Seek(0, io.SeekStart)
+		if err != nil {
+			return fmt.Errorf("failed to seek manifest %s: %w", r.CommonName(), err)
+		}
+	}
+
+	// build/send request
+	headers := http.Header{
+		"Content-Type": []string{d.MediaType},
+	}
+	q := url.Values{}
+	if tagOrDigest == r.Tag && d.Digest.Algorithm() != digest.Canonical {
+		// TODO(bmitch): EXPERIMENTAL parameter, registry support and OCI spec change needed
+		q.Add(paramManifestDigest, d.Digest.String())
+	}
+	req := &reghttp.Req{
+		MetaKind:   reqmeta.Manifest,
+		Host:       r.Registry,
+		NoMirrors:  true,
+		Method:     "PUT",
+		Repository: r.Repository,
+		Path:       "manifests/" + tagOrDigest,
+		Query:      q,
+		Headers:    headers,
+		BodyLen:    d.Size,
+		BodyFunc:   blobBodyFunc(d, rdr),
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to put manifest %s: %w", r.CommonName(), err)
+	}
+	err = resp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close request: %w", err)
+	}
+	if resp.HTTPResponse().StatusCode != 201 {
+		return fmt.Errorf("failed to put manifest %s: %w", r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+	}
+	if dRespStr := resp.HTTPResponse().Header.Get("Docker-Content-Digest"); dRespStr != "" && dRespStr != d.Digest.String() {
+		return fmt.Errorf("manifest digest mismatch, expected %s, registry returned %s%.0w", d.Digest.String(), dRespStr, errs.ErrDigestMismatch)
+	}
+	if subj := resp.HTTPResponse().Header.Get(OCISubjectHeader); subj != "" {
+		reg.cacheRL.Delete(r.SetDigest(subj))
+	}
+	return nil
+}
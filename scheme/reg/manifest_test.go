@@ -2,6 +2,7 @@ package reg
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -627,3 +629,133 @@ func TestManifest(t *testing.T) {
 		}
 	})
 }
+
+func TestManifestGzip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repoPath := "/proj"
+	tag := "gzip"
+	m := schema2.Manifest{
+		Versioned: schema2.ManifestSchemaVersion,
+		Config: descriptor.Descriptor{
+			MediaType: mediatype.Docker2ImageConfig,
+			Size:      8,
+			Digest:    digest.FromString("example1"),
+		},
+	}
+	mBody, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	mDigest := digest.FromBytes(mBody)
+	tagBody := []byte(`{"name":"proj","tags":["gzip","other"]}`)
+	gzipBytes := func(b []byte) []byte {
+		buf := &bytes.Buffer{}
+		gzw := gzip.NewWriter(buf)
+		_, _ = gzw.Write(b)
+		_ = gzw.Close()
+		return buf.Bytes()
+	}
+	var mu sync.Mutex
+	acceptEnc := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		switch r.URL.Path {
+		case "/v2" + repoPath + "/manifests/" + tag:
+			w.Header().Set("Content-Type", mediatype.Docker2Manifest)
+			w.Header().Set("Docker-Content-Digest", mDigest.String())
+			body = mBody
+		case "/v2" + repoPath + "/tags/list":
+			w.Header().Set("Content-Type", "application/json")
+			body = tagBody
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		acceptEnc = append(acceptEnc, r.Header.Get("Accept-Encoding"))
+		mu.Unlock()
+		// always encode the response, even when the client did not request it
+		body = gzipBytes(body)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tt := []struct {
+		name      string
+		enc       string
+		expectEnc string
+	}{
+		{
+			name:      "default",
+			expectEnc: "gzip",
+		},
+		{
+			name:      "gzip",
+			enc:       "gzip",
+			expectEnc: "gzip",
+		},
+		{
+			name:      "identity",
+			enc:       "identity",
+			expectEnc: "identity",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []Opts{WithConfigHosts(rcHosts), WithSlog(log)}
+			if tc.enc != "" {
+				opts = append(opts, WithAcceptEncoding(tc.enc))
+			}
+			reg := New(opts...)
+			mu.Lock()
+			acceptEnc = []string{}
+			mu.Unlock()
+			r, err := ref.New(tsHost + repoPath + ":" + tag)
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			mGet, err := reg.ManifestGet(ctx, r)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			if mGet.GetDescriptor().Digest != mDigest {
+				t.Errorf("unexpected digest, expected %s, received %s", mDigest, mGet.GetDescriptor().Digest)
+			}
+			if mGet.GetDescriptor().Size != int64(len(mBody)) {
+				t.Errorf("unexpected size, expected %d, received %d", len(mBody), mGet.GetDescriptor().Size)
+			}
+			raw, err := mGet.RawBody()
+			if err != nil || !bytes.Equal(raw, mBody) {
+				t.Errorf("unexpected body, received %s, err %v", raw, err)
+			}
+			tl, err := reg.TagList(ctx, r)
+			if err != nil {
+				t.Fatalf("failed to list tags: %v", err)
+			}
+			tags, err := tl.GetTags()
+			if err != nil || len(tags) != 2 || tags[0] != "gzip" {
+				t.Errorf("unexpected tags: %v, err %v", tags, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, enc := range acceptEnc {
+				if enc != tc.expectEnc {
+					t.Errorf("unexpected Accept-Encoding, expected %s, received %s", tc.expectEnc, enc)
+				}
+			}
+		})
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/url"
 
 	"github.com/regclient/regclient/internal/httplink"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
@@ -63,7 +65,7 @@ func (reg *Reg) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ...sche
 				reg.featureSet("referrer", r.Registry, r.Repository, err == nil)
 			}
 			if err == nil {
-				if config.MatchOpt.ArtifactType == "" {
+				if config.MatchOpt.ArtifactType == "" && len(config.MatchOpt.ArtifactTypes) == 0 {
 					// only cache if successful and artifactType is not filtered
 					reg.cacheRL.Set(r, rl)
 				}
@@ -81,6 +83,12 @@ func (reg *Reg) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ...sche
 	rl.Subject = rSubject
 	if config.SrcRepo.IsSet() {
 		rl.Source = config.SrcRepo
+		if err == nil {
+			reg.slog.Debug("Referrers listed from external repository",
+				slog.String("source", config.SrcRepo.CommonName()),
+				slog.String("method", string(rl.Method)))
+		}
+		rl.Method = referrer.MethodExternal
 	}
 	if err != nil {
 		return rl, err
@@ -91,6 +99,75 @@ func (reg *Reg) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ...sche
 	return rl, nil
 }
 
+// ReferrerListPage returns the referrers to a given reference one page at a time.
+// Each page is passed to fn after client side filters are applied, and returning false from fn stops the listing.
+// The reference must include the digest. Use [regclient.ReferrerListIter] to resolve the platform or tag.
+func (reg *Reg) ReferrerListPage(ctx context.Context, rSubject ref.Ref, fn func(referrer.ReferrerList) bool, opts ...scheme.ReferrerOpts) error {
+	config := scheme.ReferrerConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	var r ref.Ref
+	if config.SrcRepo.IsSet() {
+		r = config.SrcRepo.SetDigest(rSubject.Digest)
+	} else {
+		r = rSubject.SetDigest(rSubject.Digest)
+	}
+	if rSubject.Digest == "" {
+		return fmt.Errorf("digest required to query referrers %s", rSubject.CommonName())
+	}
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	page := func(rl referrer.ReferrerList) bool {
+		rl.Subject = rSubject
+		if config.SrcRepo.IsSet() {
+			rl.Source = config.SrcRepo
+			rl.Method = referrer.MethodExternal
+		}
+		return fn(scheme.ReferrerFilter(config, rl))
+	}
+
+	// try cache
+	if rl, err := reg.cacheRL.Get(r); err == nil {
+		page(rl)
+		return nil
+	}
+	// try referrers API, pages are not cached since the listing may be stopped early
+	referrerEnabled, ok := reg.featureGet("referrer", r.Registry, r.Repository)
+	if !ok || referrerEnabled {
+		var link *url.URL
+		for {
+			rl, linkNext, err := reg.referrerListByAPIPage(ctx, r, config, link)
+			if err != nil && link != nil {
+				return err
+			}
+			if !ok {
+				// save the referrer API state
+				reg.featureSet("referrer", r.Registry, r.Repository, err == nil)
+				ok = true
+			}
+			if err != nil {
+				break
+			}
+			rl.Method = referrer.MethodAPI
+			if !page(rl) || linkNext == nil {
+				return nil
+			}
+			link = linkNext
+		}
+	}
+	// fall back to tag
+	rl, err := reg.referrerListByTag(ctx, r)
+	if err != nil {
+		return err
+	}
+	reg.cacheRL.Set(r, rl)
+	page(rl)
+	return nil
+}
+
 func (reg *Reg) referrerListByAPI(ctx context.Context, r ref.Ref, config scheme.ReferrerConfig) (referrer.ReferrerList, error) {
 	rl := referrer.ReferrerList{
 		Subject: r,
@@ -113,6 +190,7 @@ func (reg *Reg) referrerListByAPI(ctx context.Context, r ref.Ref, config scheme.
 		}
 		link = linkNext
 	}
+	rl.Method = referrer.MethodAPI
 	return rl, nil
 }
 
@@ -122,8 +200,8 @@ func (reg *Reg) referrerListByAPIPage(ctx context.Context, r ref.Ref, config sch
 		Tags:    []string{},
 	}
 	query := url.Values{}
-	if config.MatchOpt.ArtifactType != "" {
-		query.Set("artifactType", config.MatchOpt.ArtifactType)
+	if at := referrerQueryAT(config.MatchOpt); at != "" {
+		query.Set("artifactType", at)
 	}
 	req := &reghttp.Req{
 		MetaKind:   reqmeta.Query,
@@ -194,9 +272,24 @@ func (reg *Reg) referrerListByAPIPage(ctx context.Context, r ref.Ref, config sch
 	return rl, link, nil
 }
 
+// referrerQueryAT returns the artifactType to filter on the server, when the filter is a single value.
+// Multiple artifact types are filtered on the client since the query only supports one value.
+func referrerQueryAT(mo descriptor.MatchOpt) string {
+	at := mo.ArtifactType
+	for _, cur := range mo.ArtifactTypes {
+		if at == "" {
+			at = cur
+		} else if at != cur {
+			return ""
+		}
+	}
+	return at
+}
+
 func (reg *Reg) referrerListByTag(ctx context.Context, r ref.Ref) (referrer.ReferrerList, error) {
 	rl := referrer.ReferrerList{
 		Subject: r,
+		Method:  referrer.MethodFallbackTag,
 		Tags:    []string{},
 	}
 	rlTag, err := referrer.FallbackTag(r)
@@ -23,6 +23,7 @@ import (
 	"github.com/regclient/regclient/types/mediatype"
 	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
 )
 
 func TestReferrer(t *testing.T) {
@@ -787,6 +788,9 @@ func TestReferrer(t *testing.T) {
 		if len(rl.Tags) != 1 || rl.Tags[0] != tagNoAPI {
 			t.Errorf("tag list missing entries, received: %v", rl.Tags)
 		}
+		if rl.Method != referrer.MethodFallbackTag {
+			t.Errorf("unexpected method, expected %s, received %s", referrer.MethodFallbackTag, rl.Method)
+		}
 	})
 	t.Run("List A NoAPIAuth", func(t *testing.T) {
 		r, err := ref.New(tsURLNoAPIAuth.Host + repoPath + "@" + mDigest.String())
@@ -809,6 +813,9 @@ func TestReferrer(t *testing.T) {
 		if len(rl.Tags) != 1 || rl.Tags[0] != tagNoAPI {
 			t.Errorf("tag list missing entries, received: %v", rl.Tags)
 		}
+		if rl.Method != referrer.MethodFallbackTag {
+			t.Errorf("unexpected method, expected %s, received %s", referrer.MethodFallbackTag, rl.Method)
+		}
 	})
 	t.Run("List A API", func(t *testing.T) {
 		r, err := ref.New(tsURLAPI.Host + repoPath + "@" + mDigest.String())
@@ -831,6 +838,29 @@ func TestReferrer(t *testing.T) {
 		if len(rl.Tags) != 0 {
 			t.Errorf("tag list unexpected entries, received: %v", rl.Tags)
 		}
+		if rl.Method != referrer.MethodAPI {
+			t.Errorf("unexpected method, expected %s, received %s", referrer.MethodAPI, rl.Method)
+		}
+	})
+	t.Run("List A External", func(t *testing.T) {
+		r, err := ref.New(tsURLAPI.Host + "/proj/subject@" + mDigest.String())
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		rSrc, err := ref.New(tsURLNoAPI.Host + repoPath)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		rl, err := reg.ReferrerList(ctx, r, scheme.WithReferrerSource(rSrc))
+		if err != nil {
+			t.Fatalf("Failed running ReferrerList: %v", err)
+		}
+		if len(rl.Descriptors) != 1 || rl.Descriptors[0].Digest != artifactM.GetDescriptor().Digest {
+			t.Errorf("unexpected descriptors: %v", rl.Descriptors)
+		}
+		if rl.Method != referrer.MethodExternal || rl.Source.CommonName() != rSrc.CommonName() {
+			t.Errorf("unexpected method %s or source %s", rl.Method, rl.Source.CommonName())
+		}
 	})
 
 	// list referrers to v1 without digest
@@ -898,6 +928,43 @@ func TestReferrer(t *testing.T) {
 			t.Errorf("tag list missing entries, received: %v", rl.Tags)
 		}
 	})
+	t.Run("List Both API Pages", func(t *testing.T) {
+		r, err := ref.New(tsURLAPI.Host + repoPath + "@" + mDigest.String())
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		pages := []referrer.ReferrerList{}
+		err = reg.ReferrerListPage(ctx, r, func(rl referrer.ReferrerList) bool {
+			pages = append(pages, rl)
+			return false
+		})
+		if err != nil {
+			t.Fatalf("Failed running ReferrerListPage: %v", err)
+		}
+		if len(pages) != 1 {
+			t.Fatalf("listing did not stop after the first page, received %d pages", len(pages))
+		}
+		pages = []referrer.ReferrerList{}
+		err = reg.ReferrerListPage(ctx, r, func(rl referrer.ReferrerList) bool {
+			pages = append(pages, rl)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("Failed running ReferrerListPage: %v", err)
+		}
+		if len(pages) != 2 {
+			t.Fatalf("expected 2 pages, received %d", len(pages))
+		}
+		if len(pages[0].Descriptors) != 1 || pages[0].Descriptors[0].Digest != artifactM.GetDescriptor().Digest {
+			t.Errorf("unexpected first page: %v", pages[0].Descriptors)
+		}
+		if len(pages[1].Descriptors) != 1 || pages[1].Descriptors[0].Digest != artifact2M.GetDescriptor().Digest {
+			t.Errorf("unexpected second page: %v", pages[1].Descriptors)
+		}
+		if pages[0].Method != referrer.MethodAPI || !ref.EqualRepository(pages[0].Subject, r) {
+			t.Errorf("unexpected page details, method %s, subject %s", pages[0].Method, pages[0].Subject.CommonName())
+		}
+	})
 	t.Run("List Both API", func(t *testing.T) {
 		r, err := ref.New(tsURLAPI.Host + repoPath + "@" + mDigest.String())
 		if err != nil {
@@ -949,6 +1016,26 @@ func TestReferrer(t *testing.T) {
 			t.Fatalf("unexpected descriptors: %v", rl.Descriptors)
 		}
 	})
+	t.Run("List with multiple artifact filter API", func(t *testing.T) {
+		r, err := ref.New(tsURLAPI.Host + repoPath + "@" + mDigest.String())
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		rl, err := reg.ReferrerList(ctx, r, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactTypes: []string{configMTA, configMTB}}))
+		if err != nil {
+			t.Fatalf("Failed running ReferrerList: %v", err)
+		}
+		if len(rl.Descriptors) != 2 {
+			t.Fatalf("descriptor list mismatch: %v", rl.Descriptors)
+		}
+		rl, err = reg.ReferrerList(ctx, r, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactTypes: []string{"application/vnd.example.unknown", configMTB}}))
+		if err != nil {
+			t.Fatalf("Failed running ReferrerList: %v", err)
+		}
+		if len(rl.Descriptors) != 1 || rl.Descriptors[0].ArtifactType != configMTB {
+			t.Fatalf("descriptor list mismatch: %v", rl.Descriptors)
+		}
+	})
 	t.Run("List with annotation filter", func(t *testing.T) {
 		r, err := ref.New(tsURLAPI.Host + repoPath + "@" + mDigest.String())
 		if err != nil {
@@ -2,6 +2,7 @@
 package reg
 
 import (
+	"io"
 	"log/slog"
 	"net/http"
 	"sync"
@@ -146,6 +147,14 @@ func (reg *Reg) featureSet(kind, registry, repo string, enabled bool) {
 	reg.muHost.Unlock()
 }
 
+// WithAcceptEncoding sets the Accept-Encoding header for manifest, tag, and catalog requests.
+// Gzip encoded responses are decompressed.
+func WithAcceptEncoding(enc string) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithAcceptEncoding(enc))
+	}
+}
+
 // WithBlobSize overrides default blob sizes
 func WithBlobSize(size, max int64) Opts {
 	return func(r *Reg) {
@@ -220,6 +229,13 @@ func WithConfigHosts(configHosts []*config.Host) Opts {
 	}
 }
 
+// WithDebugHTTP writes a dump of every HTTP request and response to w, see [reghttp.WithDebugHTTP].
+func WithDebugHTTP(w io.Writer, bodyMax int64) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithDebugHTTP(w, bodyMax))
+	}
+}
+
 // WithDelay initial time to wait between retries (increased with exponential backoff)
 func WithDelay(delayInit time.Duration, delayMax time.Duration) Opts {
 	return func(r *Reg) {
@@ -227,6 +243,13 @@ func WithDelay(delayInit time.Duration, delayMax time.Duration) Opts {
 	}
 }
 
+// WithHeadTimeout limits the time for each attempt of a HEAD request, e.g. a manifest or blob head.
+func WithHeadTimeout(d time.Duration) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithHeadTimeout(d))
+	}
+}
+
 // WithHTTPClient uses a specific http client with retryable requests
 func WithHTTPClient(hc *http.Client) Opts {
 	return func(r *Reg) {
@@ -242,6 +265,21 @@ func WithManifestMax(push, pull int64) Opts {
 	}
 }
 
+// WithRetryAfterMax limits the delay requested by a registry Retry-After header (defaults to 5 minutes).
+func WithRetryAfterMax(d time.Duration) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryAfterMax(d))
+	}
+}
+
+// WithRetryBackoff sets the multiplier of the delay for each retry (defaults to 2),
+// and the fraction of the delay that is randomized (defaults to 0).
+func WithRetryBackoff(factor, jitter float64) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryBackoff(factor, jitter))
+	}
+}
+
 // WithRetryLimit restricts the number of retries (defaults to 5)
 func WithRetryLimit(l int) Opts {
 	return func(r *Reg) {
@@ -264,6 +302,14 @@ func WithTransport(t *http.Transport) Opts {
 	}
 }
 
+// WithTransferTimeout cancels a transfer, e.g. a blob push or pull, that has no progress for the duration.
+// Unlike a context deadline, a large transfer that is progressing will not time out.
+func WithTransferTimeout(d time.Duration) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithTransferTimeout(d))
+	}
+}
+
 // WithUserAgent sets a user agent header
 func WithUserAgent(ua string) Opts {
 	return func(r *Reg) {
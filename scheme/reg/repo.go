@@ -41,6 +41,7 @@ func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.Re
 		NoMirrors: true,
 		Method:    "GET",
 		Path:      "_catalog",
+		Scope:     "registry:catalog:*",
 		NoPrefix:  true,
 		Query:     query,
 		Headers:   headers,
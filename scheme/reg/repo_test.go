@@ -23,6 +23,18 @@ func TestRepo(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 	partialLen := 2
+	catalogScope := "registry:catalog:*"
+	catalogToken := "catalogTokenValue"
+	tsToken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("scope") != catalogScope {
+			t.Errorf("unexpected token request scope: %s", r.Form.Get("scope"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"token":"%s","expires_in":900}`, catalogToken)))
+	}))
+	defer tsToken.Close()
 	listRegistry := []string{
 		"library/alpine",
 		"library/busybox",
@@ -82,6 +94,38 @@ func TestRepo(t *testing.T) {
 				},
 			},
 		}},
+		"auth": {
+			{
+				ReqEntry: reqresp.ReqEntry{
+					Name:   "Authorized List",
+					Method: "GET",
+					Path:   "/v2/_catalog",
+					Headers: http.Header{
+						"Authorization": {"Bearer " + catalogToken},
+					},
+				},
+				RespEntry: reqresp.RespEntry{
+					Status: http.StatusOK,
+					Body:   []byte(fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(listRegistry, `","`))),
+					Headers: http.Header{
+						"Content-Type": {"text/plain; charset=utf-8"},
+					},
+				},
+			},
+			{
+				ReqEntry: reqresp.ReqEntry{
+					Name:   "Unauthorized List",
+					Method: "GET",
+					Path:   "/v2/_catalog",
+				},
+				RespEntry: reqresp.RespEntry{
+					Status: http.StatusUnauthorized,
+					Headers: http.Header{
+						"WWW-Authenticate": {`Bearer realm="` + tsToken.URL + `/token",service=test`},
+					},
+				},
+			},
+		},
 		"registry": {
 			{
 				ReqEntry: reqresp.ReqEntry{
@@ -214,6 +258,21 @@ func TestRepo(t *testing.T) {
 		}
 
 	})
+	// catalog scope is requested when the registry challenge does not include a scope
+	t.Run("Auth", func(t *testing.T) {
+		u, _ := url.Parse(tss["auth"].URL)
+		host := u.Host
+		rl, err := reg.RepoList(ctx, host)
+		if err != nil {
+			t.Fatalf("error listing repos: %v", err)
+		}
+		rlRepos, err := rl.GetRepos()
+		if err != nil {
+			t.Errorf("error retrieving repos: %v", err)
+		} else if stringSliceCmp(listRegistry, rlRepos) == false {
+			t.Errorf("repositories do not match: expected %v, received %v", listRegistry, rlRepos)
+		}
+	})
 	// test with http errors
 	t.Run("Disabled", func(t *testing.T) {
 		u, _ := url.Parse(tss["disabled"].URL)
@@ -245,6 +245,12 @@ func (reg *Reg) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts)
 			break
 		}
 	}
+	if config.DigestTags != scheme.TagDigestAll {
+		err = tl.Filter(func(t string) bool { return scheme.TagMatch(config, t) })
+		if err != nil {
+			return tl, err
+		}
+	}
 
 	return tl, nil
 }
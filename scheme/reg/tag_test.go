@@ -2,6 +2,7 @@ package reg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -41,6 +42,13 @@ func TestTag(t *testing.T) {
 	listTagBody2 := []byte(fmt.Sprintf("{\"name\":\"%s\",\"tags\":[\"%s\"]}",
 		strings.TrimLeft(repoPath, "/"),
 		strings.Join(listTagList[pageLen:], "\",\"")))
+	repoPathDigest := "/proj-digest"
+	digestTagSig := fmt.Sprintf("sha256-%s.sig", digest.FromString("digest tag image").Encoded())
+	digestTagFallback := fmt.Sprintf("sha256-%s", digest.FromString("digest tag image").Encoded())
+	digestTagList := []string{digestTagFallback, digestTagSig, "latest", "sha256-short", "v1"}
+	digestTagBody := []byte(fmt.Sprintf("{\"name\":\"%s\",\"tags\":[\"%s\"]}",
+		strings.TrimLeft(repoPathDigest, "/"),
+		strings.Join(digestTagList, "\",\"")))
 	missingRepo := "/missing"
 	delOCITag := "del-oci"
 	delFallbackTag := "del-fallback"
@@ -135,6 +143,21 @@ func TestTag(t *testing.T) {
 				Body: listTagBody1,
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "digest tag get",
+				Method: "GET",
+				Path:   "/v2" + repoPathDigest + "/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(digestTagBody))},
+					"Content-Type":   {"application/json"},
+				},
+				Body: digestTagBody,
+			},
+		},
 
 		{
 			ReqEntry: reqresp.ReqEntry{
@@ -335,6 +358,64 @@ func TestTag(t *testing.T) {
 		}
 	})
 	// list tags on missing repos
+	t.Run("Digest filter", func(t *testing.T) {
+		listRef, err := ref.New(tsURL.Host + repoPathDigest)
+		if err != nil {
+			t.Fatalf("failed creating getRef: %v", err)
+		}
+		tt := []struct {
+			name   string
+			filter scheme.TagDigestFilter
+			expect []string
+		}{
+			{
+				name:   "all",
+				filter: scheme.TagDigestAll,
+				expect: digestTagList,
+			},
+			{
+				name:   "exclude",
+				filter: scheme.TagDigestExclude,
+				expect: []string{"latest", "sha256-short", "v1"},
+			},
+			{
+				name:   "only",
+				filter: scheme.TagDigestOnly,
+				expect: []string{digestTagFallback, digestTagSig},
+			},
+		}
+		for _, tc := range tt {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				tl, err := reg.TagList(ctx, listRef, scheme.WithTagDigestFilter(tc.filter))
+				if err != nil {
+					t.Fatalf("failed to list tags: %v", err)
+				}
+				tags, err := tl.GetTags()
+				if err != nil {
+					t.Fatalf("failed to extract tag list: %v", err)
+				}
+				if !stringSliceCmp(tags, tc.expect) {
+					t.Errorf("returned list mismatch, expected %v, received %v", tc.expect, tags)
+				}
+				// the raw body matches the filtered list
+				raw, err := tl.RawBody()
+				if err != nil {
+					t.Fatalf("failed to get raw body: %v", err)
+				}
+				tlRaw := struct {
+					Tags []string `json:"tags"`
+				}{}
+				err = json.Unmarshal(raw, &tlRaw)
+				if err != nil {
+					t.Fatalf("failed to parse raw body: %v", err)
+				}
+				if !stringSliceCmp(tlRaw.Tags, tc.expect) {
+					t.Errorf("raw body mismatch, expected %v, received %s", tc.expect, raw)
+				}
+			})
+		}
+	})
 	t.Run("Missing", func(t *testing.T) {
 		listRef, err := ref.New(tsURL.Host + missingRepo)
 		if err != nil {
@@ -4,6 +4,9 @@ package scheme
 import (
 	"context"
 	"io"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient/internal/pqueue"
 	"github.com/regclient/regclient/internal/reqmeta"
@@ -17,6 +20,8 @@ import (
 )
 
 // API is used to interface between different methods to store images.
+// Custom implementations are added to regclient with [github.com/regclient/regclient.WithScheme].
+// Methods that are not supported by an implementation should return [github.com/regclient/regclient/types/errs.ErrNotImplemented].
 type API interface {
 	// BlobDelete removes a blob from the repository.
 	BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descriptor) error
@@ -50,11 +55,41 @@ type API interface {
 	TagList(ctx context.Context, r ref.Ref, opts ...TagOpts) (*tag.List, error)
 }
 
+type blobNoMountKey struct{}
+
+// WithBlobNoMount returns a context that disables blob mount attempts in [API.BlobPut].
+// This is a workaround for registries that report a successful mount without the blob.
+func WithBlobNoMount(ctx context.Context) context.Context {
+	return context.WithValue(ctx, blobNoMountKey{}, true)
+}
+
+// BlobNoMount returns true when the context disables blob mount attempts.
+func BlobNoMount(ctx context.Context) bool {
+	v, ok := ctx.Value(blobNoMountKey{}).(bool)
+	return ok && v
+}
+
+// BlobResumer is used to check if a scheme can resume a blob download from an offset.
+type BlobResumer interface {
+	BlobGetResume(ctx context.Context, r ref.Ref, d descriptor.Descriptor, offset int64) (blob.Reader, error)
+}
+
 // Closer is used to check if a scheme implements the Close API.
 type Closer interface {
 	Close(ctx context.Context, r ref.Ref) error
 }
 
+// GarbageCollector is used to check if a scheme can remove unreferenced content.
+type GarbageCollector interface {
+	GC(ctx context.Context, r ref.Ref) (GCResult, error)
+}
+
+// GCResult describes the content removed by a garbage collection.
+type GCResult struct {
+	Blobs int   `json:"blobs"` // number of blobs deleted
+	Bytes int64 `json:"bytes"` // total size of the deleted blobs
+}
+
 // GCLocker is used to indicate locking is available for GC management.
 type GCLocker interface {
 	// GCLock a reference to prevent GC from triggering during a put, locks are not exclusive.
@@ -64,6 +99,27 @@ type GCLocker interface {
 	GCUnlock(r ref.Ref)
 }
 
+// ManifestHeadConditional is used to check if a scheme supports conditional manifest head requests.
+// The etag is typically a digest, and [errs.ErrNotModified] is returned when it matches the current manifest.
+type ManifestHeadConditional interface {
+	ManifestHeadIfNoneMatch(ctx context.Context, r ref.Ref, etag string) (manifest.Manifest, error)
+}
+
+// ManifestRawGetter is used to check if a scheme can return a manifest without parsing the content.
+type ManifestRawGetter interface {
+	ManifestGetRaw(ctx context.Context, r ref.Ref) ([]byte, descriptor.Descriptor, error)
+}
+
+// ManifestRawPutter is used to check if a scheme can push a manifest from a reader without parsing the content.
+type ManifestRawPutter interface {
+	ManifestPutRaw(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader, opts ...ManifestOpts) error
+}
+
+// ReferrerPager is used to check if a scheme can return referrers one page at a time.
+type ReferrerPager interface {
+	ReferrerListPage(ctx context.Context, r ref.Ref, fn func(referrer.ReferrerList) bool, opts ...ReferrerOpts) error
+}
+
 // Throttler is used to indicate the scheme implements Throttle.
 type Throttler interface {
 	Throttle(r ref.Ref, put bool) []*pqueue.Queue[reqmeta.Data]
@@ -72,7 +128,8 @@ type Throttler interface {
 // ManifestConfig is used by schemes to import [ManifestOpts].
 type ManifestConfig struct {
 	CheckReferrers bool
-	Child          bool // used when pushing a child of a manifest list, skips indexing in ocidir
+	Child          bool   // used when pushing a child of a manifest list, skips indexing in ocidir
+	ContentType    string // overrides the Content-Type header on a registry put
 	Manifest       manifest.Manifest
 }
 
@@ -96,6 +153,13 @@ func WithManifestChild() ManifestOpts {
 	}
 }
 
+// WithManifestContentType overrides the Content-Type header sent when pushing a manifest to a registry.
+func WithManifestContentType(mt string) ManifestOpts {
+	return func(config *ManifestConfig) {
+		config.ContentType = mt
+	}
+}
+
 // WithManifest is used to pass the manifest to a method to avoid an extra GET request.
 // This is used on a delete to check for referrers.
 func WithManifest(m manifest.Manifest) ManifestOpts {
@@ -176,6 +240,7 @@ func ReferrerFilter(config ReferrerConfig, rlIn referrer.ReferrerList) referrer.
 	return referrer.ReferrerList{
 		Subject:     rlIn.Subject,
 		Source:      rlIn.Source,
+		Method:      rlIn.Method,
 		Manifest:    rlIn.Manifest,
 		Annotations: rlIn.Annotations,
 		Tags:        rlIn.Tags,
@@ -210,10 +275,24 @@ func WithRepoLast(l string) RepoOpts {
 
 // TagConfig is used by schemes to import [TagOpts].
 type TagConfig struct {
-	Limit int
-	Last  string
+	Limit      int
+	Last       string
+	DigestTags TagDigestFilter
 }
 
+// TagDigestFilter selects how digest tags are handled in a tag list.
+// Digest tags follow the "<algorithm>-<hex>" convention, optionally with a suffix, e.g. "sha256-<hex>.sig".
+type TagDigestFilter int
+
+const (
+	// TagDigestAll includes every tag in the list.
+	TagDigestAll TagDigestFilter = iota
+	// TagDigestExclude removes digest tags from the list.
+	TagDigestExclude
+	// TagDigestOnly removes every tag that is not a digest tag from the list.
+	TagDigestOnly
+)
+
 // TagOpts is used to set options on tag APIs.
 type TagOpts func(*TagConfig)
 
@@ -232,3 +311,27 @@ func WithTagLast(last string) TagOpts {
 		t.Last = last
 	}
 }
+
+// WithTagDigestFilter includes or excludes digest tags in the tag list.
+// The filter is applied to the tags after they are received, so fewer tags than the limit may be returned.
+// The raw body of the tag list is regenerated from the filtered tags.
+func WithTagDigestFilter(f TagDigestFilter) TagOpts {
+	return func(t *TagConfig) {
+		t.DigestTags = f
+	}
+}
+
+// TagMatch returns true when the tag is included by the config.
+func TagMatch(config TagConfig, t string) bool {
+	return config.DigestTags == TagDigestAll || TagIsDigest(t) == (config.DigestTags == TagDigestOnly)
+}
+
+// TagIsDigest returns true when the tag follows the digest tag convention, "<algorithm>-<hex>" with an optional "." suffix.
+func TagIsDigest(t string) bool {
+	algo, enc, ok := strings.Cut(t, "-")
+	if !ok {
+		return false
+	}
+	enc, _, _ = strings.Cut(enc, ".")
+	return digest.Algorithm(algo).Validate(enc) == nil
+}
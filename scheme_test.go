@@ -0,0 +1,256 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ping"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// memScheme is a trivial in-memory scheme for testing custom scheme registration.
+type memScheme struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]descriptor.Descriptor
+	raw       map[string][]byte
+	tags      map[string]map[string]string
+}
+
+func newMemScheme() *memScheme {
+	return &memScheme{
+		blobs:     map[string][]byte{},
+		manifests: map[string]descriptor.Descriptor{},
+		raw:       map[string][]byte{},
+		tags:      map[string]map[string]string{},
+	}
+}
+
+func (m *memScheme) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descriptor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, d.Digest.String())
+	return nil
+}
+
+func (m *memScheme) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.Reader, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.blobs[d.Digest.String()]
+	if !ok {
+		return nil, fmt.Errorf("blob %s%.0w", d.Digest.String(), errs.ErrNotFound)
+	}
+	return blob.NewReader(blob.WithRef(r), blob.WithDesc(d), blob.WithReader(bytes.NewReader(b))), nil
+}
+
+func (m *memScheme) BlobHead(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.Reader, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[d.Digest.String()]; !ok {
+		return nil, fmt.Errorf("blob %s%.0w", d.Digest.String(), errs.ErrNotFound)
+	}
+	return blob.NewReader(blob.WithRef(r), blob.WithDesc(d)), nil
+}
+
+func (m *memScheme) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor) error {
+	return errs.ErrNotImplemented
+}
+
+func (m *memScheme) BlobPut(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader) (descriptor.Descriptor, error) {
+	b, err := io.ReadAll(rdr)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	d.Digest = digest.FromBytes(b)
+	d.Size = int64(len(b))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[d.Digest.String()] = b
+	return d, nil
+}
+
+func (m *memScheme) ManifestDelete(ctx context.Context, r ref.Ref, opts ...scheme.ManifestOpts) error {
+	return errs.ErrNotImplemented
+}
+
+func (m *memScheme) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dig := r.Digest
+	if dig == "" {
+		dig = m.tags[r.Path][r.Tag]
+	}
+	d, ok := m.manifests[dig]
+	if !ok {
+		return nil, fmt.Errorf("manifest %s%.0w", r.CommonName(), errs.ErrNotFound)
+	}
+	return manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(d),
+		manifest.WithRaw(m.raw[dig]),
+	)
+}
+
+func (m *memScheme) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dig := r.Digest
+	if dig == "" {
+		dig = m.tags[r.Path][r.Tag]
+	}
+	d, ok := m.manifests[dig]
+	if !ok {
+		return nil, fmt.Errorf("manifest %s%.0w", r.CommonName(), errs.ErrNotFound)
+	}
+	return manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(d),
+	)
+}
+
+func (m *memScheme) ManifestPut(ctx context.Context, r ref.Ref, mm manifest.Manifest, opts ...scheme.ManifestOpts) error {
+	raw, err := mm.RawBody()
+	if err != nil {
+		return err
+	}
+	d := mm.GetDescriptor()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.manifests[d.Digest.String()] = d
+	m.raw[d.Digest.String()] = raw
+	if r.Tag != "" {
+		if m.tags[r.Path] == nil {
+			m.tags[r.Path] = map[string]string{}
+		}
+		m.tags[r.Path][r.Tag] = d.Digest.String()
+	}
+	return nil
+}
+
+func (m *memScheme) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
+	return ping.Result{}, nil
+}
+
+func (m *memScheme) ReferrerList(ctx context.Context, r ref.Ref, opts ...scheme.ReferrerOpts) (referrer.ReferrerList, error) {
+	return referrer.ReferrerList{}, errs.ErrNotImplemented
+}
+
+func (m *memScheme) TagDelete(ctx context.Context, r ref.Ref) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tags[r.Path], r.Tag)
+	return nil
+}
+
+func (m *memScheme) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := []string{}
+	for t := range m.tags[r.Path] {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tag.New(
+		tag.WithRef(r),
+		tag.WithTags(tags),
+	)
+}
+
+func TestScheme(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mem := newMemScheme()
+	rc := New(
+		WithScheme("mem", mem),
+		WithScheme("reg", mem),
+	)
+	if _, ok := rc.schemes["reg"].(*reg.Reg); !ok {
+		t.Errorf("reg scheme was replaced: %T", rc.schemes["reg"])
+	}
+	if err := ref.RegisterScheme("ocidir"); !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("registering a reserved scheme did not fail: %v", err)
+	}
+	if err := ref.RegisterScheme("Invalid-Name"); !errors.Is(err, errs.ErrParsingFailed) {
+		t.Errorf("registering an invalid scheme did not fail: %v", err)
+	}
+	r, err := ref.New("mem://example/repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	if r.Scheme != "mem" || r.Path != "example/repo" || r.Tag != "v1" || r.CommonName() != "mem://example/repo:v1" {
+		t.Fatalf("unexpected ref: %#v", r)
+	}
+	// round trip a manifest through the custom scheme
+	confBytes := []byte(`{}`)
+	confDesc, err := rc.BlobPut(ctx, r, descriptor.Descriptor{MediaType: mediatype.OCI1ImageConfig}, bytes.NewReader(confBytes))
+	if err != nil {
+		t.Fatalf("failed to put config: %v", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: mediatype.OCI1Manifest,
+		Config:    confDesc,
+		Layers:    []descriptor.Descriptor{},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r, m)
+	if err != nil {
+		t.Fatalf("failed to put manifest: %v", err)
+	}
+	mGet, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	if mGet.GetDescriptor().Digest != m.GetDescriptor().Digest || mGet.GetDescriptor().MediaType != mediatype.OCI1Manifest {
+		t.Errorf("unexpected manifest, expected %v, received %v", m.GetDescriptor(), mGet.GetDescriptor())
+	}
+	mHead, err := rc.ManifestHead(ctx, r.SetDigest(m.GetDescriptor().Digest.String()))
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	if mHead.GetDescriptor().Digest != m.GetDescriptor().Digest {
+		t.Errorf("unexpected head digest, expected %s, received %s", m.GetDescriptor().Digest, mHead.GetDescriptor().Digest)
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil || len(tags) != 1 || tags[0] != "v1" {
+		t.Errorf("unexpected tags: %v, %v", tags, err)
+	}
+	br, err := rc.BlobGet(ctx, r, confDesc)
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	b, err := io.ReadAll(br)
+	_ = br.Close()
+	if err != nil || !bytes.Equal(b, confBytes) {
+		t.Errorf("unexpected config: %s, %v", b, err)
+	}
+	// unregistered schemes are still rejected
+	_, err = ref.New("unknown://example/repo:v1")
+	if !errors.Is(err, errs.ErrInvalidReference) {
+		t.Errorf("unknown scheme did not fail: %v", err)
+	}
+}
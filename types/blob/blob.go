@@ -41,6 +41,7 @@ type blobConfig struct {
 	desc    descriptor.Descriptor
 	header  http.Header
 	image   *v1.Image
+	offset  int64
 	r       ref.Ref
 	rdr     io.Reader
 	resp    *http.Response
@@ -71,6 +72,14 @@ func WithImage(image v1.Image) Opts {
 	}
 }
 
+// WithOffset indicates the reader starts at an offset within the blob, used when resuming a download.
+// The first offset bytes must be provided with [BReader.HashPrefix] to verify the digest.
+func WithOffset(offset int64) Opts {
+	return func(bc *blobConfig) {
+		bc.offset = offset
+	}
+}
+
 // WithRawBody defines the raw blob contents for OCIConfig.
 func WithRawBody(raw []byte) Opts {
 	return func(bc *blobConfig) {
@@ -13,6 +13,7 @@ import (
 
 	"github.com/opencontainers/go-digest"
 
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/mediatype"
@@ -721,6 +722,39 @@ func TestReadFile(t *testing.T) {
 			}
 		})
 	}
+	t.Run("zstd chunked", func(t *testing.T) {
+		// zstd:chunked layers wrap metadata in skippable frames that must be ignored
+		cr, err := archive.Compress(bytes.NewReader(fileBytes), archive.CompressZstd)
+		if err != nil {
+			t.Fatalf("failed to compress: %v", err)
+		}
+		zBytes, err := io.ReadAll(cr)
+		if err != nil {
+			t.Fatalf("failed to read compressed data: %v", err)
+		}
+		skip := []byte{0x50, 0x2A, 0x4D, 0x18, 0x04, 0x00, 0x00, 0x00, 't', 'e', 's', 't'}
+		zBytes = append(append(append([]byte{}, skip...), zBytes...), skip...)
+		zDesc := descriptor.Descriptor{Size: int64(len(zBytes)), Digest: digest.FromBytes(zBytes), MediaType: mediatype.OCI1LayerZstd}
+		btr := NewTarReader(WithReader(bytes.NewReader(zBytes)), WithDesc(zDesc))
+		_, rdr, err := btr.ReadFile("layer3.txt")
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		content, err := io.ReadAll(rdr)
+		if err != nil {
+			t.Errorf("failed reading file: %v", err)
+		}
+		if string(content) != "3\n" {
+			t.Errorf("file content mismatch: expected 3, received %s", string(content))
+		}
+		_ = btr.Close()
+		btr = NewTarReader(WithReader(bytes.NewReader(zBytes)), WithDesc(zDesc))
+		_, _, err = btr.ReadFile("missing.txt")
+		if !errors.Is(err, errs.ErrFileNotFound) {
+			t.Errorf("unexpected error, expected %v, received %v", errs.ErrFileNotFound, err)
+		}
+		_ = btr.Close()
+	})
 	t.Run("bad digest", func(t *testing.T) {
 		fh, err := os.Open(fileLayerWH)
 		if err != nil {
@@ -24,6 +24,8 @@ type Reader = *BReader
 type BReader struct {
 	BCommon
 	readBytes int64
+	offset    int64
+	prefixSet bool
 	reader    io.Reader
 	origRdr   io.Reader
 	digester  digest.Digester
@@ -67,6 +69,10 @@ func NewReader(opts ...Opts) *BReader {
 		},
 		origRdr: bc.rdr,
 	}
+	if bc.offset > 0 {
+		br.offset = bc.offset
+		br.readBytes = bc.offset
+	}
 	if bc.rdr != nil {
 		br.blobSet = true
 		br.digester = br.desc.DigestAlgo().Digester()
@@ -74,7 +80,7 @@ func NewReader(opts ...Opts) *BReader {
 		if br.desc.Size > 0 {
 			rdr = &limitread.LimitRead{
 				Reader: rdr,
-				Limit:  br.desc.Size,
+				Limit:  br.desc.Size - br.offset,
 			}
 		}
 		br.reader = io.TeeReader(rdr, br.digester.Hash())
@@ -95,6 +101,28 @@ func (r *BReader) Close() error {
 	return bc.Close()
 }
 
+// HashPrefix reads the first bytes of the blob that were received before a resumed download.
+// This must be called before any reads when the reader was created [WithOffset].
+func (r *BReader) HashPrefix(rdr io.Reader) error {
+	if r == nil || r.digester == nil {
+		return fmt.Errorf("blob has no reader")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.offset == 0 || r.prefixSet {
+		return fmt.Errorf("blob prefix is not needed")
+	}
+	if r.readBytes != r.offset {
+		return fmt.Errorf("unable to hash prefix after read has been performed")
+	}
+	n, err := io.CopyN(r.digester.Hash(), rdr, r.offset)
+	if err != nil {
+		return fmt.Errorf("failed to read blob prefix [expected %d, received %d]: %w", r.offset, n, err)
+	}
+	r.prefixSet = true
+	return nil
+}
+
 // RawBody returns the original body from the request.
 func (r *BReader) RawBody() ([]byte, error) {
 	return io.ReadAll(r)
@@ -119,7 +147,9 @@ func (r *BReader) Read(p []byte) (int, error) {
 			err = fmt.Errorf("%w [expected %d, received %d]: %w", errs.ErrSizeLimitExceeded, r.desc.Size, r.readBytes, err)
 		}
 		// check/save digest
-		if r.desc.Digest.Validate() != nil {
+		if r.offset > 0 && !r.prefixSet {
+			err = fmt.Errorf("%w [first %d bytes not provided to verify %s]: %w", errs.ErrDigestMismatch, r.offset, r.desc.Digest.String(), err)
+		} else if r.desc.Digest.Validate() != nil {
 			r.desc.Digest = r.digester.Digest()
 		} else if r.desc.Digest != r.digester.Digest() {
 			err = fmt.Errorf("%w [expected %s, calculated %s]: %w", errs.ErrDigestMismatch, r.desc.Digest.String(), r.digester.Digest().String(), err)
@@ -161,6 +191,8 @@ func (r *BReader) Seek(offset int64, whence int) (int64, error) {
 	r.digester = r.desc.DigestAlgo().Digester()
 	r.reader = io.TeeReader(rdr, r.digester.Hash())
 	r.readBytes = 0
+	r.offset = 0
+	r.prefixSet = false
 
 	return 0, nil
 }
@@ -215,6 +215,7 @@ func (d Descriptor) MarshalPrettyTW(tw *tabwriter.Writer, prefix string) error {
 type MatchOpt struct {
 	Platform       *platform.Platform // Platform to match including compatible platforms (darwin/arm64 matches linux/arm64)
 	ArtifactType   string             // Match ArtifactType in the descriptor
+	ArtifactTypes  []string           // Match any of the ArtifactTypes in the descriptor, combined with ArtifactType when both are set
 	Annotations    map[string]string  // Match each of the specified annotations and their value, an empty value verifies the key is set
 	SortAnnotation string             // Sort the results by an annotation, string based comparison, descriptors without the annotation are sorted last
 	SortDesc       bool               // Set to true to sort in descending order
@@ -222,8 +223,17 @@ type MatchOpt struct {
 
 // Match returns true if the descriptor matches the options, including compatible platforms.
 func (d Descriptor) Match(opt MatchOpt) bool {
-	if opt.ArtifactType != "" && d.ArtifactType != opt.ArtifactType {
-		return false
+	if opt.ArtifactType != "" || len(opt.ArtifactTypes) > 0 {
+		found := opt.ArtifactType != "" && d.ArtifactType == opt.ArtifactType
+		for _, at := range opt.ArtifactTypes {
+			if d.ArtifactType == at {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
 	if len(opt.Annotations) > 0 {
 		if d.Annotations == nil {
@@ -282,7 +292,7 @@ func DescriptorListFilter(dl []Descriptor, opt MatchOpt) []Descriptor {
 
 // DescriptorListSearch returns the first descriptor from the list matching the search options.
 func DescriptorListSearch(dl []Descriptor, opt MatchOpt) (Descriptor, error) {
-	if opt.ArtifactType != "" || opt.SortAnnotation != "" || len(opt.Annotations) > 0 {
+	if opt.ArtifactType != "" || len(opt.ArtifactTypes) > 0 || opt.SortAnnotation != "" || len(opt.Annotations) > 0 {
 		dl = DescriptorListFilter(dl, opt)
 	}
 	var ret Descriptor
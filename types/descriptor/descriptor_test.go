@@ -581,6 +581,16 @@ func TestListSearch(t *testing.T) {
 			Architecture: "amd64",
 		},
 	}
+	dAMD64WinFeature := Descriptor{
+		MediaType: mediatype.OCI1Manifest,
+		Size:      23456,
+		Digest:    EmptyDigest,
+		Platform: &platform.Platform{
+			OS:           "windows",
+			Architecture: "amd64",
+			OSFeatures:   []string{"win32k"},
+		},
+	}
 	dARM64 := Descriptor{
 		MediaType: mediatype.OCI1Manifest,
 		Size:      12345,
@@ -649,6 +659,12 @@ func TestListSearch(t *testing.T) {
 			"date":    "2022-02-28 02:04:08",
 		},
 	}
+	dOther := Descriptor{
+		MediaType:    mediatype.OCI1Manifest,
+		Size:         12345,
+		Digest:       EmptyDigest,
+		ArtifactType: "application/example.other",
+	}
 	testDL := []Descriptor{
 		dAMD64,
 		dARM64,
@@ -658,6 +674,7 @@ func TestListSearch(t *testing.T) {
 		dArtifact,
 		dArtifact2,
 		dArtifact3,
+		dOther,
 	}
 	tt := []struct {
 		name   string
@@ -681,6 +698,53 @@ func TestListSearch(t *testing.T) {
 			},
 			expect: dAMD64,
 		},
+		{
+			name: "windows os features host supported",
+			dl:   []Descriptor{dAMD64Win, dAMD64WinFeature},
+			opt: MatchOpt{
+				Platform: &platform.Platform{
+					OS:           "windows",
+					Architecture: "amd64",
+					OSFeatures:   []string{"win32k"},
+				},
+			},
+			expect: dAMD64WinFeature,
+		},
+		{
+			name: "windows os features host unsupported",
+			dl:   []Descriptor{dAMD64WinFeature, dAMD64Win},
+			opt: MatchOpt{
+				Platform: &platform.Platform{
+					OS:           "windows",
+					Architecture: "amd64",
+					OSFeatures:   []string{"other"},
+				},
+			},
+			expect: dAMD64Win,
+		},
+		{
+			name: "windows os features host unsupported only feature",
+			dl:   []Descriptor{dAMD64WinFeature},
+			opt: MatchOpt{
+				Platform: &platform.Platform{
+					OS:           "windows",
+					Architecture: "amd64",
+					OSFeatures:   []string{"other"},
+				},
+			},
+			err: errs.ErrNotFound,
+		},
+		{
+			name: "windows os features host undefined",
+			dl:   []Descriptor{dAMD64WinFeature, dAMD64Win},
+			opt: MatchOpt{
+				Platform: &platform.Platform{
+					OS:           "windows",
+					Architecture: "amd64",
+				},
+			},
+			expect: dAMD64WinFeature,
+		},
 		{
 			name: "amd64 compat",
 			dl:   testDL,
@@ -780,6 +844,41 @@ func TestListSearch(t *testing.T) {
 			},
 			expect: dArtifact2,
 		},
+		{
+			name: "artifact types",
+			dl:   testDL,
+			opt: MatchOpt{
+				ArtifactTypes: []string{"application/example.missing", "application/example.other"},
+			},
+			expect: dOther,
+		},
+		{
+			name: "artifact types any",
+			dl:   testDL,
+			opt: MatchOpt{
+				ArtifactTypes: []string{"application/example.other", "application/example.artifact"},
+			},
+			expect: dArtifact,
+		},
+		{
+			name: "artifact types with artifact type",
+			dl:   testDL,
+			opt: MatchOpt{
+				ArtifactType:   "application/example.other",
+				ArtifactTypes:  []string{"application/example.artifact"},
+				SortAnnotation: "date",
+				SortDesc:       true,
+			},
+			expect: dArtifact2,
+		},
+		{
+			name: "artifact types missing",
+			dl:   testDL,
+			opt: MatchOpt{
+				ArtifactTypes: []string{"application/example.missing", "application/example.unknown"},
+			},
+			err: errs.ErrNotFound,
+		},
 		{
 			name: "artifact sort all unique desc",
 			dl:   testDL,
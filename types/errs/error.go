@@ -16,6 +16,8 @@ var (
 	ErrBackoffLimit = errors.New("backoff limit reached")
 	// ErrCanceled if the context was canceled
 	ErrCanceled = errors.New("context was canceled")
+	// ErrDepthLimitExceeded if nested content exceeds the recursion limit
+	ErrDepthLimitExceeded = errors.New("depth limit exceeded")
 	// ErrDigestMismatch if the expected digest wasn't received
 	ErrDigestMismatch = errors.New("digest mismatch")
 	// ErrEmptyChallenge indicates an issue with the received challenge in the WWW-Authenticate header
@@ -56,6 +58,8 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrNotImplemented returned when method has not been implemented yet
 	ErrNotImplemented = errors.New("not implemented")
+	// ErrNotModified returned when a conditional request matches the current content
+	ErrNotModified = errors.New("not modified")
 	// ErrNotRetryable indicates the process cannot be retried
 	ErrNotRetryable = errors.New("not retryable")
 	// ErrParsingFailed when a string cannot be parsed
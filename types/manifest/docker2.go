@@ -230,7 +230,7 @@ func (m *docker2ManifestList) MarshalPretty() ([]byte, error) {
 		dRef := m.r
 		if dRef.Reference != "" {
 			dRef.Digest = d.Digest.String()
-			fmt.Fprintf(tw, "  Name:\t%s\n", dRef.CommonName())
+			fmt.Fprintf(tw, "  Name:\t%s\n", dRef.ShortName())
 		}
 		err := d.MarshalPrettyTW(tw, "  ")
 		if err != nil {
@@ -221,6 +221,20 @@ func GetPlatformList(m Manifest) ([]*platform.Platform, error) {
 	return getPlatformList(dl)
 }
 
+// GetPlatformDescriptors returns the descriptors from an index that include a platform.
+// The entries are the same as [GetPlatformList], with the digest of each platform.
+func GetPlatformDescriptors(m Manifest) ([]descriptor.Descriptor, error) {
+	mi, ok := m.(Indexer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported manifest type: %s", m.GetDescriptor().MediaType)
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest list: %w", err)
+	}
+	return getPlatformDescriptors(dl), nil
+}
+
 // GetRateLimit returns the current rate limit seen in headers.
 func GetRateLimit(m Manifest) types.RateLimit {
 	rl := types.RateLimit{}
@@ -585,10 +599,18 @@ func verifyMT(expected, received string) error {
 
 func getPlatformList(dl []descriptor.Descriptor) ([]*platform.Platform, error) {
 	var l []*platform.Platform
+	for _, d := range getPlatformDescriptors(dl) {
+		l = append(l, d.Platform)
+	}
+	return l, nil
+}
+
+func getPlatformDescriptors(dl []descriptor.Descriptor) []descriptor.Descriptor {
+	var l []descriptor.Descriptor
 	for _, d := range dl {
 		if d.Platform != nil {
-			l = append(l, d.Platform)
+			l = append(l, d)
 		}
 	}
-	return l, nil
+	return l
 }
@@ -1112,6 +1112,62 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestGetPlatformDescriptors(t *testing.T) {
+	t.Parallel()
+	dAMD64 := descriptor.Descriptor{
+		MediaType: mediatype.OCI1Manifest,
+		Digest:    digest.FromString("amd64"),
+		Size:      1234,
+		Platform:  &platform.Platform{OS: "linux", Architecture: "amd64"},
+	}
+	dARM64 := descriptor.Descriptor{
+		MediaType: mediatype.OCI1Manifest,
+		Digest:    digest.FromString("arm64"),
+		Size:      1234,
+		Platform:  &platform.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	}
+	dAttest := descriptor.Descriptor{
+		MediaType: mediatype.OCI1Manifest,
+		Digest:    digest.FromString("attestation"),
+		Size:      1234,
+	}
+	m, err := New(WithOrig(v1.Index{
+		Versioned: v1.IndexSchemaVersion,
+		MediaType: mediatype.OCI1ManifestList,
+		Manifests: []descriptor.Descriptor{dAMD64, dAttest, dARM64},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	dl, err := GetPlatformDescriptors(m)
+	if err != nil {
+		t.Fatalf("failed to get platform descriptors: %v", err)
+	}
+	if len(dl) != 2 || dl[0].Digest != dAMD64.Digest || dl[1].Digest != dARM64.Digest {
+		t.Errorf("unexpected descriptors, expected amd64 and arm64, received %v", dl)
+	}
+	pl, err := GetPlatformList(m)
+	if err != nil {
+		t.Fatalf("failed to get platform list: %v", err)
+	}
+	if len(pl) != len(dl) {
+		t.Fatalf("platform list does not match the descriptors, received %v", pl)
+	}
+	for i := range pl {
+		if !platform.Match(*pl[i], *dl[i].Platform) {
+			t.Errorf("platform %d mismatch, expected %s, received %s", i, dl[i].Platform.String(), pl[i].String())
+		}
+	}
+	mImg, err := New(WithRaw(rawOCIImage))
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	_, err = GetPlatformDescriptors(mImg)
+	if err == nil {
+		t.Errorf("image manifest did not fail")
+	}
+}
+
 func TestModify(t *testing.T) {
 	t.Parallel()
 	addDigest := digest.FromString("new layer digest")
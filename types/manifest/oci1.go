@@ -302,7 +302,7 @@ func (m *oci1Index) MarshalPretty() ([]byte, error) {
 		dRef := m.r
 		if dRef.Reference != "" {
 			dRef.Digest = d.Digest.String()
-			fmt.Fprintf(tw, "  Name:\t%s\n", dRef.CommonName())
+			fmt.Fprintf(tw, "  Name:\t%s\n", dRef.ShortName())
 		}
 		err := d.MarshalPrettyTW(tw, "  ")
 		if err != nil {
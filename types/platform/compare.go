@@ -70,6 +70,10 @@ func (c *compare) Better(target, prev Platform) bool {
 			return cmp < 0
 		}
 	}
+	// when the host lists OS features, prefer the target using more of them
+	if len(c.host.OSFeatures) > 0 && len(target.OSFeatures) != len(prev.OSFeatures) {
+		return len(target.OSFeatures) > len(prev.OSFeatures)
+	}
 	return false
 }
 
@@ -84,7 +88,8 @@ func (c *compare) Compatible(target Platform) bool {
 		if target.OS == "windows" {
 			return c.host.Architecture == target.Architecture &&
 				variantCompatible(c.host.Variant, target.Variant) &&
-				osVerCompatible(c.host.OSVersion, target.OSVersion)
+				osVerCompatible(c.host.OSVersion, target.OSVersion) &&
+				osFeaturesCompatible(c.host.OSFeatures, target.OSFeatures)
 		} else if target.OS == "linux" {
 			return c.host.Architecture == target.Architecture &&
 				variantCompatible(c.host.Variant, target.Variant)
@@ -113,7 +118,8 @@ func (c *compare) Match(target Platform) bool {
 		return c.host.Architecture == target.Architecture && c.host.Variant == target.Variant
 	} else if c.host.OS == "windows" {
 		return c.host.Architecture == target.Architecture && c.host.Variant == target.Variant &&
-			osVerSemver(c.host.OSVersion) == osVerSemver(target.OSVersion)
+			osVerSemver(c.host.OSVersion) == osVerSemver(target.OSVersion) &&
+			osFeaturesMatch(c.host.OSFeatures, target.OSFeatures)
 	} else {
 		return c.host.Architecture == target.Architecture &&
 			c.host.Variant == target.Variant &&
@@ -145,6 +151,36 @@ func osVerCompatible(host, target string) bool {
 	return vHost == vTarget
 }
 
+// osFeaturesCompatible returns true when the host supports every OS feature required by the target.
+// A host without a list of OS features is not checked.
+func osFeaturesCompatible(host, target []string) bool {
+	if len(host) == 0 {
+		return true
+	}
+	for _, tf := range target {
+		found := false
+		for _, hf := range host {
+			if tf == hf {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// osFeaturesMatch returns true when the OS features are the same, ignoring the order.
+// The features are only compared when provided on both sides.
+func osFeaturesMatch(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	return osFeaturesCompatible(a, b) && osFeaturesCompatible(b, a)
+}
+
 func osVerSemver(platVer string) string {
 	verParts := strings.Split(platVer, ".")
 	if len(verParts) < 4 {
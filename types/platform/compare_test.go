@@ -122,6 +122,40 @@ func TestCompare(t *testing.T) {
 			expectCompat: false,
 			expectBetter: false,
 		},
+		{
+			name:         "windows os features supported",
+			host:         Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k", "other"}},
+			target:       Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k"}},
+			prev:         Platform{OS: "windows", Architecture: "amd64"},
+			expectMatch:  false,
+			expectCompat: true,
+			expectBetter: true,
+		},
+		{
+			name:         "windows os features unsupported",
+			host:         Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"other"}},
+			target:       Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k"}},
+			expectMatch:  false,
+			expectCompat: false,
+			expectBetter: false,
+		},
+		{
+			name:         "windows os features host undef",
+			host:         Platform{OS: "windows", Architecture: "amd64"},
+			target:       Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k"}},
+			prev:         Platform{OS: "windows", Architecture: "amd64"},
+			expectMatch:  true,
+			expectCompat: true,
+			expectBetter: false,
+		},
+		{
+			name:         "windows os features order",
+			host:         Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k", "other"}},
+			target:       Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"other", "win32k"}},
+			expectMatch:  true,
+			expectCompat: true,
+			expectBetter: true,
+		},
 		{
 			name:         "windows compatible",
 			host:         Platform{OS: "windows", Architecture: "amd64"},
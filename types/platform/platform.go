@@ -97,6 +97,13 @@ func Parse(platStr string) (Platform, error) {
 			switch strings.ToLower(k) {
 			case "osver", "osversion":
 				plat.OSVersion = v
+			case "osfeatures":
+				plat.OSFeatures = []string{}
+				for _, f := range strings.Split(v, ",") {
+					if f = strings.TrimSpace(f); f != "" {
+						plat.OSFeatures = append(plat.OSFeatures, f)
+					}
+				}
 			default:
 				return Platform{}, fmt.Errorf("unsupported platform arg type, %s in %s%.0w", k, platStr, errs.ErrParsingFailed)
 			}
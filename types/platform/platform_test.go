@@ -146,6 +146,11 @@ func TestPlatformParse(t *testing.T) {
 			parse: "windows/amd64,osver=10.0.17763.4974",
 			goal:  Platform{OS: "windows", Architecture: "amd64", Variant: windowsAMD64Goal.Variant, OSVersion: "10.0.17763.4974"},
 		},
+		{
+			name:  "windows amd64 with os features",
+			parse: `windows/amd64,osver=10.0.17763.4974,osfeatures="win32k,other"`,
+			goal:  Platform{OS: "windows", Architecture: "amd64", Variant: windowsAMD64Goal.Variant, OSVersion: "10.0.17763.4974", OSFeatures: []string{"win32k", "other"}},
+		},
 		{
 			name:  "windows amd64",
 			parse: "windows/amd64/v2",
@@ -184,7 +189,7 @@ func TestPlatformParse(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if p.OS != tt.goal.OS || p.Architecture != tt.goal.Architecture || p.Variant != tt.goal.Variant || p.OSVersion != tt.goal.OSVersion {
+			if p.OS != tt.goal.OS || p.Architecture != tt.goal.Architecture || p.Variant != tt.goal.Variant || p.OSVersion != tt.goal.OSVersion || !strSliceEq(p.OSFeatures, tt.goal.OSFeatures) {
 				t.Errorf("platform did not match, want %v, received %v", tt.goal, p)
 			}
 		})
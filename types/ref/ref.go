@@ -8,6 +8,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/regclient/regclient/types/errs"
 )
@@ -23,18 +24,19 @@ const (
 )
 
 var (
-	hostPartS   = `(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)`
-	hostPortS   = `(?:` + hostPartS + `(?:` + regexp.QuoteMeta(`.`) + hostPartS + `)*` + regexp.QuoteMeta(`.`) + `?` + regexp.QuoteMeta(`:`) + `[0-9]+)`
-	hostDomainS = `(?:` + hostPartS + `(?:(?:` + regexp.QuoteMeta(`.`) + hostPartS + `)+` + regexp.QuoteMeta(`.`) + `?|` + regexp.QuoteMeta(`.`) + `))`
-	hostUpperS  = `(?:[a-zA-Z0-9]*[A-Z][a-zA-Z0-9-]*[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[A-Z][a-zA-Z0-9]*)`
-	registryS   = `(?:` + hostDomainS + `|` + hostPortS + `|` + hostUpperS + `|localhost(?:` + regexp.QuoteMeta(`:`) + `[0-9]+)?)`
-	repoPartS   = `[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*`
-	pathS       = `[/a-zA-Z0-9_\-. ~\+]+`
-	tagS        = `[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}`
-	digestS     = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*[:][[:xdigit:]]{32,}`
-	schemeRE    = regexp.MustCompile(`^([a-z]+)://(.+)$`)
-	registryRE  = regexp.MustCompile(`^(` + registryS + `)$`)
-	refRE       = regexp.MustCompile(`^(?:(` + registryS + `)` + regexp.QuoteMeta(`/`) + `)?` +
+	hostPartS    = `(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)`
+	hostPortS    = `(?:` + hostPartS + `(?:` + regexp.QuoteMeta(`.`) + hostPartS + `)*` + regexp.QuoteMeta(`.`) + `?` + regexp.QuoteMeta(`:`) + `[0-9]+)`
+	hostDomainS  = `(?:` + hostPartS + `(?:(?:` + regexp.QuoteMeta(`.`) + hostPartS + `)+` + regexp.QuoteMeta(`.`) + `?|` + regexp.QuoteMeta(`.`) + `))`
+	hostUpperS   = `(?:[a-zA-Z0-9]*[A-Z][a-zA-Z0-9-]*[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[A-Z][a-zA-Z0-9]*)`
+	registryS    = `(?:` + hostDomainS + `|` + hostPortS + `|` + hostUpperS + `|localhost(?:` + regexp.QuoteMeta(`:`) + `[0-9]+)?)`
+	repoPartS    = `[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*`
+	pathS        = `[/a-zA-Z0-9_\-. ~\+]+`
+	tagS         = `[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}`
+	digestS      = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*[:][[:xdigit:]]{32,}`
+	schemeRE     = regexp.MustCompile(`^([a-z]+)://(.+)$`)
+	schemeNameRE = regexp.MustCompile(`^[a-z]+$`)
+	registryRE   = regexp.MustCompile(`^(` + registryS + `)$`)
+	refRE        = regexp.MustCompile(`^(?:(` + registryS + `)` + regexp.QuoteMeta(`/`) + `)?` +
 		`(` + repoPartS + `(?:` + regexp.QuoteMeta(`/`) + repoPartS + `)*)` +
 		`(?:` + regexp.QuoteMeta(`:`) + `(` + tagS + `))?` +
 		`(?:` + regexp.QuoteMeta(`@`) + `(` + digestS + `))?$`)
@@ -43,16 +45,51 @@ var (
 		`(?:` + regexp.QuoteMeta(`@`) + `(` + digestS + `))?$`)
 )
 
+var (
+	schemesCustom   = map[string]bool{}
+	schemesCustomMu sync.RWMutex
+)
+
+// RegisterScheme adds a custom scheme that is recognized by [New] and [NewHost].
+// References with a custom scheme are parsed like an OCI Layout, e.g. "mystore://path/to/repo:tag@digest".
+// The name must be lowercase letters, and the "reg", "ocidir", and "ocifile" schemes are reserved.
+// Registering the same scheme multiple times is allowed.
+func RegisterScheme(name string) error {
+	if !schemeNameRE.MatchString(name) {
+		return fmt.Errorf("%w, invalid scheme name \"%s\"", errs.ErrParsingFailed, name)
+	}
+	switch name {
+	case "reg", "ocidir", "ocifile":
+		return fmt.Errorf("scheme \"%s\" is reserved%.0w", name, errs.ErrUnsupported)
+	}
+	schemesCustomMu.Lock()
+	defer schemesCustomMu.Unlock()
+	schemesCustom[name] = true
+	return nil
+}
+
+// schemeIsCustom returns true when the scheme was added with [RegisterScheme].
+func schemeIsCustom(name string) bool {
+	schemesCustomMu.RLock()
+	defer schemesCustomMu.RUnlock()
+	return schemesCustom[name]
+}
+
+// schemeIsPath returns true for schemes that reference content by a path.
+func schemeIsPath(name string) bool {
+	return name == "ocidir" || schemeIsCustom(name)
+}
+
 // Ref is a reference to a registry/repository.
 // Direct access to the contents of this struct should not be assumed.
 type Ref struct {
-	Scheme     string // Scheme is the type of reference, "reg" or "ocidir".
+	Scheme     string // Scheme is the type of reference, "reg", "ocidir", or a custom scheme.
 	Reference  string // Reference is the unparsed string or common name.
 	Registry   string // Registry is the server for the "reg" scheme.
 	Repository string // Repository is the path on the registry for the "reg" scheme.
 	Tag        string // Tag is a mutable tag for a reference.
 	Digest     string // Digest is an immutable hash for a reference.
-	Path       string // Path is the directory of the OCI Layout for "ocidir".
+	Path       string // Path is the directory of the OCI Layout for "ocidir", or the path for a custom scheme.
 }
 
 // New returns a reference based on the scheme (defaulting to "reg").
@@ -68,8 +105,8 @@ func New(parse string) (Ref, error) {
 		Scheme:    scheme,
 		Reference: parse,
 	}
-	switch scheme {
-	case "":
+	switch {
+	case scheme == "":
 		ret.Scheme = "reg"
 		matchRef := refRE.FindStringSubmatch(tail)
 		if matchRef == nil || len(matchRef) < 5 {
@@ -103,7 +140,7 @@ func New(parse string) (Ref, error) {
 			return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrInvalidReference, tail)
 		}
 
-	case "ocidir", "ocifile":
+	case scheme == "ocidir" || scheme == "ocifile" || schemeIsCustom(scheme):
 		matchPath := ocidirRE.FindStringSubmatch(tail)
 		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
 			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", errs.ErrInvalidReference, scheme, tail)
@@ -136,8 +173,8 @@ func NewHost(parse string) (Ref, error) {
 		Scheme: scheme,
 	}
 
-	switch scheme {
-	case "":
+	switch {
+	case scheme == "":
 		ret.Scheme = "reg"
 		matchReg := registryRE.FindStringSubmatch(tail)
 		if matchReg == nil || len(matchReg) < 2 {
@@ -148,7 +185,7 @@ func NewHost(parse string) (Ref, error) {
 			return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrParsingFailed, tail)
 		}
 
-	case "ocidir", "ocifile":
+	case scheme == "ocidir" || scheme == "ocifile" || schemeIsCustom(scheme):
 		matchPath := ocidirRE.FindStringSubmatch(tail)
 		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
 			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", errs.ErrParsingFailed, scheme, tail)
@@ -164,8 +201,8 @@ func NewHost(parse string) (Ref, error) {
 // CommonName outputs a parsable name from a reference.
 func (r Ref) CommonName() string {
 	cn := ""
-	switch r.Scheme {
-	case "reg":
+	switch {
+	case r.Scheme == "reg":
 		if r.Registry != "" {
 			cn = r.Registry + "/"
 		}
@@ -179,8 +216,8 @@ func (r Ref) CommonName() string {
 		if r.Digest != "" {
 			cn = cn + "@" + r.Digest
 		}
-	case "ocidir":
-		cn = fmt.Sprintf("ocidir://%s", r.Path)
+	case schemeIsPath(r.Scheme):
+		cn = fmt.Sprintf("%s://%s", r.Scheme, r.Path)
 		if r.Tag != "" {
 			cn = cn + ":" + r.Tag
 		}
@@ -191,6 +228,25 @@ func (r Ref) CommonName() string {
 	return cn
 }
 
+// ShortName outputs a parsable name from a reference, omitting the default registry.
+// Docker Hub references drop "docker.io/", and "library/" for official images, matching the docker CLI output.
+func (r Ref) ShortName() string {
+	if r.Scheme != "reg" || r.Registry != dockerRegistry || r.Repository == "" {
+		return r.CommonName()
+	}
+	sn := r.Repository
+	if strings.HasPrefix(sn, dockerLibrary+"/") && !strings.Contains(sn[len(dockerLibrary)+1:], "/") {
+		sn = sn[len(dockerLibrary)+1:]
+	}
+	if r.Tag != "" {
+		sn = sn + ":" + r.Tag
+	}
+	if r.Digest != "" {
+		sn = sn + "@" + r.Digest
+	}
+	return sn
+}
+
 // IsSet returns true if needed values are defined for a specific reference.
 func (r Ref) IsSet() bool {
 	if !r.IsSetRepo() {
@@ -205,12 +261,12 @@ func (r Ref) IsSet() bool {
 
 // IsSetRepo returns true when the ref includes values for a specific repository.
 func (r Ref) IsSetRepo() bool {
-	switch r.Scheme {
-	case "reg":
+	switch {
+	case r.Scheme == "reg":
 		if r.Registry != "" && r.Repository != "" {
 			return true
 		}
-	case "ocidir":
+	case schemeIsPath(r.Scheme):
 		if r.Path != "" {
 			return true
 		}
@@ -246,8 +302,7 @@ func (r Ref) SetTag(tag string) Ref {
 
 // ToReg converts a reference to a registry like syntax.
 func (r Ref) ToReg() Ref {
-	switch r.Scheme {
-	case "ocidir":
+	if schemeIsPath(r.Scheme) {
 		r.Scheme = "reg"
 		r.Registry = "localhost"
 		// clean the path to strip leading ".."
@@ -274,7 +329,7 @@ func EqualRegistry(a, b Ref) bool {
 		// both undefined
 		return true
 	default:
-		return false
+		return schemeIsCustom(a.Scheme) && a.Path == b.Path
 	}
 }
 
@@ -292,6 +347,6 @@ func EqualRepository(a, b Ref) bool {
 		// both undefined
 		return true
 	default:
-		return false
+		return schemeIsCustom(a.Scheme) && a.Path == b.Path
 	}
 }
@@ -657,6 +657,68 @@ func TestCommon(t *testing.T) {
 	}
 }
 
+func TestShortName(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		str    string
+		expect string
+	}{
+		{
+			name:   "official image",
+			str:    "nginx",
+			expect: "nginx:latest",
+		},
+		{
+			name:   "official image with registry",
+			str:    "docker.io/library/nginx:1.25",
+			expect: "nginx:1.25",
+		},
+		{
+			name:   "official image on legacy registry",
+			str:    "index.docker.io/library/alpine@" + testDigest,
+			expect: "alpine@" + testDigest,
+		},
+		{
+			name:   "user image",
+			str:    "docker.io/regclient/regctl:edge",
+			expect: "regclient/regctl:edge",
+		},
+		{
+			name:   "nested library repo",
+			str:    "docker.io/library/group/image:tag",
+			expect: "library/group/image:tag",
+		},
+		{
+			name:   "other registry",
+			str:    "ghcr.io/regclient/regctl:edge",
+			expect: "ghcr.io/regclient/regctl:edge",
+		},
+		{
+			name:   "other registry library repo",
+			str:    "registry.example.org/library/alpine:3",
+			expect: "registry.example.org/library/alpine:3",
+		},
+		{
+			name:   "ocidir",
+			str:    "ocidir://library/alpine:3",
+			expect: "ocidir://library/alpine:3",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := New(tc.str)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tc.str, err)
+			}
+			sn := r.ShortName()
+			if tc.expect != sn {
+				t.Errorf("short name mismatch, expected %s, received %s", tc.expect, sn)
+			}
+		})
+	}
+}
+
 func TestEqual(t *testing.T) {
 	t.Parallel()
 	tt := []struct {
@@ -974,3 +1036,45 @@ func TestToReg(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterScheme(t *testing.T) {
+	t.Parallel()
+	err := RegisterScheme("example")
+	if err != nil {
+		t.Fatalf("failed to register scheme: %v", err)
+	}
+	for _, name := range []string{"reg", "ocidir", "ocifile"} {
+		if err := RegisterScheme(name); !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("registering reserved scheme %s did not fail: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Upper", "with-dash", "num1"} {
+		if err := RegisterScheme(name); !errors.Is(err, errs.ErrParsingFailed) {
+			t.Errorf("registering invalid scheme %s did not fail: %v", name, err)
+		}
+	}
+	a, err := New("example://path/to/repo:v1@" + testDigest)
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	if a.Scheme != "example" || a.Path != "path/to/repo" || a.Tag != "v1" || a.Digest != testDigest {
+		t.Errorf("unexpected ref: %#v", a)
+	}
+	if a.CommonName() != "example://path/to/repo:v1@"+testDigest || !a.IsSet() {
+		t.Errorf("unexpected common name %s or not set", a.CommonName())
+	}
+	b, err := NewHost("example://path/to/repo")
+	if err != nil {
+		t.Fatalf("failed to parse host: %v", err)
+	}
+	if !EqualRepository(a, b) || !EqualRegistry(a, b) {
+		t.Errorf("refs are not equal: %#v, %#v", a, b)
+	}
+	if a.ToReg().CommonName() != "localhost/path/to/repo:v1@"+testDigest {
+		t.Errorf("unexpected conversion to reg: %s", a.ToReg().CommonName())
+	}
+	_, err = New("unregistered://path/to/repo:v1")
+	if !errors.Is(err, errs.ErrInvalidReference) {
+		t.Errorf("unregistered scheme did not fail: %v", err)
+	}
+}
@@ -16,10 +16,23 @@ import (
 	"github.com/regclient/regclient/types/ref"
 )
 
+// Method is how the referrers were retrieved.
+type Method string
+
+const (
+	// MethodAPI indicates the referrers were returned by the OCI referrers API.
+	MethodAPI Method = "api"
+	// MethodFallbackTag indicates the referrers were read from the fallback tag for registries without the referrers API.
+	MethodFallbackTag Method = "fallback-tag"
+	// MethodExternal indicates the referrers were queried from an external repository, see [ReferrerList.Source].
+	MethodExternal Method = "external"
+)
+
 // ReferrerList contains the response to a request for referrers to a subject
 type ReferrerList struct {
 	Subject     ref.Ref                 `json:"subject"`               // subject queried
 	Source      ref.Ref                 `json:"source"`                // source for referrers, if different from subject
+	Method      Method                  `json:"method,omitempty"`      // method used to retrieve the referrers
 	Descriptors []descriptor.Descriptor `json:"descriptors"`           // descriptors found in Index
 	Annotations map[string]string       `json:"annotations,omitempty"` // annotations extracted from Index
 	Manifest    manifest.Manifest       `json:"-"`                     // returned OCI Index
@@ -114,18 +127,18 @@ func (rl ReferrerList) MarshalPretty() ([]byte, error) {
 	var rRef ref.Ref
 	if rl.Subject.IsSet() {
 		rRef = rl.Subject
-		fmt.Fprintf(tw, "Subject:\t%s\n", rl.Subject.CommonName())
+		fmt.Fprintf(tw, "Subject:\t%s\n", rl.Subject.ShortName())
 	}
 	if rl.Source.IsSet() {
 		rRef = rl.Source
-		fmt.Fprintf(tw, "Source:\t%s\n", rl.Source.CommonName())
+		fmt.Fprintf(tw, "Source:\t%s\n", rl.Source.ShortName())
 	}
 	fmt.Fprintf(tw, "\t\n")
 	fmt.Fprintf(tw, "Referrers:\t\n")
 	for _, d := range rl.Descriptors {
 		fmt.Fprintf(tw, "\t\n")
 		if rRef.IsSet() {
-			fmt.Fprintf(tw, "  Name:\t%s\n", rRef.SetDigest(d.Digest.String()).CommonName())
+			fmt.Fprintf(tw, "  Name:\t%s\n", rRef.SetDigest(d.Digest.String()).ShortName())
 		}
 		err := d.MarshalPrettyTW(tw, "  ")
 		if err != nil {
@@ -419,4 +419,27 @@ func TestMarshal(t *testing.T) {
 		t.Errorf("empty response is missing an annotations line: %s", out)
 	}
 
+	// Docker Hub references are shown without the registry and library namespace
+	rHub, err := ref.New("docker.io/library/alpine:latest")
+	if err != nil {
+		t.Fatalf("failed to parse hub ref: %v", err)
+	}
+	rl = &ReferrerList{
+		Subject:     rHub,
+		Descriptors: []descriptor.Descriptor{dOCIImg},
+	}
+	outB, err = rl.MarshalPretty()
+	if err != nil {
+		t.Fatalf("failed to marshal referrer list: %v", err)
+	}
+	out = string(outB)
+	if !strings.Contains(out, "alpine:latest\n") {
+		t.Errorf("subject is not a short name: %s", out)
+	}
+	if !strings.Contains(out, "alpine@"+dOCIImg.Digest.String()+"\n") {
+		t.Errorf("referrer name is not a short name: %s", out)
+	}
+	if strings.Contains(out, "docker.io") {
+		t.Errorf("output includes the default registry: %s", out)
+	}
 }
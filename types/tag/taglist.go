@@ -10,6 +10,8 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/mediatype"
 	ociv1 "github.com/regclient/regclient/types/oci/v1"
@@ -205,6 +207,63 @@ func (l *List) Append(add *List) error {
 	return nil
 }
 
+// Filter keeps the tags where fn returns true.
+// Filtered tags are also removed from the GCR manifest entries and the OCI Layout index,
+// and the raw body is regenerated so the JSON output matches the filtered list.
+func (l *List) Filter(fn func(string) bool) error {
+	l.Tags = filterTags(l.Tags, fn)
+	for k, v := range l.Manifests {
+		v.Tags = filterTags(v.Tags, fn)
+		l.Manifests[k] = v
+	}
+	if l.Index.Manifests != nil {
+		dl := []descriptor.Descriptor{}
+		for _, d := range l.Index.Manifests {
+			if t, ok := d.Annotations[types.AnnotationRefName]; ok {
+				if i := strings.LastIndex(t, ":"); i >= 0 {
+					t = t[i+1:]
+				}
+				if !fn(t) {
+					continue
+				}
+			}
+			dl = append(dl, d)
+		}
+		l.Index.Manifests = dl
+	}
+	if len(l.rawBody) == 0 {
+		return nil
+	}
+	var raw []byte
+	var err error
+	if mediatype.Base(l.mt) == mediatype.OCI1ManifestList {
+		raw, err = json.Marshal(l.Index)
+	} else {
+		raw, err = json.Marshal(struct {
+			DockerList
+			GCRList
+		}{DockerList: l.DockerList, GCRList: l.GCRList})
+	}
+	if err != nil {
+		return err
+	}
+	l.rawBody = raw
+	return nil
+}
+
+func filterTags(tags []string, fn func(string) bool) []string {
+	if tags == nil {
+		return nil
+	}
+	result := []string{}
+	for _, t := range tags {
+		if fn(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
 // GetOrig returns the underlying tag data structure if defined.
 func (t tagCommon) GetOrig() interface{} {
 	return t.orig
@@ -266,6 +266,43 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	sigTag := "sha256-96ef6fb02c5a56901dc3c2e0ca34eec9ed926ab8d936ea30ec38f9ec9db017a5.sig"
+	raw := []byte(`{"name":"example/test","tags":["v0.3.0","` + sigTag + `"],"manifest":{
+		"sha256:135d8c5e27bdc917f04b415fc947d7d5b1137f99bb8fa00bffc3eca1856e9c52":{"imageSizeBytes":"22713600","mediaType":"application/vnd.docker.distribution.manifest.v2+json","tag":["v0.3.0"],"timeCreatedMs":"0","timeUploadedMs":"1618865456521"},
+		"sha256:1674ddbc630554763fcd62fee8d6fe37a39354b7be9812c32112f936301d3030":{"imageSizeBytes":"557","mediaType":"application/vnd.oci.image.manifest.v1+json","tag":["` + sigTag + `"],"timeCreatedMs":"0","timeUploadedMs":"1631661262320"}}}`)
+	gcrRef, _ := ref.New("gcr.io/example/test")
+	tl, err := New(WithRef(gcrRef), WithRaw(raw), WithMT("application/json"))
+	if err != nil {
+		t.Fatalf("failed to build tag list: %v", err)
+	}
+	err = tl.Filter(func(tag string) bool { return !strings.HasPrefix(tag, "sha256-") })
+	if err != nil {
+		t.Fatalf("failed to filter: %v", err)
+	}
+	if !cmpSliceString(tl.Tags, []string{"v0.3.0"}) {
+		t.Errorf("unexpected tags: %v", tl.Tags)
+	}
+	if tags := tl.Manifests["sha256:1674ddbc630554763fcd62fee8d6fe37a39354b7be9812c32112f936301d3030"].Tags; len(tags) != 0 {
+		t.Errorf("tag not removed from manifest: %v", tags)
+	}
+	rawFiltered, err := tl.RawBody()
+	if err != nil {
+		t.Fatalf("failed to get raw body: %v", err)
+	}
+	if bytes.Contains(rawFiltered, []byte(sigTag)) {
+		t.Errorf("raw body includes the filtered tag: %s", rawFiltered)
+	}
+	tlParsed, err := New(WithRef(gcrRef), WithRaw(rawFiltered), WithMT("application/json"))
+	if err != nil {
+		t.Fatalf("failed to parse filtered raw body: %v", err)
+	}
+	if tlParsed.Name != "example/test" || !cmpSliceString(tlParsed.Tags, tl.Tags) || !cmpManifestInfos(tlParsed.Manifests, tl.Manifests) {
+		t.Errorf("filtered raw body mismatch: %s", rawFiltered)
+	}
+}
+
 func cmpSliceString(a, b []string) bool {
 	if len(a) != len(b) {
 		return false